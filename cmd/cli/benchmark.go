@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"os"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,27 +15,56 @@ import (
 	"github.com/ritik/twitter-fan-out/internal/cache"
 	"github.com/ritik/twitter-fan-out/internal/config"
 	"github.com/ritik/twitter-fan-out/internal/models"
-	"github.com/ritik/twitter-fan-out/internal/repository"
+	"github.com/ritik/twitter-fan-out/internal/seed"
+	"github.com/ritik/twitter-fan-out/internal/storage"
 	"github.com/ritik/twitter-fan-out/internal/timeline"
 	"github.com/spf13/cobra"
 )
 
 var (
-	benchStrategy   string
-	benchTweets     int
-	benchReads      int
-	benchConcurrent int
-	benchDuration   time.Duration
-	benchOutput     string
+	benchStrategy         string
+	benchTweets           int
+	benchReads            int
+	benchConcurrent       int
+	benchDuration         time.Duration
+	benchOutput           string
+	benchAsync            bool
+	benchSeedUsers        int
+	benchMix              string
+	benchRate             string
+	benchOps              int
+	benchSnapshotInterval time.Duration
+	benchReplyFilter      string
+	benchCounterStrategy  string
+	benchCounterShards    int
+	benchBlockFilter      string
+	benchProtected        float64
+	benchLists            int
+	benchListSize         int
+	benchRankingScorer    string
 )
 
 func init() {
-	benchmarkCmd.Flags().StringVar(&benchStrategy, "strategy", "all", "Strategy to benchmark (fanout_write, fanout_read, hybrid, all)")
+	benchmarkCmd.Flags().StringVar(&benchStrategy, "strategy", "all", "Strategy to benchmark (a registered strategy name, or all)")
 	benchmarkCmd.Flags().IntVar(&benchTweets, "tweets", 1000, "Number of tweets to post")
 	benchmarkCmd.Flags().IntVar(&benchReads, "reads", 2000, "Number of timeline reads")
 	benchmarkCmd.Flags().IntVar(&benchConcurrent, "concurrent", 50, "Number of concurrent workers")
-	benchmarkCmd.Flags().DurationVar(&benchDuration, "duration", 0, "Duration to run (overrides tweet count)")
+	benchmarkCmd.Flags().DurationVar(&benchDuration, "duration", 0, "Run each phase, or the --mix workload, for this long (overrides --tweets, --reads and --ops)")
+	benchmarkCmd.Flags().DurationVar(&benchSnapshotInterval, "snapshot-interval", 5*time.Second, "How often a --duration run records rolling latency and throughput")
 	benchmarkCmd.Flags().StringVar(&benchOutput, "output", "", "Output file for results (JSON)")
+	benchmarkCmd.Flags().BoolVar(&benchAsync, "async-fanout", false, "Use the async fan-out worker pool for fanout_write")
+	benchmarkCmd.Flags().IntVar(&benchSeedUsers, "seed-users", 1000, "Users to generate when running with --backend=memory")
+	benchmarkCmd.Flags().StringVar(&benchMix, "mix", "", "Run a concurrent mixed workload instead of separate write and read phases, e.g. read=90,write=9,follow=1 (ops: read, write, follow, unfollow, retweet, reply, like, mute, block, protect, list_read)")
+	benchmarkCmd.Flags().StringVar(&benchRate, "rate", "1000/s", "Arrival rate for --mix, e.g. 2000/s or 50/100ms")
+	benchmarkCmd.Flags().IntVar(&benchOps, "ops", 10000, "Number of operations to send with --mix")
+	benchmarkCmd.Flags().StringVar(&benchReplyFilter, "reply-filter", "", "Filter replies at read or at fanout (default $REPLY_FILTER, else read)")
+	benchmarkCmd.Flags().StringVar(&benchCounterStrategy, "counter-strategy", "", "Keep like and retweet counts in sharded hashes or write_behind buffers (default $COUNTER_STRATEGY, else sharded)")
+	benchmarkCmd.Flags().IntVar(&benchCounterShards, "counter-shards", 0, "Hashes per tweet with --counter-strategy sharded (default $COUNTER_SHARDS, else 8)")
+	benchmarkCmd.Flags().StringVar(&benchBlockFilter, "block-filter", "", "Hide blocked and muted authors at read, or purge them from timelines (default $BLOCK_FILTER, else read)")
+	benchmarkCmd.Flags().Float64Var(&benchProtected, "protected", 0, "Share of the benchmark users to protect before the run, from 0 to 1")
+	benchmarkCmd.Flags().IntVar(&benchLists, "lists", 0, "Lists to create before the run, owned by random benchmark users")
+	benchmarkCmd.Flags().IntVar(&benchListSize, "list-size", 50, "Members of each list created with --lists")
+	benchmarkCmd.Flags().StringVar(&benchRankingScorer, "ranking-scorer", "", "Rank for_you timelines by recency, engagement or affinity (default $RANKING_SCORER, else recency)")
 	
 	rootCmd.AddCommand(benchmarkCmd)
 }
@@ -48,47 +78,164 @@ Measures:
   - Write latency (posting tweets)
   - Read latency (fetching timelines)
   - Throughput (operations per second)
-  - Fan-out time for high-follower users`,
+  - Fan-out time for high-follower users
+
+With --duration, workers loop until time is up instead of stopping after a
+fixed count, and rolling p50/p95/p99 and throughput are recorded every
+--snapshot-interval and saved with the results as a time series.
+
+By default writes and reads run as separate phases. With --mix, operations
+are drawn from the given ratio and sent at a fixed --rate, so fan-out writes
+contend with reads. Latency is measured from each operation's intended send
+time, so a backed-up system can't hide its queueing delay.
+
+A reply is only shown to viewers who follow both participants. Compare
+--reply-filter read (push every reply, filter each timeline read) with
+--reply-filter fanout (push only to the followers who should see it) using
+a mix that includes replies.
+
+Likes update counters kept in the cache and written back in batches. Compare
+--counter-strategy sharded (every like increments one of --counter-shards
+hashes) with --counter-strategy write_behind (likes are buffered in the
+process and applied once per flush) using a mix that includes likes.
+
+Blocked and muted authors never appear in a timeline. Compare
+--block-filter read (check every timeline read) with --block-filter purge
+(remove the author's tweets from the timeline when blocking, and leave the
+viewer out of their later fan-outs) using a mix that includes mutes or
+blocks.
+
+Protected users' tweets only reach followers they approved; following them
+files a request instead. Retweets made before an author was protected are
+checked on every read. Use --protected to protect a share of the users, and
+a mix that includes retweets (and protect, which toggles a random user) to
+see what the check adds to read latency.
+
+A post fans out to its author's followers and to every list containing the
+author, and the results report the timelines written per post (write
+amplification) for each strategy. Use --lists and --list-size to create
+lists of random members before the run, and a mix that includes list_read
+to compare pushed and pulled list timelines.`,
 	Run: runBenchmark,
 }
 
 func runBenchmark(cmd *cobra.Command, args []string) {
-	fmt.Println("🏃 Running benchmarks...")
-	fmt.Printf("   Strategy: %s\n", benchStrategy)
-	fmt.Printf("   Tweets: %d\n", benchTweets)
-	fmt.Printf("   Reads: %d\n", benchReads)
-	fmt.Printf("   Concurrent: %d\n", benchConcurrent)
-	fmt.Println()
+	if benchDuration > 0 && benchSnapshotInterval <= 0 {
+		fmt.Printf("❌ --snapshot-interval must be positive\n")
+		os.Exit(1)
+	}
 
-	cfg := config.Get()
-	ctx := context.Background()
+	var mix *workloadMix
+	var rate float64
+	if benchMix != "" {
+		var err error
+		if mix, err = parseMix(benchMix); err != nil {
+			fmt.Printf("❌ Invalid --mix: %v\n", err)
+			os.Exit(1)
+		}
+		if rate, err = parseRate(benchRate); err != nil {
+			fmt.Printf("❌ Invalid --rate: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-	// Initialize database
-	db, err := repository.InitDB(cfg)
-	if err != nil {
-		fmt.Printf("❌ Failed to connect to database: %v\n", err)
+	cfg := config.Get()
+	if benchReplyFilter != "" {
+		cfg.ReplyFilter = benchReplyFilter
+	}
+	if !timeline.IsValidReplyFilter(cfg.ReplyFilter) {
+		fmt.Printf("❌ Invalid --reply-filter %q (use %s)\n", cfg.ReplyFilter, strings.Join(timeline.ReplyFilters(), " or "))
+		os.Exit(1)
+	}
+	if benchCounterStrategy != "" {
+		cfg.CounterStrategy = benchCounterStrategy
+	}
+	if benchCounterShards > 0 {
+		cfg.CounterShards = benchCounterShards
+	}
+	if !cache.IsValidCounterStrategy(cfg.CounterStrategy) {
+		fmt.Printf("❌ Invalid --counter-strategy %q (use %s)\n", cfg.CounterStrategy, strings.Join(cache.CounterStrategies(), " or "))
 		os.Exit(1)
 	}
-	defer repository.Close()
 
-	// Initialize Redis
-	redisClient, err := cache.InitRedis(cfg)
-	if err != nil {
-		fmt.Printf("❌ Failed to connect to Redis: %v\n", err)
+	if benchBlockFilter != "" {
+		cfg.BlockFilter = benchBlockFilter
+	}
+	if !timeline.IsValidBlockFilter(cfg.BlockFilter) {
+		fmt.Printf("❌ Invalid --block-filter %q (use %s)\n", cfg.BlockFilter, strings.Join(timeline.BlockFilters(), " or "))
+		os.Exit(1)
+	}
+	if benchRankingScorer != "" {
+		cfg.RankingScorer = benchRankingScorer
+	}
+	if !timeline.IsValidScorer(cfg.RankingScorer) {
+		fmt.Printf("❌ Invalid --ranking-scorer %q (use %s)\n", cfg.RankingScorer, strings.Join(timeline.Scorers(), ", "))
+		os.Exit(1)
+	}
+	if benchProtected < 0 || benchProtected > 1 {
+		fmt.Printf("❌ --protected must be between 0 and 1\n")
+		os.Exit(1)
+	}
+	if benchLists < 0 || benchListSize < 1 {
+		fmt.Printf("❌ --lists can't be negative and --list-size must be positive\n")
+		os.Exit(1)
+	}
+	if mix != nil && mix.includes(opListRead) && benchLists == 0 {
+		fmt.Printf("❌ A mix with %s needs --lists\n", opListRead)
 		os.Exit(1)
 	}
-	defer cache.Close()
 
-	// Create repositories
-	userRepo := repository.NewUserRepository(db)
-	tweetRepo := repository.NewTweetRepository(db)
-	followRepo := repository.NewFollowRepository(db)
+	fmt.Println("🏃 Running benchmarks...")
+	fmt.Printf("   Strategy: %s\n", benchStrategy)
+	fmt.Printf("   Reply filtering: at %s\n", cfg.ReplyFilter)
+	if cfg.CounterStrategy == cache.CounterSharded {
+		fmt.Printf("   Counters: %s (%d shards)\n", cfg.CounterStrategy, cfg.CounterShards)
+	} else {
+		fmt.Printf("   Counters: %s\n", cfg.CounterStrategy)
+	}
+	fmt.Printf("   Block filtering: %s\n", cfg.BlockFilter)
+	fmt.Printf("   Ranking scorer: %s\n", cfg.RankingScorer)
+	if benchProtected > 0 {
+		fmt.Printf("   Protected users: %.0f%%\n", benchProtected*100)
+	}
+	if benchLists > 0 {
+		fmt.Printf("   Lists: %d of %d members\n", benchLists, benchListSize)
+	}
+	if mix != nil {
+		fmt.Printf("   Mix: %s\n", mix)
+		fmt.Printf("   Rate: %.0f ops/sec\n", rate)
+	}
+	if benchDuration > 0 {
+		fmt.Printf("   Duration: %s\n", benchDuration)
+		fmt.Printf("   Snapshots: every %s\n", benchSnapshotInterval)
+	} else if mix != nil {
+		fmt.Printf("   Operations: %d\n", benchOps)
+	} else {
+		fmt.Printf("   Tweets: %d\n", benchTweets)
+		fmt.Printf("   Reads: %d\n", benchReads)
+	}
+	fmt.Printf("   Concurrent: %d\n", benchConcurrent)
+	fmt.Println()
 
-	// Create cache
-	timelineCache := cache.NewTimelineCache(redisClient, cfg.TimelineCacheSize)
+	ctx := context.Background()
+
+	stores := openStores(cfg)
+	defer stores.Close()
+
+	// An in-memory backend starts empty, so generate a data set in-process
+	if stores.Backend == storage.BackendMemory {
+		opts := seed.DefaultOptions()
+		opts.Users = benchSeedUsers
+		fmt.Printf("🌱 Seeding in-memory backend with %d users...\n", opts.Users)
+		if err := seed.Run(ctx, seed.Stores{Users: stores.Users, Tweets: stores.Tweets, Follows: stores.Follows}, opts, os.Stdout); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println()
+	}
 
 	// Get users for benchmarking
-	users, err := userRepo.GetRandomUsers(ctx, 1000)
+	users, err := stores.Users.GetRandomUsers(ctx, 1000)
 	if err != nil || len(users) == 0 {
 		fmt.Printf("❌ No users found. Run 'fanout seed' first.\n")
 		os.Exit(1)
@@ -96,32 +243,152 @@ func runBenchmark(cmd *cobra.Command, args []string) {
 
 	fmt.Printf("📊 Found %d users for benchmarking\n\n", len(users))
 
+	blocks := timeline.NewBlocks(stores.Blocks, stores.Tweets, stores.Cache, cfg.BlockFilter)
+	requests := timeline.NewFollowRequests(stores.Users, stores.Follows, stores.FollowRequests)
+	lists := timeline.NewLists(stores.Lists, stores.Tweets, stores.Users, stores.Follows, stores.Cache)
+	lists.SetBlocks(blocks)
+
+	// Create the lists before protecting anyone, so any member can be added,
+	// and delete them afterwards
+	listIDs, err := createBenchmarkLists(ctx, stores, users, benchLists, benchListSize)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	defer func() {
+		for _, id := range listIDs {
+			if err := lists.Delete(ctx, id); err != nil {
+				fmt.Printf("Warning: failed to delete list %d: %v\n", id, err)
+			}
+		}
+	}()
+
+	// Protect a share of the users, and put everyone the run protected back
+	// afterwards, dropping the requests it left pending
+	protected := newProtectedUsers()
+	for _, i := range rand.Perm(len(users))[:int(benchProtected*float64(len(users)))] {
+		if err := stores.Users.SetProtected(ctx, users[i].ID, true); err != nil {
+			fmt.Printf("❌ Failed to protect user %d: %v\n", users[i].ID, err)
+			os.Exit(1)
+		}
+		protected.set(users[i].ID)
+	}
+	defer func() {
+		for _, id := range protected.touched() {
+			if err := stores.Users.SetProtected(ctx, id, false); err != nil {
+				fmt.Printf("Warning: failed to unprotect user %d: %v\n", id, err)
+			}
+			if _, err := stores.FollowRequests.DeleteAll(ctx, id); err != nil {
+				fmt.Printf("Warning: failed to drop follow requests for user %d: %v\n", id, err)
+			}
+		}
+	}()
+
+	// Counts the timelines each strategy's posts reach, including the ones
+	// the worker pool fans out to
+	fanOut := &fanOutTally{}
+
+	// Start the fan-out worker pool if benchmarking async fan-out
+	var fanOutQueue *cache.FanOutQueue
+	if benchAsync {
+		fanOutQueue = stores.NewFanOutQueue()
+		if fanOutQueue == nil {
+			fmt.Printf("⚠️  Warning: the %s backend has no fan-out queue, fanning out inline\n\n", stores.Backend)
+		}
+	}
+	if fanOutQueue != nil {
+		pool := timeline.NewFanOutWorkerPool(fanOutQueue, stores.Tweets, stores.Follows, stores.Cache, timeline.FanOutWorkerConfig{
+			Workers:    cfg.FanOutWorkers,
+			ChunkSize:  cfg.FanOutChunkSize,
+			MaxRetries: cfg.FanOutMaxRetries,
+		})
+		pool.SetBlocks(blocks)
+		pool.SetLists(lists)
+		pool.OnComplete(fanOut.add)
+		if err := pool.Start(ctx); err != nil {
+			fmt.Printf("❌ Failed to start fan-out workers: %v\n", err)
+			os.Exit(1)
+		}
+		defer pool.Stop()
+	}
+
+	counters := timeline.NewCounters(stores.Tweets, stores.Counters, timeline.CountersConfig{
+		Strategy:      cfg.CounterStrategy,
+		FlushInterval: time.Duration(cfg.CounterFlushMs) * time.Millisecond,
+	})
+	counters.Start(ctx)
+
+	// Migrate users who cross the threshold during the run, as the server does
+	reclassifier := timeline.NewReclassifier(stores.Tweets, stores.Follows, stores.Users, stores.Cache, cfg.CelebrityThreshold, timeline.ReclassifierConfig{
+		ChunkSize: cfg.FanOutChunkSize,
+	})
+	reclassifier.SetBlocks(blocks)
+	reclassifier.SetLists(lists)
+	if err := reclassifier.Start(ctx); err != nil {
+		fmt.Printf("❌ Failed to start celebrity reclassification: %v\n", err)
+		os.Exit(1)
+	}
+	defer reclassifier.Stop()
+
+	registry := timeline.NewRegistry(timeline.Dependencies{
+		TweetRepo:    stores.Tweets,
+		FollowRepo:   stores.Follows,
+		UserRepo:     stores.Users,
+		Cache:        stores.Cache,
+		Config:       cfg,
+		FanOutQueue:  fanOutQueue,
+		Reclassifier: reclassifier,
+		Counters:     counters,
+		Blocks:       blocks,
+		Lists:        lists,
+	})
+
 	var results []*models.BenchmarkResult
 
 	strategies := []string{benchStrategy}
 	if benchStrategy == "all" {
-		strategies = []string{"fanout_write", "fanout_read", "hybrid"}
+		strategies = strategies[:0]
+		for _, name := range registry.Names() {
+			strategies = append(strategies, string(name))
+		}
 	}
 
 	for _, strategyName := range strategies {
-		var strategy timeline.Strategy
-
-		switch strategyName {
-		case "fanout_write":
-			strategy = timeline.NewFanOutWriteStrategy(tweetRepo, followRepo, userRepo, timelineCache)
-		case "fanout_read":
-			strategy = timeline.NewFanOutReadStrategy(tweetRepo, followRepo, userRepo, timelineCache)
-		case "hybrid":
-			strategy = timeline.NewHybridStrategy(tweetRepo, followRepo, userRepo, timelineCache, cfg.CelebrityThreshold)
-		default:
+		strategy, ok := registry.Get(strategyName)
+		if !ok {
 			fmt.Printf("❌ Unknown strategy: %s\n", strategyName)
 			continue
 		}
 
-		result := runStrategyBenchmark(ctx, strategy, users, benchTweets, benchReads, benchConcurrent)
+		fanOut.reset()
+		var result *models.BenchmarkResult
+		if mix != nil {
+			services := &mixedServices{counters: counters, blocks: blocks, requests: requests, protected: protected, fanOut: fanOut, listIDs: listIDs}
+			result = runMixedBenchmark(ctx, strategy, services, users, mix, rate, benchOps, benchDuration, benchConcurrent)
+		} else {
+			result = runStrategyBenchmark(ctx, strategy, users, fanOut, benchTweets, benchReads, benchDuration, benchConcurrent)
+		}
+		// Async fan-out has drained by now, so every post is counted
+		result.WriteAmplification, result.ListWriteAmplification = fanOut.amplification()
+		result.Lists = len(listIDs)
+		if result.Lists > 0 {
+			result.ListSize = benchListSize
+		}
+		result.ReplyFilter = cfg.ReplyFilter
+		result.CounterStrategy = counters.Strategy()
+		result.BlockFilter = blocks.Mode()
+		result.Protected = benchProtected
+		if ranked, ok := strategy.(timeline.Ranked); ok {
+			result.RankingScorer = ranked.Scorer().Name()
+		}
 		results = append(results, result)
 	}
 
+	// Write back whatever counts the last flush didn't
+	counters.Stop()
+	stats := counters.Stats()
+	fmt.Printf("🔢 Counters (%s): %d flushes, %d tweets written back\n\n", stats.Strategy, stats.Flushes, stats.WrittenBack)
+
 	// Print results
 	printResults(results)
 
@@ -131,140 +398,193 @@ func runBenchmark(cmd *cobra.Command, args []string) {
 	}
 }
 
-func runStrategyBenchmark(ctx context.Context, strategy timeline.Strategy, users []*models.User, numTweets, numReads, concurrent int) *models.BenchmarkResult {
+// createBenchmarkLists creates count lists owned by random users, each with
+// size random members, and returns their IDs
+func createBenchmarkLists(ctx context.Context, stores *storage.Stores, users []*models.User, count, size int) ([]int64, error) {
+	if size > len(users) {
+		size = len(users)
+	}
+	ids := make([]int64, 0, count)
+	for i := 0; i < count; i++ {
+		owner := users[rand.Intn(len(users))]
+		list, err := stores.Lists.Create(ctx, owner.ID, fmt.Sprintf("benchmark-%d", i+1))
+		if err != nil {
+			return ids, fmt.Errorf("failed to create list: %w", err)
+		}
+		ids = append(ids, list.ID)
+		for _, j := range rand.Perm(len(users))[:size] {
+			if err := stores.Lists.AddMember(ctx, list.ID, users[j].ID); err != nil {
+				return ids, fmt.Errorf("failed to add list member: %w", err)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// runStrategyBenchmark posts tweets, then reads timelines. Each phase runs a
+// fixed number of operations or, with a duration, for that long.
+func runStrategyBenchmark(ctx context.Context, strategy timeline.Strategy, users []*models.User, fanOut *fanOutTally, numTweets, numReads int, duration time.Duration, concurrent int) *models.BenchmarkResult {
 	fmt.Printf("📈 Benchmarking %s...\n", strategy.Name())
 
 	result := &models.BenchmarkResult{
-		Strategy:    strategy.Name(),
-		TotalTweets: numTweets,
-		TotalReads:  numReads,
-		Timestamp:   time.Now(),
+		Strategy:  strategy.Name(),
+		Timestamp: time.Now(),
+	}
+
+	recorder := newLatencyRecorder()
+	start := time.Now()
+
+	var snapshots *snapshotter
+	if duration > 0 {
+		snapshots = startSnapshots(recorder, []string{opWrite, opRead}, benchSnapshotInterval)
 	}
 
 	// Benchmark writes
-	fmt.Printf("   Writing %d tweets with %d workers...\n", numTweets, concurrent)
-	writeLatencies := benchmarkWrites(ctx, strategy, users, numTweets, concurrent)
-	
+	if duration > 0 {
+		fmt.Printf("   Writing tweets for %s with %d workers...\n", duration, concurrent)
+	} else {
+		fmt.Printf("   Writing %d tweets with %d workers...\n", numTweets, concurrent)
+	}
+	writesStart := time.Now()
+	benchmarkWrites(ctx, strategy, users, fanOut, numTweets, duration, concurrent, recorder)
+	writesElapsed := time.Since(writesStart)
+
+	// With async fan-out, writes return before followers see the tweet -
+	// wait for the queue to drain to measure when fan-out actually completed
+	if async, ok := strategy.(timeline.AsyncFanOut); ok && async.FanOutQueue() != nil {
+		fmt.Printf("   Waiting for fan-out queue to drain...\n")
+		result.FanOutCompletion = waitForFanOutDrain(ctx, async.FanOutQueue(), writesStart)
+	}
+
 	// Benchmark reads
-	fmt.Printf("   Reading %d timelines with %d workers...\n", numReads, concurrent)
-	readLatencies, cacheHits := benchmarkReads(ctx, strategy, users, numReads, concurrent)
-
-	// Calculate statistics
-	result.WriteLatencyP50 = percentile(writeLatencies, 50)
-	result.WriteLatencyP95 = percentile(writeLatencies, 95)
-	result.WriteLatencyP99 = percentile(writeLatencies, 99)
-	result.WriteLatencyAvg = avg(writeLatencies)
-
-	result.ReadLatencyP50 = percentile(readLatencies, 50)
-	result.ReadLatencyP95 = percentile(readLatencies, 95)
-	result.ReadLatencyP99 = percentile(readLatencies, 99)
-	result.ReadLatencyAvg = avg(readLatencies)
-
-	totalWriteTime := sum(writeLatencies)
-	totalReadTime := sum(readLatencies)
-	
-	result.WriteThroughput = float64(numTweets) / totalWriteTime.Seconds() * float64(concurrent)
-	result.ReadThroughput = float64(numReads) / totalReadTime.Seconds() * float64(concurrent)
-	result.CacheHitRate = float64(cacheHits) / float64(numReads)
-	result.Duration = totalWriteTime + totalReadTime
+	if duration > 0 {
+		fmt.Printf("   Reading timelines for %s with %d workers...\n", duration, concurrent)
+	} else {
+		fmt.Printf("   Reading %d timelines with %d workers...\n", numReads, concurrent)
+	}
+	readsStart := time.Now()
+	benchmarkReads(ctx, strategy, users, numReads, duration, concurrent, recorder)
+	readsElapsed := time.Since(readsStart)
+
+	if snapshots != nil {
+		result.Snapshots = snapshots.stop()
+	}
+
+	// Calculate statistics. Throughput is completed operations over each
+	// phase's wall-clock time.
+	writes := recorder.result(opWrite, writesElapsed)
+	reads := recorder.result(opRead, readsElapsed)
+
+	result.TotalTweets = writes.Count
+	result.WriteLatencyP50 = writes.LatencyP50
+	result.WriteLatencyP95 = writes.LatencyP95
+	result.WriteLatencyP99 = writes.LatencyP99
+	result.WriteLatencyAvg = writes.LatencyAvg
+	result.WriteThroughput = writes.Throughput
+
+	result.TotalReads = reads.Count
+	result.ReadLatencyP50 = reads.LatencyP50
+	result.ReadLatencyP95 = reads.LatencyP95
+	result.ReadLatencyP99 = reads.LatencyP99
+	result.ReadLatencyAvg = reads.LatencyAvg
+	result.ReadThroughput = reads.Throughput
+
+	if reads.Count > 0 {
+		result.CacheHitRate = float64(recorder.cacheHits) / float64(reads.Count)
+	}
+	result.PrivacyCheckAvg = recorder.privacyCheckAvg(reads.Count)
+	result.CandidateGenerationAvg, result.ScoringAvg, result.TruncationAvg = recorder.rankingAvgs()
+	result.Duration = time.Since(start)
 
 	fmt.Printf("   ✓ Complete\n\n")
 
 	return result
 }
 
-func benchmarkWrites(ctx context.Context, strategy timeline.Strategy, users []*models.User, count, concurrent int) []time.Duration {
-	latencies := make([]time.Duration, 0, count)
-	var mu sync.Mutex
+// runWorkers runs op on concurrent workers, count times in total or, with a
+// duration, until the duration is up
+func runWorkers(count int, duration time.Duration, concurrent int, label string, op func()) {
 	var wg sync.WaitGroup
-	
-	tweetsPerWorker := count / concurrent
 	var completed int64
 
-	sampleContent := []string{
-		"Benchmark tweet #1",
-		"Testing the system",
-		"Performance test in progress",
-		"Just another tweet",
-		"Measuring latency",
-	}
+	perWorker := count / concurrent
+	deadline := time.Now().Add(duration)
 
 	for i := 0; i < concurrent; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			
-			for j := 0; j < tweetsPerWorker; j++ {
-				user := users[rand.Intn(len(users))]
-				content := sampleContent[rand.Intn(len(sampleContent))]
-				
-				start := time.Now()
-				_, _, err := strategy.PostTweet(ctx, user.ID, content)
-				elapsed := time.Since(start)
-				
-				if err == nil {
-					mu.Lock()
-					latencies = append(latencies, elapsed)
-					mu.Unlock()
+
+			for j := 0; ; j++ {
+				if duration > 0 {
+					if !time.Now().Before(deadline) {
+						return
+					}
+				} else if j >= perWorker {
+					return
 				}
-				
+
+				op()
+
+				// Timed runs report progress through snapshots instead
 				c := atomic.AddInt64(&completed, 1)
-				if c%100 == 0 {
-					fmt.Printf("   Progress: %d/%d tweets\r", c, count)
+				if duration == 0 && c%100 == 0 {
+					fmt.Printf("   Progress: %d/%d %s\r", c, count, label)
 				}
 			}
 		}()
 	}
 
 	wg.Wait()
-	fmt.Printf("   Progress: %d/%d tweets\n", count, count)
-	
-	return latencies
+	if duration == 0 {
+		fmt.Printf("   Progress: %d/%d %s\n", count, count, label)
+	}
 }
 
-func benchmarkReads(ctx context.Context, strategy timeline.Strategy, users []*models.User, count, concurrent int) ([]time.Duration, int) {
-	latencies := make([]time.Duration, 0, count)
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-	var cacheHits int64
-	
-	readsPerWorker := count / concurrent
-	var completed int64
+func benchmarkWrites(ctx context.Context, strategy timeline.Strategy, users []*models.User, fanOut *fanOutTally, count int, duration time.Duration, concurrent int, recorder *latencyRecorder) {
+	sampleContent := []string{
+		"Benchmark tweet #1",
+		"Testing the system",
+		"Performance test in progress",
+		"Just another tweet",
+		"Measuring latency",
+	}
 
-	for i := 0; i < concurrent; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			
-			for j := 0; j < readsPerWorker; j++ {
-				user := users[rand.Intn(len(users))]
-				
-				start := time.Now()
-				_, metrics, err := strategy.GetTimeline(ctx, user.ID, 50, 0)
-				elapsed := time.Since(start)
-				
-				if err == nil {
-					mu.Lock()
-					latencies = append(latencies, elapsed)
-					mu.Unlock()
-					
-					if metrics != nil && metrics.CacheHit {
-						atomic.AddInt64(&cacheHits, 1)
-					}
-				}
-				
-				c := atomic.AddInt64(&completed, 1)
-				if c%100 == 0 {
-					fmt.Printf("   Progress: %d/%d reads\r", c, count)
-				}
-			}
-		}()
+	runWorkers(count, duration, concurrent, "tweets", func() {
+		user := users[rand.Intn(len(users))]
+		content := sampleContent[rand.Intn(len(sampleContent))]
+
+		start := time.Now()
+		_, metrics, err := strategy.PostTweet(ctx, user.ID, content)
+		recorder.record(opWrite, time.Since(start), nil, err)
+		fanOut.add(metrics)
+	})
+}
+
+// waitForFanOutDrain polls the queue until it is empty and returns the time since start
+func waitForFanOutDrain(ctx context.Context, queue *cache.FanOutQueue, start time.Time) time.Duration {
+	for {
+		stats, err := queue.Stats(ctx)
+		if err != nil {
+			fmt.Printf("   ⚠️  Failed to read queue stats: %v\n", err)
+			return 0
+		}
+		if stats.Length+stats.Delayed == 0 {
+			return time.Since(start)
+		}
+		fmt.Printf("   Queue: %d jobs remaining\r", stats.Length+stats.Delayed)
+		time.Sleep(100 * time.Millisecond)
 	}
+}
 
-	wg.Wait()
-	fmt.Printf("   Progress: %d/%d reads\n", count, count)
-	
-	return latencies, int(cacheHits)
+func benchmarkReads(ctx context.Context, strategy timeline.Strategy, users []*models.User, count int, duration time.Duration, concurrent int, recorder *latencyRecorder) {
+	runWorkers(count, duration, concurrent, "reads", func() {
+		user := users[rand.Intn(len(users))]
+
+		start := time.Now()
+		_, metrics, err := strategy.GetTimeline(ctx, user.ID, models.Page{Limit: 50})
+		recorder.record(opRead, time.Since(start), metrics, err)
+	})
 }
 
 func printResults(results []*models.BenchmarkResult) {
@@ -303,10 +623,77 @@ func printResults(results []*models.BenchmarkResult) {
 		)
 	}
 
+	for _, r := range results {
+		if len(r.Operations) > 0 {
+			printOperations(r)
+		}
+	}
+
+	for _, r := range results {
+		if r.FanOutCompletion > 0 {
+			fmt.Println()
+			fmt.Printf("Async fan-out (%s): all followers updated %s after first write\n",
+				r.Strategy, r.FanOutCompletion.Round(time.Millisecond))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("Write amplification (timelines written per post):")
+	fmt.Printf("%-15s │ %-15s │ %-15s\n",
+		"Strategy", "Timelines", "List timelines")
+	fmt.Println("────────────────┼─────────────────┼─────────────────")
+
+	for _, r := range results {
+		fmt.Printf("%-15s │ %-15.1f │ %-15.1f\n",
+			r.Strategy,
+			r.WriteAmplification,
+			r.ListWriteAmplification,
+		)
+	}
+
+	for _, r := range results {
+		if r.PrivacyCheckAvg > 0 {
+			fmt.Println()
+			fmt.Printf("Privacy check (%s): %s per read on average, of %s average read latency\n",
+				r.Strategy, r.PrivacyCheckAvg.Round(time.Microsecond), r.ReadLatencyAvg.Round(time.Microsecond))
+		}
+	}
+
+	for _, r := range results {
+		if r.RankingScorer != "" {
+			fmt.Println()
+			fmt.Printf("Ranking (%s, %s scorer): %s candidates, %s scoring, %s truncation per read on average\n",
+				r.Strategy, r.RankingScorer, r.CandidateGenerationAvg.Round(time.Microsecond),
+				r.ScoringAvg.Round(time.Microsecond), r.TruncationAvg.Round(time.Microsecond))
+		}
+	}
+
 	fmt.Println()
 	fmt.Println("═══════════════════════════════════════════════════════════════════")
 }
 
+// printOperations prints the per-operation breakdown of a mixed workload
+func printOperations(r *models.BenchmarkResult) {
+	fmt.Println()
+	fmt.Printf("Mixed workload (%s): %s at %.0f/s target, %.1f/s achieved, max schedule lag %s\n",
+		r.Strategy, r.Mix, r.TargetRate, r.AchievedRate, r.ScheduleLag.Round(time.Microsecond))
+	fmt.Printf("%-10s │ %-8s │ %-6s │ %-12s │ %-12s │ %-12s │ %-10s\n",
+		"Operation", "Count", "Errors", "P50", "P95", "P99", "Ops/sec")
+	fmt.Println("───────────┼──────────┼────────┼──────────────┼──────────────┼──────────────┼────────────")
+
+	for _, op := range r.Operations {
+		fmt.Printf("%-10s │ %-8d │ %-6d │ %-12s │ %-12s │ %-12s │ %-10.1f\n",
+			op.Operation,
+			op.Count,
+			op.Errors,
+			op.LatencyP50.Round(time.Microsecond),
+			op.LatencyP95.Round(time.Microsecond),
+			op.LatencyP99.Round(time.Microsecond),
+			op.Throughput,
+		)
+	}
+}
+
 func saveResults(results []*models.BenchmarkResult, filename string) {
 	jsonResults := make([]models.BenchmarkResultJSON, len(results))
 	for i, r := range results {
@@ -356,11 +743,3 @@ func avg(durations []time.Duration) time.Duration {
 	}
 	return total / time.Duration(len(durations))
 }
-
-func sum(durations []time.Duration) time.Duration {
-	var total time.Duration
-	for _, d := range durations {
-		total += d
-	}
-	return total
-}
@@ -42,6 +42,7 @@ var configShowCmd = &cobra.Command{
 		fmt.Println("Current Configuration:")
 		fmt.Println("======================")
 		fmt.Printf("Server Port:          %s\n", cfg.ServerPort)
+		fmt.Printf("Storage Backend:      %s\n", cfg.Backend)
 		fmt.Printf("PostgreSQL Host:      %s:%s\n", cfg.PostgresHost, cfg.PostgresPort)
 		fmt.Printf("PostgreSQL Database:  %s\n", cfg.PostgresDB)
 		fmt.Printf("Redis Host:           %s:%s\n", cfg.RedisHost, cfg.RedisPort)
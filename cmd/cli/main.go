@@ -4,9 +4,17 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/ritik/twitter-fan-out/internal/config"
+	"github.com/ritik/twitter-fan-out/internal/storage"
 	"github.com/spf13/cobra"
 )
 
+var backend string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&backend, "backend", "", "Storage backend: postgres or memory (default $STORAGE_BACKEND, else postgres)")
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "fanout",
 	Short: "Twitter Fan-Out Timeline Prototype CLI",
@@ -25,3 +33,18 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// openStores opens the storage backend selected by --backend or the config,
+// exiting on failure
+func openStores(cfg *config.Config) *storage.Stores {
+	if backend != "" {
+		cfg.Backend = backend
+	}
+
+	stores, err := storage.Open(cfg)
+	if err != nil {
+		fmt.Printf("❌ Failed to open %s backend: %v\n", cfg.Backend, err)
+		os.Exit(1)
+	}
+	return stores
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ritik/twitter-fan-out/internal/config"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+	"github.com/ritik/twitter-fan-out/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateDir       string
+	migrateUpSteps   int
+	migrateDownSteps int
+	migrateDownAll   bool
+)
+
+func init() {
+	migrateCmd.PersistentFlags().StringVar(&migrateDir, "dir", "migrations", "Directory containing the migration files")
+	migrateUpCmd.Flags().IntVar(&migrateUpSteps, "steps", 0, "Apply at most this many migrations (default: all pending)")
+	migrateDownCmd.Flags().IntVar(&migrateDownSteps, "steps", 1, "Roll back this many migrations")
+	migrateDownCmd.Flags().BoolVar(&migrateDownAll, "all", false, "Roll back every migration, dropping the schema")
+
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+	migrateCmd.AddCommand(migrateForceCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage database schema migrations",
+	Long: `Apply, roll back and inspect the PostgreSQL schema migrations.
+
+Migrations are numbered files in --dir (NNN_description.sql), each with a
+"-- +migrate Up" section and a "-- +migrate Down" section. The applied
+version is recorded in the schema_migrations table. Each migration runs in a
+transaction; if the process dies part way the version is left marked dirty,
+and the server refuses to start until it is repaired and forced.
+
+The server and 'fanout seed' apply pending migrations on start.`,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply pending migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator, done := openMigrator()
+		defer done()
+
+		applied, err := migrator.Up(context.Background(), migrateUpSteps)
+		for _, m := range applied {
+			fmt.Printf("   ⬆️  %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if len(applied) == 0 {
+			fmt.Println("✅ Schema is up to date")
+			return
+		}
+		fmt.Printf("✅ Applied %d migration(s)\n", len(applied))
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back applied migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator, done := openMigrator()
+		defer done()
+
+		steps := migrateDownSteps
+		if migrateDownAll {
+			steps = 0
+		} else if steps <= 0 {
+			fmt.Println("❌ --steps must be positive (use --all to roll back everything)")
+			os.Exit(1)
+		}
+
+		rolledBack, err := migrator.Down(context.Background(), steps)
+		for _, m := range rolledBack {
+			fmt.Printf("   ⬇️  %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("✅ No migrations to roll back")
+			return
+		}
+		fmt.Printf("✅ Rolled back %d migration(s)\n", len(rolledBack))
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the schema version and pending migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator, done := openMigrator()
+		defer done()
+
+		ctx := context.Background()
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("📋 Schema Migrations")
+		fmt.Printf("   Current version: %d\n", version)
+		fmt.Printf("   Latest version:  %d\n", migrator.Latest())
+		fmt.Println()
+		for _, s := range statuses {
+			mark := "⏳ pending"
+			if s.Applied {
+				mark = "✓  applied"
+			}
+			if dirty && s.Version == version {
+				mark = "⚠️  dirty"
+			}
+			fmt.Printf("   %s  %03d_%s\n", mark, s.Version, s.Name)
+		}
+
+		if err := migrator.Check(ctx); err != nil {
+			fmt.Println()
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var migrateForceCmd = &cobra.Command{
+	Use:   "force VERSION",
+	Short: "Mark the schema as clean at VERSION without running migrations",
+	Long: `Record VERSION as the current schema version and clear the dirty flag,
+without running any SQL. Use it after repairing the schema by hand following
+an interrupted migration. VERSION 0 means no migrations are applied.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || version < 0 {
+			fmt.Printf("❌ Invalid version %q\n", args[0])
+			os.Exit(1)
+		}
+
+		migrator, done := openMigrator()
+		defer done()
+
+		if err := migrator.Force(context.Background(), version); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Schema version set to %d\n", version)
+	},
+}
+
+// openMigrator opens the configured backend and loads the migrations,
+// exiting if the backend has no schema
+func openMigrator() (*repository.Migrator, func()) {
+	stores := openStores(config.Get())
+	if stores.Backend == storage.BackendMemory {
+		fmt.Println("❌ The memory backend has no schema to migrate")
+		os.Exit(1)
+	}
+
+	migrator, err := stores.Migrator(migrateDir)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	return migrator, stores.Close
+}
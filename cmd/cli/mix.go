@@ -0,0 +1,497 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+	"github.com/ritik/twitter-fan-out/internal/timeline"
+)
+
+// Benchmark operations; any of them can appear in a mixed workload
+const (
+	opRead     = "read"
+	opWrite    = "write"
+	opFollow   = "follow"
+	opUnfollow = "unfollow"
+	opRetweet  = "retweet"
+	opReply    = "reply"
+	opLike     = "like"
+	opMute     = "mute"
+	opBlock    = "block"
+	opProtect  = "protect"
+	opListRead = "list_read"
+)
+
+var workloadOps = []string{opRead, opWrite, opFollow, opUnfollow, opRetweet, opReply, opLike, opMute, opBlock, opProtect, opListRead}
+
+// workloadMix is a weighted choice between operations
+type workloadMix struct {
+	ops     []string
+	weights []int
+	total   int
+}
+
+// parseMix parses a mix such as "read=90,write=9,follow=1". Weights are
+// relative and need not add up to 100.
+func parseMix(s string) (*workloadMix, error) {
+	mix := &workloadMix{}
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(s, ",") {
+		op, weightStr, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry %q (expected op=weight)", part)
+		}
+		op = strings.TrimSpace(op)
+
+		known := false
+		for _, o := range workloadOps {
+			if o == op {
+				known = true
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown operation %q (expected one of %s)", op, strings.Join(workloadOps, ", "))
+		}
+		if seen[op] {
+			return nil, fmt.Errorf("operation %q listed twice", op)
+		}
+		seen[op] = true
+
+		weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight for %s: %q", op, weightStr)
+		}
+		if weight == 0 {
+			continue
+		}
+
+		mix.ops = append(mix.ops, op)
+		mix.weights = append(mix.weights, weight)
+		mix.total += weight
+	}
+
+	if mix.total == 0 {
+		return nil, fmt.Errorf("mix %q has no operations", s)
+	}
+	return mix, nil
+}
+
+// pick chooses an operation with probability proportional to its weight
+func (m *workloadMix) pick() string {
+	n := rand.Intn(m.total)
+	for i, w := range m.weights {
+		if n < w {
+			return m.ops[i]
+		}
+		n -= w
+	}
+	return m.ops[len(m.ops)-1]
+}
+
+// includes reports whether op has a nonzero weight
+func (m *workloadMix) includes(op string) bool {
+	for _, o := range m.ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *workloadMix) String() string {
+	parts := make([]string, len(m.ops))
+	for i, op := range m.ops {
+		parts[i] = fmt.Sprintf("%s=%d", op, m.weights[i])
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseRate parses an arrival rate such as "2000/s", "500/100ms" or "120/m"
+// into operations per second. A bare number is per second.
+func parseRate(s string) (float64, error) {
+	countStr, unit, hasUnit := strings.Cut(strings.TrimSpace(s), "/")
+	count, err := strconv.ParseFloat(countStr, 64)
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	if !hasUnit {
+		return count, nil
+	}
+
+	// Allow "s" as shorthand for "1s"
+	if unit != "" && (unit[0] < '0' || unit[0] > '9') {
+		unit = "1" + unit
+	}
+	per, err := time.ParseDuration(unit)
+	if err != nil || per <= 0 {
+		return 0, fmt.Errorf("invalid rate unit in %q", s)
+	}
+	return count / per.Seconds(), nil
+}
+
+// scheduledOp is an operation and the time it should have been sent
+type scheduledOp struct {
+	kind     string
+	intended time.Time
+}
+
+// followLog remembers follows made during the run so unfollows can undo them
+type followLog struct {
+	mu      sync.Mutex
+	follows [][2]int64
+}
+
+func (l *followLog) add(followerID, followeeID int64) {
+	l.mu.Lock()
+	l.follows = append(l.follows, [2]int64{followerID, followeeID})
+	l.mu.Unlock()
+}
+
+func (l *followLog) take() ([2]int64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.follows) == 0 {
+		return [2]int64{}, false
+	}
+	i := rand.Intn(len(l.follows))
+	f := l.follows[i]
+	l.follows[i] = l.follows[len(l.follows)-1]
+	l.follows = l.follows[:len(l.follows)-1]
+	return f, true
+}
+
+// protectedUsers remembers which users the run has protected, so the
+// protect op can toggle them and the run can restore them afterwards
+type protectedUsers struct {
+	mu        sync.Mutex
+	protected map[int64]bool
+}
+
+func newProtectedUsers() *protectedUsers {
+	return &protectedUsers{protected: make(map[int64]bool)}
+}
+
+// toggle flips userID's protection and returns the new setting
+func (p *protectedUsers) toggle(userID int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.protected[userID] = !p.protected[userID]
+	return p.protected[userID]
+}
+
+func (p *protectedUsers) set(userID int64) {
+	p.mu.Lock()
+	p.protected[userID] = true
+	p.mu.Unlock()
+}
+
+// touched returns every user the run has protected at some point
+func (p *protectedUsers) touched() []int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ids := make([]int64, 0, len(p.protected))
+	for id := range p.protected {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// mixedServices are what mixed operations use besides the strategy
+type mixedServices struct {
+	counters  *timeline.Counters
+	blocks    *timeline.Blocks
+	requests  *timeline.FollowRequests
+	protected *protectedUsers
+	fanOut    *fanOutTally
+	listIDs   []int64
+}
+
+// seenTweetsLimit bounds the tweets remembered for retweeting
+const seenTweetsLimit = 10000
+
+// seenTweets remembers tweets that reads returned, so retweets, replies and likes
+// pick tweets users have actually seen - which favours celebrities' tweets, as on the
+// real site - without paying for a read of their own
+type seenTweets struct {
+	mu   sync.Mutex
+	ids  []int64
+	next int
+}
+
+func (s *seenTweets) add(tweets []*models.Tweet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range tweets {
+		if len(s.ids) < seenTweetsLimit {
+			s.ids = append(s.ids, t.RootID())
+			continue
+		}
+		s.ids[s.next] = t.RootID()
+		s.next = (s.next + 1) % seenTweetsLimit
+	}
+}
+
+func (s *seenTweets) pick() (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.ids) == 0 {
+		return 0, false
+	}
+	return s.ids[rand.Intn(len(s.ids))], true
+}
+
+// runMixedBenchmark sends count operations drawn from mix at a fixed arrival
+// rate, executed by a pool of concurrent workers. With a duration it keeps
+// sending until the duration is up instead. The schedule is open-loop: a
+// slow operation delays the ones queued behind it but not their intended
+// send times, so queueing shows up in the latencies instead of being hidden
+// by a lower request rate (coordinated omission).
+func runMixedBenchmark(ctx context.Context, strategy timeline.Strategy, services *mixedServices, users []*models.User, mix *workloadMix, rate float64, count int, duration time.Duration, concurrent int) *models.BenchmarkResult {
+	fmt.Printf("📈 Benchmarking %s...\n", strategy.Name())
+	if duration > 0 {
+		fmt.Printf("   Sending operations (%s) at %.0f/s for %s with %d workers...\n", mix, rate, duration, concurrent)
+	} else {
+		fmt.Printf("   Sending %d operations (%s) at %.0f/s with %d workers...\n", count, mix, rate, concurrent)
+	}
+
+	result := &models.BenchmarkResult{
+		Strategy:   strategy.Name(),
+		Timestamp:  time.Now(),
+		Mix:        mix.String(),
+		TargetRate: rate,
+	}
+
+	recorder := newLatencyRecorder()
+	follows := &followLog{}
+	seen := &seenTweets{}
+	queue := make(chan scheduledOp, concurrent)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range queue {
+				recorder.recordLag(time.Since(op.intended))
+				metrics, err := runMixedOp(ctx, strategy, services, users, follows, seen, op.kind)
+				recorder.record(op.kind, time.Since(op.intended), metrics, err)
+			}
+		}()
+	}
+
+	var snapshots *snapshotter
+	if duration > 0 {
+		snapshots = startSnapshots(recorder, mix.ops, benchSnapshotInterval)
+	}
+
+	start := time.Now()
+	sent := dispatch(queue, mix.pick, start, rate, count, duration)
+	close(queue)
+	wg.Wait()
+	elapsed := time.Since(start)
+	if snapshots != nil {
+		result.Snapshots = snapshots.stop()
+	} else {
+		fmt.Printf("   Progress: %d/%d ops\n", sent, count)
+	}
+
+	if async, ok := strategy.(timeline.AsyncFanOut); ok && async.FanOutQueue() != nil {
+		fmt.Printf("   Waiting for fan-out queue to drain...\n")
+		result.FanOutCompletion = waitForFanOutDrain(ctx, async.FanOutQueue(), start)
+	}
+
+	// Per-operation results, in the order the mix lists them
+	completed := 0
+	for _, op := range mix.ops {
+		opResult := recorder.result(op, elapsed)
+		result.Operations = append(result.Operations, opResult)
+		completed += opResult.Count
+
+		// Fill the read and write columns so mixed runs compare with phased ones
+		switch op {
+		case opWrite:
+			result.TotalTweets = opResult.Count
+			result.WriteLatencyP50 = opResult.LatencyP50
+			result.WriteLatencyP95 = opResult.LatencyP95
+			result.WriteLatencyP99 = opResult.LatencyP99
+			result.WriteLatencyAvg = opResult.LatencyAvg
+			result.WriteThroughput = opResult.Throughput
+		case opRead:
+			result.TotalReads = opResult.Count
+			result.ReadLatencyP50 = opResult.LatencyP50
+			result.ReadLatencyP95 = opResult.LatencyP95
+			result.ReadLatencyP99 = opResult.LatencyP99
+			result.ReadLatencyAvg = opResult.LatencyAvg
+			result.ReadThroughput = opResult.Throughput
+			if opResult.Count > 0 {
+				result.CacheHitRate = float64(recorder.cacheHits) / float64(opResult.Count)
+			}
+			result.PrivacyCheckAvg = recorder.privacyCheckAvg(opResult.Count)
+			result.CandidateGenerationAvg, result.ScoringAvg, result.TruncationAvg = recorder.rankingAvgs()
+		}
+	}
+
+	result.AchievedRate = float64(completed) / elapsed.Seconds()
+	result.ScheduleLag = recorder.scheduleLag
+	result.Duration = elapsed
+
+	fmt.Printf("   ✓ Complete\n\n")
+
+	return result
+}
+
+// dispatch sends count operations chosen by pick onto queue at rate per
+// second from start, or keeps sending until duration is up if it's set, and
+// returns how many it sent. If every worker is busy it blocks, then catches
+// up; the intended times don't move.
+func dispatch(queue chan<- scheduledOp, pick func() string, start time.Time, rate float64, count int, duration time.Duration) int {
+	deadline := start.Add(duration)
+	interval := float64(time.Second) / rate
+	sent := 0
+	for i := 0; duration > 0 || i < count; i++ {
+		intended := start.Add(time.Duration(float64(i) * interval))
+		if duration > 0 && !intended.Before(deadline) {
+			break
+		}
+		if d := time.Until(intended); d > 0 {
+			time.Sleep(d)
+		}
+		queue <- scheduledOp{kind: pick(), intended: intended}
+		sent++
+
+		if duration == 0 && sent%100 == 0 {
+			fmt.Printf("   Progress: %d/%d ops\r", sent, count)
+		}
+	}
+	return sent
+}
+
+// runMixedOp performs one operation against random users. For a read, of a
+// home or list timeline, it returns the read's metrics, which say whether
+// the timeline cache served it.
+func runMixedOp(ctx context.Context, strategy timeline.Strategy, services *mixedServices, users []*models.User, follows *followLog, seen *seenTweets, kind string) (*timeline.OperationMetrics, error) {
+	user := users[rand.Intn(len(users))]
+
+	switch kind {
+	case opRead:
+		tweets, metrics, err := strategy.GetTimeline(ctx, user.ID, models.Page{Limit: 50})
+		seen.add(tweets)
+		return metrics, err
+
+	case opWrite:
+		_, metrics, err := strategy.PostTweet(ctx, user.ID, "Mixed workload tweet")
+		services.fanOut.add(metrics)
+		return nil, err
+
+	case opFollow:
+		// Following a protected user only files a request
+		followee := randomOtherUser(users, user)
+		_, requested, err := services.requests.Follow(ctx, strategy, user.ID, followee.ID)
+		if err == nil && !requested {
+			follows.add(user.ID, followee.ID)
+		}
+		return nil, err
+
+	case opUnfollow:
+		// Undo a follow from this run so the graph doesn't drift; with none
+		// to undo, unfollow a random pair (usually a no-op)
+		f, ok := follows.take()
+		if !ok {
+			f = [2]int64{user.ID, randomOtherUser(users, user).ID}
+		}
+		_, err := services.requests.Unfollow(ctx, strategy, f[0], f[1])
+		return nil, err
+
+	case opRetweet:
+		tweetID, ok, err := pickSeenTweet(ctx, strategy, user, seen)
+		if err != nil || !ok {
+			return nil, err
+		}
+		_, metrics, err := strategy.Retweet(ctx, user.ID, tweetID)
+		services.fanOut.add(metrics)
+		if errors.Is(err, repository.ErrAlreadyRetweeted) || errors.Is(err, repository.ErrProtectedTweet) {
+			// Random picks collide, or land on a tweet that was protected
+			// after it was seen; either is rejected before any fan-out
+			return nil, nil
+		}
+		return nil, err
+
+	case opReply:
+		tweetID, ok, err := pickSeenTweet(ctx, strategy, user, seen)
+		if err != nil || !ok {
+			return nil, err
+		}
+		_, metrics, err := strategy.PostReply(ctx, user.ID, tweetID, "Mixed workload reply")
+		services.fanOut.add(metrics)
+		return nil, err
+
+	case opLike:
+		tweetID, ok, err := pickSeenTweet(ctx, strategy, user, seen)
+		if err != nil || !ok {
+			return nil, err
+		}
+		_, err = services.counters.Like(ctx, user.ID, tweetID)
+		if errors.Is(err, repository.ErrAlreadyLiked) {
+			return nil, nil
+		}
+		return nil, err
+
+	case opMute:
+		_, err := services.blocks.Mute(ctx, user.ID, randomOtherUser(users, user).ID)
+		return nil, err
+
+	case opBlock:
+		_, err := services.blocks.Block(ctx, user.ID, randomOtherUser(users, user).ID)
+		return nil, err
+
+	case opProtect:
+		// Unprotecting approves the user's pending requests
+		_, err := services.requests.SetProtected(ctx, strategy, user.ID, services.protected.toggle(user.ID))
+		return nil, err
+
+	case opListRead:
+		lists, ok := strategy.(timeline.ListTimelines)
+		if !ok {
+			return nil, fmt.Errorf("strategy %s does not support lists", strategy.Name())
+		}
+		listID := services.listIDs[rand.Intn(len(services.listIDs))]
+		tweets, metrics, err := lists.GetListTimeline(ctx, listID, models.Page{Limit: 50})
+		seen.add(tweets)
+		return metrics, err
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", kind)
+}
+
+// pickSeenTweet picks a tweet some read has returned. Until reads have seen
+// anything, it picks from the user's own timeline; ok is false if that's empty.
+func pickSeenTweet(ctx context.Context, strategy timeline.Strategy, user *models.User, seen *seenTweets) (int64, bool, error) {
+	if tweetID, ok := seen.pick(); ok {
+		return tweetID, true, nil
+	}
+	tweets, _, err := strategy.GetTimeline(ctx, user.ID, models.Page{Limit: 50})
+	if err != nil || len(tweets) == 0 {
+		return 0, false, err
+	}
+	seen.add(tweets)
+	return tweets[rand.Intn(len(tweets))].RootID(), true, nil
+}
+
+// randomOtherUser picks a random user other than user, if there is one
+func randomOtherUser(users []*models.User, user *models.User) *models.User {
+	for i := 0; i < 10; i++ {
+		if other := users[rand.Intn(len(users))]; other.ID != user.ID {
+			return other
+		}
+	}
+	return user
+}
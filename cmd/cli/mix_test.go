@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMix(t *testing.T) {
+	mix, err := parseMix("read=90, write=9,follow=1,like=0")
+	if err != nil {
+		t.Fatalf("parseMix: %v", err)
+	}
+	if got := mix.String(); got != "read=90,write=9,follow=1" {
+		t.Errorf("mix = %q, want zero weights dropped", got)
+	}
+	if mix.includes(opLike) || !mix.includes(opFollow) {
+		t.Errorf("includes: like %v, follow %v; want false, true", mix.includes(opLike), mix.includes(opFollow))
+	}
+
+	for _, bad := range []string{"read", "read=x", "read=-1", "dance=1", "read=1,read=2", "read=0"} {
+		if _, err := parseMix(bad); err == nil {
+			t.Errorf("parseMix(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := map[string]float64{
+		"2000":      2000,
+		"2000/s":    2000,
+		"500/100ms": 5000,
+		"120/m":     2,
+		"90/1m":     1.5,
+	}
+	for in, want := range tests {
+		if got, err := parseRate(in); err != nil || got != want {
+			t.Errorf("parseRate(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "0/s", "-5", "10/fortnight", "10/0s"} {
+		if _, err := parseRate(bad); err == nil {
+			t.Errorf("parseRate(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+// A stalled worker delays the operations queued behind it but not their
+// intended send times, so the wait shows up as latency
+func TestDispatchKeepsTheScheduleWhenWorkersStall(t *testing.T) {
+	const (
+		rate  = 1000.0
+		count = 20
+		stall = 10 * time.Millisecond
+	)
+	queue := make(chan scheduledOp)
+	type delivery struct{ intended, received time.Time }
+	done := make(chan []delivery)
+	go func() {
+		var got []delivery
+		for op := range queue {
+			got = append(got, delivery{op.intended, time.Now()})
+			if len(got) == 1 {
+				time.Sleep(stall)
+			}
+		}
+		done <- got
+	}()
+
+	start := time.Now()
+	sent := dispatch(queue, func() string { return opRead }, start, rate, count, 0)
+	close(queue)
+	got := <-done
+
+	if sent != count || len(got) != count {
+		t.Fatalf("sent %d, received %d; want %d", sent, len(got), count)
+	}
+	for i, d := range got {
+		if want := start.Add(time.Duration(i) * time.Millisecond); !d.intended.Equal(want) {
+			t.Fatalf("op %d intended at +%v, want +%v", i, d.intended.Sub(start), want.Sub(start))
+		}
+	}
+	// The op queued behind the stall went out late, but kept its slot
+	if late := got[1].received.Sub(got[1].intended); late < stall-time.Millisecond {
+		t.Errorf("op 1 received %v after its intended time, want it held up by the %v stall", late, stall)
+	}
+}
+
+func TestDispatchStopsAtTheDuration(t *testing.T) {
+	queue := make(chan scheduledOp, 1000)
+	start := time.Now()
+	sent := dispatch(queue, func() string { return opWrite }, start, 1000, 0, 30*time.Millisecond)
+	close(queue)
+
+	if sent != 30 {
+		t.Errorf("sent %d ops in 30ms at 1000/s, want 30", sent)
+	}
+	for op := range queue {
+		if !op.intended.Before(start.Add(30 * time.Millisecond)) {
+			t.Errorf("op intended at +%v, after the 30ms duration", op.intended.Sub(start))
+		}
+		if op.kind != opWrite {
+			t.Errorf("op kind %q, want %q", op.kind, opWrite)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/timeline"
+)
+
+// latencyRecorder collects per-operation latencies from concurrent workers,
+// both for the whole run and for the current snapshot window
+type latencyRecorder struct {
+	mu           sync.Mutex
+	latencies    map[string][]time.Duration
+	errors       map[string]int
+	cacheHits    int
+	privacyCheck time.Duration
+	scheduleLag  time.Duration
+
+	// Ranked reads only: time spent in each stage
+	rankedReads    int
+	candidateTime  time.Duration
+	scoringTime    time.Duration
+	truncationTime time.Duration
+
+	windowStart     time.Time
+	windowLatencies map[string][]time.Duration
+	windowErrors    map[string]int
+
+	now func() time.Time // Wall clock for snapshot windows; replaced in tests
+}
+
+func newLatencyRecorder() *latencyRecorder {
+	return &latencyRecorder{
+		now:             time.Now,
+		latencies:       make(map[string][]time.Duration),
+		errors:          make(map[string]int),
+		windowStart:     time.Now(),
+		windowLatencies: make(map[string][]time.Duration),
+		windowErrors:    make(map[string]int),
+	}
+}
+
+// record adds one completed operation, along with the cache hit, privacy
+// check and ranking stage times from a home timeline read's metrics. Failed
+// operations count as errors and are left out of the latencies.
+func (r *latencyRecorder) record(op string, latency time.Duration, metrics *timeline.OperationMetrics, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.errors[op]++
+		r.windowErrors[op]++
+		return
+	}
+	r.latencies[op] = append(r.latencies[op], latency)
+	r.windowLatencies[op] = append(r.windowLatencies[op], latency)
+	if metrics != nil && op == opRead {
+		if metrics.CacheHit {
+			r.cacheHits++
+		}
+		r.privacyCheck += metrics.PrivacyCheck
+		if metrics.CandidateTime > 0 {
+			r.rankedReads++
+			r.candidateTime += metrics.CandidateTime
+			r.scoringTime += metrics.ScoringTime
+			r.truncationTime += metrics.TruncationTime
+		}
+	}
+}
+
+// privacyCheckAvg is the privacy check time averaged over reads
+func (r *latencyRecorder) privacyCheckAvg(reads int) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if reads == 0 {
+		return 0
+	}
+	return r.privacyCheck / time.Duration(reads)
+}
+
+// rankingAvgs are the ranking stage times averaged over ranked reads
+func (r *latencyRecorder) rankingAvgs() (candidates, scoring, truncation time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rankedReads == 0 {
+		return 0, 0, 0
+	}
+	n := time.Duration(r.rankedReads)
+	return r.candidateTime / n, r.scoringTime / n, r.truncationTime / n
+}
+
+// recordLag notes how long an operation waited past its intended send time
+func (r *latencyRecorder) recordLag(lag time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lag > r.scheduleLag {
+		r.scheduleLag = lag
+	}
+}
+
+// fanOutTally counts the timelines posts were fanned out to, for write
+// amplification. Posts handed to the async worker pool are counted when the
+// pool reports the job done rather than when they are queued.
+type fanOutTally struct {
+	mu            sync.Mutex
+	posts         int
+	timelines     int
+	listTimelines int
+}
+
+// add counts one post's fan-out from its metrics
+func (t *fanOutTally) add(metrics *timeline.OperationMetrics) {
+	if metrics == nil || !metrics.Success || metrics.FanOutQueued {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.posts++
+	t.timelines += metrics.FanOutCount
+	t.listTimelines += metrics.ListFanOutCount
+}
+
+// reset starts a new count, for the next strategy
+func (t *fanOutTally) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.posts, t.timelines, t.listTimelines = 0, 0, 0
+}
+
+// amplification returns the timelines, and list timelines, written per post
+func (t *fanOutTally) amplification() (timelines, listTimelines float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.posts == 0 {
+		return 0, 0
+	}
+	return float64(t.timelines) / float64(t.posts), float64(t.listTimelines) / float64(t.posts)
+}
+
+// result summarizes every recorded op, with throughput over elapsed wall-clock time
+func (r *latencyRecorder) result(op string, elapsed time.Duration) models.OperationResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return operationResult(op, r.latencies[op], r.errors[op], elapsed)
+}
+
+// snapshot summarizes the ops recorded since the previous snapshot and starts
+// a new window. Operations with no activity in the window are skipped.
+func (r *latencyRecorder) snapshot(ops []string) []models.OperationResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	elapsed := now.Sub(r.windowStart)
+
+	var results []models.OperationResult
+	for _, op := range ops {
+		if len(r.windowLatencies[op]) == 0 && r.windowErrors[op] == 0 {
+			continue
+		}
+		results = append(results, operationResult(op, r.windowLatencies[op], r.windowErrors[op], elapsed))
+	}
+
+	r.windowStart = now
+	r.windowLatencies = make(map[string][]time.Duration)
+	r.windowErrors = make(map[string]int)
+	return results
+}
+
+func operationResult(op string, latencies []time.Duration, errors int, elapsed time.Duration) models.OperationResult {
+	result := models.OperationResult{
+		Operation:  op,
+		Count:      len(latencies),
+		Errors:     errors,
+		LatencyP50: percentile(latencies, 50),
+		LatencyP95: percentile(latencies, 95),
+		LatencyP99: percentile(latencies, 99),
+		LatencyAvg: avg(latencies),
+		LatencyMax: percentile(latencies, 100),
+	}
+	if elapsed > 0 {
+		result.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+	return result
+}
+
+// snapshotter periodically records rolling statistics during a timed run
+type snapshotter struct {
+	recorder  *latencyRecorder
+	ops       []string
+	start     time.Time
+	done      chan struct{}
+	wg        sync.WaitGroup
+	snapshots []models.BenchmarkSnapshot
+}
+
+// startSnapshots takes a snapshot of ops every interval, printing each one as
+// progress, until stop is called
+func startSnapshots(recorder *latencyRecorder, ops []string, interval time.Duration) *snapshotter {
+	s := &snapshotter{
+		recorder: recorder,
+		ops:      ops,
+		start:    time.Now(),
+		done:     make(chan struct{}),
+	}
+
+	// Start the first window with the run
+	recorder.snapshot(nil)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.take()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+func (s *snapshotter) take() {
+	elapsed := time.Since(s.start)
+	for _, r := range s.recorder.snapshot(s.ops) {
+		s.snapshots = append(s.snapshots, models.BenchmarkSnapshot{Elapsed: elapsed, OperationResult: r})
+		fmt.Printf("   [%6s] %-8s %8.1f/s  p50 %-10s p95 %-10s p99 %-10s errors %d\n",
+			elapsed.Round(time.Second), r.Operation, r.Throughput,
+			r.LatencyP50.Round(time.Microsecond), r.LatencyP95.Round(time.Microsecond), r.LatencyP99.Round(time.Microsecond),
+			r.Errors)
+	}
+}
+
+// stop ends the snapshots, recording the final partial window, and returns the time series
+func (s *snapshotter) stop() []models.BenchmarkSnapshot {
+	close(s.done)
+	s.wg.Wait()
+	s.take()
+	return s.snapshots
+}
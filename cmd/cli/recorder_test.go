@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestRecorder returns a recorder whose clock only moves when told to
+func newTestRecorder() (*latencyRecorder, *time.Time) {
+	r := newLatencyRecorder()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+	r.windowStart = now
+	return r, &now
+}
+
+// Throughput is ops over the window's wall-clock time, however long each op
+// took - concurrent workers overlap
+func TestSnapshotThroughputIsWallClock(t *testing.T) {
+	r, now := newTestRecorder()
+
+	for i := 0; i < 10; i++ {
+		r.record(opRead, time.Second, nil, nil)
+	}
+	r.record(opWrite, 50*time.Millisecond, nil, errors.New("boom"))
+	*now = now.Add(2 * time.Second)
+
+	results := r.snapshot([]string{opRead, opWrite, opFollow})
+	if len(results) != 2 {
+		t.Fatalf("snapshot = %+v, want read and write only - follow had no activity", results)
+	}
+	read, write := results[0], results[1]
+	if read.Operation != opRead || read.Count != 10 || read.Throughput != 5 {
+		t.Errorf("read = %+v, want 10 ops at 5/s over the 2s window", read)
+	}
+	if read.LatencyP50 != time.Second {
+		t.Errorf("read p50 = %v, want 1s", read.LatencyP50)
+	}
+	if write.Operation != opWrite || write.Count != 0 || write.Errors != 1 || write.Throughput != 0 {
+		t.Errorf("write = %+v, want a single error and no throughput", write)
+	}
+
+	// The next window starts empty
+	r.record(opRead, time.Millisecond, nil, nil)
+	*now = now.Add(500 * time.Millisecond)
+	results = r.snapshot([]string{opRead, opWrite})
+	if len(results) != 1 || results[0].Count != 1 || results[0].Throughput != 2 {
+		t.Errorf("second window = %+v, want 1 read at 2/s", results)
+	}
+
+	// The run totals keep everything
+	if total := r.result(opRead, 10*time.Second); total.Count != 11 || total.Throughput != 1.1 {
+		t.Errorf("run result = %+v, want 11 reads at 1.1/s", total)
+	}
+}
+
+func TestSnapshotOfAnEmptyWindow(t *testing.T) {
+	r, _ := newTestRecorder()
+	if results := r.snapshot([]string{opRead}); len(results) != 0 {
+		t.Errorf("snapshot = %+v, want nothing", results)
+	}
+	// A zero-length window has no throughput rather than dividing by zero
+	r.record(opRead, time.Millisecond, nil, nil)
+	if results := r.snapshot([]string{opRead}); len(results) != 1 || results[0].Throughput != 0 {
+		t.Errorf("snapshot = %+v, want 1 read with no throughput", results)
+	}
+}
@@ -90,6 +90,53 @@ func printResultsTable(results []models.BenchmarkResultJSON) {
 		fmt.Printf("    Reads/sec:  %.1f\n", r.ReadThroughput)
 		fmt.Println()
 		fmt.Printf("  Cache Hit Rate: %.1f%%\n", r.CacheHitRate*100)
+		if r.FanOutCompletion != "" {
+			fmt.Printf("  Fan-Out Completion: %s\n", r.FanOutCompletion)
+		}
+		if r.ReplyFilter != "" {
+			fmt.Printf("  Reply Filtering: at %s\n", r.ReplyFilter)
+		}
+		if r.CounterStrategy != "" {
+			fmt.Printf("  Counters: %s\n", r.CounterStrategy)
+		}
+		if r.BlockFilter != "" {
+			fmt.Printf("  Block Filtering: %s\n", r.BlockFilter)
+		}
+		if r.Protected > 0 {
+			fmt.Printf("  Protected Users: %.0f%%\n", r.Protected*100)
+		}
+		if r.PrivacyCheckAvg != "" {
+			fmt.Printf("  Privacy Check: %s avg per read\n", r.PrivacyCheckAvg)
+		}
+		if r.RankingScorer != "" {
+			fmt.Printf("  Ranking: %s scorer, %s candidates, %s scoring, %s truncation avg per read\n",
+				r.RankingScorer, r.CandidateGenerationAvg, r.ScoringAvg, r.TruncationAvg)
+		}
+		if r.WriteAmplification > 0 {
+			fmt.Printf("  Write Amplification: %.1f timelines per post\n", r.WriteAmplification)
+		}
+		if r.Lists > 0 {
+			fmt.Printf("  Lists: %d of %d members, %.1f list timelines per post\n", r.Lists, r.ListSize, r.ListWriteAmplification)
+		}
+		if r.Mix != "" {
+			fmt.Println()
+			fmt.Printf("  Mixed Workload: %s\n", r.Mix)
+			fmt.Printf("    Target Rate:   %.1f ops/sec\n", r.TargetRate)
+			fmt.Printf("    Achieved Rate: %.1f ops/sec\n", r.AchievedRate)
+			fmt.Printf("    Schedule Lag:  %s\n", r.ScheduleLag)
+			for _, op := range r.Operations {
+				fmt.Printf("    %-9s %d ops, %d errors, P50 %s, P95 %s, P99 %s, %.1f/sec\n",
+					op.Operation+":", op.Count, op.Errors, op.LatencyP50, op.LatencyP95, op.LatencyP99, op.Throughput)
+			}
+		}
+		if len(r.Snapshots) > 0 {
+			fmt.Println()
+			fmt.Println("  Snapshots:")
+			for _, snap := range r.Snapshots {
+				fmt.Printf("    %-14s %-9s %9.1f/sec, P50 %s, P95 %s, P99 %s\n",
+					snap.Elapsed, snap.Operation, snap.Throughput, snap.LatencyP50, snap.LatencyP95, snap.LatencyP99)
+			}
+		}
 		fmt.Println()
 	}
 
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ritik/twitter-fan-out/internal/config"
+	"github.com/ritik/twitter-fan-out/internal/storage"
+	"github.com/ritik/twitter-fan-out/internal/timeline"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyStrategy string
+	verifySample   int
+	verifyUsers    []int64
+	verifyRepair   bool
+	verifyVerbose  bool
+)
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyStrategy, "strategy", "", "Strategy to verify (one with cached timelines)")
+	verifyCmd.Flags().IntVar(&verifySample, "sample", 100, "Number of random users to check")
+	verifyCmd.Flags().Int64SliceVar(&verifyUsers, "user", nil, "Check these user IDs instead of a random sample")
+	verifyCmd.Flags().BoolVar(&verifyRepair, "repair", false, "Rebuild the timelines of divergent users")
+	verifyCmd.Flags().BoolVar(&verifyVerbose, "verbose", false, "List the tweet IDs of every divergence")
+	verifyCmd.MarkFlagRequired("strategy")
+
+	rootCmd.AddCommand(verifyCmd)
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check cached timelines against the database",
+	Long: `Sample users and diff each cached timeline against the one the database
+implies: the newest tweets from the users the strategy pushes into it.
+
+Reports:
+  - Missing: tweets that should be in the timeline but aren't
+  - Extra:   tweets from authors the user doesn't (or no longer) get pushed
+  - Stale:   tweets that have been deleted
+  - Cold:    users with no cached timeline at all
+
+Strategies that share the timeline cache (fanout_write, hybrid and for_you)
+disagree about celebrity tweets, so --strategy is required: verify the one
+that has been serving writes. Checking the others would report its timelines
+as divergent, and --repair would rewrite them for the wrong strategy.
+With BLOCK_FILTER=purge, blocked and muted authors' tweets count as extra.`,
+	Run: runVerify,
+}
+
+func runVerify(cmd *cobra.Command, args []string) {
+	cfg := config.Get()
+	ctx := context.Background()
+
+	stores := openStores(cfg)
+	defer stores.Close()
+	if stores.Backend == storage.BackendMemory {
+		fmt.Println("ℹ️  The memory backend starts empty - there is nothing to verify across runs")
+	}
+
+	// Repairs must push the same tweets the server would
+	blocks := timeline.NewBlocks(stores.Blocks, stores.Tweets, stores.Cache, cfg.BlockFilter)
+	registry := timeline.NewRegistry(timeline.Dependencies{
+		TweetRepo:  stores.Tweets,
+		FollowRepo: stores.Follows,
+		UserRepo:   stores.Users,
+		Cache:      stores.Cache,
+		Config:     cfg,
+		Blocks:     blocks,
+	})
+
+	s, ok := registry.Get(verifyStrategy)
+	if !ok {
+		fmt.Printf("❌ Unknown strategy: %s\n", verifyStrategy)
+		os.Exit(1)
+	}
+	strategy, ok := s.(timeline.Precomputed)
+	if !ok {
+		fmt.Printf("❌ %s does not cache timelines - nothing to verify\n", s.Name())
+		os.Exit(1)
+	}
+
+	userIDs := verifyUsers
+	if len(userIDs) == 0 {
+		users, err := stores.Users.GetRandomUsers(ctx, verifySample)
+		if err != nil || len(users) == 0 {
+			fmt.Printf("❌ No users found. Run 'fanout seed' first.\n")
+			os.Exit(1)
+		}
+		for _, u := range users {
+			userIDs = append(userIDs, u.ID)
+		}
+	}
+
+	fmt.Printf("🔍 Verifying %d timelines...\n\n", len(userIDs))
+
+	verifier := timeline.NewTimelineVerifier(stores.Tweets, stores.Follows, stores.Cache)
+	verifier.SetBlocks(blocks)
+	if !verifyTimelines(ctx, verifier, strategy, userIDs) {
+		os.Exit(1)
+	}
+}
+
+// verifyTimelines checks one strategy and reports whether every timeline ended up consistent
+func verifyTimelines(ctx context.Context, verifier *timeline.TimelineVerifier, strategy timeline.Precomputed, userIDs []int64) bool {
+	fmt.Printf("📋 %s\n", strategy.Name())
+
+	var consistent, divergent, cold, missing, extra, stale, repaired, errors int
+	for _, userID := range userIDs {
+		diff, err := verifier.Verify(ctx, strategy, userID)
+		if err != nil {
+			fmt.Printf("   ⚠️  user %d: %v\n", userID, err)
+			errors++
+			continue
+		}
+
+		switch {
+		case diff.Cold:
+			cold++
+			continue
+		case diff.Consistent():
+			consistent++
+			continue
+		}
+
+		divergent++
+		missing += len(diff.Missing)
+		extra += len(diff.Extra)
+		stale += len(diff.Stale)
+		printDiff(diff)
+
+		if verifyRepair {
+			diff, err = verifier.Repair(ctx, strategy, userID)
+			if err != nil {
+				fmt.Printf("      ❌ repair failed: %v\n", err)
+				continue
+			}
+			if !diff.Consistent() {
+				fmt.Printf("      ❌ still divergent after rebuild\n")
+				printDiff(diff)
+				continue
+			}
+			fmt.Printf("      ✓ rebuilt\n")
+			repaired++
+		}
+	}
+
+	fmt.Printf("   Consistent: %d/%d\n", consistent, len(userIDs))
+	fmt.Printf("   Divergent:  %d (missing %d, extra %d, stale %d)\n", divergent, missing, extra, stale)
+	fmt.Printf("   Cold:       %d (no cached timeline)\n", cold)
+	if verifyRepair {
+		fmt.Printf("   Repaired:   %d\n", repaired)
+	}
+	if errors > 0 {
+		fmt.Printf("   Errors:     %d\n", errors)
+	}
+	fmt.Println()
+
+	if verifyRepair {
+		return divergent == repaired && errors == 0
+	}
+	return divergent == 0 && errors == 0
+}
+
+func printDiff(diff *timeline.TimelineDiff) {
+	fmt.Printf("   ✗ user %d: %d cached, %d expected - missing %d, extra %d, stale %d\n",
+		diff.UserID, diff.Cached, diff.Expected, len(diff.Missing), len(diff.Extra), len(diff.Stale))
+	if !verifyVerbose {
+		return
+	}
+	for _, part := range []struct {
+		label string
+		ids   []int64
+	}{{"missing", diff.Missing}, {"extra", diff.Extra}, {"stale", diff.Stale}} {
+		if len(part.ids) > 0 {
+			fmt.Printf("      %-8s %v\n", part.label+":", part.ids)
+		}
+	}
+}
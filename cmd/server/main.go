@@ -2,59 +2,147 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/ritik/twitter-fan-out/internal/api"
 	"github.com/ritik/twitter-fan-out/internal/cache"
 	"github.com/ritik/twitter-fan-out/internal/config"
-	"github.com/ritik/twitter-fan-out/internal/repository"
+	"github.com/ritik/twitter-fan-out/internal/seed"
+	"github.com/ritik/twitter-fan-out/internal/storage"
 	"github.com/ritik/twitter-fan-out/internal/timeline"
 )
 
 func main() {
+	backend := flag.String("backend", "", "Storage backend: postgres or memory (default $STORAGE_BACKEND, else postgres)")
+	seedUsers := flag.Int("seed-users", 1000, "Users to generate at startup with the memory backend (0 to start empty)")
+	flag.Parse()
+
 	// Load configuration
 	cfg := config.Get()
+	if *backend != "" {
+		cfg.Backend = *backend
+	}
+	if !timeline.IsValidReplyFilter(cfg.ReplyFilter) {
+		log.Fatalf("Invalid REPLY_FILTER %q (use %s)", cfg.ReplyFilter, strings.Join(timeline.ReplyFilters(), " or "))
+	}
+	if !timeline.IsValidBlockFilter(cfg.BlockFilter) {
+		log.Fatalf("Invalid BLOCK_FILTER %q (use %s)", cfg.BlockFilter, strings.Join(timeline.BlockFilters(), " or "))
+	}
+	if !timeline.IsValidScorer(cfg.RankingScorer) {
+		log.Fatalf("Invalid RANKING_SCORER %q (use %s)", cfg.RankingScorer, strings.Join(timeline.Scorers(), ", "))
+	}
+	if !cache.IsValidCounterStrategy(cfg.CounterStrategy) {
+		log.Fatalf("Invalid COUNTER_STRATEGY %q (use %s)", cfg.CounterStrategy, strings.Join(cache.CounterStrategies(), " or "))
+	}
 
-	// Initialize database
-	db, err := repository.InitDB(cfg)
+	// Open storage
+	stores, err := storage.Open(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		log.Fatalf("Failed to open %s backend: %v", cfg.Backend, err)
 	}
-	defer repository.Close()
+	defer stores.Close()
 
-	// Run migrations
-	if err := repository.RunMigrations(db, "migrations"); err != nil {
-		log.Printf("Warning: Failed to run migrations: %v", err)
+	// Run migrations, refusing to start on a dirty or unknown schema version
+	if err := stores.Migrate("migrations"); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
-	// Initialize Redis
-	redisClient, err := cache.InitRedis(cfg)
-	if err != nil {
-		log.Fatalf("Failed to initialize Redis: %v", err)
+	// An in-memory backend starts empty, so generate a data set in-process
+	if stores.Backend == storage.BackendMemory && *seedUsers > 0 {
+		opts := seed.DefaultOptions()
+		opts.Users = *seedUsers
+		fmt.Printf("🌱 Seeding in-memory backend with %d users...\n", opts.Users)
+		if err := seed.Run(context.Background(), seed.Stores{Users: stores.Users, Tweets: stores.Tweets, Follows: stores.Follows}, opts, os.Stdout); err != nil {
+			log.Fatalf("Failed to seed memory backend: %v", err)
+		}
+		fmt.Println()
+	}
+
+	// Announce fan-out writes and new tweets so clients can stream their timelines
+	events := stores.EnableEvents()
+
+	// Set up async fan-out if enabled
+	var fanOutQueue *cache.FanOutQueue
+	if cfg.AsyncFanOut {
+		fanOutQueue = stores.NewFanOutQueue()
+		if fanOutQueue == nil {
+			log.Printf("Warning: the %s backend has no fan-out queue, fanning out inline", stores.Backend)
+		}
 	}
-	defer cache.Close()
 
-	// Create repositories
-	userRepo := repository.NewUserRepository(db)
-	tweetRepo := repository.NewTweetRepository(db)
-	followRepo := repository.NewFollowRepository(db)
+	// Route follows of protected users through follow requests
+	requests := timeline.NewFollowRequests(stores.Users, stores.Follows, stores.FollowRequests)
+
+	// Keep blocked and muted authors out of timelines
+	blocks := timeline.NewBlocks(stores.Blocks, stores.Tweets, stores.Cache, cfg.BlockFilter)
+
+	// Lists get their own timelines, fanned out to alongside followers'
+	lists := timeline.NewLists(stores.Lists, stores.Tweets, stores.Users, stores.Follows, stores.Cache)
+	lists.SetBlocks(blocks)
+
+	// Migrate tweets between pushed timelines and author tweet caches as users cross the threshold
+	reclassifier := timeline.NewReclassifier(stores.Tweets, stores.Follows, stores.Users, stores.Cache, cfg.CelebrityThreshold, timeline.ReclassifierConfig{
+		ChunkSize: cfg.FanOutChunkSize,
+	})
+	reclassifier.SetBlocks(blocks)
+	reclassifier.SetLists(lists)
+	if err := reclassifier.Start(context.Background()); err != nil {
+		log.Fatalf("Failed to start celebrity reclassification: %v", err)
+	}
+	defer reclassifier.Stop()
 
-	// Create cache
-	timelineCache := cache.NewTimelineCache(redisClient, cfg.TimelineCacheSize)
+	// Keep like and retweet counts in the cache, writing them back in batches
+	counters := timeline.NewCounters(stores.Tweets, stores.Counters, timeline.CountersConfig{
+		Strategy:      cfg.CounterStrategy,
+		FlushInterval: time.Duration(cfg.CounterFlushMs) * time.Millisecond,
+	})
+	counters.Start(context.Background())
+	defer counters.Stop()
+
+	// Serve users' own tweets from the author tweet caches every strategy keeps
+	userTweets := timeline.NewUserTweets(stores.Tweets, stores.Users, stores.Follows, stores.Cache)
+	userTweets.SetCounters(counters)
 
 	// Create timeline strategies
-	fanOutWrite := timeline.NewFanOutWriteStrategy(tweetRepo, followRepo, userRepo, timelineCache)
-	fanOutRead := timeline.NewFanOutReadStrategy(tweetRepo, followRepo, userRepo, timelineCache)
-	hybrid := timeline.NewHybridStrategy(tweetRepo, followRepo, userRepo, timelineCache, cfg.CelebrityThreshold)
+	strategies := timeline.NewRegistry(timeline.Dependencies{
+		TweetRepo:    stores.Tweets,
+		FollowRepo:   stores.Follows,
+		UserRepo:     stores.Users,
+		Cache:        stores.Cache,
+		Config:       cfg,
+		FanOutQueue:  fanOutQueue,
+		Reclassifier: reclassifier,
+		Counters:     counters,
+		Blocks:       blocks,
+		Lists:        lists,
+	})
 
 	// Create API handler
-	handler := api.NewHandler(cfg, fanOutWrite, fanOutRead, hybrid, userRepo, followRepo)
+	handler := api.NewHandler(cfg, strategies, stores.Users, stores.Follows, stores.Tweets, fanOutQueue, reclassifier, counters, blocks, requests, lists, userTweets, events)
+
+	// Start fan-out workers
+	if fanOutQueue != nil {
+		pool := timeline.NewFanOutWorkerPool(fanOutQueue, stores.Tweets, stores.Follows, stores.Cache, timeline.FanOutWorkerConfig{
+			Workers:    cfg.FanOutWorkers,
+			ChunkSize:  cfg.FanOutChunkSize,
+			MaxRetries: cfg.FanOutMaxRetries,
+		})
+		pool.SetBlocks(blocks)
+		pool.SetLists(lists)
+		pool.OnComplete(handler.RecordFanOut)
+		if err := pool.Start(context.Background()); err != nil {
+			log.Fatalf("Failed to start fan-out workers: %v", err)
+		}
+		defer pool.Stop()
+	}
 
 	// Create router
 	router := api.NewRouter(handler)
@@ -67,20 +155,60 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	server.RegisterOnShutdown(handler.CloseStreams)
 
 	// Start server in goroutine
 	go func() {
 		fmt.Printf("🚀 Server starting on http://localhost:%s\n", cfg.ServerPort)
+		fmt.Printf("   Storage backend:     %s\n", stores.Backend)
 		fmt.Printf("   Celebrity threshold: %d followers\n", cfg.CelebrityThreshold)
 		fmt.Printf("   Timeline cache size: %d tweets\n", cfg.TimelineCacheSize)
+		fmt.Printf("   Reply filtering:     at %s\n", cfg.ReplyFilter)
+		fmt.Printf("   Block filtering:     %s\n", cfg.BlockFilter)
+		fmt.Printf("   Ranking scorer:      %s\n", cfg.RankingScorer)
+		if cfg.CounterStrategy == cache.CounterSharded {
+			fmt.Printf("   Counters:            %s (%d shards), flushed every %dms\n", cfg.CounterStrategy, cfg.CounterShards, cfg.CounterFlushMs)
+		} else {
+			fmt.Printf("   Counters:            %s, flushed every %dms\n", cfg.CounterStrategy, cfg.CounterFlushMs)
+		}
+		if fanOutQueue != nil {
+			fmt.Printf("   Async fan-out:       %d workers, %d followers/chunk\n", cfg.FanOutWorkers, cfg.FanOutChunkSize)
+		}
 		fmt.Println()
 		fmt.Println("Available endpoints:")
 		fmt.Println("   POST /api/tweet              - Post a tweet")
+		fmt.Println("   DELETE /api/tweets/{id}      - Delete a tweet")
+		fmt.Println("   POST /api/tweets/{id}/retweet - Retweet a tweet")
+		fmt.Println("   POST /api/tweets/{id}/like   - Like a tweet")
+		fmt.Println("   DELETE /api/tweets/{id}/like - Unlike a tweet")
+		fmt.Println("   GET  /api/tweets/{id}/thread - Get a tweet's ancestors and replies")
 		fmt.Println("   GET  /api/timeline/{user_id} - Get user timeline")
+		fmt.Println("   GET  /api/timeline/{user_id}/stream - Stream new timeline tweets (SSE)")
+		fmt.Println("   GET  /api/users/{id}/tweets  - Get a user's own tweets")
+		fmt.Println("   POST /api/users/{id}/follow/{target} - Follow a user")
+		fmt.Println("   DELETE /api/users/{id}/follow/{target} - Unfollow a user")
+		fmt.Println("   POST /api/users/{id}/block/{target} - Block a user")
+		fmt.Println("   DELETE /api/users/{id}/block/{target} - Unblock a user")
+		fmt.Println("   POST /api/users/{id}/mute/{target} - Mute a user")
+		fmt.Println("   DELETE /api/users/{id}/mute/{target} - Unmute a user")
+		fmt.Println("   PUT  /api/users/{id}/protected - Protect or unprotect an account")
+		fmt.Println("   GET  /api/users/{id}/follow_requests - Pending follow requests")
+		fmt.Println("   POST /api/users/{id}/follow_requests/{requester}/approve - Approve a follow request")
+		fmt.Println("   POST /api/users/{id}/follow_requests/{requester}/deny - Deny a follow request")
+		fmt.Println("   POST /api/lists              - Create a list")
+		fmt.Println("   GET  /api/lists/{id}         - Get a list and its members")
+		fmt.Println("   DELETE /api/lists/{id}       - Delete a list")
+		fmt.Println("   GET  /api/users/{id}/lists   - Lists a user owns")
+		fmt.Println("   POST /api/lists/{id}/members/{user_id} - Add a list member")
+		fmt.Println("   DELETE /api/lists/{id}/members/{user_id} - Remove a list member")
+		fmt.Println("   GET  /api/lists/{id}/timeline - Get a list's timeline")
 		fmt.Println("   GET  /api/config             - Get configuration")
 		fmt.Println("   PUT  /api/config             - Update configuration")
 		fmt.Println("   GET  /api/metrics            - Get metrics summary")
 		fmt.Println("   GET  /api/metrics/recent     - Get recent metrics")
+		fmt.Println("   GET  /api/fanout/queue       - Get fan-out queue stats")
+		fmt.Println("   GET  /api/reclassify         - Get celebrity reclassification progress")
+		fmt.Println("   GET  /metrics                - Prometheus metrics")
 		fmt.Println("   GET  /health                 - Health check")
 		fmt.Println()
 
@@ -1,11 +1,18 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/ritik/twitter-fan-out/internal/cache"
 	"github.com/ritik/twitter-fan-out/internal/config"
 	"github.com/ritik/twitter-fan-out/internal/models"
 	"github.com/ritik/twitter-fan-out/internal/repository"
@@ -15,32 +22,75 @@ import (
 // Handler holds all HTTP handlers
 type Handler struct {
 	config         *config.Config
-	fanOutWrite    *timeline.FanOutWriteStrategy
-	fanOutRead     *timeline.FanOutReadStrategy
-	hybrid         *timeline.HybridStrategy
+	strategies     *timeline.Registry
 	metricsStore   *MetricsStore
-	userRepo       *repository.UserRepository
-	followRepo     *repository.FollowRepository
+	userRepo       repository.UserStore
+	followRepo     repository.FollowStore
+	tweetRepo      repository.TweetStore
+	fanOutQueue    *cache.FanOutQueue // nil when async fan-out is disabled
+	reclassifier   *timeline.Reclassifier // nil when reclassification is disabled
+	counters       *timeline.Counters
+	blocks         *timeline.Blocks
+	requests       *timeline.FollowRequests
+	lists          *timeline.Lists
+	userTweets     *timeline.UserTweets
+	events         cache.EventBus         // nil when timeline streaming is disabled
+
+	streamsDone  chan struct{}
+	closeStreams sync.Once
 }
 
 // NewHandler creates a new Handler
 func NewHandler(
 	cfg *config.Config,
-	fanOutWrite *timeline.FanOutWriteStrategy,
-	fanOutRead *timeline.FanOutReadStrategy,
-	hybrid *timeline.HybridStrategy,
-	userRepo *repository.UserRepository,
-	followRepo *repository.FollowRepository,
+	strategies *timeline.Registry,
+	userRepo repository.UserStore,
+	followRepo repository.FollowStore,
+	tweetRepo repository.TweetStore,
+	fanOutQueue *cache.FanOutQueue,
+	reclassifier *timeline.Reclassifier,
+	counters *timeline.Counters,
+	blocks *timeline.Blocks,
+	requests *timeline.FollowRequests,
+	lists *timeline.Lists,
+	userTweets *timeline.UserTweets,
+	events cache.EventBus,
 ) *Handler {
 	return &Handler{
 		config:       cfg,
-		fanOutWrite:  fanOutWrite,
-		fanOutRead:   fanOutRead,
-		hybrid:       hybrid,
+		strategies:   strategies,
 		metricsStore: NewMetricsStore(),
 		userRepo:     userRepo,
 		followRepo:   followRepo,
+		tweetRepo:    tweetRepo,
+		fanOutQueue:  fanOutQueue,
+		reclassifier: reclassifier,
+		counters:     counters,
+		blocks:       blocks,
+		requests:     requests,
+		lists:        lists,
+		userTweets:   userTweets,
+		events:       events,
+		streamsDone:  make(chan struct{}),
+	}
+}
+
+// recordMetrics stores a write's metrics for the dashboard and observes them
+// in Prometheus. Failed writes are only counted as errors.
+func (h *Handler) recordMetrics(m *timeline.OperationMetrics) {
+	if m == nil {
+		return
+	}
+	if m.Success {
+		h.metricsStore.AddWriteMetric(m)
 	}
+	observeWrite(m)
+}
+
+// RecordFanOut stores metrics for a fan-out job completed by the worker pool
+func (h *Handler) RecordFanOut(m *timeline.OperationMetrics) {
+	h.metricsStore.AddFanOutMetric(m)
+	observeFanOutJob(m)
 }
 
 // Response helpers
@@ -54,14 +104,28 @@ func respondError(w http.ResponseWriter, status int, message string) {
 	respondJSON(w, status, map[string]string{"error": message})
 }
 
+// strategy looks up a registered strategy, responding with 400 if it doesn't exist
+func (h *Handler) strategy(w http.ResponseWriter, name string) (timeline.Strategy, bool) {
+	s, ok := h.strategies.Get(name)
+	if !ok {
+		names := make([]string, 0)
+		for _, n := range h.strategies.Names() {
+			names = append(names, string(n))
+		}
+		respondError(w, http.StatusBadRequest, "Invalid strategy. Use: "+strings.Join(names, ", "))
+	}
+	return s, ok
+}
+
 // PostTweetRequest represents the request body for posting a tweet
 type PostTweetRequest struct {
-	UserID   int64  `json:"user_id"`
-	Content  string `json:"content"`
-	Strategy string `json:"strategy"`
+	UserID      int64  `json:"user_id"`
+	Content     string `json:"content"`
+	Strategy    string `json:"strategy"`
+	InReplyToID int64  `json:"in_reply_to_id"` // Optional - posts a reply to this tweet
 }
 
-// PostTweet handles POST /api/tweet
+// PostTweet handles POST /api/tweet. With in_reply_to_id set it posts a reply.
 func (h *Handler) PostTweet(w http.ResponseWriter, r *http.Request) {
 	var req PostTweetRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -81,31 +145,82 @@ func (h *Handler) PostTweet(w http.ResponseWriter, r *http.Request) {
 		req.Strategy = "hybrid" // Default strategy
 	}
 
+	strategy, ok := h.strategy(w, req.Strategy)
+	if !ok {
+		return
+	}
+
 	var tweet *models.Tweet
 	var metrics *timeline.OperationMetrics
 	var err error
+	if req.InReplyToID != 0 {
+		tweet, metrics, err = strategy.PostReply(r.Context(), req.UserID, req.InReplyToID, req.Content)
+	} else {
+		tweet, metrics, err = strategy.PostTweet(r.Context(), req.UserID, req.Content)
+	}
+	h.recordMetrics(metrics)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "Tweet being replied to not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
-	ctx := r.Context()
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"tweet":   tweet,
+		"metrics": metricsToJSON(metrics),
+	})
+}
 
-	switch req.Strategy {
-	case "fanout_write":
-		tweet, metrics, err = h.fanOutWrite.PostTweet(ctx, req.UserID, req.Content)
-	case "fanout_read":
-		tweet, metrics, err = h.fanOutRead.PostTweet(ctx, req.UserID, req.Content)
-	case "hybrid":
-		tweet, metrics, err = h.hybrid.PostTweet(ctx, req.UserID, req.Content)
-	default:
-		respondError(w, http.StatusBadRequest, "Invalid strategy. Use: fanout_write, fanout_read, or hybrid")
+// RetweetRequest represents the request body for retweeting
+type RetweetRequest struct {
+	UserID   int64  `json:"user_id"`
+	Strategy string `json:"strategy"`
+}
+
+// Retweet handles POST /api/tweets/{id}/retweet
+func (h *Handler) Retweet(w http.ResponseWriter, r *http.Request) {
+	tweetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid tweet id")
 		return
 	}
 
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+	var req RetweetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.UserID == 0 {
+		respondError(w, http.StatusBadRequest, "user_id is required")
 		return
 	}
+	if req.Strategy == "" {
+		req.Strategy = "hybrid"
+	}
 
-	// Store metrics
-	h.metricsStore.AddWriteMetric(metrics)
+	strategy, ok := h.strategy(w, req.Strategy)
+	if !ok {
+		return
+	}
+
+	tweet, metrics, err := strategy.Retweet(r.Context(), req.UserID, tweetID)
+	h.recordMetrics(metrics)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondError(w, http.StatusNotFound, "Tweet not found")
+		case errors.Is(err, repository.ErrAlreadyRetweeted):
+			respondError(w, http.StatusConflict, "Tweet already retweeted")
+		case errors.Is(err, repository.ErrProtectedTweet):
+			respondError(w, http.StatusForbidden, "Tweet is protected")
+		default:
+			respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
 
 	respondJSON(w, http.StatusCreated, map[string]interface{}{
 		"tweet":   tweet,
@@ -113,6 +228,268 @@ func (h *Handler) PostTweet(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetThread handles GET /api/tweets/{id}/thread. It returns the tweets the
+// given one replies to, oldest first, and a page of the replies below it at
+// any depth, also oldest first; pass next_cursor as since_id for the next page.
+// Tweets viewer_id mustn't see - protected authors they don't follow, and
+// authors hidden by blocks or mutes - are left out.
+func (h *Handler) GetThread(w http.ResponseWriter, r *http.Request) {
+	tweetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid tweet id")
+		return
+	}
+
+	var viewerID int64
+	if v := r.URL.Query().Get("viewer_id"); v != "" {
+		if viewerID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid viewer_id")
+			return
+		}
+	}
+
+	limit := h.config.TimelinePageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	page := models.Page{Limit: limit}
+	if v := r.URL.Query().Get("since_id"); v != "" {
+		if page.SinceID, err = models.ParseCursor(v); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid since_id cursor")
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	tweet, err := h.tweetRepo.GetByID(ctx, tweetID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "Tweet not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	privacy := timeline.NewThreadPrivacy(h.userRepo, h.followRepo, viewerID)
+	hidden := h.blocks.HiddenAuthors(viewerID)
+	visible := func(tweets []*models.Tweet) ([]*models.Tweet, error) {
+		result := make([]*models.Tweet, 0, len(tweets))
+		for _, t := range tweets {
+			ok, err := true, error(nil)
+			if hidden != nil {
+				ok, err = hidden.Visible(ctx, t)
+			}
+			if err == nil && ok {
+				ok, err = privacy.Visible(ctx, t)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				result = append(result, t)
+			}
+		}
+		return result, nil
+	}
+
+	// The tweet asked for is refused outright, like a protected profile
+	shown, err := visible([]*models.Tweet{tweet})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(shown) == 0 {
+		respondError(w, http.StatusForbidden, "Tweet is not visible to this viewer")
+		return
+	}
+
+	ancestors, err := h.tweetRepo.GetAncestors(ctx, tweetID)
+	if err == nil {
+		ancestors, err = visible(ancestors)
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Filtering can leave a short page, so the cursor comes from the
+	// unfiltered one
+	descendants, err := h.tweetRepo.GetDescendants(ctx, tweetID, page)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	replies, err := visible(descendants)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	thread := append([]*models.Tweet{tweet}, ancestors...)
+	if err := h.counters.Embed(ctx, append(thread, replies...)); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := map[string]interface{}{
+		"tweet":     tweet,
+		"ancestors": ancestors,
+		"replies":   replies,
+		"count":     len(replies),
+		"limit":     limit,
+	}
+	if len(descendants) == limit {
+		response["next_cursor"] = models.CursorFor(descendants[len(descendants)-1]).Encode()
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// LikeRequest represents the request body for liking a tweet
+type LikeRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+// LikeTweet handles POST /api/tweets/{id}/like. Liking a retweet likes its
+// original.
+func (h *Handler) LikeTweet(w http.ResponseWriter, r *http.Request) {
+	tweetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid tweet id")
+		return
+	}
+
+	var req LikeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.UserID == 0 {
+		respondError(w, http.StatusBadRequest, "user_id is required")
+		return
+	}
+
+	likedID, err := h.counters.Like(r.Context(), req.UserID, tweetID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondError(w, http.StatusNotFound, "Tweet not found")
+		case errors.Is(err, repository.ErrAlreadyLiked):
+			respondError(w, http.StatusConflict, "Tweet already liked")
+		default:
+			respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	h.respondLike(w, r, http.StatusCreated, likedID, true)
+}
+
+// UnlikeTweet handles DELETE /api/tweets/{id}/like?user_id=...
+func (h *Handler) UnlikeTweet(w http.ResponseWriter, r *http.Request) {
+	tweetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid tweet id")
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		respondError(w, http.StatusBadRequest, "user_id is required")
+		return
+	}
+
+	likedID, err := h.counters.Unlike(r.Context(), userID, tweetID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotLiked) {
+			respondError(w, http.StatusNotFound, "Like not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondLike(w, r, http.StatusOK, likedID, false)
+}
+
+// respondLike responds to a like or unlike with the tweet's counts as this
+// server sees them. Other servers may lag until the counts are flushed.
+func (h *Handler) respondLike(w http.ResponseWriter, r *http.Request, status int, tweetID int64, liked bool) {
+	counts, err := h.counters.Get(r.Context(), []int64{tweetID})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, status, map[string]interface{}{
+		"tweet_id":         tweetID,
+		"liked":            liked,
+		"like_count":       counts[tweetID].Likes,
+		"retweet_count":    counts[tweetID].Retweets,
+		"counter_strategy": h.counters.Strategy(),
+	})
+}
+
+// DeleteTweet handles DELETE /api/tweets/{id}?user_id=...
+func (h *Handler) DeleteTweet(w http.ResponseWriter, r *http.Request) {
+	tweetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid tweet id")
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		respondError(w, http.StatusBadRequest, "user_id is required")
+		return
+	}
+
+	strategyName := r.URL.Query().Get("strategy")
+	if strategyName == "" {
+		strategyName = "hybrid"
+	}
+	strategy, ok := h.strategy(w, strategyName)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	// Verify ownership
+	tweet, err := h.tweetRepo.GetByID(ctx, tweetID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "Tweet not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if tweet.UserID != userID {
+		respondError(w, http.StatusForbidden, "Tweet belongs to another user")
+		return
+	}
+
+	metrics, err := strategy.DeleteTweet(ctx, tweetID, userID)
+	h.recordMetrics(metrics)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"tweet_id": tweetID,
+		"deleted":  true,
+		"strategy": strategyName,
+		"metrics":  metricsToJSON(metrics),
+	})
+}
+
 // GetTimeline handles GET /api/timeline/{user_id}
 func (h *Handler) GetTimeline(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "user_id")
@@ -122,9 +499,13 @@ func (h *Handler) GetTimeline(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	strategy := r.URL.Query().Get("strategy")
-	if strategy == "" {
-		strategy = "hybrid"
+	strategyName := r.URL.Query().Get("strategy")
+	if strategyName == "" {
+		strategyName = "hybrid"
+	}
+	strategy, ok := h.strategy(w, strategyName)
+	if !ok {
+		return
 	}
 
 	limitStr := r.URL.Query().Get("limit")
@@ -135,48 +516,75 @@ func (h *Handler) GetTimeline(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	offsetStr := r.URL.Query().Get("offset")
-	offset := 0
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+	page := models.Page{Limit: limit}
+	if v := r.URL.Query().Get("max_id"); v != "" {
+		if page.MaxID, err = models.ParseCursor(v); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid max_id cursor")
+			return
 		}
 	}
-
-	var tweets []*models.Tweet
-	var metrics *timeline.OperationMetrics
-
-	ctx := r.Context()
-
-	switch strategy {
-	case "fanout_write":
-		tweets, metrics, err = h.fanOutWrite.GetTimeline(ctx, userID, limit, offset)
-	case "fanout_read":
-		tweets, metrics, err = h.fanOutRead.GetTimeline(ctx, userID, limit, offset)
-	case "hybrid":
-		tweets, metrics, err = h.hybrid.GetTimeline(ctx, userID, limit, offset)
-	default:
-		respondError(w, http.StatusBadRequest, "Invalid strategy. Use: fanout_write, fanout_read, or hybrid")
-		return
+	if v := r.URL.Query().Get("since_id"); v != "" {
+		if page.SinceID, err = models.ParseCursor(v); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid since_id cursor")
+			return
+		}
 	}
 
+	tweets, next, metrics, err := timelinePage(r.Context(), strategy, userID, page)
 	if err != nil {
+		if metrics != nil {
+			observeRead(metrics)
+		}
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	// Store metrics
 	h.metricsStore.AddReadMetric(metrics)
+	observeRead(metrics)
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
+	response := map[string]interface{}{
 		"user_id":  userID,
 		"tweets":   tweets,
 		"count":    len(tweets),
 		"limit":    limit,
-		"offset":   offset,
-		"strategy": strategy,
+		"strategy": strategyName,
 		"metrics":  metricsToJSON(metrics),
-	})
+	}
+
+	// next_cursor pages further back (pass as max_id); prev_cursor polls for
+	// newer tweets (pass as since_id). Ranked timelines aren't newest first,
+	// so prev_cursor comes from the newest tweet shown.
+	if len(tweets) > 0 {
+		newest := tweets[0]
+		for _, t := range tweets {
+			if models.CursorFor(newest).Newer(t) {
+				newest = t
+			}
+		}
+		response["prev_cursor"] = models.CursorFor(newest).Encode()
+	}
+	if next != nil {
+		response["next_cursor"] = next.Encode()
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// timelinePage reads one page of userID's timeline and the cursor to pass as
+// the next page's MaxID, nil after the last page. Ranked timelines page
+// through their candidates themselves; the rest continue from the oldest
+// tweet shown.
+func timelinePage(ctx context.Context, strategy timeline.Strategy, userID int64, page models.Page) ([]*models.Tweet, *models.Cursor, *timeline.OperationMetrics, error) {
+	if ranked, ok := strategy.(timeline.Ranked); ok {
+		return ranked.GetRankedTimeline(ctx, userID, page)
+	}
+	tweets, metrics, err := strategy.GetTimeline(ctx, userID, page)
+	var next *models.Cursor
+	if err == nil && len(tweets) == page.Limit {
+		next = models.CursorFor(tweets[len(tweets)-1])
+	}
+	return tweets, next, metrics, err
 }
 
 // GetConfig handles GET /api/config
@@ -185,6 +593,12 @@ func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
 		"celebrity_threshold":  h.config.CelebrityThreshold,
 		"timeline_cache_size":  h.config.TimelineCacheSize,
 		"timeline_page_size":   h.config.TimelinePageSize,
+		"reply_filter":         h.config.ReplyFilter,
+		"block_filter":         h.config.BlockFilter,
+		"ranking_scorer":       h.config.RankingScorer,
+		"counter_strategy":     h.config.CounterStrategy,
+		"counter_shards":       h.config.CounterShards,
+		"counter_flush_ms":     h.config.CounterFlushMs,
 	})
 }
 
@@ -207,12 +621,42 @@ func (h *Handler) UpdateConfig(w http.ResponseWriter, r *http.Request) {
 		req.Value = int(v)
 	}
 
+	if req.Key == "reply_filter" || req.Key == "reply-filter" {
+		mode, _ := req.Value.(string)
+		if !timeline.IsValidReplyFilter(mode) {
+			respondError(w, http.StatusBadRequest, "Invalid reply_filter. Use: "+strings.Join(timeline.ReplyFilters(), ", "))
+			return
+		}
+		for _, s := range h.strategies.All() {
+			if rf, ok := s.(timeline.ReplyFiltering); ok {
+				rf.SetReplyFilterMode(mode)
+			}
+		}
+	}
+
+	if req.Key == "ranking_scorer" || req.Key == "ranking-scorer" {
+		name, _ := req.Value.(string)
+		if !timeline.IsValidScorer(name) {
+			respondError(w, http.StatusBadRequest, "Invalid ranking_scorer. Use: "+strings.Join(timeline.Scorers(), ", "))
+			return
+		}
+		for _, s := range h.strategies.All() {
+			if rs, ok := s.(timeline.Ranked); ok {
+				rs.SetScorer(timeline.NewScorer(name, h.followRepo))
+			}
+		}
+	}
+
 	h.config.Update(req.Key, req.Value)
 
-	// Update hybrid strategy threshold if needed
+	// Update the threshold on every strategy that cares about celebrities
 	if req.Key == "celebrity_threshold" || req.Key == "celebrity-threshold" {
 		if v, ok := req.Value.(int); ok {
-			h.hybrid.SetCelebrityThreshold(v)
+			for _, s := range h.strategies.All() {
+				if ta, ok := s.(timeline.ThresholdAware); ok {
+					ta.SetCelebrityThreshold(v)
+				}
+			}
 		}
 	}
 
@@ -249,6 +693,42 @@ func (h *Handler) ClearMetrics(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Metrics cleared"})
 }
 
+// GetFanOutQueue handles GET /api/fanout/queue
+func (h *Handler) GetFanOutQueue(w http.ResponseWriter, r *http.Request) {
+	if h.fanOutQueue == nil {
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"enabled": false,
+		})
+		return
+	}
+
+	stats, err := h.fanOutQueue.Stats(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled": true,
+		"queue":   stats,
+	})
+}
+
+// GetReclassification handles GET /api/reclassify
+func (h *Handler) GetReclassification(w http.ResponseWriter, r *http.Request) {
+	if h.reclassifier == nil {
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"enabled": false,
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled": true,
+		"status":  h.reclassifier.Status(),
+	})
+}
+
 // HealthCheck handles GET /health
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
@@ -286,6 +766,7 @@ func (h *Handler) GetSampleUsers(w http.ResponseWriter, r *http.Request) {
 			"username":      user.Username,
 			"follower_count": user.FollowerCount,
 			"is_celebrity":   user.IsCelebrity(h.config.CelebrityThreshold),
+			"protected":      user.Protected,
 		}
 	}
 	
@@ -364,21 +845,691 @@ func (h *Handler) GetUserFollowing(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Helper to convert metrics to JSON-friendly format
-func metricsToJSON(m *timeline.OperationMetrics) map[string]interface{} {
-	result := map[string]interface{}{
-		"strategy":      m.Strategy,
-		"operation":     m.Operation,
-		"duration_ms":   m.Duration().Milliseconds(),
-		"duration":      m.Duration().String(),
-		"success":       m.Success,
-		"cache_hit":     m.CacheHit,
-		"fan_out_count": m.FanOutCount, // Always include, even if 0
+// GetUserTweets handles GET /api/users/{id}/tweets - the user's own
+// tweets, retweets and replies as viewer_id sees them
+func (h *Handler) GetUserTweets(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		return
 	}
 
-	if m.FanOutDuration > 0 {
-		result["fan_out_duration_ms"] = m.FanOutDuration.Milliseconds()
-		result["fan_out_duration"] = m.FanOutDuration.String()
+	var viewerID int64
+	if v := r.URL.Query().Get("viewer_id"); v != "" {
+		if viewerID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid viewer_id")
+			return
+		}
+	}
+
+	limit := h.config.TimelinePageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	page := models.Page{Limit: limit}
+	if v := r.URL.Query().Get("max_id"); v != "" {
+		if page.MaxID, err = models.ParseCursor(v); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid max_id cursor")
+			return
+		}
+	}
+	if v := r.URL.Query().Get("since_id"); v != "" {
+		if page.SinceID, err = models.ParseCursor(v); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid since_id cursor")
+			return
+		}
+	}
+
+	tweets, metrics, err := h.userTweets.Get(r.Context(), userID, viewerID, page)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondError(w, http.StatusNotFound, "User not found")
+		case errors.Is(err, timeline.ErrProtectedProfile):
+			respondError(w, http.StatusForbidden, err.Error())
+		default:
+			respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	response := map[string]interface{}{
+		"user_id": userID,
+		"tweets":  tweets,
+		"count":   len(tweets),
+		"limit":   limit,
+		"metrics": metricsToJSON(metrics),
+	}
+
+	// Cursors work as for home timelines
+	if len(tweets) > 0 {
+		response["prev_cursor"] = models.CursorFor(tweets[0]).Encode()
+	}
+	if len(tweets) == limit {
+		response["next_cursor"] = models.CursorFor(tweets[len(tweets)-1]).Encode()
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// FollowUser handles POST /api/users/{id}/follow/{target}
+func (h *Handler) FollowUser(w http.ResponseWriter, r *http.Request) {
+	h.updateFollow(w, r, true)
+}
+
+// UnfollowUser handles DELETE /api/users/{id}/follow/{target}
+func (h *Handler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	h.updateFollow(w, r, false)
+}
+
+// updateFollow changes the follow graph and lets the selected strategy
+// backfill or evict the follower's cached timeline. Following a protected
+// user files a request instead.
+func (h *Handler) updateFollow(w http.ResponseWriter, r *http.Request, follow bool) {
+	followerID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+	followeeID, err := strconv.ParseInt(chi.URLParam(r, "target"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid target user_id")
+		return
+	}
+	if followerID == followeeID {
+		respondError(w, http.StatusBadRequest, "Users cannot follow themselves")
+		return
+	}
+
+	strategyName := r.URL.Query().Get("strategy")
+	if strategyName == "" {
+		strategyName = "hybrid"
+	}
+	strategy, ok := h.strategy(w, strategyName)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	// Verify both users exist
+	if _, err := h.userRepo.GetByID(ctx, followerID); err != nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if _, err := h.userRepo.GetByID(ctx, followeeID); err != nil {
+		respondError(w, http.StatusNotFound, "Target user not found")
+		return
+	}
+
+	var metrics *timeline.OperationMetrics
+	requested := false
+	if follow {
+		metrics, requested, err = h.requests.Follow(ctx, strategy, followerID, followeeID)
+	} else {
+		metrics, err = h.requests.Unfollow(ctx, strategy, followerID, followeeID)
+	}
+	h.recordMetrics(metrics)
+
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if requested {
+		respondJSON(w, http.StatusAccepted, map[string]interface{}{
+			"follower_id": followerID,
+			"followee_id": followeeID,
+			"following":   false,
+			"requested":   true,
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"follower_id": followerID,
+		"followee_id": followeeID,
+		"following":   follow,
+		"strategy":    strategyName,
+		"metrics":     metricsToJSON(metrics),
+	})
+}
+
+// BlockUser handles POST /api/users/{id}/block/{target}
+func (h *Handler) BlockUser(w http.ResponseWriter, r *http.Request) {
+	h.updateBlock(w, r, "blocked", true)
+}
+
+// UnblockUser handles DELETE /api/users/{id}/block/{target}
+func (h *Handler) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	h.updateBlock(w, r, "blocked", false)
+}
+
+// MuteUser handles POST /api/users/{id}/mute/{target}
+func (h *Handler) MuteUser(w http.ResponseWriter, r *http.Request) {
+	h.updateBlock(w, r, "muted", true)
+}
+
+// UnmuteUser handles DELETE /api/users/{id}/mute/{target}
+func (h *Handler) UnmuteUser(w http.ResponseWriter, r *http.Request) {
+	h.updateBlock(w, r, "muted", false)
+}
+
+// updateBlock adds or removes a block or mute. When purging, adding one
+// also removes the hidden tweets from the cached timelines.
+func (h *Handler) updateBlock(w http.ResponseWriter, r *http.Request, kind string, on bool) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "target"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid target user_id")
+		return
+	}
+	if userID == targetID {
+		respondError(w, http.StatusBadRequest, "Users cannot block or mute themselves")
+		return
+	}
+
+	ctx := r.Context()
+
+	// Verify both users exist
+	if _, err := h.userRepo.GetByID(ctx, userID); err != nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if _, err := h.userRepo.GetByID(ctx, targetID); err != nil {
+		respondError(w, http.StatusNotFound, "Target user not found")
+		return
+	}
+
+	start := time.Now()
+	purged := 0
+	switch {
+	case kind == "blocked" && on:
+		purged, err = h.blocks.Block(ctx, userID, targetID)
+	case kind == "blocked":
+		err = h.blocks.Unblock(ctx, userID, targetID)
+	case on:
+		purged, err = h.blocks.Mute(ctx, userID, targetID)
+	default:
+		err = h.blocks.Unmute(ctx, userID, targetID)
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"user_id":      userID,
+		"target_id":    targetID,
+		kind:           on,
+		"block_filter": h.blocks.Mode(),
+		"purged":       purged,
+		"duration_ms":  duration.Milliseconds(),
+		"duration":     duration.String(),
+	})
+}
+
+// SetProtectedRequest represents the request body for protecting an account
+type SetProtectedRequest struct {
+	Protected bool `json:"protected"`
+}
+
+// SetProtected handles PUT /api/users/{id}/protected. Unprotecting approves
+// every pending follow request through the selected strategy.
+func (h *Handler) SetProtected(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	var req SetProtectedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	strategyName := r.URL.Query().Get("strategy")
+	if strategyName == "" {
+		strategyName = "hybrid"
+	}
+	strategy, ok := h.strategy(w, strategyName)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := h.userRepo.GetByID(ctx, userID); err != nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	approved, err := h.requests.SetProtected(ctx, strategy, userID, req.Protected)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"user_id":   userID,
+		"protected": req.Protected,
+		"approved":  approved,
+		"strategy":  strategyName,
+	})
+}
+
+// GetFollowRequests handles GET /api/users/{id}/follow_requests
+func (h *Handler) GetFollowRequests(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	ctx := r.Context()
+	if _, err := h.userRepo.GetByID(ctx, userID); err != nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	requests, err := h.requests.Pending(ctx, userID, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"user_id":  userID,
+		"count":    len(requests),
+		"requests": requests,
+	})
+}
+
+// ApproveFollowRequest handles POST /api/users/{id}/follow_requests/{requester}/approve
+func (h *Handler) ApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	h.answerFollowRequest(w, r, true)
+}
+
+// DenyFollowRequest handles POST /api/users/{id}/follow_requests/{requester}/deny
+func (h *Handler) DenyFollowRequest(w http.ResponseWriter, r *http.Request) {
+	h.answerFollowRequest(w, r, false)
+}
+
+// answerFollowRequest approves or denies a pending follow request. An
+// approved follow goes through the selected strategy, which backfills the
+// requester's timeline.
+func (h *Handler) answerFollowRequest(w http.ResponseWriter, r *http.Request, approve bool) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+	requesterID, err := strconv.ParseInt(chi.URLParam(r, "requester"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid requester user_id")
+		return
+	}
+
+	strategyName := r.URL.Query().Get("strategy")
+	if strategyName == "" {
+		strategyName = "hybrid"
+	}
+	strategy, ok := h.strategy(w, strategyName)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	var metrics *timeline.OperationMetrics
+	if approve {
+		metrics, err = h.requests.Approve(ctx, strategy, userID, requesterID)
+	} else {
+		err = h.requests.Deny(ctx, userID, requesterID)
+	}
+	h.recordMetrics(metrics)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoFollowRequest) {
+			respondError(w, http.StatusNotFound, "Follow request not found")
+		} else {
+			respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	response := map[string]interface{}{
+		"user_id":      userID,
+		"requester_id": requesterID,
+		"approved":     approve,
+	}
+	if metrics != nil {
+		response["strategy"] = strategyName
+		response["metrics"] = metricsToJSON(metrics)
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// CreateListRequest represents a request to create a list
+type CreateListRequest struct {
+	OwnerID int64  `json:"owner_id"`
+	Name    string `json:"name"`
+}
+
+// CreateList handles POST /api/lists
+func (h *Handler) CreateList(w http.ResponseWriter, r *http.Request) {
+	var req CreateListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.OwnerID == 0 {
+		respondError(w, http.StatusBadRequest, "owner_id is required")
+		return
+	}
+	if req.Name == "" || len(req.Name) > 100 {
+		respondError(w, http.StatusBadRequest, "name is required and must be at most 100 characters")
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := h.userRepo.GetByID(ctx, req.OwnerID); err != nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	list, err := h.lists.Create(ctx, req.OwnerID, req.Name)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, list)
+}
+
+// GetList handles GET /api/lists/{id}
+func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+
+	ctx := r.Context()
+	list, err := h.lists.Get(ctx, listID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "List not found")
+		} else {
+			respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	members, err := h.lists.Members(ctx, listID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"list":    list,
+		"members": members,
+	})
+}
+
+// DeleteList handles DELETE /api/lists/{id}
+func (h *Handler) DeleteList(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+
+	if err := h.lists.Delete(r.Context(), listID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "List not found")
+		} else {
+			respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"list_id": listID,
+		"deleted": true,
+	})
+}
+
+// GetUserLists handles GET /api/users/{id}/lists
+func (h *Handler) GetUserLists(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := h.userRepo.GetByID(ctx, userID); err != nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	lists, err := h.lists.ForOwner(ctx, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"user_id": userID,
+		"count":   len(lists),
+		"lists":   lists,
+	})
+}
+
+// listStrategy looks up a registered strategy that serves list timelines,
+// responding with 400 if there isn't one
+func (h *Handler) listStrategy(w http.ResponseWriter, name string) (timeline.ListTimelines, bool) {
+	strategy, ok := h.strategy(w, name)
+	if !ok {
+		return nil, false
+	}
+	lt, ok := strategy.(timeline.ListTimelines)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Strategy "+name+" does not support lists")
+	}
+	return lt, ok
+}
+
+// AddListMember handles POST /api/lists/{id}/members/{user_id}
+func (h *Handler) AddListMember(w http.ResponseWriter, r *http.Request) {
+	h.updateListMember(w, r, true)
+}
+
+// RemoveListMember handles DELETE /api/lists/{id}/members/{user_id}
+func (h *Handler) RemoveListMember(w http.ResponseWriter, r *http.Request) {
+	h.updateListMember(w, r, false)
+}
+
+// updateListMember changes a list's members and lets the selected strategy
+// backfill or evict the list's cached timeline. Protected users can only be
+// added by their followers.
+func (h *Handler) updateListMember(w http.ResponseWriter, r *http.Request, add bool) {
+	listID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+	userID, err := strconv.ParseInt(chi.URLParam(r, "user_id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	strategyName := r.URL.Query().Get("strategy")
+	if strategyName == "" {
+		strategyName = "hybrid"
+	}
+	strategy, ok := h.listStrategy(w, strategyName)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	var metrics *timeline.OperationMetrics
+	if add {
+		metrics, err = h.lists.AddMember(ctx, strategy, listID, userID)
+	} else {
+		metrics, err = h.lists.RemoveMember(ctx, strategy, listID, userID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondError(w, http.StatusNotFound, "List or user not found")
+		case errors.Is(err, timeline.ErrProtectedListMember):
+			respondError(w, http.StatusForbidden, err.Error())
+		default:
+			respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"list_id":  listID,
+		"user_id":  userID,
+		"member":   add,
+		"strategy": strategyName,
+		"metrics":  metricsToJSON(metrics),
+	})
+}
+
+// GetListTimeline handles GET /api/lists/{id}/timeline
+func (h *Handler) GetListTimeline(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+
+	strategyName := r.URL.Query().Get("strategy")
+	if strategyName == "" {
+		strategyName = "hybrid"
+	}
+	strategy, ok := h.listStrategy(w, strategyName)
+	if !ok {
+		return
+	}
+
+	limit := h.config.TimelinePageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	page := models.Page{Limit: limit}
+	if v := r.URL.Query().Get("max_id"); v != "" {
+		if page.MaxID, err = models.ParseCursor(v); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid max_id cursor")
+			return
+		}
+	}
+	if v := r.URL.Query().Get("since_id"); v != "" {
+		if page.SinceID, err = models.ParseCursor(v); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid since_id cursor")
+			return
+		}
+	}
+
+	tweets, metrics, err := strategy.GetListTimeline(r.Context(), listID, page)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "List not found")
+			return
+		}
+		if metrics != nil {
+			observeRead(metrics)
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.metricsStore.AddReadMetric(metrics)
+	observeRead(metrics)
+
+	response := map[string]interface{}{
+		"list_id":  listID,
+		"tweets":   tweets,
+		"count":    len(tweets),
+		"limit":    limit,
+		"strategy": strategyName,
+		"metrics":  metricsToJSON(metrics),
+	}
+
+	// Cursors work as for home timelines
+	if len(tweets) > 0 {
+		response["prev_cursor"] = models.CursorFor(tweets[0]).Encode()
+	}
+	if len(tweets) == limit {
+		response["next_cursor"] = models.CursorFor(tweets[len(tweets)-1]).Encode()
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// Helper to convert metrics to JSON-friendly format
+func metricsToJSON(m *timeline.OperationMetrics) map[string]interface{} {
+	result := map[string]interface{}{
+		"strategy":      m.Strategy,
+		"operation":     m.Operation,
+		"duration_ms":   m.Duration().Milliseconds(),
+		"duration":      m.Duration().String(),
+		"success":       m.Success,
+		"cache_hit":     m.CacheHit,
+		"fan_out_count": m.FanOutCount, // Always include, even if 0
+	}
+
+	if m.FanOutQueued {
+		result["fan_out_queued"] = true
+	}
+	if m.QueueLag > 0 {
+		result["queue_lag_ms"] = m.QueueLag.Milliseconds()
+		result["queue_lag"] = m.QueueLag.String()
+	}
+	if m.ListFanOutCount > 0 {
+		result["list_fan_out_count"] = m.ListFanOutCount
+	}
+	if m.FanOutDuration > 0 {
+		result["fan_out_duration_ms"] = m.FanOutDuration.Milliseconds()
+		result["fan_out_duration"] = m.FanOutDuration.String()
+	}
+	if m.PrivacyCheck > 0 {
+		result["privacy_check_ms"] = m.PrivacyCheck.Milliseconds()
+		result["privacy_check"] = m.PrivacyCheck.String()
+	}
+	if m.CandidateTime > 0 {
+		result["candidate_count"] = m.CandidateCount
+		result["candidate_generation_ms"] = m.CandidateTime.Milliseconds()
+		result["candidate_generation"] = m.CandidateTime.String()
+		result["scoring_ms"] = m.ScoringTime.Milliseconds()
+		result["scoring"] = m.ScoringTime.String()
+		result["truncation_ms"] = m.TruncationTime.Milliseconds()
+		result["truncation"] = m.TruncationTime.String()
 	}
 	if m.Error != nil {
 		result["error"] = m.Error.Error()
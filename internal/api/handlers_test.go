@@ -0,0 +1,355 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/config"
+	"github.com/ritik/twitter-fan-out/internal/memory"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+	"github.com/ritik/twitter-fan-out/internal/timeline"
+)
+
+// testServer is the API on an in-memory backend
+type testServer struct {
+	*httptest.Server
+	handler *Handler
+	db      *memory.DB
+	users   *memory.UserRepository
+	tweets  repository.TweetStore
+	follows *memory.FollowRepository
+	blocks  *memory.BlockRepository
+	cache   *memory.TimelineCache
+	events  *memory.EventBus
+}
+
+// testOptions swap in wrapped stores to inject failures or hooks
+type testOptions struct {
+	wrapTweets func(repository.TweetStore) repository.TweetStore
+	wrapCache  func(cache.TimelineStore) cache.TimelineStore
+}
+
+// newTestServer starts the API on a fresh memory backend. Fan-out writes
+// are announced on an in-process event bus for timeline streams.
+func newTestServer(t *testing.T, opts testOptions) *testServer {
+	t.Helper()
+	db := memory.NewDB()
+	s := &testServer{
+		db:      db,
+		users:   memory.NewUserRepository(db),
+		tweets:  memory.NewTweetRepository(db),
+		follows: memory.NewFollowRepository(db),
+		blocks:  memory.NewBlockRepository(db),
+		cache:   memory.NewTimelineCache(800),
+		events:  memory.NewEventBus(),
+	}
+	if opts.wrapTweets != nil {
+		s.tweets = opts.wrapTweets(s.tweets)
+	}
+	timelines := cache.WithDeliveryEvents(s.cache, s.events)
+	if opts.wrapCache != nil {
+		timelines = opts.wrapCache(timelines)
+	}
+
+	cfg := config.Default()
+	cfg.Backend = "memory"
+	blocks := timeline.NewBlocks(s.blocks, s.tweets, s.cache, cfg.BlockFilter)
+	lists := timeline.NewLists(memory.NewListRepository(db), s.tweets, s.users, s.follows, s.cache)
+	lists.SetBlocks(blocks)
+	requests := timeline.NewFollowRequests(s.users, s.follows, memory.NewFollowRequestRepository(db))
+	counters := timeline.NewCounters(s.tweets, s.cache, timeline.CountersConfig{Strategy: cache.CounterWriteBehind})
+	userTweets := timeline.NewUserTweets(s.tweets, s.users, s.follows, s.cache)
+
+	strategies := timeline.NewRegistry(timeline.Dependencies{
+		TweetRepo:  s.tweets,
+		FollowRepo: s.follows,
+		UserRepo:   s.users,
+		Cache:      timelines,
+		Config:     cfg,
+		Counters:   counters,
+		Blocks:     blocks,
+		Lists:      lists,
+	})
+	s.handler = NewHandler(cfg, strategies, s.users, s.follows, s.tweets, nil, nil, counters, blocks, requests, lists, userTweets, s.events)
+	s.Server = httptest.NewServer(NewRouter(s.handler))
+	t.Cleanup(func() {
+		s.handler.CloseStreams()
+		s.Server.Close()
+	})
+	return s
+}
+
+// newUsers creates n users and returns their IDs
+func (s *testServer) newUsers(t *testing.T, n int) []int64 {
+	t.Helper()
+	ids := make([]int64, n)
+	for i := range ids {
+		user, err := s.users.Create(context.Background(), fmt.Sprintf("user_%d", i+1))
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		ids[i] = user.ID
+	}
+	return ids
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out, returning the status code
+func (s *testServer) do(t *testing.T, method, path string, body interface{}, out interface{}) int {
+	t.Helper()
+	var reader *strings.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		reader = strings.NewReader(string(data))
+	} else {
+		reader = strings.NewReader("")
+	}
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s %s: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// post posts a tweet as userID through strategy
+func (s *testServer) post(t *testing.T, userID int64, strategy string) *models.Tweet {
+	t.Helper()
+	var resp struct {
+		Tweet *models.Tweet `json:"tweet"`
+	}
+	status := s.do(t, http.MethodPost, "/api/tweet", map[string]interface{}{
+		"user_id": userID, "content": "hello", "strategy": strategy,
+	}, &resp)
+	if status != http.StatusCreated {
+		t.Fatalf("post tweet: status %d", status)
+	}
+	return resp.Tweet
+}
+
+// cachedTimeline returns the tweet IDs in userID's cached timeline
+func (s *testServer) cachedTimeline(t *testing.T, userID int64) []int64 {
+	t.Helper()
+	ids, err := s.cache.GetTimeline(context.Background(), userID, 800, 0)
+	if err != nil {
+		t.Fatalf("get cached timeline: %v", err)
+	}
+	return ids
+}
+
+// failingTweets fails every GetByID with err
+type failingTweets struct {
+	repository.TweetStore
+	err error
+}
+
+func (f *failingTweets) GetByID(ctx context.Context, id int64) (*models.Tweet, error) {
+	return nil, f.err
+}
+
+func TestDeleteTweet(t *testing.T) {
+	s := newTestServer(t, testOptions{})
+	u := s.newUsers(t, 2)
+	if status := s.do(t, http.MethodPost, fmt.Sprintf("/api/users/%d/follow/%d?strategy=fanout_write", u[1], u[0]), nil, nil); status != http.StatusOK {
+		t.Fatalf("follow: status %d", status)
+	}
+	tweet := s.post(t, u[0], "fanout_write")
+	if got := s.cachedTimeline(t, u[1]); len(got) != 1 || got[0] != tweet.ID {
+		t.Fatalf("follower's cached timeline %v, want the tweet", got)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"missing tweet", "/api/tweets/999?user_id=1&strategy=fanout_write", http.StatusNotFound},
+		{"another user's tweet", fmt.Sprintf("/api/tweets/%d?user_id=%d&strategy=fanout_write", tweet.ID, u[1]), http.StatusForbidden},
+		{"no user", fmt.Sprintf("/api/tweets/%d?strategy=fanout_write", tweet.ID), http.StatusBadRequest},
+		{"own tweet", fmt.Sprintf("/api/tweets/%d?user_id=%d&strategy=fanout_write", tweet.ID, u[0]), http.StatusOK},
+		{"already deleted", fmt.Sprintf("/api/tweets/%d?user_id=%d&strategy=fanout_write", tweet.ID, u[0]), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if status := s.do(t, http.MethodDelete, tt.path, nil, nil); status != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, status, tt.status)
+		}
+	}
+
+	// The tweet is gone from the follower's timeline and the tweet cache
+	if got := s.cachedTimeline(t, u[1]); len(got) != 0 {
+		t.Errorf("follower's cached timeline %v after delete, want it empty", got)
+	}
+	if cached, _ := s.cache.GetCachedTweet(context.Background(), tweet.ID); cached != nil {
+		t.Errorf("tweet %d still cached after delete", tweet.ID)
+	}
+
+	// The delete's metrics are recorded alongside the follow's and the post's
+	var summary MetricsSummary
+	s.do(t, http.MethodGet, "/api/metrics", nil, &summary)
+	if got := summary.ByStrategy["fanout_write"].WriteCount; got != 3 {
+		t.Errorf("recorded %d writes for fanout_write, want the follow, the post and the delete", got)
+	}
+}
+
+func TestFollowAndUnfollowRecordMetrics(t *testing.T) {
+	s := newTestServer(t, testOptions{})
+	u := s.newUsers(t, 2)
+	path := fmt.Sprintf("/api/users/%d/follow/%d?strategy=fanout_write", u[1], u[0])
+
+	if status := s.do(t, http.MethodPost, path, nil, nil); status != http.StatusOK {
+		t.Fatalf("follow: status %d", status)
+	}
+	if status := s.do(t, http.MethodDelete, path, nil, nil); status != http.StatusOK {
+		t.Fatalf("unfollow: status %d", status)
+	}
+
+	var summary MetricsSummary
+	s.do(t, http.MethodGet, "/api/metrics", nil, &summary)
+	if got := summary.ByStrategy["fanout_write"].WriteCount; got != 2 {
+		t.Errorf("recorded %d writes for fanout_write, want the follow and the unfollow", got)
+	}
+}
+
+func TestDeleteTweetStoreFailureIsNotNotFound(t *testing.T) {
+	s := newTestServer(t, testOptions{wrapTweets: func(tweets repository.TweetStore) repository.TweetStore {
+		return &failingTweets{TweetStore: tweets, err: errors.New("connection refused")}
+	}})
+	u := s.newUsers(t, 1)
+
+	var resp map[string]string
+	status := s.do(t, http.MethodDelete, fmt.Sprintf("/api/tweets/1?user_id=%d", u[0]), nil, &resp)
+	if status != http.StatusInternalServerError {
+		t.Errorf("status %d, want %d", status, http.StatusInternalServerError)
+	}
+	if !strings.Contains(resp["error"], "connection refused") {
+		t.Errorf("error %q, want the store's error", resp["error"])
+	}
+}
+
+func TestGetThreadIsFilteredForTheViewer(t *testing.T) {
+	s := newTestServer(t, testOptions{})
+	ctx := context.Background()
+	u := s.newUsers(t, 4)
+	author, viewer, protected, blocked := u[0], u[1], u[2], u[3]
+
+	reply := func(userID, inReplyToID int64) *models.Tweet {
+		t.Helper()
+		tweet, err := s.tweets.CreateReply(ctx, userID, inReplyToID, "reply")
+		if err != nil {
+			t.Fatalf("reply: %v", err)
+		}
+		return tweet
+	}
+	root, err := s.tweets.Create(ctx, author, "root")
+	if err != nil {
+		t.Fatalf("create tweet: %v", err)
+	}
+	fromProtected := reply(protected, root.ID)
+	fromBlocked := reply(blocked, root.ID)
+	answer := reply(author, fromProtected.ID)
+
+	if err := s.users.SetProtected(ctx, protected, true); err != nil {
+		t.Fatalf("protect: %v", err)
+	}
+	if err := s.blocks.Block(ctx, viewer, blocked); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+
+	type thread struct {
+		Ancestors  []*models.Tweet `json:"ancestors"`
+		Replies    []*models.Tweet `json:"replies"`
+		NextCursor string          `json:"next_cursor"`
+	}
+	get := func(path string, want int) thread {
+		t.Helper()
+		var resp thread
+		if status := s.do(t, http.MethodGet, path, nil, &resp); status != want {
+			t.Fatalf("GET %s: status %d, want %d", path, status, want)
+		}
+		return resp
+	}
+
+	// The protected reply and the blocked user's are left out, but a visible
+	// reply below the protected one stays
+	resp := get(fmt.Sprintf("/api/tweets/%d/thread?viewer_id=%d", root.ID, viewer), http.StatusOK)
+	if got := tweetIDs(resp.Replies); !equalIDs(got, []int64{answer.ID}) {
+		t.Errorf("replies %v, want only %d", got, answer.ID)
+	}
+	resp = get(fmt.Sprintf("/api/tweets/%d/thread?viewer_id=%d", answer.ID, viewer), http.StatusOK)
+	if got := tweetIDs(resp.Ancestors); !equalIDs(got, []int64{root.ID}) {
+		t.Errorf("ancestors %v, want only the root %d", got, root.ID)
+	}
+
+	// A filtered page still links to the next one
+	resp = get(fmt.Sprintf("/api/tweets/%d/thread?viewer_id=%d&limit=2", root.ID, viewer), http.StatusOK)
+	if len(resp.Replies) != 0 || resp.NextCursor == "" {
+		t.Fatalf("first page %v with cursor %q, want no replies and a cursor", tweetIDs(resp.Replies), resp.NextCursor)
+	}
+	resp = get(fmt.Sprintf("/api/tweets/%d/thread?viewer_id=%d&limit=2&since_id=%s", root.ID, viewer, resp.NextCursor), http.StatusOK)
+	if got := tweetIDs(resp.Replies); !equalIDs(got, []int64{answer.ID}) {
+		t.Errorf("second page %v, want only %d", got, answer.ID)
+	}
+
+	// Asking for a hidden tweet's own thread is refused, as for anonymous
+	// viewers
+	get(fmt.Sprintf("/api/tweets/%d/thread?viewer_id=%d", fromProtected.ID, viewer), http.StatusForbidden)
+	get(fmt.Sprintf("/api/tweets/%d/thread?viewer_id=%d", fromBlocked.ID, viewer), http.StatusForbidden)
+	get(fmt.Sprintf("/api/tweets/%d/thread", fromProtected.ID), http.StatusForbidden)
+	get("/api/tweets/1/thread?viewer_id=x", http.StatusBadRequest)
+
+	// Following the protected user lets the viewer see their reply; the
+	// blocked user's stays hidden
+	if err := s.follows.Create(ctx, viewer, protected); err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	resp = get(fmt.Sprintf("/api/tweets/%d/thread?viewer_id=%d", root.ID, viewer), http.StatusOK)
+	if got := tweetIDs(resp.Replies); !equalIDs(got, []int64{fromProtected.ID, answer.ID}) {
+		t.Errorf("replies %v once following, want %d and %d", got, fromProtected.ID, answer.ID)
+	}
+
+	// The blocked user sees their own reply but nothing from whoever blocked them
+	resp = get(fmt.Sprintf("/api/tweets/%d/thread?viewer_id=%d", root.ID, blocked), http.StatusOK)
+	if got := tweetIDs(resp.Replies); !equalIDs(got, []int64{fromBlocked.ID, answer.ID}) {
+		t.Errorf("blocked user's replies %v, want %d and %d", got, fromBlocked.ID, answer.ID)
+	}
+}
+
+func tweetIDs(tweets []*models.Tweet) []int64 {
+	ids := make([]int64, len(tweets))
+	for i, t := range tweets {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -11,17 +11,19 @@ import (
 // MetricsStore stores operation metrics for analysis
 type MetricsStore struct {
 	mu           sync.RWMutex
-	writeMetrics []*timeline.OperationMetrics
-	readMetrics  []*timeline.OperationMetrics
-	maxSize      int
+	writeMetrics  []*timeline.OperationMetrics
+	readMetrics   []*timeline.OperationMetrics
+	fanOutMetrics []*timeline.OperationMetrics // Async fan-out jobs completed by the worker pool
+	maxSize       int
 }
 
 // NewMetricsStore creates a new MetricsStore
 func NewMetricsStore() *MetricsStore {
 	return &MetricsStore{
-		writeMetrics: make([]*timeline.OperationMetrics, 0),
-		readMetrics:  make([]*timeline.OperationMetrics, 0),
-		maxSize:      10000, // Keep last 10k metrics
+		writeMetrics:  make([]*timeline.OperationMetrics, 0),
+		readMetrics:   make([]*timeline.OperationMetrics, 0),
+		fanOutMetrics: make([]*timeline.OperationMetrics, 0),
+		maxSize:       10000, // Keep last 10k metrics
 	}
 }
 
@@ -47,6 +49,17 @@ func (ms *MetricsStore) AddReadMetric(m *timeline.OperationMetrics) {
 	}
 }
 
+// AddFanOutMetric adds an async fan-out job metric
+func (ms *MetricsStore) AddFanOutMetric(m *timeline.OperationMetrics) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.fanOutMetrics = append(ms.fanOutMetrics, m)
+	if len(ms.fanOutMetrics) > ms.maxSize {
+		ms.fanOutMetrics = ms.fanOutMetrics[len(ms.fanOutMetrics)-ms.maxSize:]
+	}
+}
+
 // MetricsSummary holds aggregated metrics
 type MetricsSummary struct {
 	TotalWrites     int                        `json:"total_writes"`
@@ -74,6 +87,22 @@ type StrategySummary struct {
 	ReadLatencyP99  string  `json:"read_latency_p99"`
 	AvgFanOutCount  float64 `json:"avg_fan_out_count"`
 	CacheHitRate    float64 `json:"cache_hit_rate"`
+
+	// List timelines among the timelines each write fanned out to
+	AvgListFanOutCount float64 `json:"avg_list_fan_out_count"`
+
+	// Async fan-out (only populated when the worker pool is enabled)
+	FanOutJobCount      int    `json:"fan_out_job_count,omitempty"`
+	QueueLagP50         string `json:"queue_lag_p50,omitempty"`
+	QueueLagP95         string `json:"queue_lag_p95,omitempty"`
+	FanOutCompletionP50 string `json:"fan_out_completion_p50,omitempty"` // Enqueue to last follower written
+	FanOutCompletionP95 string `json:"fan_out_completion_p95,omitempty"`
+
+	// Ranking stages (only populated for ranked strategies)
+	AvgCandidateCount      float64 `json:"avg_candidate_count,omitempty"`
+	CandidateGenerationAvg string  `json:"candidate_generation_avg,omitempty"`
+	ScoringAvg             string  `json:"scoring_avg,omitempty"`
+	TruncationAvg          string  `json:"truncation_avg,omitempty"`
 }
 
 // GetSummary returns aggregated metrics
@@ -90,6 +119,7 @@ func (ms *MetricsStore) GetSummary() *MetricsSummary {
 	// Group by strategy
 	writeByStrategy := make(map[string][]*timeline.OperationMetrics)
 	readByStrategy := make(map[string][]*timeline.OperationMetrics)
+	fanOutByStrategy := make(map[string][]*timeline.OperationMetrics)
 
 	for _, m := range ms.writeMetrics {
 		writeByStrategy[m.Strategy] = append(writeByStrategy[m.Strategy], m)
@@ -97,10 +127,13 @@ func (ms *MetricsStore) GetSummary() *MetricsSummary {
 	for _, m := range ms.readMetrics {
 		readByStrategy[m.Strategy] = append(readByStrategy[m.Strategy], m)
 	}
+	for _, m := range ms.fanOutMetrics {
+		fanOutByStrategy[m.Strategy] = append(fanOutByStrategy[m.Strategy], m)
+	}
 
 	// Calculate per-strategy metrics
-	strategies := []string{"fanout_write", "fanout_read", "hybrid"}
-	for _, strategy := range strategies {
+	for _, st := range timeline.ValidStrategies() {
+		strategy := string(st)
 		writes := writeByStrategy[strategy]
 		reads := readByStrategy[strategy]
 
@@ -111,32 +144,62 @@ func (ms *MetricsStore) GetSummary() *MetricsSummary {
 
 		if len(writes) > 0 {
 			writeDurations := make([]time.Duration, len(writes))
-			var totalFanOut int
+			var totalFanOut, totalListFanOut int
 			for i, m := range writes {
 				writeDurations[i] = m.Duration()
 				totalFanOut += m.FanOutCount
+				totalListFanOut += m.ListFanOutCount
 			}
 			ss.WriteLatencyAvg = avgDuration(writeDurations).String()
 			ss.WriteLatencyP50 = percentileDuration(writeDurations, 50).String()
 			ss.WriteLatencyP95 = percentileDuration(writeDurations, 95).String()
 			ss.WriteLatencyP99 = percentileDuration(writeDurations, 99).String()
 			ss.AvgFanOutCount = float64(totalFanOut) / float64(len(writes))
+			ss.AvgListFanOutCount = float64(totalListFanOut) / float64(len(writes))
 		}
 
 		if len(reads) > 0 {
 			readDurations := make([]time.Duration, len(reads))
-			var cacheHits int
+			var cacheHits, ranked, candidates int
+			var candidateTime, scoringTime, truncationTime time.Duration
 			for i, m := range reads {
 				readDurations[i] = m.Duration()
 				if m.CacheHit {
 					cacheHits++
 				}
+				if m.CandidateTime > 0 {
+					ranked++
+					candidates += m.CandidateCount
+					candidateTime += m.CandidateTime
+					scoringTime += m.ScoringTime
+					truncationTime += m.TruncationTime
+				}
 			}
 			ss.ReadLatencyAvg = avgDuration(readDurations).String()
 			ss.ReadLatencyP50 = percentileDuration(readDurations, 50).String()
 			ss.ReadLatencyP95 = percentileDuration(readDurations, 95).String()
 			ss.ReadLatencyP99 = percentileDuration(readDurations, 99).String()
 			ss.CacheHitRate = float64(cacheHits) / float64(len(reads))
+			if ranked > 0 {
+				ss.AvgCandidateCount = float64(candidates) / float64(ranked)
+				ss.CandidateGenerationAvg = (candidateTime / time.Duration(ranked)).String()
+				ss.ScoringAvg = (scoringTime / time.Duration(ranked)).String()
+				ss.TruncationAvg = (truncationTime / time.Duration(ranked)).String()
+			}
+		}
+
+		if fanOuts := fanOutByStrategy[strategy]; len(fanOuts) > 0 {
+			lags := make([]time.Duration, len(fanOuts))
+			completions := make([]time.Duration, len(fanOuts))
+			for i, m := range fanOuts {
+				lags[i] = m.QueueLag
+				completions[i] = m.QueueLag + m.Duration()
+			}
+			ss.FanOutJobCount = len(fanOuts)
+			ss.QueueLagP50 = percentileDuration(lags, 50).String()
+			ss.QueueLagP95 = percentileDuration(lags, 95).String()
+			ss.FanOutCompletionP50 = percentileDuration(completions, 50).String()
+			ss.FanOutCompletionP95 = percentileDuration(completions, 95).String()
 		}
 
 		summary.ByStrategy[strategy] = ss
@@ -227,6 +290,7 @@ func (ms *MetricsStore) Clear() {
 
 	ms.writeMetrics = make([]*timeline.OperationMetrics, 0)
 	ms.readMetrics = make([]*timeline.OperationMetrics, 0)
+	ms.fanOutMetrics = make([]*timeline.OperationMetrics, 0)
 }
 
 // Helper functions
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/ritik/twitter-fan-out/internal/timeline"
+)
+
+// latencyBuckets run from 0.5ms to 10s; in-memory reads finish well under
+// the client's default 5ms bucket
+var latencyBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Prometheus metrics for timeline operations, served on /metrics. Unlike
+// MetricsStore they cover the whole lifetime of the process.
+var (
+	postLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fanout_post_latency_seconds",
+		Help:    "Time to post, retweet or delete a tweet, including inline fan-out.",
+		Buckets: latencyBuckets,
+	}, []string{"strategy", "operation"})
+	readLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fanout_read_latency_seconds",
+		Help:    "Time to read a page of a timeline.",
+		Buckets: latencyBuckets,
+	}, []string{"strategy"})
+	fanOutSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fanout_fanout_size",
+		Help:    "Timelines written per post (operation=post_tweet or fan_out for async jobs), or users merged per read (get_timeline).",
+		Buckets: append([]float64{0}, prometheus.ExponentialBuckets(1, 4, 10)...),
+	}, []string{"strategy", "operation"})
+	fanOutDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fanout_fanout_duration_seconds",
+		Help:    "Time spent writing followers' timelines for one tweet.",
+		Buckets: latencyBuckets,
+	}, []string{"strategy", "operation"})
+	fanOutQueueLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fanout_queue_lag_seconds",
+		Help:    "Time an async fan-out job waited in the queue.",
+		Buckets: latencyBuckets,
+	}, []string{"strategy"})
+	rankingStage = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fanout_ranking_stage_seconds",
+		Help:    "Time ranked reads spent in each stage (candidate_generation, scoring or truncation).",
+		Buckets: latencyBuckets,
+	}, []string{"strategy", "stage"})
+	cacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fanout_cache_hits_total",
+		Help: "Timeline reads served from the timeline cache.",
+	}, []string{"strategy"})
+	cacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fanout_cache_misses_total",
+		Help: "Timeline reads that found no cached timeline.",
+	}, []string{"strategy"})
+	operationErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fanout_operation_errors_total",
+		Help: "Timeline operations that returned an error.",
+	}, []string{"strategy", "operation"})
+)
+
+// observeWrite records a post, retweet or delete in the Prometheus metrics
+func observeWrite(m *timeline.OperationMetrics) {
+	if !m.Success {
+		operationErrors.WithLabelValues(m.Strategy, m.Operation).Inc()
+		return
+	}
+	postLatency.WithLabelValues(m.Strategy, m.Operation).Observe(m.Duration().Seconds())
+
+	// Queued fan-out is observed when the worker finishes the job
+	if !m.FanOutQueued {
+		observeFanOut(m)
+	}
+}
+
+// observeRead records a timeline read in the Prometheus metrics
+func observeRead(m *timeline.OperationMetrics) {
+	if !m.Success {
+		operationErrors.WithLabelValues(m.Strategy, m.Operation).Inc()
+		return
+	}
+	readLatency.WithLabelValues(m.Strategy).Observe(m.Duration().Seconds())
+	fanOutSize.WithLabelValues(m.Strategy, m.Operation).Observe(float64(m.FanOutCount))
+	if m.CandidateTime > 0 {
+		rankingStage.WithLabelValues(m.Strategy, "candidate_generation").Observe(m.CandidateTime.Seconds())
+		rankingStage.WithLabelValues(m.Strategy, "scoring").Observe(m.ScoringTime.Seconds())
+		rankingStage.WithLabelValues(m.Strategy, "truncation").Observe(m.TruncationTime.Seconds())
+	}
+
+	if m.CacheHit {
+		cacheHits.WithLabelValues(m.Strategy).Inc()
+	} else {
+		cacheMisses.WithLabelValues(m.Strategy).Inc()
+	}
+}
+
+// observeFanOutJob records an async fan-out job in the Prometheus metrics
+func observeFanOutJob(m *timeline.OperationMetrics) {
+	if !m.Success {
+		operationErrors.WithLabelValues(m.Strategy, m.Operation).Inc()
+		return
+	}
+	fanOutQueueLag.WithLabelValues(m.Strategy).Observe(m.QueueLag.Seconds())
+	observeFanOut(m)
+}
+
+func observeFanOut(m *timeline.OperationMetrics) {
+	fanOutSize.WithLabelValues(m.Strategy, m.Operation).Observe(float64(m.FanOutCount))
+	if m.FanOutCount > 0 {
+		fanOutDuration.WithLabelValues(m.Strategy, m.Operation).Observe(m.FanOutDuration.Seconds())
+	}
+}
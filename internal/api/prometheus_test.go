@@ -0,0 +1,63 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// scrape returns the value of one series from /metrics, or 0 if it hasn't
+// been observed yet
+func (s *testServer) scrape(t *testing.T, series string) float64 {
+	t.Helper()
+	resp, err := http.Get(s.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), series+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatalf("parse %s: %v", series, err)
+			}
+			return v
+		}
+	}
+	return 0
+}
+
+// Posts, retweets and deletes share the write latency histogram, told apart by operation
+func TestPostLatencyIsLabelledByOperation(t *testing.T) {
+	s := newTestServer(t, testOptions{})
+	u := s.newUsers(t, 2)
+
+	series := func(operation string) string {
+		return fmt.Sprintf(`fanout_post_latency_seconds_count{operation=%q,strategy="fanout_read"}`, operation)
+	}
+	before := map[string]float64{}
+	for _, op := range []string{"post_tweet", "retweet", "delete_tweet"} {
+		before[op] = s.scrape(t, series(op))
+	}
+
+	tweet := s.post(t, u[0], "fanout_read")
+	status := s.do(t, http.MethodPost, fmt.Sprintf("/api/tweets/%d/retweet", tweet.ID),
+		map[string]interface{}{"user_id": u[1], "strategy": "fanout_read"}, nil)
+	if status != http.StatusCreated && status != http.StatusOK {
+		t.Fatalf("retweet: status %d", status)
+	}
+	if status := s.do(t, http.MethodDelete, fmt.Sprintf("/api/tweets/%d?user_id=%d&strategy=fanout_read", tweet.ID, u[0]), nil, nil); status != http.StatusOK {
+		t.Fatalf("delete: status %d", status)
+	}
+
+	for _, op := range []string{"post_tweet", "retweet", "delete_tweet"} {
+		if got := s.scrape(t, series(op)) - before[op]; got != 1 {
+			t.Errorf("%s observed %v times, want 1", op, got)
+		}
+	}
+}
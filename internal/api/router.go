@@ -4,6 +4,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // NewRouter creates and configures the HTTP router
@@ -29,18 +30,47 @@ func NewRouter(h *Handler) *chi.Mux {
 	// Health check
 	r.Get("/health", h.HealthCheck)
 
+	// Prometheus scrape endpoint
+	r.Handle("/metrics", promhttp.Handler())
+
 	// API routes
 	r.Route("/api", func(r chi.Router) {
 		// Tweet operations
 		r.Post("/tweet", h.PostTweet)
+		r.Delete("/tweets/{id}", h.DeleteTweet)
+		r.Post("/tweets/{id}/retweet", h.Retweet)
+		r.Post("/tweets/{id}/like", h.LikeTweet)
+		r.Delete("/tweets/{id}/like", h.UnlikeTweet)
+		r.Get("/tweets/{id}/thread", h.GetThread)
 
 		// Timeline operations
 		r.Get("/timeline/{user_id}", h.GetTimeline)
+		r.Get("/timeline/{user_id}/stream", h.StreamTimeline)
 
 		// User operations
 		r.Get("/users/sample", h.GetSampleUsers)
 		r.Get("/users/{id}/followers", h.GetUserFollowers)
 		r.Get("/users/{id}/following", h.GetUserFollowing)
+		r.Get("/users/{id}/tweets", h.GetUserTweets)
+		r.Post("/users/{id}/follow/{target}", h.FollowUser)
+		r.Delete("/users/{id}/follow/{target}", h.UnfollowUser)
+		r.Post("/users/{id}/block/{target}", h.BlockUser)
+		r.Delete("/users/{id}/block/{target}", h.UnblockUser)
+		r.Post("/users/{id}/mute/{target}", h.MuteUser)
+		r.Delete("/users/{id}/mute/{target}", h.UnmuteUser)
+		r.Put("/users/{id}/protected", h.SetProtected)
+		r.Get("/users/{id}/follow_requests", h.GetFollowRequests)
+		r.Post("/users/{id}/follow_requests/{requester}/approve", h.ApproveFollowRequest)
+		r.Post("/users/{id}/follow_requests/{requester}/deny", h.DenyFollowRequest)
+		r.Get("/users/{id}/lists", h.GetUserLists)
+
+		// Lists
+		r.Post("/lists", h.CreateList)
+		r.Get("/lists/{id}", h.GetList)
+		r.Delete("/lists/{id}", h.DeleteList)
+		r.Post("/lists/{id}/members/{user_id}", h.AddListMember)
+		r.Delete("/lists/{id}/members/{user_id}", h.RemoveListMember)
+		r.Get("/lists/{id}/timeline", h.GetListTimeline)
 
 		// Configuration
 		r.Get("/config", h.GetConfig)
@@ -50,6 +80,12 @@ func NewRouter(h *Handler) *chi.Mux {
 		r.Get("/metrics", h.GetMetrics)
 		r.Get("/metrics/recent", h.GetRecentMetrics)
 		r.Delete("/metrics", h.ClearMetrics)
+
+		// Async fan-out
+		r.Get("/fanout/queue", h.GetFanOutQueue)
+
+		// Celebrity reclassification
+		r.Get("/reclassify", h.GetReclassification)
 	})
 
 	return r
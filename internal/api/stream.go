@@ -0,0 +1,271 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/timeline"
+)
+
+// streamKeepAlive is how often an idle stream sends a comment so proxies
+// don't close the connection
+const streamKeepAlive = 15 * time.Second
+
+// sourceReplay marks tweets sent on reconnect that arrived while the client was away
+const sourceReplay = "replay"
+
+// streamSeenLimit bounds the tweet IDs remembered to drop duplicate events
+const streamSeenLimit = 1024
+
+// streamSourcesRefresh is how often a stream re-reads the authors it pulls
+// from, so follows and celebrity reclassifications made mid-stream reach it
+var streamSourcesRefresh = 30 * time.Second
+
+// CloseStreams ends every open timeline stream. Register it with
+// http.Server.RegisterOnShutdown so shutdown doesn't wait on them.
+func (h *Handler) CloseStreams() {
+	h.closeStreams.Do(func() { close(h.streamsDone) })
+}
+
+// StreamTimeline handles GET /api/timeline/{user_id}/stream. New tweets are
+// sent as Server-Sent Events as soon as they reach the user's timeline:
+// pushed strategies announce each fan-out write, and authors whose tweets
+// are merged at read time announce each post.
+func (h *Handler) StreamTimeline(w http.ResponseWriter, r *http.Request) {
+	if h.events == nil {
+		respondError(w, http.StatusServiceUnavailable, "Timeline streaming is not enabled")
+		return
+	}
+
+	userID, err := strconv.ParseInt(chi.URLParam(r, "user_id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	strategyName := r.URL.Query().Get("strategy")
+	if strategyName == "" {
+		strategyName = "hybrid"
+	}
+	strategy, ok := h.strategy(w, strategyName)
+	if !ok {
+		return
+	}
+
+	// A reconnecting EventSource sends the id of the last event it saw
+	var since *models.Cursor
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		since, _ = models.ParseCursor(v)
+	} else if v := r.URL.Query().Get("since_id"); v != "" {
+		if since, err = models.ParseCursor(v); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid since_id cursor")
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	// 1. Work out where new tweets for this user come from
+	var timelines []int64
+	if _, ok := strategy.(timeline.Precomputed); ok {
+		timelines = []int64{userID}
+	}
+	pull, _ := strategy.(timeline.PullSources)
+	pullSources := func() ([]int64, error) {
+		if pull == nil {
+			return nil, nil
+		}
+		return pull.PullSources(ctx, userID)
+	}
+	authors, err := pullSources()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// 2. Subscribe before replaying so nothing posted in between is lost
+	subCtx, unsubscribe := context.WithCancel(ctx)
+	defer func() { unsubscribe() }()
+	events, err := h.events.Subscribe(subCtx, timelines, authors)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// 3. Switch to an event stream; it outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	writeEvent(w, "ready", "", map[string]interface{}{
+		"user_id":   userID,
+		"strategy":  strategyName,
+		"timelines": len(timelines),
+		"authors":   len(authors),
+	})
+
+	seen := make(map[int64]bool)
+	visibility := timeline.NewReplyVisibility(h.followRepo, userID)
+	send := func(event cache.TimelineEvent) {
+		if seen[event.TweetID] {
+			return
+		}
+		// Replies, hidden authors and retweets of protected tweets are
+		// filtered like the timeline itself would, whichever channel they
+		// arrived on. Blocks and protection are looked up for every event,
+		// so a change made mid-stream applies at once.
+		shell := &models.Tweet{ID: event.TweetID, UserID: event.AuthorID, InReplyToUserID: event.InReplyToUserID, OriginalUserID: event.OriginalUserID}
+		if visible, err := visibility.Visible(ctx, shell); err != nil || !visible {
+			return
+		}
+		if hidden := h.blocks.HiddenAuthors(userID); hidden != nil {
+			if visible, err := hidden.Visible(ctx, shell); err != nil || !visible {
+				return
+			}
+		}
+		if visible, err := timeline.NewPrivacy(h.userRepo, h.followRepo, userID).Visible(ctx, shell); err != nil || !visible {
+			return
+		}
+		if len(seen) >= streamSeenLimit {
+			seen = make(map[int64]bool)
+		}
+		seen[event.TweetID] = true
+
+		cursor := models.Cursor{CreatedAt: event.CreatedAt, ID: event.TweetID}
+		writeEvent(w, "tweet", cursor.Encode(), event)
+	}
+
+	// 4. Replay what arrived since the client's last event, oldest first
+	if since != nil {
+		tweets, err := h.replay(ctx, strategy, userID, since)
+		if err != nil {
+			fmt.Printf("Warning: failed to replay timeline for user %d: %v\n", userID, err)
+		}
+		for _, t := range tweets {
+			send(cache.TimelineEvent{
+				TweetID:         t.ID,
+				AuthorID:        t.UserID,
+				InReplyToUserID: t.InReplyToUserID,
+				OriginalUserID:  t.OriginalUserID,
+				CreatedAt:       t.CreatedAt,
+				Source:          sourceReplay,
+			})
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	// 5. Forward events until the client goes away
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	refresh := time.NewTicker(streamSourcesRefresh)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.streamsDone:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			send(event)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case <-refresh.C:
+			// Subscribe to the new authors before dropping the old
+			// subscription, so nothing is missed while switching; events
+			// carried by both are dropped as duplicates
+			latest, err := pullSources()
+			if err != nil || sameIDs(authors, latest) {
+				continue
+			}
+			nextCtx, nextUnsubscribe := context.WithCancel(ctx)
+			next, err := h.events.Subscribe(nextCtx, timelines, latest)
+			if err != nil {
+				nextUnsubscribe()
+				fmt.Printf("Warning: failed to resubscribe stream for user %d: %v\n", userID, err)
+				continue
+			}
+			unsubscribe()
+			for event := range events {
+				send(event)
+			}
+			events, unsubscribe, authors = next, nextUnsubscribe, latest
+			writeEvent(w, "sources", "", map[string]interface{}{"authors": len(authors)})
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// replay returns the tweets that reached userID's timeline after since,
+// oldest first. It pages back from the newest until since is reached, so a
+// client that missed more than a page gets everything it missed.
+func (h *Handler) replay(ctx context.Context, strategy timeline.Strategy, userID int64, since *models.Cursor) ([]*models.Tweet, error) {
+	var missed []*models.Tweet
+	page := models.Page{Limit: h.config.TimelinePageSize, SinceID: since}
+	for {
+		tweets, next, _, err := timelinePage(ctx, strategy, userID, page)
+		if err != nil {
+			return nil, err
+		}
+		missed = append(missed, tweets...)
+		if next == nil {
+			break
+		}
+		page.MaxID = next
+	}
+
+	sort.Slice(missed, func(i, j int) bool {
+		return models.CursorFor(missed[j]).Older(missed[i])
+	})
+	return missed, nil
+}
+
+// sameIDs reports whether a and b hold the same IDs in any order
+func sameIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[int64]bool, len(a))
+	for _, id := range a {
+		set[id] = true
+	}
+	for _, id := range b {
+		if !set[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// writeEvent writes one Server-Sent Event with a JSON payload
+func writeEvent(w http.ResponseWriter, name, id string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\n", name)
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", payload)
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// sseEvent is one parsed Server-Sent Event
+type sseEvent struct {
+	name string
+	id   string
+	data string
+}
+
+// openStream connects to a timeline stream and returns its events as they arrive
+func (s *testServer) openStream(t *testing.T, path, lastEventID string) <-chan sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL+path, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		t.Fatalf("GET %s: status %d", path, resp.StatusCode)
+	}
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if event.name != "" {
+					events <- event
+				}
+				event = sseEvent{}
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "id: "):
+				event.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events
+}
+
+// nextTweet waits for the stream's next tweet event
+func nextTweet(t *testing.T, events <-chan sseEvent) cache.TimelineEvent {
+	t.Helper()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			if event.name != "tweet" {
+				continue
+			}
+			var tweet cache.TimelineEvent
+			if err := json.Unmarshal([]byte(event.data), &tweet); err != nil {
+				t.Fatalf("decode tweet event: %v", err)
+			}
+			return tweet
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for a tweet event")
+		}
+	}
+}
+
+// replayHook runs before and after around the first timeline page read,
+// which is the stream's replay
+type replayHook struct {
+	cache.TimelineStore
+	once          sync.Once
+	before, after func()
+}
+
+func (h *replayHook) GetTimelinePage(ctx context.Context, userID int64, page models.Page) ([]int64, error) {
+	first := false
+	h.once.Do(func() { first = true })
+	if !first {
+		return h.TimelineStore.GetTimelinePage(ctx, userID, page)
+	}
+	h.before()
+	defer h.after()
+	return h.TimelineStore.GetTimelinePage(ctx, userID, page)
+}
+
+// A reconnecting stream replays what it missed, oldest first, then carries on
+// live. It subscribes before replaying, so a tweet posted while the replay is
+// read is neither lost nor sent twice.
+func TestStreamReplaysThenSubscribes(t *testing.T) {
+	hook := &replayHook{}
+	s := newTestServer(t, testOptions{wrapCache: func(c cache.TimelineStore) cache.TimelineStore {
+		hook.TimelineStore = c
+		return hook
+	}})
+	u := s.newUsers(t, 2)
+	reader, author := u[0], u[1]
+
+	if status := s.do(t, http.MethodPost, fmt.Sprintf("/api/users/%d/follow/%d?strategy=fanout_write", reader, author), nil, nil); status != http.StatusOK {
+		t.Fatalf("follow: status %d", status)
+	}
+	seen := s.post(t, author, "fanout_write")
+	missed := s.post(t, author, "fanout_write")
+
+	var beforeReplay, afterReplay *models.Tweet
+	hook.before = func() { beforeReplay = s.post(t, author, "fanout_write") }
+	hook.after = func() { afterReplay = s.post(t, author, "fanout_write") }
+
+	events := s.openStream(t, fmt.Sprintf("/api/timeline/%d/stream?strategy=fanout_write", reader), models.CursorFor(seen).Encode())
+
+	for _, want := range []struct {
+		tweet  func() *models.Tweet
+		source string
+	}{
+		{func() *models.Tweet { return missed }, sourceReplay},
+		{func() *models.Tweet { return beforeReplay }, sourceReplay},
+		{func() *models.Tweet { return afterReplay }, cache.EventSourceTimeline},
+	} {
+		got := nextTweet(t, events)
+		if tweet := want.tweet(); got.TweetID != tweet.ID || got.Source != want.source {
+			t.Fatalf("got tweet %d from %q, want %d from %q", got.TweetID, got.Source, tweet.ID, want.source)
+		}
+	}
+
+	// The live event for the tweet already replayed was dropped, so the next
+	// one is a new post
+	live := s.post(t, author, "fanout_write")
+	if got := nextTweet(t, events); got.TweetID != live.ID || got.Source != cache.EventSourceTimeline {
+		t.Fatalf("got tweet %d from %q, want %d live from the timeline", got.TweetID, got.Source, live.ID)
+	}
+}
+
+// A client that missed more than a page of tweets is replayed all of them,
+// oldest first, not just the newest page
+func TestStreamReplaysMoreThanAPage(t *testing.T) {
+	s := newTestServer(t, testOptions{})
+	s.handler.config.TimelinePageSize = 3
+	u := s.newUsers(t, 2)
+	reader, author := u[0], u[1]
+
+	if status := s.do(t, http.MethodPost, fmt.Sprintf("/api/users/%d/follow/%d?strategy=fanout_write", reader, author), nil, nil); status != http.StatusOK {
+		t.Fatalf("follow: status %d", status)
+	}
+	seen := s.post(t, author, "fanout_write")
+	var missed []*models.Tweet
+	for i := 0; i < 8; i++ {
+		missed = append(missed, s.post(t, author, "fanout_write"))
+	}
+
+	events := s.openStream(t, fmt.Sprintf("/api/timeline/%d/stream?strategy=fanout_write", reader), models.CursorFor(seen).Encode())
+	for _, want := range missed {
+		if got := nextTweet(t, events); got.TweetID != want.ID || got.Source != sourceReplay {
+			t.Fatalf("got tweet %d from %q, want %d replayed", got.TweetID, got.Source, want.ID)
+		}
+	}
+
+	live := s.post(t, author, "fanout_write")
+	if got := nextTweet(t, events); got.TweetID != live.ID || got.Source != cache.EventSourceTimeline {
+		t.Fatalf("got tweet %d from %q, want %d live from the timeline", got.TweetID, got.Source, live.ID)
+	}
+}
+
+// A stream re-reads the authors it pulls from, so someone followed after it
+// opened reaches it
+func TestStreamPicksUpNewSources(t *testing.T) {
+	defer func(d time.Duration) { streamSourcesRefresh = d }(streamSourcesRefresh)
+	streamSourcesRefresh = 20 * time.Millisecond
+
+	s := newTestServer(t, testOptions{})
+	u := s.newUsers(t, 2)
+	reader, author := u[0], u[1]
+
+	events := s.openStream(t, fmt.Sprintf("/api/timeline/%d/stream?strategy=fanout_read", reader), "")
+	if status := s.do(t, http.MethodPost, fmt.Sprintf("/api/users/%d/follow/%d?strategy=fanout_read", reader, author), nil, nil); status != http.StatusOK {
+		t.Fatalf("follow: status %d", status)
+	}
+
+	for resubscribed := false; !resubscribed; {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			resubscribed = event.name == "sources"
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for the stream to pick up the new followee")
+		}
+	}
+
+	// The test server's tweet store doesn't announce posts, so announce it here
+	tweet := s.post(t, author, "fanout_read")
+	if err := s.events.PublishPosted(context.Background(), tweet); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if got := nextTweet(t, events); got.TweetID != tweet.ID || got.Source != cache.EventSourceAuthor {
+		t.Fatalf("got tweet %d from %q, want %d from the author", got.TweetID, got.Source, tweet.ID)
+	}
+}
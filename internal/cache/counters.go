@@ -0,0 +1,280 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// Counter strategies decide how like and retweet counts absorb the writes
+// to a hot tweet. Either way the counts are written back to the database in
+// batches.
+const (
+	// CounterSharded spreads each tweet's counts over several hashes and
+	// sends every like to a random one, so a hot tweet's writes don't all
+	// land on one key. Reads sum the shards.
+	CounterSharded = "sharded"
+	// CounterWriteBehind buffers changes in the process and applies them to
+	// one hash per tweet when it flushes, so a hot tweet costs one write per
+	// flush however many likes it gets. Other servers don't see buffered
+	// changes until then, and they are lost if the process dies.
+	CounterWriteBehind = "write_behind"
+)
+
+// CounterStrategies returns the counter strategies
+func CounterStrategies() []string {
+	return []string{CounterSharded, CounterWriteBehind}
+}
+
+// IsValidCounterStrategy checks if a counter strategy is valid
+func IsValidCounterStrategy(strategy string) bool {
+	for _, valid := range CounterStrategies() {
+		if valid == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// CounterStore holds the like and retweet counts of recently active tweets
+// and tracks which have changed since they were written back to the
+// database. Counts are loaded from the database on first use. TimelineCache
+// implements it on Redis; internal/memory provides an in-process version.
+type CounterStore interface {
+	// IncrCounts adds delta to a tweet's counts and marks them changed. It
+	// reports false, changing nothing, if the tweet's counts aren't loaded.
+	IncrCounts(ctx context.Context, tweetID int64, delta models.TweetCounts) (bool, error)
+	// LoadCounts stores counts read from the database, skipping tweets
+	// whose counts are already loaded
+	LoadCounts(ctx context.Context, counts map[int64]models.TweetCounts) error
+	// GetCounts returns the counts of the tweets that are loaded, and the
+	// IDs of those that aren't
+	GetCounts(ctx context.Context, tweetIDs []int64) (map[int64]models.TweetCounts, []int64, error)
+	// TakeChanged removes and returns up to max tweets whose counts have
+	// changed since they were last taken
+	TakeChanged(ctx context.Context, max int) ([]int64, error)
+	// MarkChanged marks tweets as changed again, e.g. after a failed write-back
+	MarkChanged(ctx context.Context, tweetIDs []int64) error
+	// RemoveCounts drops a tweet's counts
+	RemoveCounts(ctx context.Context, tweetID int64) error
+}
+
+var _ CounterStore = (*TimelineCache)(nil)
+
+const (
+	changedCountsPrefix = "counts:changed:"
+
+	// Counts expire with the cached tweet. Every shard is loaded at once,
+	// so they expire together, by which time the database has caught up.
+	countsTTL = tweetCacheTTL
+)
+
+// countsKey returns the Redis key for one shard of a tweet's counts. The
+// first shard sits next to the cached tweet JSON, at tweet:{id}:counts.
+func countsKey(tweetID int64, shard int) string {
+	if shard == 0 {
+		return fmt.Sprintf("%s%d:counts", tweetCacheKeyPrefix, tweetID)
+	}
+	return fmt.Sprintf("%s%d:counts:%d", tweetCacheKeyPrefix, tweetID, shard)
+}
+
+// changedCountsKey returns the Redis key of the set of tweets whose counts
+// changed in one shard. The set is sharded too, or every like would still
+// write to the same key.
+func changedCountsKey(shard int) string {
+	return fmt.Sprintf("%s%d", changedCountsPrefix, shard)
+}
+
+// incrCountsScript increments one shard of a tweet's counts (KEYS[1]) and
+// adds the tweet to that shard's changed set (KEYS[2]), unless the counts
+// aren't loaded
+var incrCountsScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HINCRBY', KEYS[1], 'likes', ARGV[1])
+redis.call('HINCRBY', KEYS[1], 'retweets', ARGV[2])
+redis.call('SADD', KEYS[2], ARGV[3])
+return 1
+`)
+
+// loadCountsScript stores a tweet's counts in its first shard (KEYS[1]) and
+// zeroes the others, unless the counts are already loaded
+var loadCountsScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+for i, key in ipairs(KEYS) do
+	if i == 1 then
+		redis.call('HSET', key, 'likes', ARGV[1], 'retweets', ARGV[2])
+	else
+		redis.call('HSET', key, 'likes', 0, 'retweets', 0)
+	end
+	redis.call('EXPIRE', key, ARGV[3])
+end
+return 1
+`)
+
+// SetCounterShards sets how many hashes each tweet's counts are spread
+// over. Change it only while no counts are loaded.
+func (tc *TimelineCache) SetCounterShards(shards int) {
+	if shards < 1 {
+		shards = 1
+	}
+	tc.counterShards = shards
+}
+
+// shards returns the number of counter shards
+func (tc *TimelineCache) shards() int {
+	if tc.counterShards < 1 {
+		return 1
+	}
+	return tc.counterShards
+}
+
+// IncrCounts adds delta to one randomly chosen shard of a tweet's counts
+func (tc *TimelineCache) IncrCounts(ctx context.Context, tweetID int64, delta models.TweetCounts) (bool, error) {
+	shard := 0
+	if n := tc.shards(); n > 1 {
+		shard = rand.Intn(n)
+	}
+
+	keys := []string{countsKey(tweetID, shard), changedCountsKey(shard)}
+	loaded, err := incrCountsScript.Run(ctx, tc.client, keys, delta.Likes, delta.Retweets, tweetID).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to increment counts: %w", err)
+	}
+	return loaded == 1, nil
+}
+
+// LoadCounts stores counts read from the database in one pipeline
+func (tc *TimelineCache) LoadCounts(ctx context.Context, counts map[int64]models.TweetCounts) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	ttl := int(countsTTL.Seconds())
+	pipe := tc.client.Pipeline()
+	for tweetID, c := range counts {
+		keys := make([]string, tc.shards())
+		for shard := range keys {
+			keys[shard] = countsKey(tweetID, shard)
+		}
+		loadCountsScript.Eval(ctx, pipe, keys, c.Likes, c.Retweets, ttl)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to load counts: %w", err)
+	}
+	return nil
+}
+
+// GetCounts reads every shard of the tweets' counts in one pipeline and
+// sums them
+func (tc *TimelineCache) GetCounts(ctx context.Context, tweetIDs []int64) (map[int64]models.TweetCounts, []int64, error) {
+	counts := make(map[int64]models.TweetCounts, len(tweetIDs))
+	if len(tweetIDs) == 0 {
+		return counts, []int64{}, nil
+	}
+
+	shards := tc.shards()
+	pipe := tc.client.Pipeline()
+	cmds := make([][]*redis.SliceCmd, len(tweetIDs))
+	for i, id := range tweetIDs {
+		cmds[i] = make([]*redis.SliceCmd, shards)
+		for shard := 0; shard < shards; shard++ {
+			cmds[i][shard] = pipe.HMGet(ctx, countsKey(id, shard), "likes", "retweets")
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to get counts: %w", err)
+	}
+
+	missingIDs := make([]int64, 0)
+	for i, id := range tweetIDs {
+		var total models.TweetCounts
+		loaded := false
+		for shard, cmd := range cmds[i] {
+			values := cmd.Val()
+			if len(values) != 2 || values[0] == nil {
+				continue
+			}
+			if shard == 0 {
+				loaded = true
+			}
+			total = total.Add(models.TweetCounts{Likes: parseCount(values[0]), Retweets: parseCount(values[1])})
+		}
+		if !loaded {
+			missingIDs = append(missingIDs, id)
+			continue
+		}
+		counts[id] = total
+	}
+
+	return counts, missingIDs, nil
+}
+
+// parseCount parses a hash field returned by HMGET
+func parseCount(value interface{}) int64 {
+	s, ok := value.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+// TakeChanged pops changed tweets from every shard's changed set
+func (tc *TimelineCache) TakeChanged(ctx context.Context, max int) ([]int64, error) {
+	shards := tc.shards()
+	perShard := (max + shards - 1) / shards
+
+	pipe := tc.client.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, shards)
+	for shard := range cmds {
+		cmds[shard] = pipe.SPopN(ctx, changedCountsKey(shard), int64(perShard))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to take changed counts: %w", err)
+	}
+
+	// A tweet changed in several shards is taken from each of them
+	seen := make(map[int64]bool)
+	tweetIDs := make([]int64, 0)
+	for _, cmd := range cmds {
+		for _, member := range cmd.Val() {
+			id, err := strconv.ParseInt(member, 10, 64)
+			if err != nil || seen[id] {
+				continue
+			}
+			seen[id] = true
+			tweetIDs = append(tweetIDs, id)
+		}
+	}
+	return tweetIDs, nil
+}
+
+// MarkChanged adds tweets back to the first shard's changed set
+func (tc *TimelineCache) MarkChanged(ctx context.Context, tweetIDs []int64) error {
+	if len(tweetIDs) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(tweetIDs))
+	for i, id := range tweetIDs {
+		members[i] = id
+	}
+	return tc.client.SAdd(ctx, changedCountsKey(0), members...).Err()
+}
+
+// RemoveCounts deletes every shard of a tweet's counts
+func (tc *TimelineCache) RemoveCounts(ctx context.Context, tweetID int64) error {
+	keys := make([]string, tc.shards())
+	for shard := range keys {
+		keys[shard] = countsKey(tweetID, shard)
+	}
+	return tc.client.Del(ctx, keys...).Err()
+}
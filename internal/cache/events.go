@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+const (
+	timelineEventsPrefix = "events:timeline:"
+	authorEventsPrefix   = "events:author:"
+)
+
+// Event sources
+const (
+	EventSourceTimeline = "timeline" // The tweet was pushed into the subscriber's timeline
+	EventSourceAuthor   = "author"   // A followed author posted; the tweet is merged at read time
+)
+
+// TimelineEvent announces a tweet that is now part of a subscriber's timeline
+type TimelineEvent struct {
+	TweetID         int64     `json:"tweet_id"`
+	AuthorID        int64     `json:"author_id"`
+	InReplyToUserID int64     `json:"in_reply_to_user_id,omitempty"` // Replies: lets subscribers filter them
+	OriginalUserID  int64     `json:"original_user_id,omitempty"`    // Retweets: lets subscribers hide blocked authors
+	CreatedAt       time.Time `json:"created_at"`
+	Source          string    `json:"source"`
+}
+
+// EventBus carries new timeline items to streaming clients
+type EventBus interface {
+	// PublishDelivered announces that tweet was written into each user's timeline
+	PublishDelivered(ctx context.Context, userIDs []int64, tweet *models.Tweet) error
+	// PublishPosted announces a new tweet on its author's channel
+	PublishPosted(ctx context.Context, tweet *models.Tweet) error
+	// Subscribe receives deliveries to the given timelines and posts by the
+	// given authors. The channel is closed once ctx is done.
+	Subscribe(ctx context.Context, timelines, authors []int64) (<-chan TimelineEvent, error)
+}
+
+// TimelineEventsChannel returns the channel deliveries to userID's timeline are published on
+func TimelineEventsChannel(userID int64) string {
+	return fmt.Sprintf("%s%d", timelineEventsPrefix, userID)
+}
+
+// AuthorEventsChannel returns the channel an author's new tweets are published on
+func AuthorEventsChannel(authorID int64) string {
+	return fmt.Sprintf("%s%d", authorEventsPrefix, authorID)
+}
+
+// EventChannels returns the channels a subscription to timelines and authors listens on
+func EventChannels(timelines, authors []int64) []string {
+	channels := make([]string, 0, len(timelines)+len(authors))
+	for _, id := range timelines {
+		channels = append(channels, TimelineEventsChannel(id))
+	}
+	for _, id := range authors {
+		channels = append(channels, AuthorEventsChannel(id))
+	}
+	return channels
+}
+
+func newTimelineEvent(tweet *models.Tweet, source string) TimelineEvent {
+	return TimelineEvent{
+		TweetID:         tweet.ID,
+		AuthorID:        tweet.UserID,
+		InReplyToUserID: tweet.InReplyToUserID,
+		OriginalUserID:  tweet.OriginalUserID,
+		CreatedAt:       tweet.CreatedAt,
+		Source:          source,
+	}
+}
+
+// RedisEventBus publishes timeline events over Redis pub/sub
+type RedisEventBus struct {
+	client *redis.Client
+}
+
+var _ EventBus = (*RedisEventBus)(nil)
+
+// NewRedisEventBus creates a new RedisEventBus
+func NewRedisEventBus(client *redis.Client) *RedisEventBus {
+	return &RedisEventBus{client: client}
+}
+
+// PublishDelivered publishes one message per timeline in a single pipeline
+func (b *RedisEventBus) PublishDelivered(ctx context.Context, userIDs []int64, tweet *models.Tweet) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(newTimelineEvent(tweet, EventSourceTimeline))
+	if err != nil {
+		return err
+	}
+
+	pipe := b.client.Pipeline()
+	for _, userID := range userIDs {
+		pipe.Publish(ctx, TimelineEventsChannel(userID), payload)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to publish deliveries: %w", err)
+	}
+	return nil
+}
+
+// PublishPosted publishes a new tweet on its author's channel
+func (b *RedisEventBus) PublishPosted(ctx context.Context, tweet *models.Tweet) error {
+	payload, err := json.Marshal(newTimelineEvent(tweet, EventSourceAuthor))
+	if err != nil {
+		return err
+	}
+	if err := b.client.Publish(ctx, AuthorEventsChannel(tweet.UserID), payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish tweet: %w", err)
+	}
+	return nil
+}
+
+// Subscribe listens on the timeline and author channels until ctx is done
+func (b *RedisEventBus) Subscribe(ctx context.Context, timelines, authors []int64) (<-chan TimelineEvent, error) {
+	pubsub := b.client.Subscribe(ctx, EventChannels(timelines, authors)...)
+
+	// Wait for the subscription to be confirmed so no event published after
+	// Subscribe returns can be missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	events := make(chan TimelineEvent)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event TimelineEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// eventTimelineStore publishes a delivery event for every tweet pushed into a timeline
+type eventTimelineStore struct {
+	TimelineStore
+	events EventBus
+}
+
+// WithDeliveryEvents wraps store so that successful AddToTimeline and
+// AddToTimelineBatch calls - the fan-out path - are published on events.
+// Backfills on follow and rebuilds are not announced.
+func WithDeliveryEvents(store TimelineStore, events EventBus) TimelineStore {
+	return &eventTimelineStore{TimelineStore: store, events: events}
+}
+
+// AddToTimeline adds a tweet to a user's timeline and announces it
+func (s *eventTimelineStore) AddToTimeline(ctx context.Context, userID int64, tweet *models.Tweet) error {
+	if err := s.TimelineStore.AddToTimeline(ctx, userID, tweet); err != nil {
+		return err
+	}
+	if err := s.events.PublishDelivered(ctx, []int64{userID}, tweet); err != nil {
+		fmt.Printf("Warning: failed to publish delivery: %v\n", err)
+	}
+	return nil
+}
+
+// AddToTimelineBatch fans a tweet out and announces it to every recipient
+func (s *eventTimelineStore) AddToTimelineBatch(ctx context.Context, userIDs []int64, tweet *models.Tweet) error {
+	if err := s.TimelineStore.AddToTimelineBatch(ctx, userIDs, tweet); err != nil {
+		return err
+	}
+	if err := s.events.PublishDelivered(ctx, userIDs, tweet); err != nil {
+		fmt.Printf("Warning: failed to publish deliveries: %v\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+const (
+	// Redis Stream holding pending fan-out jobs
+	fanOutStreamKey = "fanout:jobs"
+	// Stream for jobs that exhausted their retries
+	fanOutDeadLetterKey = "fanout:jobs:dead"
+	// Sorted set of failed jobs waiting out their retry backoff, scored by
+	// when they're due in milliseconds
+	fanOutDelayedKey = "fanout:jobs:delayed"
+	// Consumer group shared by all fan-out workers
+	fanOutGroup = "fanout-workers"
+	// Field name the job payload is stored under
+	fanOutJobField = "job"
+)
+
+// QueuedJob is a fan-out job together with its stream message ID
+type QueuedJob struct {
+	MessageID string
+	Job       *models.FanOutJob
+}
+
+// FanOutQueueStats describes the state of the fan-out queue
+type FanOutQueueStats struct {
+	Length      int64 `json:"length"`       // Entries currently in the stream
+	Pending     int64 `json:"pending"`      // Delivered to a worker but not yet acknowledged
+	Lag         int64 `json:"lag"`          // Entries not yet delivered to any worker
+	DeadLetters int64 `json:"dead_letters"` // Jobs that exhausted their retries
+	Delayed     int64 `json:"delayed"`      // Failed jobs waiting to be retried
+}
+
+// FanOutQueue is a durable fan-out job queue backed by a Redis Stream
+type FanOutQueue struct {
+	client *redis.Client
+	now    func() time.Time
+}
+
+// NewFanOutQueue creates a new FanOutQueue
+func NewFanOutQueue(client *redis.Client) *FanOutQueue {
+	return &FanOutQueue{client: client, now: time.Now}
+}
+
+// EnsureGroup creates the stream and consumer group if they don't exist yet
+func (q *FanOutQueue) EnsureGroup(ctx context.Context) error {
+	err := q.client.XGroupCreateMkStream(ctx, fanOutStreamKey, fanOutGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	return nil
+}
+
+// Enqueue appends a fan-out job to the queue
+func (q *FanOutQueue) Enqueue(ctx context.Context, job *models.FanOutJob) error {
+	if job.EnqueuedAt.IsZero() {
+		job.EnqueuedAt = time.Now()
+	}
+	return q.add(ctx, q.client, fanOutStreamKey, job)
+}
+
+func (q *FanOutQueue) add(ctx context.Context, c redis.Cmdable, stream string, job *models.FanOutJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fan-out job: %w", err)
+	}
+	err = c.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		Values: map[string]interface{}{fanOutJobField: data},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to enqueue fan-out job: %w", err)
+	}
+	return nil
+}
+
+// Read blocks for up to block waiting for new jobs for the given consumer
+func (q *FanOutQueue) Read(ctx context.Context, consumer string, count int64, block time.Duration) ([]*QueuedJob, error) {
+	streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    fanOutGroup,
+		Consumer: consumer,
+		Streams:  []string{fanOutStreamKey, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err == redis.Nil {
+		return []*QueuedJob{}, nil // Timed out with nothing to do
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fan-out jobs: %w", err)
+	}
+
+	jobs := make([]*QueuedJob, 0)
+	for _, stream := range streams {
+		jobs = append(jobs, q.decodeMessages(ctx, stream.Messages)...)
+	}
+	return jobs, nil
+}
+
+// Reclaim takes over jobs that another consumer received but never acknowledged,
+// e.g. because its process crashed mid fan-out
+func (q *FanOutQueue) Reclaim(ctx context.Context, consumer string, minIdle time.Duration, count int64) ([]*QueuedJob, error) {
+	messages, _, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   fanOutStreamKey,
+		Group:    fanOutGroup,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reclaim fan-out jobs: %w", err)
+	}
+	return q.decodeMessages(ctx, messages), nil
+}
+
+// decodeMessages parses stream messages, dead-lettering any that can't be decoded
+func (q *FanOutQueue) decodeMessages(ctx context.Context, messages []redis.XMessage) []*QueuedJob {
+	jobs := make([]*QueuedJob, 0, len(messages))
+	for _, msg := range messages {
+		raw, _ := msg.Values[fanOutJobField].(string)
+		job := &models.FanOutJob{}
+		if err := json.Unmarshal([]byte(raw), job); err != nil {
+			// A malformed payload will never succeed - drop it rather than redeliver forever
+			q.client.XAdd(ctx, &redis.XAddArgs{Stream: fanOutDeadLetterKey, Values: msg.Values})
+			q.client.XAck(ctx, fanOutStreamKey, fanOutGroup, msg.ID)
+			continue
+		}
+		jobs = append(jobs, &QueuedJob{MessageID: msg.ID, Job: job})
+	}
+	return jobs
+}
+
+// Ack marks a job as done and removes it from the stream
+func (q *FanOutQueue) Ack(ctx context.Context, messageID string) error {
+	pipe := q.client.TxPipeline()
+	pipe.XAck(ctx, fanOutStreamKey, fanOutGroup, messageID)
+	pipe.XDel(ctx, fanOutStreamKey, messageID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to ack fan-out job: %w", err)
+	}
+	return nil
+}
+
+// Retry acks a failed job and schedules it to be re-enqueued with its
+// attempt counter bumped once delay has passed. PromoteDue moves it back.
+func (q *FanOutQueue) Retry(ctx context.Context, qj *QueuedJob, delay time.Duration) error {
+	qj.Job.Attempt++
+	qj.Job.RetryAt = q.now().Add(delay)
+	data, err := json.Marshal(qj.Job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fan-out job: %w", err)
+	}
+
+	pipe := q.client.TxPipeline()
+	pipe.ZAdd(ctx, fanOutDelayedKey, redis.Z{Score: float64(qj.Job.RetryAt.UnixMilli()), Member: data})
+	pipe.XAck(ctx, fanOutStreamKey, fanOutGroup, qj.MessageID)
+	pipe.XDel(ctx, fanOutStreamKey, qj.MessageID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to schedule fan-out job retry: %w", err)
+	}
+	return nil
+}
+
+// promoteDueScript moves up to ARGV[2] jobs due by ARGV[1] from the delayed
+// set (KEYS[1]) onto the stream (KEYS[2]) under the ARGV[3] field
+var promoteDueScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, job in ipairs(due) do
+	redis.call('XADD', KEYS[2], '*', ARGV[3], job)
+	redis.call('ZREM', KEYS[1], job)
+end
+return #due
+`)
+
+// PromoteDue moves retries whose backoff has passed back onto the queue and
+// returns how many were moved. Workers call it before every read, so it's
+// safe to run concurrently.
+func (q *FanOutQueue) PromoteDue(ctx context.Context) (int, error) {
+	n, err := promoteDueScript.Run(ctx, q.client, []string{fanOutDelayedKey, fanOutStreamKey},
+		q.now().UnixMilli(), 100, fanOutJobField).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to promote fan-out retries: %w", err)
+	}
+	return n, nil
+}
+
+// DeadLetter moves a job that exhausted its retries to the dead-letter stream
+func (q *FanOutQueue) DeadLetter(ctx context.Context, qj *QueuedJob) error {
+	return q.moveTo(ctx, fanOutDeadLetterKey, qj)
+}
+
+func (q *FanOutQueue) moveTo(ctx context.Context, stream string, qj *QueuedJob) error {
+	pipe := q.client.TxPipeline()
+	if err := q.add(ctx, pipe, stream, qj.Job); err != nil {
+		return err
+	}
+	pipe.XAck(ctx, fanOutStreamKey, fanOutGroup, qj.MessageID)
+	pipe.XDel(ctx, fanOutStreamKey, qj.MessageID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to move fan-out job to %s: %w", stream, err)
+	}
+	return nil
+}
+
+// Stats returns the current queue depth, pending and dead-letter counts
+func (q *FanOutQueue) Stats(ctx context.Context) (*FanOutQueueStats, error) {
+	stats := &FanOutQueueStats{}
+
+	length, err := q.client.XLen(ctx, fanOutStreamKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue length: %w", err)
+	}
+	stats.Length = length
+
+	groups, err := q.client.XInfoGroups(ctx, fanOutStreamKey).Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get queue groups: %w", err)
+	}
+	for _, g := range groups {
+		if g.Name == fanOutGroup {
+			stats.Pending = g.Pending
+			stats.Lag = g.Lag
+		}
+	}
+
+	dead, err := q.client.XLen(ctx, fanOutDeadLetterKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead-letter length: %w", err)
+	}
+	stats.DeadLetters = dead
+
+	delayed, err := q.client.ZCard(ctx, fanOutDelayedKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delayed retry count: %w", err)
+	}
+	stats.Delayed = delayed
+
+	return stats, nil
+}
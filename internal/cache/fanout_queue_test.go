@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// newTestQueue returns a queue on a fresh in-process Redis whose clock, and
+// the queue's, start at the same instant and only move when told to
+func newTestQueue(t *testing.T) (*FanOutQueue, *miniredis.Miniredis, *time.Time) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	mr.SetTime(now)
+	q := NewFanOutQueue(client)
+	q.now = func() time.Time { return now }
+
+	if err := q.EnsureGroup(context.Background()); err != nil {
+		t.Fatalf("EnsureGroup: %v", err)
+	}
+	// A second call finds the group already there
+	if err := q.EnsureGroup(context.Background()); err != nil {
+		t.Fatalf("EnsureGroup again: %v", err)
+	}
+	return q, mr, &now
+}
+
+func enqueue(t *testing.T, q *FanOutQueue, tweetID int64) {
+	t.Helper()
+	if err := q.Enqueue(context.Background(), &models.FanOutJob{TweetID: tweetID, AuthorID: 1, Strategy: "fanout_write"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+}
+
+func read(t *testing.T, q *FanOutQueue, consumer string, want int) []*QueuedJob {
+	t.Helper()
+	jobs, err := q.Read(context.Background(), consumer, 10, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(jobs) != want {
+		t.Fatalf("Read returned %d jobs, want %d", len(jobs), want)
+	}
+	return jobs
+}
+
+func stats(t *testing.T, q *FanOutQueue) FanOutQueueStats {
+	t.Helper()
+	s, err := q.Stats(context.Background())
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	return *s
+}
+
+func TestFanOutQueueAck(t *testing.T) {
+	q, _, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	enqueue(t, q, 42)
+	if s := stats(t, q); s.Length != 1 || s.Lag != 1 {
+		t.Fatalf("after enqueue: %+v, want length 1 and lag 1", s)
+	}
+
+	jobs := read(t, q, "a", 1)
+	if jobs[0].Job.TweetID != 42 || jobs[0].Job.EnqueuedAt.IsZero() {
+		t.Fatalf("read job %+v, want tweet 42 with an enqueue time", jobs[0].Job)
+	}
+	if s := stats(t, q); s.Pending != 1 {
+		t.Fatalf("after read: %+v, want 1 pending", s)
+	}
+
+	if err := q.Ack(ctx, jobs[0].MessageID); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if s := stats(t, q); s.Length != 0 || s.Pending != 0 {
+		t.Fatalf("after ack: %+v, want an empty stream with nothing pending", s)
+	}
+	read(t, q, "a", 0)
+}
+
+func TestFanOutQueueReclaim(t *testing.T) {
+	q, mr, now := newTestQueue(t)
+	ctx := context.Background()
+
+	enqueue(t, q, 42)
+	read(t, q, "crashed", 1)
+
+	// Not idle long enough yet
+	jobs, err := q.Reclaim(ctx, "b", 30*time.Second, 10)
+	if err != nil {
+		t.Fatalf("Reclaim: %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Fatalf("reclaimed %d jobs before they went idle, want 0", len(jobs))
+	}
+
+	*now = now.Add(31 * time.Second)
+	mr.SetTime(*now)
+	jobs, err = q.Reclaim(ctx, "b", 30*time.Second, 10)
+	if err != nil {
+		t.Fatalf("Reclaim: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Job.TweetID != 42 {
+		t.Fatalf("reclaimed %+v, want the crashed consumer's job for tweet 42", jobs)
+	}
+
+	// The new owner acks it like any other job
+	if err := q.Ack(ctx, jobs[0].MessageID); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if s := stats(t, q); s.Length != 0 || s.Pending != 0 {
+		t.Fatalf("after ack: %+v, want an empty stream with nothing pending", s)
+	}
+}
+
+func TestFanOutQueueRetryWaitsOutBackoff(t *testing.T) {
+	q, mr, now := newTestQueue(t)
+	ctx := context.Background()
+
+	enqueue(t, q, 42)
+	jobs := read(t, q, "a", 1)
+	if err := q.Retry(ctx, jobs[0], 2*time.Second); err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	if s := stats(t, q); s.Length != 0 || s.Pending != 0 || s.Delayed != 1 {
+		t.Fatalf("after retry: %+v, want only 1 delayed", s)
+	}
+
+	// Not due yet
+	if n, err := q.PromoteDue(ctx); err != nil || n != 0 {
+		t.Fatalf("PromoteDue before the backoff = %d, %v; want 0", n, err)
+	}
+	read(t, q, "a", 0)
+
+	*now = now.Add(2 * time.Second)
+	mr.SetTime(*now)
+	if n, err := q.PromoteDue(ctx); err != nil || n != 1 {
+		t.Fatalf("PromoteDue after the backoff = %d, %v; want 1", n, err)
+	}
+	jobs = read(t, q, "a", 1)
+	if job := jobs[0].Job; job.TweetID != 42 || job.Attempt != 1 {
+		t.Fatalf("retried job %+v, want tweet 42 on attempt 1", job)
+	}
+	if s := stats(t, q); s.Delayed != 0 {
+		t.Fatalf("after promotion: %+v, want nothing delayed", s)
+	}
+}
+
+func TestFanOutQueueDeadLetter(t *testing.T) {
+	q, _, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	enqueue(t, q, 42)
+	jobs := read(t, q, "a", 1)
+	if err := q.DeadLetter(ctx, jobs[0]); err != nil {
+		t.Fatalf("DeadLetter: %v", err)
+	}
+	if s := stats(t, q); s.Length != 0 || s.Pending != 0 || s.DeadLetters != 1 {
+		t.Fatalf("after dead-lettering: %+v, want only 1 dead letter", s)
+	}
+	read(t, q, "a", 0)
+}
+
+func TestFanOutQueueDeadLettersMalformedJobs(t *testing.T) {
+	q, _, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	err := q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: fanOutStreamKey,
+		Values: map[string]interface{}{fanOutJobField: "not json"},
+	}).Err()
+	if err != nil {
+		t.Fatalf("XAdd: %v", err)
+	}
+	enqueue(t, q, 42)
+
+	jobs := read(t, q, "a", 1)
+	if jobs[0].Job.TweetID != 42 {
+		t.Fatalf("read %+v, want only the well-formed job", jobs[0].Job)
+	}
+	if s := stats(t, q); s.DeadLetters != 1 || s.Pending != 1 {
+		t.Fatalf("after read: %+v, want the malformed job dead-lettered and acked", s)
+	}
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// TimelineStore holds precomputed home and list timelines, cached tweet data
+// and each author's recent tweets. TimelineCache implements it on Redis;
+// internal/memory provides an in-process version.
+//
+// Every strategy adds an author's tweets, retweets and replies to their
+// author tweet set as they are posted, and removes them on delete, so a set
+// always holds everything its author posted after its oldest entry. A set
+// can also be marked complete - holding its author's whole history - which
+// trimming undoes. GetAuthorTweetsPage uses both to tell readers which
+// authors' sets can't vouch for a page.
+type TimelineStore interface {
+	AddToTimeline(ctx context.Context, userID int64, tweet *models.Tweet) error
+	AddToTimelineBatch(ctx context.Context, userIDs []int64, tweet *models.Tweet) error
+	AddTweetsToTimeline(ctx context.Context, userID int64, tweets []*models.Tweet) error
+	GetTimeline(ctx context.Context, userID int64, limit, offset int) ([]int64, error)
+	GetTimelinePage(ctx context.Context, userID int64, page models.Page) ([]int64, error)
+	GetTimelineSize(ctx context.Context, userID int64) (int64, error)
+	RemoveFromTimeline(ctx context.Context, userID int64, tweetID int64) error
+	RemoveFromTimelineBatch(ctx context.Context, userIDs []int64, tweetID int64) error
+	RemoveTweetsFromTimeline(ctx context.Context, userID int64, tweetIDs []int64) error
+	ClearTimeline(ctx context.Context, userID int64) error
+	TimelineExists(ctx context.Context, userID int64) (bool, error)
+	MaxTimelineSize() int
+
+	AddToListTimelineBatch(ctx context.Context, listIDs []int64, tweet *models.Tweet) error
+	AddTweetsToListTimeline(ctx context.Context, listID int64, tweets []*models.Tweet) error
+	GetListTimelinePage(ctx context.Context, listID int64, page models.Page) ([]int64, error)
+	RemoveFromListTimelineBatch(ctx context.Context, listIDs []int64, tweetID int64) error
+	RemoveTweetsFromListTimeline(ctx context.Context, listID int64, tweetIDs []int64) error
+	ClearListTimeline(ctx context.Context, listID int64) error
+
+	CacheTweet(ctx context.Context, tweet *models.Tweet) error
+	CacheTweetsBatch(ctx context.Context, tweets []*models.Tweet) error
+	GetCachedTweet(ctx context.Context, tweetID int64) (*models.Tweet, error)
+	GetCachedTweets(ctx context.Context, tweetIDs []int64) ([]*models.Tweet, []int64, error)
+	InvalidateTweet(ctx context.Context, tweetID int64) error
+
+	AddAuthorTweet(ctx context.Context, userID int64, tweet *models.Tweet) error
+	AddAuthorTweets(ctx context.Context, userID int64, tweets []*models.Tweet, complete bool) error
+	RemoveAuthorTweet(ctx context.Context, userID int64, tweetID int64) error
+	GetAuthorTweets(ctx context.Context, userID int64, limit int) ([]int64, error)
+	GetAuthorTweetsBatch(ctx context.Context, userIDs []int64, limitPerUser int) ([]int64, error)
+	GetAuthorTweetsPage(ctx context.Context, userIDs []int64, page models.Page) (tweetIDs []int64, uncovered []int64, err error)
+}
+
+var _ TimelineStore = (*TimelineCache)(nil)
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
 	"time"
 
@@ -15,17 +17,27 @@ const (
 	// Key prefixes
 	timelineKeyPrefix       = "timeline:"
 	tweetCacheKeyPrefix     = "tweet:"
-	celebrityTweetsPrefix   = "celebrity:tweets:"
+	authorTweetsPrefix      = "author:tweets:"
+	listTimelineKeyPrefix   = "list:timeline:"
 	
 	// TTL settings
 	tweetCacheTTL    = 24 * time.Hour
 	timelineCacheTTL = 7 * 24 * time.Hour
+
+	// authorTweetsLimit is how many recent tweets each author's set keeps
+	authorTweetsLimit = 100
+
+	// authorCompleteMember marks an author tweet set that holds the author's
+	// whole history. It scores below every tweet, so trimming drops it
+	// first, and isn't a tweet ID, so readers skip it.
+	authorCompleteMember = "complete"
 )
 
 // TimelineCache handles timeline caching operations
 type TimelineCache struct {
 	client        *redis.Client
 	maxTimelineSize int
+	counterShards   int // Hashes each tweet's counts are spread over
 }
 
 // NewTimelineCache creates a new TimelineCache
@@ -33,6 +45,7 @@ func NewTimelineCache(client *redis.Client, maxSize int) *TimelineCache {
 	return &TimelineCache{
 		client:        client,
 		maxTimelineSize: maxSize,
+		counterShards:   1,
 	}
 }
 
@@ -46,9 +59,14 @@ func tweetCacheKey(tweetID int64) string {
 	return fmt.Sprintf("%s%d", tweetCacheKeyPrefix, tweetID)
 }
 
-// celebrityTweetsKey returns the Redis key for a celebrity's tweets
-func celebrityTweetsKey(userID int64) string {
-	return fmt.Sprintf("%s%d", celebrityTweetsPrefix, userID)
+// authorTweetsKey returns the Redis key for an author's recent tweets
+func authorTweetsKey(userID int64) string {
+	return fmt.Sprintf("%s%d", authorTweetsPrefix, userID)
+}
+
+// listTimelineKey returns the Redis key for a list's timeline
+func listTimelineKey(listID int64) string {
+	return fmt.Sprintf("%s%d", listTimelineKeyPrefix, listID)
 }
 
 // AddToTimeline adds a tweet to a user's timeline cache
@@ -79,15 +97,33 @@ func (tc *TimelineCache) AddToTimeline(ctx context.Context, userID int64, tweet
 
 // AddToTimelineBatch adds a tweet to multiple users' timelines (fan-out)
 func (tc *TimelineCache) AddToTimelineBatch(ctx context.Context, userIDs []int64, tweet *models.Tweet) error {
-	if len(userIDs) == 0 {
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = timelineKey(userID)
+	}
+	return tc.addToKeys(ctx, keys, tweet)
+}
+
+// AddToListTimelineBatch adds a tweet to multiple lists' timelines (fan-out)
+func (tc *TimelineCache) AddToListTimelineBatch(ctx context.Context, listIDs []int64, tweet *models.Tweet) error {
+	keys := make([]string, len(listIDs))
+	for i, listID := range listIDs {
+		keys[i] = listTimelineKey(listID)
+	}
+	return tc.addToKeys(ctx, keys, tweet)
+}
+
+// addToKeys adds a tweet to the timeline sorted sets at keys, trimming each
+// to the maximum size
+func (tc *TimelineCache) addToKeys(ctx context.Context, keys []string, tweet *models.Tweet) error {
+	if len(keys) == 0 {
 		return nil
 	}
 
 	pipe := tc.client.Pipeline()
 	score := float64(tweet.CreatedAt.UnixNano())
 
-	for _, userID := range userIDs {
-		key := timelineKey(userID)
+	for _, key := range keys {
 		pipe.ZAdd(ctx, key, redis.Z{
 			Score:  score,
 			Member: tweet.ID,
@@ -104,6 +140,75 @@ func (tc *TimelineCache) AddToTimelineBatch(ctx context.Context, userIDs []int64
 	return nil
 }
 
+// AddTweetsToTimeline merges several tweets into a single user's timeline (e.g. backfill on follow)
+func (tc *TimelineCache) AddTweetsToTimeline(ctx context.Context, userID int64, tweets []*models.Tweet) error {
+	return tc.addTweetsToKey(ctx, timelineKey(userID), tweets)
+}
+
+// AddTweetsToListTimeline merges several tweets into a list's timeline (e.g. backfill on adding a member)
+func (tc *TimelineCache) AddTweetsToListTimeline(ctx context.Context, listID int64, tweets []*models.Tweet) error {
+	return tc.addTweetsToKey(ctx, listTimelineKey(listID), tweets)
+}
+
+// addTweetsToKey merges several tweets into the timeline sorted set at key
+func (tc *TimelineCache) addTweetsToKey(ctx context.Context, key string, tweets []*models.Tweet) error {
+	if len(tweets) == 0 {
+		return nil
+	}
+
+	members := make([]redis.Z, len(tweets))
+	for i, tweet := range tweets {
+		members[i] = redis.Z{
+			Score:  float64(tweet.CreatedAt.UnixNano()),
+			Member: tweet.ID,
+		}
+	}
+
+	pipe := tc.client.Pipeline()
+	pipe.ZAdd(ctx, key, members...)
+	pipe.ZRemRangeByRank(ctx, key, 0, int64(-tc.maxTimelineSize-1))
+	pipe.Expire(ctx, key, timelineCacheTTL)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to merge into timeline: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveTweetsFromTimeline removes several tweets from a single user's timeline (e.g. evict on unfollow)
+func (tc *TimelineCache) RemoveTweetsFromTimeline(ctx context.Context, userID int64, tweetIDs []int64) error {
+	return tc.removeTweetsFromKey(ctx, timelineKey(userID), tweetIDs)
+}
+
+// RemoveTweetsFromListTimeline removes several tweets from a list's timeline (e.g. evict on removing a member)
+func (tc *TimelineCache) RemoveTweetsFromListTimeline(ctx context.Context, listID int64, tweetIDs []int64) error {
+	return tc.removeTweetsFromKey(ctx, listTimelineKey(listID), tweetIDs)
+}
+
+// removeTweetsFromKey removes several tweets from the timeline sorted set at key
+func (tc *TimelineCache) removeTweetsFromKey(ctx context.Context, key string, tweetIDs []int64) error {
+	if len(tweetIDs) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, len(tweetIDs))
+	for i, id := range tweetIDs {
+		members[i] = id
+	}
+
+	if err := tc.client.ZRem(ctx, key, members...).Err(); err != nil {
+		return fmt.Errorf("failed to remove from timeline: %w", err)
+	}
+	return nil
+}
+
+// MaxTimelineSize returns the maximum number of tweets kept per timeline
+func (tc *TimelineCache) MaxTimelineSize() int {
+	return tc.maxTimelineSize
+}
+
 // GetTimeline retrieves tweet IDs from a user's timeline cache
 func (tc *TimelineCache) GetTimeline(ctx context.Context, userID int64, limit, offset int) ([]int64, error) {
 	key := timelineKey(userID)
@@ -126,6 +231,138 @@ func (tc *TimelineCache) GetTimeline(ctx context.Context, userID int64, limit, o
 	return tweetIDs, nil
 }
 
+// GetTimelinePage retrieves a keyset page of tweet IDs from a user's timeline cache
+func (tc *TimelineCache) GetTimelinePage(ctx context.Context, userID int64, page models.Page) ([]int64, error) {
+	ids, err := tc.getPage(ctx, []string{timelineKey(userID)}, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timeline page: %w", err)
+	}
+	return ids, nil
+}
+
+// scoredID is a sorted-set member paired with its score
+type scoredID struct {
+	score float64
+	id    int64
+}
+
+// cursorScore maps a cursor onto the same score AddToTimeline uses
+func cursorScore(c *models.Cursor) float64 {
+	return float64(c.CreatedAt.UnixNano())
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+// getPage returns the newest page.Limit tweet IDs across the given sorted sets
+// that fall inside the page's keyset bounds, ordered by (score, id) descending.
+// Seeded tweets often share a created_at, and Redis orders equal scores by
+// member string, so tie groups on a cursor or page boundary are fetched whole
+// and ordered by numeric ID here.
+func (tc *TimelineCache) getPage(ctx context.Context, keys []string, page models.Page) ([]int64, error) {
+	scored, err := tc.getScoredPage(ctx, keys, page)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(scored))
+	for i, c := range scored {
+		ids[i] = c.id
+	}
+	return ids, nil
+}
+
+// getScoredPage is getPage with each ID's score
+func (tc *TimelineCache) getScoredPage(ctx context.Context, keys []string, page models.Page) ([]scoredID, error) {
+	if len(keys) == 0 || page.Limit <= 0 {
+		return []scoredID{}, nil
+	}
+
+	max, min := "+inf", "-inf"
+	if page.MaxID != nil {
+		max = "(" + formatScore(cursorScore(page.MaxID))
+	}
+	if page.SinceID != nil {
+		min = "(" + formatScore(cursorScore(page.SinceID))
+	}
+
+	// 1. The page itself, plus the tie groups sitting exactly on each cursor
+	pipe := tc.client.Pipeline()
+	mainCmds := make([]*redis.ZSliceCmd, len(keys))
+	tieCmds := make([]*redis.ZSliceCmd, 0)
+	for i, key := range keys {
+		mainCmds[i] = pipe.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+			Max:   max,
+			Min:   min,
+			Count: int64(page.Limit),
+		})
+		for _, c := range []*models.Cursor{page.MaxID, page.SinceID} {
+			if c != nil {
+				score := formatScore(cursorScore(c))
+				tieCmds = append(tieCmds, pipe.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{Min: score, Max: score}))
+			}
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	// 2. A full page may end part-way through a tie group - fetch those groups whole
+	pipe = tc.client.Pipeline()
+	boundaryCmds := make([]*redis.ZSliceCmd, 0)
+	for i, cmd := range mainCmds {
+		zs := cmd.Val()
+		if len(zs) == page.Limit {
+			score := formatScore(zs[len(zs)-1].Score)
+			boundaryCmds = append(boundaryCmds, pipe.ZRangeByScoreWithScores(ctx, keys[i], &redis.ZRangeBy{Min: score, Max: score}))
+		}
+	}
+	if len(boundaryCmds) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, err
+		}
+	}
+
+	// 3. Apply the keyset bounds, dedupe and order
+	seen := make(map[int64]bool)
+	candidates := make([]scoredID, 0, page.Limit)
+	for _, group := range [][]*redis.ZSliceCmd{mainCmds, tieCmds, boundaryCmds} {
+		for _, cmd := range group {
+			for _, z := range cmd.Val() {
+				member, _ := z.Member.(string)
+				id, err := strconv.ParseInt(member, 10, 64)
+				if err != nil || seen[id] {
+					continue
+				}
+				if page.MaxID != nil && !keysetLess(z.Score, id, cursorScore(page.MaxID), page.MaxID.ID) {
+					continue
+				}
+				if page.SinceID != nil && !keysetLess(cursorScore(page.SinceID), page.SinceID.ID, z.Score, id) {
+					continue
+				}
+				seen[id] = true
+				candidates = append(candidates, scoredID{score: z.Score, id: id})
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return keysetLess(candidates[j].score, candidates[j].id, candidates[i].score, candidates[i].id)
+	})
+	if len(candidates) > page.Limit {
+		candidates = candidates[:page.Limit]
+	}
+	return candidates, nil
+}
+
+// keysetLess reports whether (scoreA, idA) sorts before (scoreB, idB) in ascending order
+func keysetLess(scoreA float64, idA int64, scoreB float64, idB int64) bool {
+	if scoreA == scoreB {
+		return idA < idB
+	}
+	return scoreA < scoreB
+}
+
 // GetTimelineSize returns the number of tweets in a user's timeline cache
 func (tc *TimelineCache) GetTimelineSize(ctx context.Context, userID int64) (int64, error) {
 	key := timelineKey(userID)
@@ -140,13 +377,30 @@ func (tc *TimelineCache) RemoveFromTimeline(ctx context.Context, userID int64, t
 
 // RemoveFromTimelineBatch removes a tweet from multiple users' timelines
 func (tc *TimelineCache) RemoveFromTimelineBatch(ctx context.Context, userIDs []int64, tweetID int64) error {
-	if len(userIDs) == 0 {
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = timelineKey(userID)
+	}
+	return tc.removeFromKeys(ctx, keys, tweetID)
+}
+
+// RemoveFromListTimelineBatch removes a tweet from multiple lists' timelines
+func (tc *TimelineCache) RemoveFromListTimelineBatch(ctx context.Context, listIDs []int64, tweetID int64) error {
+	keys := make([]string, len(listIDs))
+	for i, listID := range listIDs {
+		keys[i] = listTimelineKey(listID)
+	}
+	return tc.removeFromKeys(ctx, keys, tweetID)
+}
+
+// removeFromKeys removes a tweet from the timeline sorted sets at keys
+func (tc *TimelineCache) removeFromKeys(ctx context.Context, keys []string, tweetID int64) error {
+	if len(keys) == 0 {
 		return nil
 	}
 
 	pipe := tc.client.Pipeline()
-	for _, userID := range userIDs {
-		key := timelineKey(userID)
+	for _, key := range keys {
 		pipe.ZRem(ctx, key, tweetID)
 	}
 
@@ -160,6 +414,21 @@ func (tc *TimelineCache) ClearTimeline(ctx context.Context, userID int64) error
 	return tc.client.Del(ctx, key).Err()
 }
 
+// GetListTimelinePage returns the IDs of the tweets in one page of a list's
+// timeline, newest first
+func (tc *TimelineCache) GetListTimelinePage(ctx context.Context, listID int64, page models.Page) ([]int64, error) {
+	ids, err := tc.getPage(ctx, []string{listTimelineKey(listID)}, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list timeline page: %w", err)
+	}
+	return ids, nil
+}
+
+// ClearListTimeline clears a list's timeline cache
+func (tc *TimelineCache) ClearListTimeline(ctx context.Context, listID int64) error {
+	return tc.client.Del(ctx, listTimelineKey(listID)).Err()
+}
+
 // CacheTweet caches a tweet's data
 func (tc *TimelineCache) CacheTweet(ctx context.Context, tweet *models.Tweet) error {
 	key := tweetCacheKey(tweet.ID)
@@ -230,6 +499,11 @@ func (tc *TimelineCache) GetCachedTweets(ctx context.Context, tweetIDs []int64)
 	return tweets, missingIDs, nil
 }
 
+// InvalidateTweet removes a tweet's cached data
+func (tc *TimelineCache) InvalidateTweet(ctx context.Context, tweetID int64) error {
+	return tc.client.Del(ctx, tweetCacheKey(tweetID)).Err()
+}
+
 // CacheTweetsBatch caches multiple tweets
 func (tc *TimelineCache) CacheTweetsBatch(ctx context.Context, tweets []*models.Tweet) error {
 	if len(tweets) == 0 {
@@ -250,40 +524,55 @@ func (tc *TimelineCache) CacheTweetsBatch(ctx context.Context, tweets []*models.
 	return err
 }
 
-// AddCelebrityTweet adds a tweet to a celebrity's tweet cache (for hybrid approach)
-func (tc *TimelineCache) AddCelebrityTweet(ctx context.Context, userID int64, tweet *models.Tweet) error {
-	key := celebrityTweetsKey(userID)
-	score := float64(tweet.CreatedAt.UnixNano())
-	
-	err := tc.client.ZAdd(ctx, key, redis.Z{
-		Score:  score,
-		Member: tweet.ID,
-	}).Err()
-	if err != nil {
-		return fmt.Errorf("failed to add celebrity tweet: %w", err)
+// AddAuthorTweet adds a tweet, retweet or reply to its author's tweet set
+func (tc *TimelineCache) AddAuthorTweet(ctx context.Context, userID int64, tweet *models.Tweet) error {
+	return tc.AddAuthorTweets(ctx, userID, []*models.Tweet{tweet}, false)
+}
+
+// AddAuthorTweets merges several of an author's tweets into their tweet set
+// (e.g. warming it from the database). complete marks the set as holding the
+// author's whole history, until trimming drops the marker.
+func (tc *TimelineCache) AddAuthorTweets(ctx context.Context, userID int64, tweets []*models.Tweet, complete bool) error {
+	members := make([]redis.Z, 0, len(tweets)+1)
+	for _, tweet := range tweets {
+		members = append(members, redis.Z{
+			Score:  float64(tweet.CreatedAt.UnixNano()),
+			Member: tweet.ID,
+		})
+	}
+	if complete {
+		members = append(members, redis.Z{Score: math.Inf(-1), Member: authorCompleteMember})
+	}
+	if len(members) == 0 {
+		return nil
 	}
 
-	// Keep only recent tweets
-	tc.client.ZRemRangeByRank(ctx, key, 0, -101) // Keep last 100
-	tc.client.Expire(ctx, key, timelineCacheTTL)
+	key := authorTweetsKey(userID)
+	pipe := tc.client.Pipeline()
+	pipe.ZAdd(ctx, key, members...)
+	pipe.ZRemRangeByRank(ctx, key, 0, -authorTweetsLimit-1) // Keep the newest, dropping the marker first
+	pipe.Expire(ctx, key, timelineCacheTTL)
 
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add author tweets: %w", err)
+	}
 	return nil
 }
 
-// GetCelebrityTweets retrieves recent tweet IDs from a celebrity
-func (tc *TimelineCache) GetCelebrityTweets(ctx context.Context, userID int64, limit int) ([]int64, error) {
-	key := celebrityTweetsKey(userID)
+// GetAuthorTweets retrieves an author's recent tweet IDs
+func (tc *TimelineCache) GetAuthorTweets(ctx context.Context, userID int64, limit int) ([]int64, error) {
+	key := authorTweetsKey(userID)
 	
 	results, err := tc.client.ZRevRange(ctx, key, 0, int64(limit-1)).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get celebrity tweets: %w", err)
+		return nil, fmt.Errorf("failed to get author tweets: %w", err)
 	}
 
 	tweetIDs := make([]int64, 0, len(results))
 	for _, r := range results {
 		id, err := strconv.ParseInt(r, 10, 64)
 		if err != nil {
-			continue
+			continue // The complete marker
 		}
 		tweetIDs = append(tweetIDs, id)
 	}
@@ -291,8 +580,78 @@ func (tc *TimelineCache) GetCelebrityTweets(ctx context.Context, userID int64, l
 	return tweetIDs, nil
 }
 
-// GetCelebrityTweetsBatch retrieves recent tweets from multiple celebrities
-func (tc *TimelineCache) GetCelebrityTweetsBatch(ctx context.Context, userIDs []int64, limitPerUser int) ([]int64, error) {
+// RemoveAuthorTweet removes a tweet from its author's tweet set
+func (tc *TimelineCache) RemoveAuthorTweet(ctx context.Context, userID int64, tweetID int64) error {
+	key := authorTweetsKey(userID)
+	return tc.client.ZRem(ctx, key, tweetID).Err()
+}
+
+// GetAuthorTweetsPage retrieves a keyset page of tweet IDs merged across
+// several authors' tweet sets. It also returns the authors whose sets can't
+// vouch for the page - they may be missing tweets that belong in it - so
+// the caller can read those authors from the database instead. A set covers
+// the page if it is complete, or if its oldest tweet is no newer than the
+// page's last one (or, for a short page, its since_id bound).
+func (tc *TimelineCache) GetAuthorTweetsPage(ctx context.Context, userIDs []int64, page models.Page) ([]int64, []int64, error) {
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = authorTweetsKey(userID)
+	}
+
+	scored, err := tc.getScoredPage(ctx, keys, page)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get author tweets page: %w", err)
+	}
+
+	// The lowest two members of each set are the complete marker, if it's
+	// there, and the oldest tweet
+	pipe := tc.client.Pipeline()
+	cmds := make([]*redis.ZSliceCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.ZRangeWithScores(ctx, key, 0, 1)
+	}
+	if len(keys) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, nil, fmt.Errorf("failed to get author tweets coverage: %w", err)
+		}
+	}
+
+	var bound *scoredID
+	if page.Limit > 0 && len(scored) == page.Limit {
+		bound = &scored[len(scored)-1]
+	} else if page.SinceID != nil {
+		bound = &scoredID{score: cursorScore(page.SinceID), id: page.SinceID.ID}
+	}
+
+	uncovered := []int64{}
+	for i, cmd := range cmds {
+		complete := false
+		var oldest *scoredID
+		for _, z := range cmd.Val() {
+			member, _ := z.Member.(string)
+			if member == authorCompleteMember {
+				complete = true
+				continue
+			}
+			if id, err := strconv.ParseInt(member, 10, 64); err == nil && oldest == nil {
+				oldest = &scoredID{score: z.Score, id: id}
+			}
+		}
+		if complete || (bound != nil && oldest != nil && !keysetLess(bound.score, bound.id, oldest.score, oldest.id)) {
+			continue
+		}
+		uncovered = append(uncovered, userIDs[i])
+	}
+
+	ids := make([]int64, len(scored))
+	for i, c := range scored {
+		ids[i] = c.id
+	}
+	return ids, uncovered, nil
+}
+
+// GetAuthorTweetsBatch retrieves recent tweets from multiple authors
+func (tc *TimelineCache) GetAuthorTweetsBatch(ctx context.Context, userIDs []int64, limitPerUser int) ([]int64, error) {
 	if len(userIDs) == 0 {
 		return []int64{}, nil
 	}
@@ -301,13 +660,13 @@ func (tc *TimelineCache) GetCelebrityTweetsBatch(ctx context.Context, userIDs []
 	cmds := make([]*redis.StringSliceCmd, len(userIDs))
 	
 	for i, userID := range userIDs {
-		key := celebrityTweetsKey(userID)
+		key := authorTweetsKey(userID)
 		cmds[i] = pipe.ZRevRange(ctx, key, 0, int64(limitPerUser-1))
 	}
 
 	_, err := pipe.Exec(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get celebrity tweets batch: %w", err)
+		return nil, fmt.Errorf("failed to get author tweets batch: %w", err)
 	}
 
 	allTweetIDs := make([]int64, 0)
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+func newTestTimelineCache(t *testing.T) *TimelineCache {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewTimelineCache(client, 800)
+}
+
+func TestKeysetLess(t *testing.T) {
+	tests := []struct {
+		scoreA float64
+		idA    int64
+		scoreB float64
+		idB    int64
+		want   bool
+	}{
+		{1, 5, 2, 1, true},
+		{2, 1, 1, 5, false},
+		{1, 2, 1, 10, true},
+		{1, 10, 1, 2, false},
+		{1, 3, 1, 3, false},
+	}
+	for _, tt := range tests {
+		if got := keysetLess(tt.scoreA, tt.idA, tt.scoreB, tt.idB); got != tt.want {
+			t.Errorf("keysetLess(%v, %d, %v, %d) = %v, want %v", tt.scoreA, tt.idA, tt.scoreB, tt.idB, got, tt.want)
+		}
+	}
+}
+
+// Seeded tweets share created_at, and Redis orders a tie group by member string
+// ("10" before "9"), so pages that start or end inside one must still come back
+// in numeric ID order with nothing skipped or repeated
+func TestTimelinePagesWalkTieGroupsInIDOrder(t *testing.T) {
+	tc := newTestTimelineCache(t)
+	ctx := context.Background()
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tweets := []*models.Tweet{{ID: 20, CreatedAt: at.Add(time.Minute)}}
+	for id := int64(2); id <= 12; id++ {
+		tweets = append(tweets, &models.Tweet{ID: id, CreatedAt: at})
+	}
+	tweets = append(tweets, &models.Tweet{ID: 1, CreatedAt: at.Add(-time.Minute)})
+	if err := tc.AddTweetsToTimeline(ctx, 1, tweets); err != nil {
+		t.Fatalf("AddTweetsToTimeline: %v", err)
+	}
+	byID := make(map[int64]*models.Tweet)
+	for _, tweet := range tweets {
+		byID[tweet.ID] = tweet
+	}
+
+	want := []int64{20, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+	var got []int64
+	page := models.Page{Limit: 4}
+	for {
+		ids, err := tc.GetTimelinePage(ctx, 1, page)
+		if err != nil {
+			t.Fatalf("GetTimelinePage: %v", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+		got = append(got, ids...)
+		page.MaxID = models.CursorFor(byID[ids[len(ids)-1]])
+		if len(got) > len(want) {
+			break
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("paged %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("paged %v, want %v", got, want)
+		}
+	}
+
+	// since_id from inside the tie group returns only what sorts above it
+	ids, err := tc.GetTimelinePage(ctx, 1, models.Page{Limit: 10, SinceID: models.CursorFor(byID[10])})
+	if err != nil {
+		t.Fatalf("GetTimelinePage: %v", err)
+	}
+	if len(ids) != 3 || ids[0] != 20 || ids[1] != 12 || ids[2] != 11 {
+		t.Fatalf("since tweet 10 = %v, want [20 12 11]", ids)
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"os"
+	"strconv"
 	"sync"
 )
 
@@ -11,6 +12,9 @@ type Config struct {
 	// Server settings
 	ServerPort string `json:"server_port"`
 
+	// Storage backend: "postgres" (PostgreSQL + Redis) or "memory" (in-process)
+	Backend string `json:"backend"`
+
 	// Database settings
 	PostgresHost     string `json:"postgres_host"`
 	PostgresPort     string `json:"postgres_port"`
@@ -29,6 +33,33 @@ type Config struct {
 	TimelineCacheSize  int `json:"timeline_cache_size"` // Max tweets to keep in timeline cache
 	TimelinePageSize   int `json:"timeline_page_size"`  // Default page size for timeline queries
 
+	// Reply filtering: "read" drops replies the viewer shouldn't see when the
+	// timeline is read, "fanout" only pushes replies to those who should
+	ReplyFilter string `json:"reply_filter"`
+
+	// Block and mute filtering, fixed at startup: "read" drops hidden
+	// authors' tweets when the timeline is read, "purge" removes them from
+	// cached timelines on block and leaves the viewer out of later fan-out
+	BlockFilter string `json:"block_filter"`
+
+	// How for_you timelines rank their candidates: "recency", "engagement"
+	// or "affinity"
+	RankingScorer string `json:"ranking_scorer"`
+
+	// Like and retweet counters, fixed at startup. "sharded" spreads each
+	// tweet's counts over CounterShards Redis hashes; "write_behind" buffers
+	// changes in the process. Both write back to PostgreSQL every
+	// CounterFlushMs milliseconds.
+	CounterStrategy string `json:"counter_strategy"`
+	CounterShards   int    `json:"counter_shards"`
+	CounterFlushMs  int    `json:"counter_flush_ms"`
+
+	// Async fan-out settings
+	AsyncFanOut      bool `json:"async_fan_out"`       // Enqueue fan-out jobs instead of fanning out inline
+	FanOutWorkers    int  `json:"fan_out_workers"`     // Number of fan-out workers
+	FanOutChunkSize  int  `json:"fan_out_chunk_size"`  // Followers written per pipeline
+	FanOutMaxRetries int  `json:"fan_out_max_retries"` // Attempts before a job is dead-lettered
+
 	// Benchmark settings
 	BenchmarkTweets     int `json:"benchmark_tweets"`
 	BenchmarkConcurrent int `json:"benchmark_concurrent"`
@@ -43,6 +74,7 @@ var (
 func Default() *Config {
 	return &Config{
 		ServerPort:         "8080",
+		Backend:            "postgres",
 		PostgresHost:       "localhost",
 		PostgresPort:       "5432",
 		PostgresUser:       "fanout",
@@ -55,6 +87,16 @@ func Default() *Config {
 		CelebrityThreshold: 10000,
 		TimelineCacheSize:  800,
 		TimelinePageSize:   50,
+		ReplyFilter:        "read",
+		BlockFilter:        "read",
+		RankingScorer:      "recency",
+		CounterStrategy:    "sharded",
+		CounterShards:      8,
+		CounterFlushMs:     1000,
+		AsyncFanOut:        false,
+		FanOutWorkers:      8,
+		FanOutChunkSize:    1000,
+		FanOutMaxRetries:   3,
 		BenchmarkTweets:    1000,
 		BenchmarkConcurrent: 50,
 	}
@@ -74,6 +116,9 @@ func (c *Config) loadFromEnv() {
 	if v := os.Getenv("SERVER_PORT"); v != "" {
 		c.ServerPort = v
 	}
+	if v := os.Getenv("STORAGE_BACKEND"); v != "" {
+		c.Backend = v
+	}
 	if v := os.Getenv("POSTGRES_HOST"); v != "" {
 		c.PostgresHost = v
 	}
@@ -98,6 +143,43 @@ func (c *Config) loadFromEnv() {
 	if v := os.Getenv("REDIS_PASSWORD"); v != "" {
 		c.RedisPassword = v
 	}
+	if v := os.Getenv("REPLY_FILTER"); v != "" {
+		c.ReplyFilter = v
+	}
+	if v := os.Getenv("BLOCK_FILTER"); v != "" {
+		c.BlockFilter = v
+	}
+	if v := os.Getenv("RANKING_SCORER"); v != "" {
+		c.RankingScorer = v
+	}
+	if v := os.Getenv("COUNTER_STRATEGY"); v != "" {
+		c.CounterStrategy = v
+	}
+	if v := os.Getenv("COUNTER_SHARDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.CounterShards = n
+		}
+	}
+	if v := os.Getenv("COUNTER_FLUSH_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.CounterFlushMs = n
+		}
+	}
+	if v := os.Getenv("FANOUT_ASYNC"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			c.AsyncFanOut = b
+		}
+	}
+	if v := os.Getenv("FANOUT_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.FanOutWorkers = n
+		}
+	}
+	if v := os.Getenv("FANOUT_CHUNK_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.FanOutChunkSize = n
+		}
+	}
 }
 
 // PostgresDSN returns the PostgreSQL connection string
@@ -148,5 +230,13 @@ func (c *Config) Update(key string, value interface{}) {
 		if v, ok := value.(int); ok {
 			c.TimelinePageSize = v
 		}
+	case "reply_filter", "reply-filter":
+		if v, ok := value.(string); ok {
+			c.ReplyFilter = v
+		}
+	case "ranking_scorer", "ranking-scorer":
+		if v, ok := value.(string); ok {
+			c.RankingScorer = v
+		}
 	}
 }
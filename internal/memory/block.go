@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// BlockRepository is an in-memory repository.BlockStore
+type BlockRepository struct {
+	db *DB
+}
+
+var _ repository.BlockStore = (*BlockRepository)(nil)
+
+// NewBlockRepository creates a new BlockRepository
+func NewBlockRepository(db *DB) *BlockRepository {
+	return &BlockRepository{db: db}
+}
+
+// addEdge records from -> to in forward and to -> from in reverse
+func addEdge(forward, reverse map[int64]map[int64]bool, from, to int64) {
+	if forward[from] == nil {
+		forward[from] = make(map[int64]bool)
+	}
+	if reverse[to] == nil {
+		reverse[to] = make(map[int64]bool)
+	}
+	forward[from][to] = true
+	reverse[to][from] = true
+}
+
+// removeEdge removes an edge recorded by addEdge
+func removeEdge(forward, reverse map[int64]map[int64]bool, from, to int64) {
+	delete(forward[from], to)
+	delete(reverse[to], from)
+}
+
+// checkUsers enforces the foreign keys and the check constraint on the
+// blocks and mutes tables. Callers must hold db.mu.
+func (r *BlockRepository) checkUsers(fromID, toID int64) error {
+	for _, id := range []int64{fromID, toID} {
+		if _, ok := r.db.users[id]; !ok {
+			return fmt.Errorf("user %d does not exist", id)
+		}
+	}
+	if fromID == toID {
+		return fmt.Errorf("user %d cannot block or mute themselves", fromID)
+	}
+	return nil
+}
+
+// Block records that blockerID blocked blockedID
+func (r *BlockRepository) Block(ctx context.Context, blockerID, blockedID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := r.checkUsers(blockerID, blockedID); err != nil {
+		return fmt.Errorf("failed to create block: %w", err)
+	}
+	addEdge(r.db.blocks, r.db.blockedBy, blockerID, blockedID)
+	return nil
+}
+
+// Unblock removes a block
+func (r *BlockRepository) Unblock(ctx context.Context, blockerID, blockedID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	removeEdge(r.db.blocks, r.db.blockedBy, blockerID, blockedID)
+	return nil
+}
+
+// Mute records that muterID muted mutedID
+func (r *BlockRepository) Mute(ctx context.Context, muterID, mutedID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := r.checkUsers(muterID, mutedID); err != nil {
+		return fmt.Errorf("failed to create mute: %w", err)
+	}
+	addEdge(r.db.mutes, r.db.mutedBy, muterID, mutedID)
+	return nil
+}
+
+// Unmute removes a mute
+func (r *BlockRepository) Unmute(ctx context.Context, muterID, mutedID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	removeEdge(r.db.mutes, r.db.mutedBy, muterID, mutedID)
+	return nil
+}
+
+// GetHidden retrieves the users whose tweets viewerID must not see: those
+// the viewer blocked or muted, and those who blocked the viewer
+func (r *BlockRepository) GetHidden(ctx context.Context, viewerID int64) ([]int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	return union(r.db.blocks[viewerID], r.db.blockedBy[viewerID], r.db.mutes[viewerID]), nil
+}
+
+// GetHiddenFrom retrieves the users who must not see authorID's tweets:
+// those who blocked or muted the author, and those the author blocked
+func (r *BlockRepository) GetHiddenFrom(ctx context.Context, authorID int64) ([]int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	return union(r.db.blockedBy[authorID], r.db.blocks[authorID], r.db.mutedBy[authorID]), nil
+}
+
+// union returns the IDs in any of the sets, in ascending order
+func union(sets ...map[int64]bool) []int64 {
+	all := make(map[int64]bool)
+	for _, set := range sets {
+		for id := range set {
+			all[id] = true
+		}
+	}
+	return sortedIDs(all)
+}
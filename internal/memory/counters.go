@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// The in-memory cache keeps each tweet's counts in a single map entry: there
+// are no keys to spread a hot tweet over, so counter shards are not modelled.
+
+var _ cache.CounterStore = (*TimelineCache)(nil)
+
+// IncrCounts adds delta to a tweet's counts and marks them changed
+func (tc *TimelineCache) IncrCounts(ctx context.Context, tweetID int64, delta models.TweetCounts) (bool, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	counts, ok := tc.counts[tweetID]
+	if !ok {
+		return false, nil
+	}
+	tc.counts[tweetID] = counts.Add(delta)
+	tc.changedCounts[tweetID] = true
+	return true, nil
+}
+
+// LoadCounts stores counts read from the database, skipping tweets whose
+// counts are already loaded
+func (tc *TimelineCache) LoadCounts(ctx context.Context, counts map[int64]models.TweetCounts) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	for tweetID, c := range counts {
+		if _, ok := tc.counts[tweetID]; !ok {
+			tc.counts[tweetID] = c
+		}
+	}
+	return nil
+}
+
+// GetCounts returns the counts of the tweets that are loaded, and the IDs of
+// those that aren't
+func (tc *TimelineCache) GetCounts(ctx context.Context, tweetIDs []int64) (map[int64]models.TweetCounts, []int64, error) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	counts := make(map[int64]models.TweetCounts, len(tweetIDs))
+	missingIDs := make([]int64, 0)
+	for _, id := range tweetIDs {
+		if c, ok := tc.counts[id]; ok {
+			counts[id] = c
+		} else {
+			missingIDs = append(missingIDs, id)
+		}
+	}
+	return counts, missingIDs, nil
+}
+
+// TakeChanged removes and returns up to max tweets whose counts have changed
+// since they were last taken
+func (tc *TimelineCache) TakeChanged(ctx context.Context, max int) ([]int64, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	tweetIDs := make([]int64, 0, min(max, len(tc.changedCounts)))
+	for id := range tc.changedCounts {
+		if len(tweetIDs) == max {
+			break
+		}
+		tweetIDs = append(tweetIDs, id)
+		delete(tc.changedCounts, id)
+	}
+	return tweetIDs, nil
+}
+
+// MarkChanged marks tweets as changed again
+func (tc *TimelineCache) MarkChanged(ctx context.Context, tweetIDs []int64) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	for _, id := range tweetIDs {
+		tc.changedCounts[id] = true
+	}
+	return nil
+}
+
+// RemoveCounts drops a tweet's counts
+func (tc *TimelineCache) RemoveCounts(ctx context.Context, tweetID int64) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	delete(tc.counts, tweetID)
+	delete(tc.changedCounts, tweetID)
+	return nil
+}
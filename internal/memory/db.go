@@ -0,0 +1,190 @@
+// Package memory is an in-process storage backend. It implements the
+// repository and cache store interfaces with mutex-protected maps so the
+// strategies, API and CLI can run without PostgreSQL or Redis.
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// DB is an in-process stand-in for the PostgreSQL schema. It mirrors the
+// behaviour the repositories rely on: unique usernames, foreign keys with
+// cascading deletes and the follower/following count trigger.
+type DB struct {
+	mu sync.RWMutex
+
+	users      map[int64]*models.User
+	usernames  map[string]int64
+	nextUserID int64
+
+	tweets      map[int64]*models.Tweet
+	userTweets  map[int64]map[int64]bool  // author -> tweet IDs
+	retweets    map[int64]map[int64]int64 // original tweet -> retweeter -> retweet ID
+	replies     map[int64]map[int64]bool  // parent tweet -> reply IDs
+	likes       map[int64]map[int64]bool  // tweet -> users who liked it
+	nextTweetID int64
+
+	followers map[int64]map[int64]bool // followee -> followers
+	following map[int64]map[int64]bool // follower -> followees
+
+	followRequests map[int64]map[int64]time.Time // target -> requester -> created_at
+
+	blocks    map[int64]map[int64]bool // blocker -> blocked
+	blockedBy map[int64]map[int64]bool // blocked -> blockers
+	mutes     map[int64]map[int64]bool // muter -> muted
+	mutedBy   map[int64]map[int64]bool // muted -> muters
+
+	lists       map[int64]*models.List
+	listMembers map[int64]map[int64]bool // list -> members
+	memberLists map[int64]map[int64]bool // member -> lists
+	nextListID  int64
+
+	clock func() time.Time
+}
+
+// NewDB creates an empty DB
+func NewDB() *DB {
+	db := &DB{clock: time.Now}
+	db.reset()
+	return db
+}
+
+func (db *DB) reset() {
+	db.users = make(map[int64]*models.User)
+	db.usernames = make(map[string]int64)
+	db.tweets = make(map[int64]*models.Tweet)
+	db.userTweets = make(map[int64]map[int64]bool)
+	db.retweets = make(map[int64]map[int64]int64)
+	db.replies = make(map[int64]map[int64]bool)
+	db.likes = make(map[int64]map[int64]bool)
+	db.followers = make(map[int64]map[int64]bool)
+	db.following = make(map[int64]map[int64]bool)
+	db.followRequests = make(map[int64]map[int64]time.Time)
+	db.blocks = make(map[int64]map[int64]bool)
+	db.blockedBy = make(map[int64]map[int64]bool)
+	db.mutes = make(map[int64]map[int64]bool)
+	db.mutedBy = make(map[int64]map[int64]bool)
+	db.lists = make(map[int64]*models.List)
+	db.listMembers = make(map[int64]map[int64]bool)
+	db.memberLists = make(map[int64]map[int64]bool)
+}
+
+// SetClock replaces the source of created_at timestamps, e.g. with a fake
+// clock so separate DBs assign identical timestamps
+func (db *DB) SetClock(clock func() time.Time) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.clock = clock
+}
+
+// now returns the clock's time at PostgreSQL's microsecond precision.
+// Callers must hold db.mu.
+func (db *DB) now() time.Time {
+	return db.clock().Truncate(time.Microsecond)
+}
+
+// user returns a copy of a user row. Callers must hold db.mu.
+func (db *DB) user(id int64) *models.User {
+	u, ok := db.users[id]
+	if !ok {
+		return nil
+	}
+	copied := *u
+	return &copied
+}
+
+// tweet returns a copy of a tweet row joined with its author's username
+// and, for a retweet, the original tweet's author, time and counts.
+// Callers must hold db.mu.
+func (db *DB) tweet(id int64) *models.Tweet {
+	t, ok := db.tweets[id]
+	if !ok {
+		return nil
+	}
+	copied := *t
+	if u, ok := db.users[t.UserID]; ok {
+		copied.Username = u.Username
+	}
+	if o, ok := db.tweets[t.OriginalID]; ok {
+		createdAt := o.CreatedAt
+		copied.OriginalUserID = o.UserID
+		copied.OriginalCreatedAt = &createdAt
+		copied.LikeCount, copied.RetweetCount = o.LikeCount, o.RetweetCount
+		if u, ok := db.users[o.UserID]; ok {
+			copied.OriginalUsername = u.Username
+		}
+	}
+	return &copied
+}
+
+// deleteTweet removes a tweet row along with its retweets and likes, as
+// the foreign keys cascade. Replies to it lose their in_reply_to_id
+// but keep the author they answered. Callers must hold db.mu.
+func (db *DB) deleteTweet(id int64) {
+	t, ok := db.tweets[id]
+	if !ok {
+		return
+	}
+	for _, retweetID := range db.retweets[id] {
+		db.deleteTweet(retweetID)
+	}
+	delete(db.retweets, id)
+	if t.OriginalID != 0 {
+		delete(db.retweets[t.OriginalID], t.UserID)
+	}
+	for replyID := range db.replies[id] {
+		db.tweets[replyID].InReplyToID = 0
+	}
+	delete(db.replies, id)
+	delete(db.likes, id)
+	if t.InReplyToID != 0 {
+		delete(db.replies[t.InReplyToID], id)
+	}
+	delete(db.userTweets[t.UserID], id)
+	delete(db.tweets, id)
+}
+
+// sortedIDs returns the keys of a set in ascending order
+func sortedIDs(set map[int64]bool) []int64 {
+	ids := make([]int64, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// sortOldestFirst orders tweets by created_at, then ID, both ascending
+func sortOldestFirst(tweets []*models.Tweet) {
+	sort.Slice(tweets, func(i, j int) bool {
+		if tweets[i].CreatedAt.Equal(tweets[j].CreatedAt) {
+			return tweets[i].ID < tweets[j].ID
+		}
+		return tweets[i].CreatedAt.Before(tweets[j].CreatedAt)
+	})
+}
+
+// sortNewestFirst orders tweets by created_at, then ID, both descending
+func sortNewestFirst(tweets []*models.Tweet) {
+	sort.Slice(tweets, func(i, j int) bool {
+		if tweets[i].CreatedAt.Equal(tweets[j].CreatedAt) {
+			return tweets[i].ID > tweets[j].ID
+		}
+		return tweets[i].CreatedAt.After(tweets[j].CreatedAt)
+	})
+}
+
+// limitTweets truncates tweets to at most limit entries
+func limitTweets(tweets []*models.Tweet, limit int) []*models.Tweet {
+	if limit < 0 {
+		limit = 0
+	}
+	if len(tweets) > limit {
+		return tweets[:limit]
+	}
+	return tweets
+}
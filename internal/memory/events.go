@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// subscriberBuffer is how many events a slow subscriber can fall behind
+// before further events are dropped, like a Redis client output buffer
+const subscriberBuffer = 256
+
+// EventBus is an in-process implementation of cache.EventBus
+type EventBus struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan cache.TimelineEvent]struct{}
+}
+
+var _ cache.EventBus = (*EventBus)(nil)
+
+// NewEventBus creates an empty EventBus
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[string]map[chan cache.TimelineEvent]struct{})}
+}
+
+// PublishDelivered announces tweet to the subscribers of each user's timeline
+func (b *EventBus) PublishDelivered(ctx context.Context, userIDs []int64, tweet *models.Tweet) error {
+	event := cache.TimelineEvent{
+		TweetID:         tweet.ID,
+		AuthorID:        tweet.UserID,
+		InReplyToUserID: tweet.InReplyToUserID,
+		OriginalUserID:  tweet.OriginalUserID,
+		CreatedAt:       tweet.CreatedAt,
+		Source:          cache.EventSourceTimeline,
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, userID := range userIDs {
+		b.publish(cache.TimelineEventsChannel(userID), event)
+	}
+	return nil
+}
+
+// PublishPosted announces tweet to the subscribers of its author
+func (b *EventBus) PublishPosted(ctx context.Context, tweet *models.Tweet) error {
+	event := cache.TimelineEvent{
+		TweetID:         tweet.ID,
+		AuthorID:        tweet.UserID,
+		InReplyToUserID: tweet.InReplyToUserID,
+		OriginalUserID:  tweet.OriginalUserID,
+		CreatedAt:       tweet.CreatedAt,
+		Source:          cache.EventSourceAuthor,
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	b.publish(cache.AuthorEventsChannel(tweet.UserID), event)
+	return nil
+}
+
+// publish sends event to every subscriber of channel. Caller must hold b.mu.
+func (b *EventBus) publish(channel string, event cache.TimelineEvent) {
+	for ch := range b.subs[channel] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe listens on the timeline and author channels until ctx is done
+func (b *EventBus) Subscribe(ctx context.Context, timelines, authors []int64) (<-chan cache.TimelineEvent, error) {
+	ch := make(chan cache.TimelineEvent, subscriberBuffer)
+	channels := cache.EventChannels(timelines, authors)
+
+	b.mu.Lock()
+	for _, name := range channels {
+		if b.subs[name] == nil {
+			b.subs[name] = make(map[chan cache.TimelineEvent]struct{})
+		}
+		b.subs[name][ch] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		for _, name := range channels {
+			delete(b.subs[name], ch)
+			if len(b.subs[name]) == 0 {
+				delete(b.subs, name)
+			}
+		}
+		b.mu.Unlock()
+		close(ch)
+	}()
+
+	return ch, nil
+}
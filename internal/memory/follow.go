@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// FollowRepository is an in-memory repository.FollowStore
+type FollowRepository struct {
+	db *DB
+}
+
+var _ repository.FollowStore = (*FollowRepository)(nil)
+
+// NewFollowRepository creates a new FollowRepository
+func NewFollowRepository(db *DB) *FollowRepository {
+	return &FollowRepository{db: db}
+}
+
+// follow inserts an edge and bumps the counts. Callers must hold db.mu.
+func (db *DB) follow(followerID, followeeID int64) {
+	if db.following[followerID][followeeID] {
+		return
+	}
+	if db.following[followerID] == nil {
+		db.following[followerID] = make(map[int64]bool)
+	}
+	if db.followers[followeeID] == nil {
+		db.followers[followeeID] = make(map[int64]bool)
+	}
+	db.following[followerID][followeeID] = true
+	db.followers[followeeID][followerID] = true
+	db.users[followeeID].FollowerCount++
+	db.users[followerID].FollowingCount++
+}
+
+// unfollow removes an edge and decrements the counts. Callers must hold db.mu.
+func (db *DB) unfollow(followerID, followeeID int64) {
+	if !db.following[followerID][followeeID] {
+		return
+	}
+	delete(db.following[followerID], followeeID)
+	delete(db.followers[followeeID], followerID)
+	if u, ok := db.users[followeeID]; ok {
+		u.FollowerCount--
+	}
+	if u, ok := db.users[followerID]; ok {
+		u.FollowingCount--
+	}
+}
+
+// Create creates a new follow relationship
+func (r *FollowRepository) Create(ctx context.Context, followerID, followeeID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := r.checkUsers(followerID, followeeID); err != nil {
+		return fmt.Errorf("failed to create follow: %w", err)
+	}
+	r.db.follow(followerID, followeeID)
+	return nil
+}
+
+// checkUsers enforces the follows table's foreign keys. Callers must hold db.mu.
+func (r *FollowRepository) checkUsers(ids ...int64) error {
+	for _, id := range ids {
+		if _, ok := r.db.users[id]; !ok {
+			return fmt.Errorf("user %d does not exist", id)
+		}
+	}
+	return nil
+}
+
+// Delete removes a follow relationship
+func (r *FollowRepository) Delete(ctx context.Context, followerID, followeeID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	r.db.unfollow(followerID, followeeID)
+	return nil
+}
+
+// GetFollowers retrieves all followers of a user
+func (r *FollowRepository) GetFollowers(ctx context.Context, userID int64) ([]int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	return sortedIDs(r.db.followers[userID]), nil
+}
+
+// GetFollowing retrieves all users that a user follows
+func (r *FollowRepository) GetFollowing(ctx context.Context, userID int64) ([]int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	return sortedIDs(r.db.following[userID]), nil
+}
+
+// GetFollowingUsers retrieves all users that a user follows with full user data
+func (r *FollowRepository) GetFollowingUsers(ctx context.Context, userID int64) ([]*models.User, error) {
+	return r.followingWhere(userID, func(*models.User) bool { return true }), nil
+}
+
+// GetFollowingCelebrities retrieves celebrities that a user follows
+func (r *FollowRepository) GetFollowingCelebrities(ctx context.Context, userID int64, threshold int) ([]*models.User, error) {
+	return r.followingWhere(userID, func(u *models.User) bool { return u.FollowerCount >= threshold }), nil
+}
+
+// GetFollowingNonCelebrities retrieves non-celebrities that a user follows
+func (r *FollowRepository) GetFollowingNonCelebrities(ctx context.Context, userID int64, threshold int) ([]int64, error) {
+	users := r.followingWhere(userID, func(u *models.User) bool { return u.FollowerCount < threshold })
+	ids := make([]int64, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids, nil
+}
+
+// followingWhere returns the followees of userID that match keep
+func (r *FollowRepository) followingWhere(userID int64, keep func(*models.User) bool) []*models.User {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	users := []*models.User{}
+	for _, id := range sortedIDs(r.db.following[userID]) {
+		if u := r.db.user(id); u != nil && keep(u) {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+// IsFollowing checks if a user follows another user
+func (r *FollowRepository) IsFollowing(ctx context.Context, followerID, followeeID int64) (bool, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	return r.db.following[followerID][followeeID], nil
+}
+
+// Count returns the total number of follow relationships
+func (r *FollowRepository) Count(ctx context.Context) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	count := 0
+	for _, followees := range r.db.following {
+		count += len(followees)
+	}
+	return count, nil
+}
+
+// BulkCreate creates multiple follow relationships, skipping existing ones.
+// Like the SQL insert, the whole batch fails if any user is missing.
+func (r *FollowRepository) BulkCreate(ctx context.Context, follows []struct {
+	FollowerID int64
+	FolloweeID int64
+}) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, f := range follows {
+		if err := r.checkUsers(f.FollowerID, f.FolloweeID); err != nil {
+			return fmt.Errorf("failed to bulk create follows: %w", err)
+		}
+	}
+	for _, f := range follows {
+		r.db.follow(f.FollowerID, f.FolloweeID)
+	}
+	return nil
+}
+
+// Truncate removes all follows and resets the follow counts
+func (r *FollowRepository) Truncate(ctx context.Context) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.followers = make(map[int64]map[int64]bool)
+	r.db.following = make(map[int64]map[int64]bool)
+	for _, u := range r.db.users {
+		u.FollowerCount = 0
+		u.FollowingCount = 0
+	}
+	return nil
+}
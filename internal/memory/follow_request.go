@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// FollowRequestRepository is an in-memory repository.FollowRequestStore
+type FollowRequestRepository struct {
+	db *DB
+}
+
+var _ repository.FollowRequestStore = (*FollowRequestRepository)(nil)
+
+// NewFollowRequestRepository creates a new FollowRequestRepository
+func NewFollowRequestRepository(db *DB) *FollowRequestRepository {
+	return &FollowRequestRepository{db: db}
+}
+
+// Create records requesterID's request to follow targetID
+func (r *FollowRequestRepository) Create(ctx context.Context, requesterID, targetID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, id := range []int64{requesterID, targetID} {
+		if _, ok := r.db.users[id]; !ok {
+			return fmt.Errorf("failed to create follow request: user %d does not exist", id)
+		}
+	}
+	if requesterID == targetID {
+		return fmt.Errorf("failed to create follow request: user %d cannot follow themselves", requesterID)
+	}
+
+	if r.db.followRequests[targetID] == nil {
+		r.db.followRequests[targetID] = make(map[int64]time.Time)
+	}
+	if _, exists := r.db.followRequests[targetID][requesterID]; !exists {
+		r.db.followRequests[targetID][requesterID] = r.db.now()
+	}
+	return nil
+}
+
+// Delete removes a pending request
+func (r *FollowRequestRepository) Delete(ctx context.Context, requesterID, targetID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.followRequests[targetID][requesterID]; !ok {
+		return repository.ErrNoFollowRequest
+	}
+	delete(r.db.followRequests[targetID], requesterID)
+	return nil
+}
+
+// GetPending retrieves up to limit requests to follow targetID, oldest first
+func (r *FollowRequestRepository) GetPending(ctx context.Context, targetID int64, limit int) ([]*models.FollowRequest, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	requests := r.db.pendingRequests(targetID)
+	if limit >= 0 && len(requests) > limit {
+		requests = requests[:limit]
+	}
+	return requests, nil
+}
+
+// DeleteAll removes every request to follow targetID and returns the
+// requesters, oldest request first
+func (r *FollowRequestRepository) DeleteAll(ctx context.Context, targetID int64) ([]int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	requests := r.db.pendingRequests(targetID)
+	delete(r.db.followRequests, targetID)
+
+	requesters := make([]int64, len(requests))
+	for i, req := range requests {
+		requesters[i] = req.RequesterID
+	}
+	return requesters, nil
+}
+
+// pendingRequests returns the requests to follow targetID, oldest first.
+// Callers must hold db.mu.
+func (db *DB) pendingRequests(targetID int64) []*models.FollowRequest {
+	requests := make([]*models.FollowRequest, 0, len(db.followRequests[targetID]))
+	for requesterID, createdAt := range db.followRequests[targetID] {
+		requests = append(requests, &models.FollowRequest{RequesterID: requesterID, TargetID: targetID, CreatedAt: createdAt})
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].CreatedAt.Equal(requests[j].CreatedAt) {
+			return requests[i].RequesterID < requests[j].RequesterID
+		}
+		return requests[i].CreatedAt.Before(requests[j].CreatedAt)
+	})
+	return requests
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// ListRepository is an in-memory repository.ListStore
+type ListRepository struct {
+	db *DB
+}
+
+var _ repository.ListStore = (*ListRepository)(nil)
+
+// NewListRepository creates a new ListRepository
+func NewListRepository(db *DB) *ListRepository {
+	return &ListRepository{db: db}
+}
+
+// list returns a copy of a list row with its member count. Callers must
+// hold db.mu.
+func (db *DB) list(id int64) *models.List {
+	l, ok := db.lists[id]
+	if !ok {
+		return nil
+	}
+	copied := *l
+	copied.MemberCount = len(db.listMembers[id])
+	return &copied
+}
+
+// deleteList removes a list row and its members. Callers must hold db.mu.
+func (db *DB) deleteList(id int64) {
+	for userID := range db.listMembers[id] {
+		delete(db.memberLists[userID], id)
+	}
+	delete(db.listMembers, id)
+	delete(db.lists, id)
+}
+
+// Create creates a new empty list owned by ownerID
+func (r *ListRepository) Create(ctx context.Context, ownerID int64, name string) (*models.List, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[ownerID]; !ok {
+		return nil, fmt.Errorf("failed to create list: user %d does not exist", ownerID)
+	}
+
+	r.db.nextListID++
+	r.db.lists[r.db.nextListID] = &models.List{
+		ID:        r.db.nextListID,
+		OwnerID:   ownerID,
+		Name:      name,
+		CreatedAt: r.db.now(),
+	}
+	return r.db.list(r.db.nextListID), nil
+}
+
+// GetByID retrieves a list by ID
+func (r *ListRepository) GetByID(ctx context.Context, id int64) (*models.List, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	list := r.db.list(id)
+	if list == nil {
+		return nil, fmt.Errorf("failed to get list: %w", sql.ErrNoRows)
+	}
+	return list, nil
+}
+
+// GetByOwner retrieves the lists ownerID owns, oldest first
+func (r *ListRepository) GetByOwner(ctx context.Context, ownerID int64) ([]*models.List, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	lists := []*models.List{}
+	for id, l := range r.db.lists {
+		if l.OwnerID == ownerID {
+			lists = append(lists, r.db.list(id))
+		}
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].ID < lists[j].ID })
+	return lists, nil
+}
+
+// Delete removes a list and its members
+func (r *ListRepository) Delete(ctx context.Context, id int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.lists[id]; !ok {
+		return fmt.Errorf("failed to delete list: %w", sql.ErrNoRows)
+	}
+	r.db.deleteList(id)
+	return nil
+}
+
+// AddMember adds userID to a list
+func (r *ListRepository) AddMember(ctx context.Context, listID, userID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.lists[listID]; !ok {
+		return fmt.Errorf("failed to add list member: list %d does not exist", listID)
+	}
+	if _, ok := r.db.users[userID]; !ok {
+		return fmt.Errorf("failed to add list member: user %d does not exist", userID)
+	}
+	addEdge(r.db.listMembers, r.db.memberLists, listID, userID)
+	return nil
+}
+
+// RemoveMember removes userID from a list
+func (r *ListRepository) RemoveMember(ctx context.Context, listID, userID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	removeEdge(r.db.listMembers, r.db.memberLists, listID, userID)
+	return nil
+}
+
+// GetMembers retrieves a list's members with full user data
+func (r *ListRepository) GetMembers(ctx context.Context, listID int64) ([]*models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	ids := sortedIDs(r.db.listMembers[listID])
+	users := make([]*models.User, len(ids))
+	for i, id := range ids {
+		users[i] = r.db.user(id)
+	}
+	return users, nil
+}
+
+// GetListsByMember retrieves the IDs of the lists containing userID, which
+// a tweet of theirs fans out to
+func (r *ListRepository) GetListsByMember(ctx context.Context, userID int64) ([]int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return sortedIDs(r.db.memberLists[userID]), nil
+}
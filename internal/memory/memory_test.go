@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+func newUsers(t *testing.T, users *UserRepository, n int) []int64 {
+	t.Helper()
+	ids := make([]int64, n)
+	for i := range ids {
+		u, err := users.Create(context.Background(), fmt.Sprintf("user_%d", i+1))
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		ids[i] = u.ID
+	}
+	return ids
+}
+
+func TestFollowKeepsCountsInStep(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB()
+	users := NewUserRepository(db)
+	follows := NewFollowRepository(db)
+	u := newUsers(t, users, 3)
+
+	for _, edge := range [][2]int64{{u[0], u[2]}, {u[1], u[2]}, {u[1], u[2]}, {u[2], u[0]}} {
+		if err := follows.Create(ctx, edge[0], edge[1]); err != nil {
+			t.Fatalf("follow: %v", err)
+		}
+	}
+	if err := follows.Create(ctx, u[0], 999); err == nil {
+		t.Error("following a missing user succeeded")
+	}
+
+	followers, _ := follows.GetFollowers(ctx, u[2])
+	if len(followers) != 2 || followers[0] != u[0] || followers[1] != u[1] {
+		t.Errorf("followers = %v, want [%d %d]", followers, u[0], u[1])
+	}
+	if n, _ := follows.Count(ctx); n != 3 {
+		t.Errorf("Count = %d, want 3 with the repeat follow ignored", n)
+	}
+	celebs, _ := follows.GetFollowingCelebrities(ctx, u[0], 2)
+	if len(celebs) != 1 || celebs[0].ID != u[2] {
+		t.Errorf("celebrities followed at threshold 2 = %v, want only user %d", celebs, u[2])
+	}
+
+	if err := follows.Delete(ctx, u[1], u[2]); err != nil {
+		t.Fatalf("unfollow: %v", err)
+	}
+	if ok, _ := follows.IsFollowing(ctx, u[1], u[2]); ok {
+		t.Error("still following after unfollow")
+	}
+	user, _ := users.GetByID(ctx, u[2])
+	if user.FollowerCount != 1 {
+		t.Errorf("follower count = %d after unfollow, want 1", user.FollowerCount)
+	}
+
+	// Deleting a user drops their edges from both sides
+	if err := users.Delete(ctx, u[2]); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if n, _ := follows.Count(ctx); n != 0 {
+		t.Errorf("Count = %d after deleting the followee, want 0", n)
+	}
+	user, _ = users.GetByID(ctx, u[0])
+	if user.FollowerCount != 0 || user.FollowingCount != 0 {
+		t.Errorf("user %d counts = %d/%d, want 0/0", u[0], user.FollowerCount, user.FollowingCount)
+	}
+}
+
+func TestTweetNotFoundIsErrNoRows(t *testing.T) {
+	_, err := NewTweetRepository(NewDB()).GetByID(context.Background(), 1)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetByID of a missing tweet = %v, want sql.ErrNoRows like the Postgres store", err)
+	}
+}
+
+func TestTimelineCacheTrimsOldest(t *testing.T) {
+	ctx := context.Background()
+	tc := NewTimelineCache(3)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Added out of order, with a tie on created_at
+	for _, tw := range []*models.Tweet{
+		{ID: 5, CreatedAt: at.Add(2 * time.Minute)},
+		{ID: 1, CreatedAt: at},
+		{ID: 4, CreatedAt: at.Add(time.Minute)},
+		{ID: 3, CreatedAt: at.Add(time.Minute)},
+	} {
+		if err := tc.AddToTimeline(ctx, 1, tw); err != nil {
+			t.Fatalf("AddToTimeline: %v", err)
+		}
+	}
+	ids, _ := tc.GetTimeline(ctx, 1, 10, 0)
+	if len(ids) != 3 || ids[0] != 5 || ids[1] != 4 || ids[2] != 3 {
+		t.Fatalf("timeline = %v, want [5 4 3]", ids)
+	}
+	if ids, _ := tc.GetTimeline(ctx, 1, 1, 1); len(ids) != 1 || ids[0] != 4 {
+		t.Errorf("GetTimeline(limit 1, offset 1) = %v, want [4]", ids)
+	}
+
+	// A batch over the cap keeps only the newest
+	batch := make([]*models.Tweet, 0, 5)
+	for id := int64(10); id < 15; id++ {
+		batch = append(batch, &models.Tweet{ID: id, CreatedAt: at.Add(time.Hour)})
+	}
+	if err := tc.AddTweetsToTimeline(ctx, 1, batch); err != nil {
+		t.Fatalf("AddTweetsToTimeline: %v", err)
+	}
+	if ids, _ := tc.GetTimeline(ctx, 1, 10, 0); len(ids) != 3 || ids[0] != 14 || ids[2] != 12 {
+		t.Errorf("timeline after batch = %v, want [14 13 12]", ids)
+	}
+
+	// Removing the last member drops the timeline like Redis drops empty keys
+	if err := tc.RemoveTweetsFromTimeline(ctx, 1, []int64{12, 13, 14}); err != nil {
+		t.Fatalf("RemoveTweetsFromTimeline: %v", err)
+	}
+	if ok, _ := tc.TimelineExists(ctx, 1); ok {
+		t.Error("empty timeline still exists")
+	}
+}
+
+func TestTimelineCachePages(t *testing.T) {
+	ctx := context.Background()
+	tc := NewTimelineCache(800)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	byID := make(map[int64]*models.Tweet)
+	tweets := make([]*models.Tweet, 0, 6)
+	for id := int64(1); id <= 6; id++ {
+		tw := &models.Tweet{ID: id, CreatedAt: at.Add(time.Duration(id/3) * time.Minute)}
+		byID[id] = tw
+		tweets = append(tweets, tw)
+	}
+	if err := tc.AddTweetsToTimeline(ctx, 1, tweets); err != nil {
+		t.Fatalf("AddTweetsToTimeline: %v", err)
+	}
+
+	first, _ := tc.GetTimelinePage(ctx, 1, models.Page{Limit: 4})
+	if len(first) != 4 || first[0] != 6 || first[3] != 3 {
+		t.Fatalf("first page = %v, want [6 5 4 3]", first)
+	}
+	rest, _ := tc.GetTimelinePage(ctx, 1, models.Page{Limit: 4, MaxID: models.CursorFor(byID[3])})
+	if len(rest) != 2 || rest[0] != 2 || rest[1] != 1 {
+		t.Fatalf("second page = %v, want [2 1]", rest)
+	}
+	newer, _ := tc.GetTimelinePage(ctx, 1, models.Page{Limit: 4, SinceID: models.CursorFor(byID[4])})
+	if len(newer) != 2 || newer[0] != 6 || newer[1] != 5 {
+		t.Fatalf("since tweet 4 = %v, want [6 5]", newer)
+	}
+}
@@ -0,0 +1,473 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// authorTweetsLimit mirrors the number of tweets Redis keeps per author
+const authorTweetsLimit = 100
+
+// sortedSet maps tweet IDs to their score (created_at in Unix nanoseconds)
+type sortedSet map[int64]int64
+
+// entry is a sorted-set member paired with its score
+type entry struct {
+	score int64
+	id    int64
+}
+
+// newer reports whether a sorts before b in reverse-chronological order
+func (a entry) newer(b entry) bool {
+	if a.score == b.score {
+		return a.id > b.id
+	}
+	return a.score > b.score
+}
+
+// entries returns the set's members newest first
+func (s sortedSet) entries() []entry {
+	out := make([]entry, 0, len(s))
+	for id, score := range s {
+		out = append(out, entry{score: score, id: id})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].newer(out[j]) })
+	return out
+}
+
+// oldest returns the set's oldest member without sorting; ok is false if
+// the set is empty
+func (s sortedSet) oldest() (oldest entry, ok bool) {
+	for id, score := range s {
+		e := entry{score: score, id: id}
+		if !ok || oldest.newer(e) {
+			oldest, ok = e, true
+		}
+	}
+	return oldest, ok
+}
+
+// trim drops the oldest members until at most max remain
+func (s sortedSet) trim(max int) {
+	if len(s) <= max {
+		return
+	}
+	if len(s) == max+1 {
+		// Common case after a single add - drop the oldest without sorting
+		oldest, _ := s.oldest()
+		delete(s, oldest.id)
+		return
+	}
+	for _, e := range s.entries()[max:] {
+		delete(s, e.id)
+	}
+}
+
+// TimelineCache is an in-memory cache.TimelineStore. Like the Redis version
+// it trims timelines to maxTimelineSize and author tweet sets to the newest
+// 100 tweets; TTLs are not modelled.
+type TimelineCache struct {
+	mu              sync.RWMutex
+	maxTimelineSize int
+	timelines       map[int64]sortedSet // user -> timeline
+	lists           map[int64]sortedSet // list -> timeline
+	authors         map[int64]sortedSet // author -> own recent tweets
+	complete        map[int64]bool      // authors whose set holds their whole history
+	tweets          map[int64]models.Tweet
+	counts          map[int64]models.TweetCounts // tweet -> like and retweet counts
+	changedCounts   map[int64]bool               // tweets whose counts changed since taken
+}
+
+var _ cache.TimelineStore = (*TimelineCache)(nil)
+
+// NewTimelineCache creates a new TimelineCache
+func NewTimelineCache(maxSize int) *TimelineCache {
+	return &TimelineCache{
+		maxTimelineSize: maxSize,
+		timelines:       make(map[int64]sortedSet),
+		lists:           make(map[int64]sortedSet),
+		authors:         make(map[int64]sortedSet),
+		complete:        make(map[int64]bool),
+		tweets:          make(map[int64]models.Tweet),
+		counts:          make(map[int64]models.TweetCounts),
+		changedCounts:   make(map[int64]bool),
+	}
+}
+
+// Flush empties the cache
+func (tc *TimelineCache) Flush() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.timelines = make(map[int64]sortedSet)
+	tc.lists = make(map[int64]sortedSet)
+	tc.authors = make(map[int64]sortedSet)
+	tc.complete = make(map[int64]bool)
+	tc.tweets = make(map[int64]models.Tweet)
+	tc.counts = make(map[int64]models.TweetCounts)
+	tc.changedCounts = make(map[int64]bool)
+}
+
+// add inserts tweets into the set under key, creating it if needed, then trims.
+// Callers must hold tc.mu.
+func add(sets map[int64]sortedSet, key int64, max int, tweets ...*models.Tweet) {
+	set, ok := sets[key]
+	if !ok {
+		set = make(sortedSet)
+		sets[key] = set
+	}
+	for _, t := range tweets {
+		set[t.ID] = t.CreatedAt.UnixNano()
+	}
+	set.trim(max)
+	if len(set) == 0 {
+		delete(sets, key)
+	}
+}
+
+// remove deletes tweet IDs from the set under key, dropping it once empty
+// the way Redis drops empty sorted sets. Callers must hold tc.mu.
+func remove(sets map[int64]sortedSet, key int64, tweetIDs ...int64) {
+	set, ok := sets[key]
+	if !ok {
+		return
+	}
+	for _, id := range tweetIDs {
+		delete(set, id)
+	}
+	if len(set) == 0 {
+		delete(sets, key)
+	}
+}
+
+// rangeIDs returns up to limit IDs from the set, newest first, skipping offset
+func rangeIDs(set sortedSet, limit, offset int) []int64 {
+	ids := []int64{}
+	entries := set.entries()
+	for i := offset; i >= 0 && i < len(entries) && len(ids) < limit; i++ {
+		ids = append(ids, entries[i].id)
+	}
+	return ids
+}
+
+// AddToTimeline adds a tweet to a user's timeline cache
+func (tc *TimelineCache) AddToTimeline(ctx context.Context, userID int64, tweet *models.Tweet) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	add(tc.timelines, userID, tc.maxTimelineSize, tweet)
+	return nil
+}
+
+// AddToTimelineBatch adds a tweet to multiple users' timelines (fan-out)
+func (tc *TimelineCache) AddToTimelineBatch(ctx context.Context, userIDs []int64, tweet *models.Tweet) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	for _, userID := range userIDs {
+		add(tc.timelines, userID, tc.maxTimelineSize, tweet)
+	}
+	return nil
+}
+
+// AddTweetsToTimeline merges several tweets into a single user's timeline
+func (tc *TimelineCache) AddTweetsToTimeline(ctx context.Context, userID int64, tweets []*models.Tweet) error {
+	if len(tweets) == 0 {
+		return nil
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	add(tc.timelines, userID, tc.maxTimelineSize, tweets...)
+	return nil
+}
+
+// RemoveTweetsFromTimeline removes several tweets from a single user's timeline
+func (tc *TimelineCache) RemoveTweetsFromTimeline(ctx context.Context, userID int64, tweetIDs []int64) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	remove(tc.timelines, userID, tweetIDs...)
+	return nil
+}
+
+// MaxTimelineSize returns the maximum number of tweets kept per timeline
+func (tc *TimelineCache) MaxTimelineSize() int {
+	return tc.maxTimelineSize
+}
+
+// GetTimeline retrieves tweet IDs from a user's timeline cache
+func (tc *TimelineCache) GetTimeline(ctx context.Context, userID int64, limit, offset int) ([]int64, error) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return rangeIDs(tc.timelines[userID], limit, offset), nil
+}
+
+// GetTimelinePage retrieves a keyset page of tweet IDs from a user's timeline cache
+func (tc *TimelineCache) GetTimelinePage(ctx context.Context, userID int64, page models.Page) ([]int64, error) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return getPage([]sortedSet{tc.timelines[userID]}, page), nil
+}
+
+// getPage returns the newest page.Limit tweet IDs across the given sets that
+// fall inside the page's keyset bounds. Callers must hold tc.mu.
+func getPage(sets []sortedSet, page models.Page) []int64 {
+	if page.Limit <= 0 {
+		return []int64{}
+	}
+
+	var max, min *entry
+	if page.MaxID != nil {
+		max = &entry{score: page.MaxID.CreatedAt.UnixNano(), id: page.MaxID.ID}
+	}
+	if page.SinceID != nil {
+		min = &entry{score: page.SinceID.CreatedAt.UnixNano(), id: page.SinceID.ID}
+	}
+
+	merged := make(sortedSet)
+	for _, set := range sets {
+		for id, score := range set {
+			e := entry{score: score, id: id}
+			if (max != nil && !max.newer(e)) || (min != nil && !e.newer(*min)) {
+				continue
+			}
+			merged[id] = score
+		}
+	}
+	return rangeIDs(merged, page.Limit, 0)
+}
+
+// GetTimelineSize returns the number of tweets in a user's timeline cache
+func (tc *TimelineCache) GetTimelineSize(ctx context.Context, userID int64) (int64, error) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return int64(len(tc.timelines[userID])), nil
+}
+
+// RemoveFromTimeline removes a tweet from a user's timeline
+func (tc *TimelineCache) RemoveFromTimeline(ctx context.Context, userID int64, tweetID int64) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	remove(tc.timelines, userID, tweetID)
+	return nil
+}
+
+// RemoveFromTimelineBatch removes a tweet from multiple users' timelines
+func (tc *TimelineCache) RemoveFromTimelineBatch(ctx context.Context, userIDs []int64, tweetID int64) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	for _, userID := range userIDs {
+		remove(tc.timelines, userID, tweetID)
+	}
+	return nil
+}
+
+// ClearTimeline clears a user's timeline cache
+func (tc *TimelineCache) ClearTimeline(ctx context.Context, userID int64) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	delete(tc.timelines, userID)
+	return nil
+}
+
+// AddToListTimelineBatch adds a tweet to multiple lists' timelines (fan-out)
+func (tc *TimelineCache) AddToListTimelineBatch(ctx context.Context, listIDs []int64, tweet *models.Tweet) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	for _, listID := range listIDs {
+		add(tc.lists, listID, tc.maxTimelineSize, tweet)
+	}
+	return nil
+}
+
+// AddTweetsToListTimeline merges several tweets into a list's timeline
+func (tc *TimelineCache) AddTweetsToListTimeline(ctx context.Context, listID int64, tweets []*models.Tweet) error {
+	if len(tweets) == 0 {
+		return nil
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	add(tc.lists, listID, tc.maxTimelineSize, tweets...)
+	return nil
+}
+
+// GetListTimelinePage retrieves a keyset page of tweet IDs from a list's timeline cache
+func (tc *TimelineCache) GetListTimelinePage(ctx context.Context, listID int64, page models.Page) ([]int64, error) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return getPage([]sortedSet{tc.lists[listID]}, page), nil
+}
+
+// RemoveFromListTimelineBatch removes a tweet from multiple lists' timelines
+func (tc *TimelineCache) RemoveFromListTimelineBatch(ctx context.Context, listIDs []int64, tweetID int64) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	for _, listID := range listIDs {
+		remove(tc.lists, listID, tweetID)
+	}
+	return nil
+}
+
+// RemoveTweetsFromListTimeline removes several tweets from a list's timeline
+func (tc *TimelineCache) RemoveTweetsFromListTimeline(ctx context.Context, listID int64, tweetIDs []int64) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	remove(tc.lists, listID, tweetIDs...)
+	return nil
+}
+
+// ClearListTimeline clears a list's timeline cache
+func (tc *TimelineCache) ClearListTimeline(ctx context.Context, listID int64) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	delete(tc.lists, listID)
+	return nil
+}
+
+// TimelineExists checks if a user has a cached timeline
+func (tc *TimelineCache) TimelineExists(ctx context.Context, userID int64) (bool, error) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	_, ok := tc.timelines[userID]
+	return ok, nil
+}
+
+// CacheTweet caches a tweet's data
+func (tc *TimelineCache) CacheTweet(ctx context.Context, tweet *models.Tweet) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.tweets[tweet.ID] = *tweet
+	return nil
+}
+
+// CacheTweetsBatch caches multiple tweets
+func (tc *TimelineCache) CacheTweetsBatch(ctx context.Context, tweets []*models.Tweet) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	for _, tweet := range tweets {
+		tc.tweets[tweet.ID] = *tweet
+	}
+	return nil
+}
+
+// GetCachedTweet retrieves a cached tweet, returning nil on a cache miss
+func (tc *TimelineCache) GetCachedTweet(ctx context.Context, tweetID int64) (*models.Tweet, error) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	tweet, ok := tc.tweets[tweetID]
+	if !ok {
+		return nil, nil
+	}
+	return &tweet, nil
+}
+
+// GetCachedTweets retrieves multiple cached tweets along with the IDs that missed
+func (tc *TimelineCache) GetCachedTweets(ctx context.Context, tweetIDs []int64) ([]*models.Tweet, []int64, error) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	tweets := make([]*models.Tweet, 0, len(tweetIDs))
+	missingIDs := make([]int64, 0)
+	for _, id := range tweetIDs {
+		tweet, ok := tc.tweets[id]
+		if !ok {
+			missingIDs = append(missingIDs, id)
+			continue
+		}
+		tweets = append(tweets, &tweet)
+	}
+	return tweets, missingIDs, nil
+}
+
+// InvalidateTweet removes a tweet's cached data
+func (tc *TimelineCache) InvalidateTweet(ctx context.Context, tweetID int64) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	delete(tc.tweets, tweetID)
+	return nil
+}
+
+// AddAuthorTweet adds a tweet, retweet or reply to its author's tweet set
+func (tc *TimelineCache) AddAuthorTweet(ctx context.Context, userID int64, tweet *models.Tweet) error {
+	return tc.AddAuthorTweets(ctx, userID, []*models.Tweet{tweet}, false)
+}
+
+// AddAuthorTweets merges several of an author's tweets into their tweet set.
+// As in Redis the complete marker takes a slot, so a full set loses it.
+func (tc *TimelineCache) AddAuthorTweets(ctx context.Context, userID int64, tweets []*models.Tweet, complete bool) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	add(tc.authors, userID, authorTweetsLimit, tweets...)
+	if complete {
+		tc.complete[userID] = true
+	}
+	if len(tc.authors[userID]) >= authorTweetsLimit {
+		delete(tc.complete, userID)
+	}
+	return nil
+}
+
+// RemoveAuthorTweet removes a tweet from its author's tweet set
+func (tc *TimelineCache) RemoveAuthorTweet(ctx context.Context, userID int64, tweetID int64) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	remove(tc.authors, userID, tweetID)
+	return nil
+}
+
+// GetAuthorTweets retrieves an author's recent tweet IDs
+func (tc *TimelineCache) GetAuthorTweets(ctx context.Context, userID int64, limit int) ([]int64, error) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return rangeIDs(tc.authors[userID], limit, 0), nil
+}
+
+// GetAuthorTweetsBatch retrieves recent tweets from multiple authors
+func (tc *TimelineCache) GetAuthorTweetsBatch(ctx context.Context, userIDs []int64, limitPerUser int) ([]int64, error) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	allTweetIDs := make([]int64, 0)
+	for _, userID := range userIDs {
+		allTweetIDs = append(allTweetIDs, rangeIDs(tc.authors[userID], limitPerUser, 0)...)
+	}
+	return allTweetIDs, nil
+}
+
+// GetAuthorTweetsPage retrieves a keyset page of tweet IDs merged across
+// several authors, along with the authors whose sets can't vouch for it
+func (tc *TimelineCache) GetAuthorTweetsPage(ctx context.Context, userIDs []int64, page models.Page) ([]int64, []int64, error) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	sets := make([]sortedSet, len(userIDs))
+	for i, userID := range userIDs {
+		sets[i] = tc.authors[userID]
+	}
+	ids := getPage(sets, page)
+
+	var bound *entry
+	if page.Limit > 0 && len(ids) == page.Limit {
+		last := ids[len(ids)-1]
+		for _, set := range sets {
+			if score, ok := set[last]; ok {
+				bound = &entry{score: score, id: last}
+				break
+			}
+		}
+	} else if page.SinceID != nil {
+		bound = &entry{score: page.SinceID.CreatedAt.UnixNano(), id: page.SinceID.ID}
+	}
+
+	uncovered := []int64{}
+	for i, userID := range userIDs {
+		if tc.complete[userID] {
+			continue
+		}
+		if oldest, ok := sets[i].oldest(); ok && bound != nil && !oldest.newer(*bound) {
+			continue
+		}
+		uncovered = append(uncovered, userID)
+	}
+	return ids, uncovered, nil
+}
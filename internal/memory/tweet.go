@@ -0,0 +1,364 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// TweetRepository is an in-memory repository.TweetStore
+type TweetRepository struct {
+	db *DB
+}
+
+var _ repository.TweetStore = (*TweetRepository)(nil)
+
+// NewTweetRepository creates a new TweetRepository
+func NewTweetRepository(db *DB) *TweetRepository {
+	return &TweetRepository{db: db}
+}
+
+// insert adds a tweet row. Callers must hold db.mu.
+func (r *TweetRepository) insert(userID int64, content string, createdAt time.Time) *models.Tweet {
+	r.db.nextTweetID++
+	tweet := &models.Tweet{
+		ID:        r.db.nextTweetID,
+		UserID:    userID,
+		Content:   content,
+		CreatedAt: createdAt,
+		Kind:      models.TweetKindTweet,
+	}
+	r.db.tweets[tweet.ID] = tweet
+	if r.db.userTweets[userID] == nil {
+		r.db.userTweets[userID] = make(map[int64]bool)
+	}
+	r.db.userTweets[userID][tweet.ID] = true
+	return tweet
+}
+
+// Create creates a new tweet
+func (r *TweetRepository) Create(ctx context.Context, userID int64, content string) (*models.Tweet, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[userID]; !ok {
+		return nil, fmt.Errorf("failed to create tweet: user %d does not exist", userID)
+	}
+	tweet := *r.insert(userID, content, r.db.now())
+	return &tweet, nil
+}
+
+// GetByID retrieves a tweet by ID
+func (r *TweetRepository) GetByID(ctx context.Context, id int64) (*models.Tweet, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	tweet := r.db.tweet(id)
+	if tweet == nil {
+		return nil, fmt.Errorf("failed to get tweet: %w", sql.ErrNoRows)
+	}
+	return tweet, nil
+}
+
+// GetByIDs retrieves multiple tweets by IDs
+func (r *TweetRepository) GetByIDs(ctx context.Context, ids []int64) ([]*models.Tweet, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	tweets := []*models.Tweet{}
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if t := r.db.tweet(id); t != nil {
+			tweets = append(tweets, t)
+		}
+	}
+	sortNewestFirst(tweets)
+	return tweets, nil
+}
+
+// GetByUserID retrieves tweets by user ID
+func (r *TweetRepository) GetByUserID(ctx context.Context, userID int64, limit int) ([]*models.Tweet, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	return limitTweets(r.byUser(userID, models.Page{}), limit), nil
+}
+
+// byUser returns a user's tweets inside the page bounds, newest first.
+// Callers must hold db.mu.
+func (r *TweetRepository) byUser(userID int64, page models.Page) []*models.Tweet {
+	tweets := make([]*models.Tweet, 0, len(r.db.userTweets[userID]))
+	for id := range r.db.userTweets[userID] {
+		if t := r.db.tweet(id); page.Contains(t) {
+			tweets = append(tweets, t)
+		}
+	}
+	sortNewestFirst(tweets)
+	return tweets
+}
+
+// GetByUserIDs retrieves a keyset page of tweets from multiple users (for fan-out-read)
+func (r *TweetRepository) GetByUserIDs(ctx context.Context, userIDs []int64, page models.Page) ([]*models.Tweet, error) {
+	return r.GetRecentByUserIDs(ctx, userIDs, page.Limit, page)
+}
+
+// GetRecentByUserIDs retrieves a keyset page of tweets from multiple users with per-user limit
+func (r *TweetRepository) GetRecentByUserIDs(ctx context.Context, userIDs []int64, perUserLimit int, page models.Page) ([]*models.Tweet, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	tweets := []*models.Tweet{}
+	seen := make(map[int64]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		tweets = append(tweets, limitTweets(r.byUser(userID, page), perUserLimit)...)
+	}
+	sortNewestFirst(tweets)
+	return limitTweets(tweets, page.Limit), nil
+}
+
+// CreateReply creates userID's reply to inReplyToID. Replying to a retweet
+// replies to its original.
+func (r *TweetRepository) CreateReply(ctx context.Context, userID, inReplyToID int64, content string) (*models.Tweet, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[userID]; !ok {
+		return nil, fmt.Errorf("failed to create reply: user %d does not exist", userID)
+	}
+	parent, ok := r.db.tweets[inReplyToID]
+	if !ok {
+		return nil, fmt.Errorf("failed to create reply: %w", sql.ErrNoRows)
+	}
+	if parent.OriginalID != 0 {
+		parent = r.db.tweets[parent.OriginalID]
+	}
+
+	reply := r.insert(userID, content, r.db.now())
+	reply.Kind = models.TweetKindReply
+	reply.InReplyToID = parent.ID
+	reply.InReplyToUserID = parent.UserID
+	if r.db.replies[parent.ID] == nil {
+		r.db.replies[parent.ID] = make(map[int64]bool)
+	}
+	r.db.replies[parent.ID][reply.ID] = true
+	return r.db.tweet(reply.ID), nil
+}
+
+// GetAncestors retrieves the chain of tweets a reply answers, starting from
+// the conversation's first tweet. It stops early where a parent was deleted.
+func (r *TweetRepository) GetAncestors(ctx context.Context, tweetID int64) ([]*models.Tweet, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	tweets := []*models.Tweet{}
+	t, ok := r.db.tweets[tweetID]
+	for ok && t.InReplyToID != 0 {
+		t, ok = r.db.tweets[t.InReplyToID]
+		if ok {
+			tweets = append(tweets, r.db.tweet(t.ID))
+		}
+	}
+	for i, j := 0, len(tweets)-1; i < j; i, j = i+1, j-1 {
+		tweets[i], tweets[j] = tweets[j], tweets[i]
+	}
+	return tweets, nil
+}
+
+// GetDescendants retrieves a keyset page of the replies below a tweet, at
+// any depth, oldest first. The page's SinceID is the cursor.
+func (r *TweetRepository) GetDescendants(ctx context.Context, tweetID int64, page models.Page) ([]*models.Tweet, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	tweets := []*models.Tweet{}
+	queue := []int64{tweetID}
+	for len(queue) > 0 {
+		parentID := queue[0]
+		queue = queue[1:]
+		for replyID := range r.db.replies[parentID] {
+			queue = append(queue, replyID)
+			if t := r.db.tweet(replyID); page.Contains(t) {
+				tweets = append(tweets, t)
+			}
+		}
+	}
+	sortOldestFirst(tweets)
+	return limitTweets(tweets, page.Limit), nil
+}
+
+// Retweet creates userID's retweet of tweetID. Retweeting a retweet
+// retweets its original. Only the author may retweet a protected user's
+// tweet.
+func (r *TweetRepository) Retweet(ctx context.Context, userID, tweetID int64) (*models.Tweet, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[userID]; !ok {
+		return nil, fmt.Errorf("failed to create retweet: user %d does not exist", userID)
+	}
+	original, ok := r.db.tweets[tweetID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get tweet: %w", sql.ErrNoRows)
+	}
+	if original.OriginalID != 0 {
+		original = r.db.tweets[original.OriginalID]
+	}
+	if author, ok := r.db.users[original.UserID]; ok && author.Protected && original.UserID != userID {
+		return nil, repository.ErrProtectedTweet
+	}
+	if _, ok := r.db.retweets[original.ID][userID]; ok {
+		return nil, repository.ErrAlreadyRetweeted
+	}
+
+	retweet := r.insert(userID, original.Content, r.db.now())
+	retweet.Kind = models.TweetKindRetweet
+	retweet.OriginalID = original.ID
+	if r.db.retweets[original.ID] == nil {
+		r.db.retweets[original.ID] = make(map[int64]int64)
+	}
+	r.db.retweets[original.ID][userID] = retweet.ID
+	return r.db.tweet(retweet.ID), nil
+}
+
+// GetRetweets retrieves every retweet of a tweet, newest first
+func (r *TweetRepository) GetRetweets(ctx context.Context, tweetID int64) ([]*models.Tweet, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	tweets := make([]*models.Tweet, 0, len(r.db.retweets[tweetID]))
+	for _, id := range r.db.retweets[tweetID] {
+		tweets = append(tweets, r.db.tweet(id))
+	}
+	sortNewestFirst(tweets)
+	return tweets, nil
+}
+
+// Like records userID's like of tweetID and returns the ID of the tweet
+// liked. Liking a retweet likes its original.
+func (r *TweetRepository) Like(ctx context.Context, userID, tweetID int64) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[userID]; !ok {
+		return 0, fmt.Errorf("failed to like tweet: user %d does not exist", userID)
+	}
+	t, ok := r.db.tweets[tweetID]
+	if !ok {
+		return 0, fmt.Errorf("failed to like tweet: %w", sql.ErrNoRows)
+	}
+	likedID := t.RootID()
+	if r.db.likes[likedID][userID] {
+		return 0, repository.ErrAlreadyLiked
+	}
+
+	if r.db.likes[likedID] == nil {
+		r.db.likes[likedID] = make(map[int64]bool)
+	}
+	r.db.likes[likedID][userID] = true
+	return likedID, nil
+}
+
+// Unlike removes userID's like of tweetID and returns the ID of the tweet
+// that was liked
+func (r *TweetRepository) Unlike(ctx context.Context, userID, tweetID int64) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	t, ok := r.db.tweets[tweetID]
+	if !ok || !r.db.likes[t.RootID()][userID] {
+		return 0, repository.ErrNotLiked
+	}
+	likedID := t.RootID()
+	delete(r.db.likes[likedID], userID)
+	return likedID, nil
+}
+
+// GetCounts retrieves the like and retweet counts last written back for
+// tweets. Tweets that don't exist are left out.
+func (r *TweetRepository) GetCounts(ctx context.Context, tweetIDs []int64) (map[int64]models.TweetCounts, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	counts := make(map[int64]models.TweetCounts, len(tweetIDs))
+	for _, id := range tweetIDs {
+		if t, ok := r.db.tweets[id]; ok {
+			counts[id] = models.TweetCounts{Likes: t.LikeCount, Retweets: t.RetweetCount}
+		}
+	}
+	return counts, nil
+}
+
+// SetCounts writes back a batch of tweets' like and retweet counts
+func (r *TweetRepository) SetCounts(ctx context.Context, counts map[int64]models.TweetCounts) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for id, c := range counts {
+		if t, ok := r.db.tweets[id]; ok {
+			t.LikeCount, t.RetweetCount = c.Likes, c.Retweets
+		}
+	}
+	return nil
+}
+
+// Count returns the total number of tweets
+func (r *TweetRepository) Count(ctx context.Context) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	return len(r.db.tweets), nil
+}
+
+// BulkCreate creates multiple tweets. As with the SQL insert, the whole
+// batch shares one created_at and fails if any author is missing.
+func (r *TweetRepository) BulkCreate(ctx context.Context, tweets []struct {
+	UserID  int64
+	Content string
+}) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, t := range tweets {
+		if _, ok := r.db.users[t.UserID]; !ok {
+			return fmt.Errorf("failed to bulk create tweets: user %d does not exist", t.UserID)
+		}
+	}
+
+	createdAt := r.db.now()
+	for _, t := range tweets {
+		r.insert(t.UserID, t.Content, createdAt)
+	}
+	return nil
+}
+
+// Delete deletes a tweet
+func (r *TweetRepository) Delete(ctx context.Context, id int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.deleteTweet(id)
+	return nil
+}
+
+// Truncate removes all tweets
+func (r *TweetRepository) Truncate(ctx context.Context) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.tweets = make(map[int64]*models.Tweet)
+	r.db.userTweets = make(map[int64]map[int64]bool)
+	r.db.retweets = make(map[int64]map[int64]int64)
+	r.db.replies = make(map[int64]map[int64]bool)
+	r.db.likes = make(map[int64]map[int64]bool)
+	return nil
+}
@@ -0,0 +1,256 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// UserRepository is an in-memory repository.UserStore
+type UserRepository struct {
+	db *DB
+}
+
+var _ repository.UserStore = (*UserRepository)(nil)
+
+// NewUserRepository creates a new UserRepository
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Create creates a new user
+func (r *UserRepository) Create(ctx context.Context, username string) (*models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, exists := r.db.usernames[username]; exists {
+		return nil, fmt.Errorf("failed to create user: username %q already exists", username)
+	}
+	return r.insert(username), nil
+}
+
+// insert adds a user row. Callers must hold db.mu.
+func (r *UserRepository) insert(username string) *models.User {
+	r.db.nextUserID++
+	user := &models.User{
+		ID:        r.db.nextUserID,
+		Username:  username,
+		CreatedAt: r.db.now(),
+	}
+	r.db.users[user.ID] = user
+	r.db.usernames[username] = user.ID
+	return r.db.user(user.ID)
+}
+
+// GetByID retrieves a user by ID
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	user := r.db.user(id)
+	if user == nil {
+		return nil, fmt.Errorf("failed to get user: %w", sql.ErrNoRows)
+	}
+	return user, nil
+}
+
+// GetByUsername retrieves a user by username
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	id, ok := r.db.usernames[username]
+	if !ok {
+		return nil, fmt.Errorf("failed to get user: %w", sql.ErrNoRows)
+	}
+	return r.db.user(id), nil
+}
+
+// GetByUsernames retrieves the users with the given usernames, skipping any that don't exist
+func (r *UserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]*models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	users := []*models.User{}
+	for _, username := range usernames {
+		if id, ok := r.db.usernames[username]; ok {
+			users = append(users, r.db.user(id))
+		}
+	}
+	return users, nil
+}
+
+// GetAll retrieves all users ordered by ID with pagination
+func (r *UserRepository) GetAll(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	ids := make([]int64, 0, len(r.db.users))
+	for id := range r.db.users {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	users := []*models.User{}
+	for i := offset; i < len(ids) && len(users) < limit; i++ {
+		users = append(users, r.db.user(ids[i]))
+	}
+	return users, nil
+}
+
+// GetCelebrities retrieves users with follower count above threshold
+func (r *UserRepository) GetCelebrities(ctx context.Context, threshold int) ([]*models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	users := []*models.User{}
+	for id, u := range r.db.users {
+		if u.FollowerCount >= threshold {
+			users = append(users, r.db.user(id))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].FollowerCount == users[j].FollowerCount {
+			return users[i].ID < users[j].ID
+		}
+		return users[i].FollowerCount > users[j].FollowerCount
+	})
+	return users, nil
+}
+
+// GetRandomUsers retrieves random users for benchmarking
+func (r *UserRepository) GetRandomUsers(ctx context.Context, count int) ([]*models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	ids := make([]int64, 0, len(r.db.users))
+	for id := range r.db.users {
+		ids = append(ids, id)
+	}
+	rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+
+	users := []*models.User{}
+	for i := 0; i < len(ids) && i < count; i++ {
+		users = append(users, r.db.user(ids[i]))
+	}
+	return users, nil
+}
+
+// Count returns the total number of users
+func (r *UserRepository) Count(ctx context.Context) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	return len(r.db.users), nil
+}
+
+// CountCelebrities returns the number of celebrities
+func (r *UserRepository) CountCelebrities(ctx context.Context, threshold int) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	count := 0
+	for _, u := range r.db.users {
+		if u.FollowerCount >= threshold {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// SetProtected sets whether only approved followers see a user's tweets
+func (r *UserRepository) SetProtected(ctx context.Context, id int64, protected bool) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[id]
+	if !ok {
+		return fmt.Errorf("failed to update user: %w", sql.ErrNoRows)
+	}
+	user.Protected = protected
+	return nil
+}
+
+// BulkCreate creates multiple users, skipping usernames that already exist
+func (r *UserRepository) BulkCreate(ctx context.Context, usernames []string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, username := range usernames {
+		if _, exists := r.db.usernames[username]; exists {
+			continue
+		}
+		r.insert(username)
+	}
+	return nil
+}
+
+// Delete deletes a user along with their tweets and follows
+func (r *UserRepository) Delete(ctx context.Context, id int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[id]
+	if !ok {
+		return nil
+	}
+
+	for tweetID := range r.db.userTweets[id] {
+		r.db.deleteTweet(tweetID)
+	}
+	delete(r.db.userTweets, id)
+	for _, t := range r.db.tweets {
+		if t.InReplyToUserID == id {
+			t.InReplyToUserID = 0
+			t.Kind = models.TweetKindTweet
+		}
+	}
+
+	for _, likers := range r.db.likes {
+		delete(likers, id)
+	}
+
+	delete(r.db.followRequests, id)
+	for _, requests := range r.db.followRequests {
+		delete(requests, id)
+	}
+
+	for _, edges := range []map[int64]map[int64]bool{r.db.blocks, r.db.blockedBy, r.db.mutes, r.db.mutedBy} {
+		delete(edges, id)
+		for _, set := range edges {
+			delete(set, id)
+		}
+	}
+
+	for listID, list := range r.db.lists {
+		if list.OwnerID == id {
+			r.db.deleteList(listID)
+		}
+	}
+	for listID := range r.db.memberLists[id] {
+		removeEdge(r.db.listMembers, r.db.memberLists, listID, id)
+	}
+
+	for followerID := range r.db.followers[id] {
+		r.db.unfollow(followerID, id)
+	}
+	for followeeID := range r.db.following[id] {
+		r.db.unfollow(id, followeeID)
+	}
+
+	delete(r.db.usernames, user.Username)
+	delete(r.db.users, id)
+	return nil
+}
+
+// Truncate removes all users, tweets and follows
+func (r *UserRepository) Truncate(ctx context.Context) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	r.db.reset()
+	return nil
+}
@@ -1,6 +1,10 @@
 package models
 
 import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -10,6 +14,7 @@ type User struct {
 	Username      string    `json:"username" db:"username"`
 	FollowerCount int       `json:"follower_count" db:"follower_count"`
 	FollowingCount int      `json:"following_count" db:"following_count"`
+	Protected     bool      `json:"protected" db:"protected"` // Only approved followers see their tweets
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 }
 
@@ -25,8 +30,67 @@ type Tweet struct {
 	Content   string    `json:"content" db:"content"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	
+	Kind       string    `json:"kind" db:"kind"`                             // TweetKindTweet, TweetKindRetweet or TweetKindReply
+	OriginalID int64     `json:"original_id,omitempty" db:"original_id"` // Retweets: the retweeted tweet
+
+	// Replies only: the tweet being answered and its author. The author is
+	// kept when the parent is deleted.
+	InReplyToID     int64 `json:"in_reply_to_id,omitempty" db:"in_reply_to_id"`
+	InReplyToUserID int64 `json:"in_reply_to_user_id,omitempty" db:"in_reply_to_user_id"`
+
 	// Joined fields (not stored in DB)
 	Username  string    `json:"username,omitempty" db:"username"`
+
+	// Retweets only: the retweeted tweet's author and time
+	OriginalUserID    int64      `json:"original_user_id,omitempty" db:"original_user_id"`
+	OriginalUsername  string     `json:"original_username,omitempty" db:"original_username"`
+	OriginalCreatedAt *time.Time `json:"original_created_at,omitempty" db:"original_created_at"`
+
+	// Usernames of followees whose retweets of the same tweet were collapsed
+	// into this timeline entry, newest first
+	RetweetedBy []string `json:"retweeted_by,omitempty" db:"-"`
+
+	// Engagement counts; for a retweet, the original's. The database copy
+	// lags behind the counters, which timelines embed when they're read.
+	LikeCount    int64 `json:"like_count" db:"like_count"`
+	RetweetCount int64 `json:"retweet_count" db:"retweet_count"`
+}
+
+// TweetCounts holds a tweet's engagement counts, or a change to them
+type TweetCounts struct {
+	Likes    int64 `json:"likes"`
+	Retweets int64 `json:"retweets"`
+}
+
+// Add returns the sum of two sets of counts
+func (c TweetCounts) Add(other TweetCounts) TweetCounts {
+	return TweetCounts{Likes: c.Likes + other.Likes, Retweets: c.Retweets + other.Retweets}
+}
+
+// Tweet kinds
+const (
+	TweetKindTweet   = "tweet"
+	TweetKindRetweet = "retweet"
+	TweetKindReply   = "reply"
+)
+
+// IsRetweet reports whether the tweet is a retweet of another
+func (t *Tweet) IsRetweet() bool {
+	return t.OriginalID != 0
+}
+
+// IsReply reports whether the tweet answers another
+func (t *Tweet) IsReply() bool {
+	return t.InReplyToUserID != 0
+}
+
+// RootID returns the ID of the tweet being shown: the original for a
+// retweet, the tweet itself otherwise
+func (t *Tweet) RootID() int64 {
+	if t.OriginalID != 0 {
+		return t.OriginalID
+	}
+	return t.ID
 }
 
 // TweetWithAuthor includes author information
@@ -42,6 +106,36 @@ type Follow struct {
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
+// FollowRequest is a pending request to follow a protected user
+type FollowRequest struct {
+	RequesterID int64     `json:"requester_id" db:"requester_id"`
+	TargetID    int64     `json:"target_id" db:"target_id"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// List is a user-curated set of accounts with a timeline of their tweets
+type List struct {
+	ID          int64     `json:"id" db:"id"`
+	OwnerID     int64     `json:"owner_id" db:"owner_id"`
+	Name        string    `json:"name" db:"name"`
+	MemberCount int       `json:"member_count" db:"member_count"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// FanOutJob is a queued request to push a tweet into its author's followers' timelines
+type FanOutJob struct {
+	TweetID         int64     `json:"tweet_id"`
+	AuthorID        int64     `json:"author_id"`
+	InReplyToUserID int64     `json:"in_reply_to_user_id,omitempty"` // Replies: the user being answered
+	ReplyAudience   bool      `json:"reply_audience,omitempty"`      // Only deliver to followers who also follow InReplyToUserID
+	OriginalUserID  int64     `json:"original_user_id,omitempty"`    // Retweets: the original's author, for block filtering
+	Strategy        string    `json:"strategy"`
+	CreatedAt       time.Time `json:"created_at"`  // Tweet creation time, used as the timeline score
+	EnqueuedAt      time.Time `json:"enqueued_at"` // When the job entered the queue, used for lag
+	Attempt         int       `json:"attempt"`
+	RetryAt         time.Time `json:"retry_at"` // Retries: when the job is due back on the queue
+}
+
 // Timeline represents a user's timeline
 type Timeline struct {
 	UserID int64    `json:"user_id"`
@@ -53,14 +147,102 @@ type TimelineRequest struct {
 	UserID   int64  `json:"user_id"`
 	Strategy string `json:"strategy"` // "fanout_write", "fanout_read", "hybrid"
 	Limit    int    `json:"limit"`
-	Offset   int    `json:"offset"`
+	MaxID    string `json:"max_id,omitempty"`   // Cursor: only return tweets older than this
+	SinceID  string `json:"since_id,omitempty"` // Cursor: only return tweets newer than this
+}
+
+// Cursor is a keyset position in a reverse-chronological timeline.
+// Tweets are ordered by created_at, then ID, both descending.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+	// Offset is only set by ranked timelines: how many of the candidates
+	// ranked below this position have already been shown
+	Offset int
+}
+
+// CursorFor returns the cursor positioned at a tweet
+func CursorFor(t *Tweet) *Cursor {
+	return &Cursor{CreatedAt: t.CreatedAt, ID: t.ID}
+}
+
+// Encode returns the opaque string form of the cursor
+func (c *Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)
+	if c.Offset > 0 {
+		raw += fmt.Sprintf(":%d", c.Offset)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a cursor produced by Encode
+func ParseCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	fields := strings.Split(string(raw), ":")
+	if len(fields) != 2 && len(fields) != 3 {
+		return nil, fmt.Errorf("invalid cursor: %q", raw)
+	}
+	nanos, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	id, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	c := &Cursor{CreatedAt: time.Unix(0, nanos), ID: id}
+	if len(fields) == 3 {
+		if c.Offset, err = strconv.Atoi(fields[2]); err != nil || c.Offset < 0 {
+			return nil, fmt.Errorf("invalid cursor offset: %q", fields[2])
+		}
+	}
+	return c, nil
+}
+
+// Newer reports whether t sorts before (is more recent than) the cursor position
+func (c *Cursor) Newer(t *Tweet) bool {
+	if t.CreatedAt.Equal(c.CreatedAt) {
+		return t.ID > c.ID
+	}
+	return t.CreatedAt.After(c.CreatedAt)
+}
+
+// Older reports whether t sorts after (is older than) the cursor position
+func (c *Cursor) Older(t *Tweet) bool {
+	if t.CreatedAt.Equal(c.CreatedAt) {
+		return t.ID < c.ID
+	}
+	return t.CreatedAt.Before(c.CreatedAt)
+}
+
+// Page selects a window of a timeline by keyset instead of offset, so tweets
+// arriving between requests don't cause duplicates or skipped items
+type Page struct {
+	Limit   int
+	MaxID   *Cursor // Exclusive upper bound: only tweets older than this
+	SinceID *Cursor // Exclusive lower bound: only tweets newer than this
+}
+
+// Contains reports whether t falls inside the page bounds (ignoring Limit)
+func (p Page) Contains(t *Tweet) bool {
+	if p.MaxID != nil && !p.MaxID.Older(t) {
+		return false
+	}
+	if p.SinceID != nil && !p.SinceID.Newer(t) {
+		return false
+	}
+	return true
 }
 
 // PostTweetRequest represents a request to post a tweet
 type PostTweetRequest struct {
-	UserID   int64  `json:"user_id"`
-	Content  string `json:"content"`
-	Strategy string `json:"strategy"` // "fanout_write", "fanout_read", "hybrid"
+	UserID      int64  `json:"user_id"`
+	Content     string `json:"content"`
+	Strategy    string `json:"strategy"`                 // "fanout_write", "fanout_read", "hybrid"
+	InReplyToID int64  `json:"in_reply_to_id,omitempty"` // Post as a reply to this tweet
 }
 
 // BenchmarkResult holds the results of a benchmark run
@@ -81,6 +263,70 @@ type BenchmarkResult struct {
 	CacheHitRate    float64       `json:"cache_hit_rate"`
 	Duration        time.Duration `json:"duration"`
 	Timestamp       time.Time     `json:"timestamp"`
+
+	// Async fan-out only: time from the first write until the queue drained
+	FanOutCompletion time.Duration `json:"fan_out_completion,omitempty"`
+
+	// Where replies were filtered: "read" or "fanout"
+	ReplyFilter string `json:"reply_filter,omitempty"`
+
+	// How like and retweet counts were kept: "sharded" or "write_behind"
+	CounterStrategy string `json:"counter_strategy,omitempty"`
+
+	// How blocked and muted authors were hidden: "read" or "purge"
+	BlockFilter string `json:"block_filter,omitempty"`
+
+	// Share of the benchmark users made protected before the run, and the
+	// average time each read spent checking retweets of protected tweets
+	Protected       float64       `json:"protected,omitempty"`
+	PrivacyCheckAvg time.Duration `json:"privacy_check_avg,omitempty"`
+
+	// Ranked strategies only: the scorer, and the average time each read
+	// spent gathering, scoring and truncating candidates
+	RankingScorer          string        `json:"ranking_scorer,omitempty"`
+	CandidateGenerationAvg time.Duration `json:"candidate_generation_avg,omitempty"`
+	ScoringAvg             time.Duration `json:"scoring_avg,omitempty"`
+	TruncationAvg          time.Duration `json:"truncation_avg,omitempty"`
+
+	// Write amplification: timelines each post (tweet, retweet or reply) was
+	// fanned out to on average, and how many of those were list timelines.
+	// Lists and ListSize describe the lists created before the run.
+	WriteAmplification     float64 `json:"write_amplification,omitempty"`
+	ListWriteAmplification float64 `json:"list_write_amplification,omitempty"`
+	Lists                  int     `json:"lists,omitempty"`
+	ListSize               int     `json:"list_size,omitempty"`
+
+	// Mixed workload only: operations arrive at TargetRate regardless of how
+	// fast earlier ones complete, and latencies are measured from each
+	// operation's intended send time
+	Mix          string            `json:"mix,omitempty"`
+	TargetRate   float64           `json:"target_rate,omitempty"`   // ops/sec
+	AchievedRate float64           `json:"achieved_rate,omitempty"` // ops/sec
+	ScheduleLag  time.Duration     `json:"schedule_lag,omitempty"`  // Longest an operation waited for a free worker
+	Operations   []OperationResult `json:"operations,omitempty"`
+
+	// Timed runs only: rolling statistics per operation, one entry per
+	// operation per snapshot interval
+	Snapshots []BenchmarkSnapshot `json:"snapshots,omitempty"`
+}
+
+// OperationResult holds the results for one operation type in a mixed workload
+type OperationResult struct {
+	Operation  string        `json:"operation"`
+	Count      int           `json:"count"`
+	Errors     int           `json:"errors"`
+	LatencyP50 time.Duration `json:"latency_p50"`
+	LatencyP95 time.Duration `json:"latency_p95"`
+	LatencyP99 time.Duration `json:"latency_p99"`
+	LatencyAvg time.Duration `json:"latency_avg"`
+	LatencyMax time.Duration `json:"latency_max"`
+	Throughput float64       `json:"throughput"` // ops/sec
+}
+
+// BenchmarkSnapshot holds one operation's results over one interval of a timed run
+type BenchmarkSnapshot struct {
+	Elapsed time.Duration `json:"elapsed"` // From the start of the run to the end of the interval
+	OperationResult
 }
 
 // BenchmarkResultJSON is for JSON serialization with string durations
@@ -101,10 +347,108 @@ type BenchmarkResultJSON struct {
 	CacheHitRate    float64 `json:"cache_hit_rate"`
 	Duration        string  `json:"duration"`
 	Timestamp       string  `json:"timestamp"`
+
+	FanOutCompletion string `json:"fan_out_completion,omitempty"`
+
+	ReplyFilter string `json:"reply_filter,omitempty"`
+
+	CounterStrategy string `json:"counter_strategy,omitempty"`
+
+	BlockFilter string `json:"block_filter,omitempty"`
+
+	Protected       float64 `json:"protected,omitempty"`
+	PrivacyCheckAvg string  `json:"privacy_check_avg,omitempty"`
+
+	RankingScorer          string `json:"ranking_scorer,omitempty"`
+	CandidateGenerationAvg string `json:"candidate_generation_avg,omitempty"`
+	ScoringAvg             string `json:"scoring_avg,omitempty"`
+	TruncationAvg          string `json:"truncation_avg,omitempty"`
+
+	WriteAmplification     float64 `json:"write_amplification,omitempty"`
+	ListWriteAmplification float64 `json:"list_write_amplification,omitempty"`
+	Lists                  int     `json:"lists,omitempty"`
+	ListSize               int     `json:"list_size,omitempty"`
+
+	Mix          string                `json:"mix,omitempty"`
+	TargetRate   float64               `json:"target_rate,omitempty"`
+	AchievedRate float64               `json:"achieved_rate,omitempty"`
+	ScheduleLag  string                `json:"schedule_lag,omitempty"`
+	Operations   []OperationResultJSON `json:"operations,omitempty"`
+
+	Snapshots []BenchmarkSnapshotJSON `json:"snapshots,omitempty"`
+}
+
+// BenchmarkSnapshotJSON is for JSON serialization with string durations
+type BenchmarkSnapshotJSON struct {
+	Elapsed string `json:"elapsed"`
+	OperationResultJSON
+}
+
+// OperationResultJSON is for JSON serialization with string durations
+type OperationResultJSON struct {
+	Operation  string  `json:"operation"`
+	Count      int     `json:"count"`
+	Errors     int     `json:"errors"`
+	LatencyP50 string  `json:"latency_p50"`
+	LatencyP95 string  `json:"latency_p95"`
+	LatencyP99 string  `json:"latency_p99"`
+	LatencyAvg string  `json:"latency_avg"`
+	LatencyMax string  `json:"latency_max"`
+	Throughput float64 `json:"throughput"`
+}
+
+// ToJSON converts OperationResult to JSON-friendly format
+func (o *OperationResult) ToJSON() OperationResultJSON {
+	return OperationResultJSON{
+		Operation:  o.Operation,
+		Count:      o.Count,
+		Errors:     o.Errors,
+		LatencyP50: o.LatencyP50.String(),
+		LatencyP95: o.LatencyP95.String(),
+		LatencyP99: o.LatencyP99.String(),
+		LatencyAvg: o.LatencyAvg.String(),
+		LatencyMax: o.LatencyMax.String(),
+		Throughput: o.Throughput,
+	}
 }
 
 // ToJSON converts BenchmarkResult to JSON-friendly format
 func (b *BenchmarkResult) ToJSON() BenchmarkResultJSON {
+	var fanOutCompletion string
+	if b.FanOutCompletion > 0 {
+		fanOutCompletion = b.FanOutCompletion.String()
+	}
+
+	var privacyCheckAvg string
+	if b.PrivacyCheckAvg > 0 {
+		privacyCheckAvg = b.PrivacyCheckAvg.String()
+	}
+
+	var candidateGenerationAvg, scoringAvg, truncationAvg string
+	if b.RankingScorer != "" {
+		candidateGenerationAvg = b.CandidateGenerationAvg.String()
+		scoringAvg = b.ScoringAvg.String()
+		truncationAvg = b.TruncationAvg.String()
+	}
+
+	var scheduleLag string
+	var operations []OperationResultJSON
+	if b.Mix != "" {
+		scheduleLag = b.ScheduleLag.String()
+		operations = make([]OperationResultJSON, len(b.Operations))
+		for i := range b.Operations {
+			operations[i] = b.Operations[i].ToJSON()
+		}
+	}
+
+	var snapshots []BenchmarkSnapshotJSON
+	for i := range b.Snapshots {
+		snapshots = append(snapshots, BenchmarkSnapshotJSON{
+			Elapsed:             b.Snapshots[i].Elapsed.String(),
+			OperationResultJSON: b.Snapshots[i].OperationResult.ToJSON(),
+		})
+	}
+
 	return BenchmarkResultJSON{
 		Strategy:        b.Strategy,
 		TotalTweets:     b.TotalTweets,
@@ -122,6 +466,35 @@ func (b *BenchmarkResult) ToJSON() BenchmarkResultJSON {
 		CacheHitRate:    b.CacheHitRate,
 		Duration:        b.Duration.String(),
 		Timestamp:       b.Timestamp.Format(time.RFC3339),
+
+		FanOutCompletion: fanOutCompletion,
+
+		ReplyFilter: b.ReplyFilter,
+
+		CounterStrategy: b.CounterStrategy,
+
+		BlockFilter: b.BlockFilter,
+
+		Protected:       b.Protected,
+		PrivacyCheckAvg: privacyCheckAvg,
+
+		RankingScorer:          b.RankingScorer,
+		CandidateGenerationAvg: candidateGenerationAvg,
+		ScoringAvg:             scoringAvg,
+		TruncationAvg:          truncationAvg,
+
+		WriteAmplification:     b.WriteAmplification,
+		ListWriteAmplification: b.ListWriteAmplification,
+		Lists:                  b.Lists,
+		ListSize:               b.ListSize,
+
+		Mix:          b.Mix,
+		TargetRate:   b.TargetRate,
+		AchievedRate: b.AchievedRate,
+		ScheduleLag:  scheduleLag,
+		Operations:   operations,
+
+		Snapshots: snapshots,
 	}
 }
 
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := &Cursor{CreatedAt: time.Date(2024, 3, 1, 9, 30, 0, 123456789, time.UTC), ID: 987654321}
+	got, err := ParseCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("ParseCursor: %v", err)
+	}
+	if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID {
+		t.Fatalf("round trip = %+v, want %+v", got, c)
+	}
+}
+
+func TestCursorRoundTripsAnOffset(t *testing.T) {
+	c := &Cursor{CreatedAt: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC), ID: 42, Offset: 20}
+	got, err := ParseCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("ParseCursor: %v", err)
+	}
+	if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID || got.Offset != c.Offset {
+		t.Fatalf("round trip = %+v, want %+v", got, c)
+	}
+
+	// Keyset cursors are unchanged by the optional offset
+	plain := &Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	if got, _ := ParseCursor(plain.Encode()); got.Offset != 0 {
+		t.Errorf("plain cursor parsed with offset %d", got.Offset)
+	}
+}
+
+func TestParseCursorRejectsGarbage(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"!!!",
+		"bm90IGEgY3Vyc29y", // "not a cursor"
+		"MTIz",             // "123"
+		"MTp4",             // "1:x"
+		"MToyOi0z",         // "1:2:-3", a negative offset
+		"MToyOjM6NA",       // "1:2:3:4"
+	} {
+		if _, err := ParseCursor(s); err == nil {
+			t.Errorf("ParseCursor(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestCursorOrdersByTimeThenID(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	c := &Cursor{CreatedAt: at, ID: 10}
+
+	tests := []struct {
+		name         string
+		tweet        *Tweet
+		newer, older bool
+	}{
+		{"later", &Tweet{ID: 1, CreatedAt: at.Add(time.Second)}, true, false},
+		{"earlier", &Tweet{ID: 99, CreatedAt: at.Add(-time.Second)}, false, true},
+		{"same time, higher ID", &Tweet{ID: 11, CreatedAt: at}, true, false},
+		{"same time, lower ID", &Tweet{ID: 9, CreatedAt: at}, false, true},
+		{"the cursor's own tweet", &Tweet{ID: 10, CreatedAt: at}, false, false},
+	}
+	for _, tt := range tests {
+		if got := c.Newer(tt.tweet); got != tt.newer {
+			t.Errorf("%s: Newer = %v, want %v", tt.name, got, tt.newer)
+		}
+		if got := c.Older(tt.tweet); got != tt.older {
+			t.Errorf("%s: Older = %v, want %v", tt.name, got, tt.older)
+		}
+	}
+}
+
+func TestPageContainsIsExclusive(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	tweet := func(id int64) *Tweet { return &Tweet{ID: id, CreatedAt: at} }
+	p := Page{MaxID: CursorFor(tweet(8)), SinceID: CursorFor(tweet(4))}
+
+	for id, want := range map[int64]bool{3: false, 4: false, 5: true, 7: true, 8: false, 9: false} {
+		if got := p.Contains(tweet(id)); got != want {
+			t.Errorf("Contains(tweet %d) = %v, want %v", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// BlockRepository handles block- and mute-related database operations
+type BlockRepository struct {
+	db *sqlx.DB
+}
+
+// NewBlockRepository creates a new BlockRepository
+func NewBlockRepository(db *sqlx.DB) *BlockRepository {
+	return &BlockRepository{db: db}
+}
+
+// Block records that blockerID blocked blockedID
+func (r *BlockRepository) Block(ctx context.Context, blockerID, blockedID int64) error {
+	query := `INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+		return fmt.Errorf("failed to create block: %w", err)
+	}
+	return nil
+}
+
+// Unblock removes a block
+func (r *BlockRepository) Unblock(ctx context.Context, blockerID, blockedID int64) error {
+	query := `DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2`
+	if _, err := r.db.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+		return fmt.Errorf("failed to delete block: %w", err)
+	}
+	return nil
+}
+
+// Mute records that muterID muted mutedID
+func (r *BlockRepository) Mute(ctx context.Context, muterID, mutedID int64) error {
+	query := `INSERT INTO mutes (muter_id, muted_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, query, muterID, mutedID); err != nil {
+		return fmt.Errorf("failed to create mute: %w", err)
+	}
+	return nil
+}
+
+// Unmute removes a mute
+func (r *BlockRepository) Unmute(ctx context.Context, muterID, mutedID int64) error {
+	query := `DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2`
+	if _, err := r.db.ExecContext(ctx, query, muterID, mutedID); err != nil {
+		return fmt.Errorf("failed to delete mute: %w", err)
+	}
+	return nil
+}
+
+// GetHidden retrieves the users whose tweets viewerID must not see: those
+// the viewer blocked or muted, and those who blocked the viewer
+func (r *BlockRepository) GetHidden(ctx context.Context, viewerID int64) ([]int64, error) {
+	query := `
+		SELECT blocked_id FROM blocks WHERE blocker_id = $1
+		UNION
+		SELECT blocker_id FROM blocks WHERE blocked_id = $1
+		UNION
+		SELECT muted_id FROM mutes WHERE muter_id = $1
+	`
+	hidden := []int64{}
+	if err := r.db.SelectContext(ctx, &hidden, query, viewerID); err != nil {
+		return nil, fmt.Errorf("failed to get hidden users: %w", err)
+	}
+	return hidden, nil
+}
+
+// GetHiddenFrom retrieves the users who must not see authorID's tweets:
+// those who blocked or muted the author, and those the author blocked
+func (r *BlockRepository) GetHiddenFrom(ctx context.Context, authorID int64) ([]int64, error) {
+	query := `
+		SELECT blocker_id FROM blocks WHERE blocked_id = $1
+		UNION
+		SELECT blocked_id FROM blocks WHERE blocker_id = $1
+		UNION
+		SELECT muter_id FROM mutes WHERE muted_id = $1
+	`
+	viewers := []int64{}
+	if err := r.db.SelectContext(ctx, &viewers, query, authorID); err != nil {
+		return nil, fmt.Errorf("failed to get hiding users: %w", err)
+	}
+	return viewers, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// copyRows streams rows into table with COPY FROM STDIN. Unlike a
+// multi-VALUES INSERT it has no parameter limit, so batches can be any size.
+func copyRows(ctx context.Context, tx *sqlx.Tx, table string, columns []string, n int, row func(i int) []interface{}) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return fmt.Errorf("failed to start copy into %s: %w", table, err)
+	}
+	defer stmt.Close()
+
+	for i := 0; i < n; i++ {
+		if _, err := stmt.ExecContext(ctx, row(i)...); err != nil {
+			return fmt.Errorf("failed to copy into %s: %w", table, err)
+		}
+	}
+
+	// An Exec with no arguments flushes the buffered rows
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to copy into %s: %w", table, err)
+	}
+	return nil
+}
+
+// copyViaStaging copies rows into a temporary table shaped like columns and
+// then runs insert, which moves them into place. COPY can't skip conflicting
+// rows itself, so inserts that need ON CONFLICT go through staging.
+func copyViaStaging(ctx context.Context, db *sqlx.DB, staging, columns string, names []string, n int, row func(i int) []interface{}, insert string) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TEMP TABLE %s (%s) ON COMMIT DROP", staging, columns)); err != nil {
+		return fmt.Errorf("failed to create staging table: %w", err)
+	}
+	if err := copyRows(ctx, tx, staging, names, n, row); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, insert); err != nil {
+		return fmt.Errorf("failed to insert from staging: %w", err)
+	}
+	return tx.Commit()
+}
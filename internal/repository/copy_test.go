@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// newMockDB returns a sqlx handle backed by sqlmock. Every expectation set on
+// the mock must have been met by the end of the test.
+func newMockDB(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	return sqlx.NewDb(db, "postgres"), mock
+}
+
+// expectCopy expects a COPY into table carrying rows, one Exec per row, then
+// the argument-less Exec that flushes them
+func expectCopy(mock sqlmock.Sqlmock, table string, columns []string, rows ...[]interface{}) {
+	stmt := mock.ExpectPrepare(regexp.QuoteMeta(pq.CopyIn(table, columns...))).WillBeClosed()
+	for _, row := range rows {
+		args := make([]driver.Value, len(row))
+		for i, v := range row {
+			args[i] = v
+		}
+		stmt.ExpectExec().WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	stmt.ExpectExec().WithoutArgs().WillReturnResult(sqlmock.NewResult(0, int64(len(rows))))
+}
+
+func TestUserBulkCreateCopiesThroughStaging(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TEMP TABLE users_staging (username VARCHAR(50) NOT NULL) ON COMMIT DROP")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectCopy(mock, "users_staging", []string{"username"}, []interface{}{"alice"}, []interface{}{"bob"})
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users (username) SELECT username FROM users_staging ON CONFLICT (username) DO NOTHING")).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	if err := NewUserRepository(db).BulkCreate(context.Background(), []string{"alice", "bob"}); err != nil {
+		t.Fatalf("BulkCreate: %v", err)
+	}
+}
+
+func TestFollowBulkCreateCopiesThroughStaging(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TEMP TABLE follows_staging (follower_id BIGINT NOT NULL, followee_id BIGINT NOT NULL) ON COMMIT DROP")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectCopy(mock, "follows_staging", []string{"follower_id", "followee_id"},
+		[]interface{}{int64(1), int64(2)}, []interface{}{int64(1), int64(2)}, []interface{}{int64(2), int64(3)})
+	// Duplicates in the batch are removed by the insert, not by the caller
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO follows (follower_id, followee_id) SELECT DISTINCT follower_id, followee_id FROM follows_staging ON CONFLICT DO NOTHING")).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	follows := []struct {
+		FollowerID int64
+		FolloweeID int64
+	}{{1, 2}, {1, 2}, {2, 3}}
+	if err := NewFollowRepository(db).BulkCreate(context.Background(), follows); err != nil {
+		t.Fatalf("BulkCreate: %v", err)
+	}
+}
+
+func TestTweetBulkCreateCopiesIntoTweets(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	expectCopy(mock, "tweets", []string{"user_id", "content"},
+		[]interface{}{int64(1), "hello"}, []interface{}{int64(2), "world"})
+	mock.ExpectCommit()
+
+	tweets := []struct {
+		UserID  int64
+		Content string
+	}{{1, "hello"}, {2, "world"}}
+	if err := NewTweetRepository(db).BulkCreate(context.Background(), tweets); err != nil {
+		t.Fatalf("BulkCreate: %v", err)
+	}
+}
+
+func TestBulkCreateRollsBackAFailedCopy(t *testing.T) {
+	db, mock := newMockDB(t)
+	failure := errors.New("insert or update on table violates foreign key constraint")
+
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare(regexp.QuoteMeta(pq.CopyIn("tweets", "user_id", "content"))).WillBeClosed()
+	stmt.ExpectExec().WithArgs(int64(1), "hello").WillReturnResult(sqlmock.NewResult(0, 1))
+	stmt.ExpectExec().WithoutArgs().WillReturnError(failure)
+	mock.ExpectRollback()
+
+	tweets := []struct {
+		UserID  int64
+		Content string
+	}{{1, "hello"}}
+	err := NewTweetRepository(db).BulkCreate(context.Background(), tweets)
+	if !errors.Is(err, failure) {
+		t.Fatalf("BulkCreate = %v, want the COPY error", err)
+	}
+	if !strings.Contains(err.Error(), "failed to bulk create tweets") {
+		t.Errorf("error %q doesn't say what failed", err)
+	}
+}
+
+func TestBulkCreateRollsBackAFailedInsertFromStaging(t *testing.T) {
+	db, mock := newMockDB(t)
+	failure := errors.New("connection reset")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TEMP TABLE users_staging").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectCopy(mock, "users_staging", []string{"username"}, []interface{}{"alice"})
+	mock.ExpectExec("INSERT INTO users").WillReturnError(failure)
+	mock.ExpectRollback()
+
+	err := NewUserRepository(db).BulkCreate(context.Background(), []string{"alice"})
+	if !errors.Is(err, failure) {
+		t.Fatalf("BulkCreate = %v, want the insert error", err)
+	}
+}
+
+func TestBulkCreateWithNothingToDo(t *testing.T) {
+	// No expectations: an empty batch must not touch the database
+	db, _ := newMockDB(t)
+	ctx := context.Background()
+
+	if err := NewUserRepository(db).BulkCreate(ctx, nil); err != nil {
+		t.Errorf("users: %v", err)
+	}
+	if err := NewFollowRepository(db).BulkCreate(ctx, nil); err != nil {
+		t.Errorf("follows: %v", err)
+	}
+	if err := NewTweetRepository(db).BulkCreate(ctx, nil); err != nil {
+		t.Errorf("tweets: %v", err)
+	}
+}
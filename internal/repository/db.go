@@ -2,7 +2,6 @@ package repository
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -31,23 +30,6 @@ func GetDB() *sqlx.DB {
 	return db
 }
 
-// RunMigrations runs the SQL migration files
-func RunMigrations(db *sqlx.DB, migrationsPath string) error {
-	// Read and execute migration file
-	migrationFile := migrationsPath + "/001_init.sql"
-	content, err := os.ReadFile(migrationFile)
-	if err != nil {
-		return fmt.Errorf("failed to read migration file: %w", err)
-	}
-
-	_, err = db.Exec(string(content))
-	if err != nil {
-		return fmt.Errorf("failed to execute migration: %w", err)
-	}
-
-	return nil
-}
-
 // Close closes the database connection
 func Close() error {
 	if db != nil {
@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/ritik/twitter-fan-out/internal/models"
@@ -64,7 +63,7 @@ func (r *FollowRepository) GetFollowing(ctx context.Context, userID int64) ([]in
 // GetFollowingUsers retrieves all users that a user follows with full user data
 func (r *FollowRepository) GetFollowingUsers(ctx context.Context, userID int64) ([]*models.User, error) {
 	query := `
-		SELECT u.id, u.username, u.follower_count, u.following_count, u.created_at
+		SELECT u.id, u.username, u.follower_count, u.following_count, u.protected, u.created_at
 		FROM users u
 		JOIN follows f ON u.id = f.followee_id
 		WHERE f.follower_id = $1
@@ -80,7 +79,7 @@ func (r *FollowRepository) GetFollowingUsers(ctx context.Context, userID int64)
 // GetFollowingCelebrities retrieves celebrities that a user follows
 func (r *FollowRepository) GetFollowingCelebrities(ctx context.Context, userID int64, threshold int) ([]*models.User, error) {
 	query := `
-		SELECT u.id, u.username, u.follower_count, u.following_count, u.created_at
+		SELECT u.id, u.username, u.follower_count, u.following_count, u.protected, u.created_at
 		FROM users u
 		JOIN follows f ON u.id = f.followee_id
 		WHERE f.follower_id = $1 AND u.follower_count >= $2
@@ -130,7 +129,9 @@ func (r *FollowRepository) Count(ctx context.Context) (int, error) {
 	return count, nil
 }
 
-// BulkCreate creates multiple follow relationships efficiently
+// BulkCreate creates multiple follow relationships efficiently, skipping
+// existing ones. Rows are loaded with COPY through a staging table; the
+// statement-level count trigger then updates every affected user once.
 func (r *FollowRepository) BulkCreate(ctx context.Context, follows []struct {
 	FollowerID int64
 	FolloweeID int64
@@ -139,17 +140,10 @@ func (r *FollowRepository) BulkCreate(ctx context.Context, follows []struct {
 		return nil
 	}
 
-	// Build bulk insert query
-	valueStrings := make([]string, 0, len(follows))
-	valueArgs := make([]interface{}, 0, len(follows)*2)
-	
-	for i, f := range follows {
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2))
-		valueArgs = append(valueArgs, f.FollowerID, f.FolloweeID)
-	}
-
-	query := fmt.Sprintf("INSERT INTO follows (follower_id, followee_id) VALUES %s ON CONFLICT DO NOTHING", strings.Join(valueStrings, ","))
-	_, err := r.db.ExecContext(ctx, query, valueArgs...)
+	err := copyViaStaging(ctx, r.db, "follows_staging", "follower_id BIGINT NOT NULL, followee_id BIGINT NOT NULL",
+		[]string{"follower_id", "followee_id"},
+		len(follows), func(i int) []interface{} { return []interface{}{follows[i].FollowerID, follows[i].FolloweeID} },
+		"INSERT INTO follows (follower_id, followee_id) SELECT DISTINCT follower_id, followee_id FROM follows_staging ON CONFLICT DO NOTHING")
 	if err != nil {
 		return fmt.Errorf("failed to bulk create follows: %w", err)
 	}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// ErrNoFollowRequest is returned when approving, denying or cancelling a
+// follow request that isn't pending
+var ErrNoFollowRequest = errors.New("no pending follow request")
+
+// FollowRequestRepository handles follow-request-related database operations
+type FollowRequestRepository struct {
+	db *sqlx.DB
+}
+
+// NewFollowRequestRepository creates a new FollowRequestRepository
+func NewFollowRequestRepository(db *sqlx.DB) *FollowRequestRepository {
+	return &FollowRequestRepository{db: db}
+}
+
+// Create records requesterID's request to follow targetID
+func (r *FollowRequestRepository) Create(ctx context.Context, requesterID, targetID int64) error {
+	query := `INSERT INTO follow_requests (requester_id, target_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, query, requesterID, targetID); err != nil {
+		return fmt.Errorf("failed to create follow request: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a pending request
+func (r *FollowRequestRepository) Delete(ctx context.Context, requesterID, targetID int64) error {
+	query := `DELETE FROM follow_requests WHERE requester_id = $1 AND target_id = $2`
+	result, err := r.db.ExecContext(ctx, query, requesterID, targetID)
+	if err != nil {
+		return fmt.Errorf("failed to delete follow request: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNoFollowRequest
+	}
+	return nil
+}
+
+// GetPending retrieves up to limit requests to follow targetID, oldest first
+func (r *FollowRequestRepository) GetPending(ctx context.Context, targetID int64, limit int) ([]*models.FollowRequest, error) {
+	query := `
+		SELECT requester_id, target_id, created_at
+		FROM follow_requests
+		WHERE target_id = $1
+		ORDER BY created_at, requester_id
+		LIMIT $2
+	`
+	requests := []*models.FollowRequest{}
+	err := r.db.SelectContext(ctx, &requests, query, targetID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follow requests: %w", err)
+	}
+	return requests, nil
+}
+
+// DeleteAll removes every request to follow targetID and returns the
+// requesters, oldest request first
+func (r *FollowRequestRepository) DeleteAll(ctx context.Context, targetID int64) ([]int64, error) {
+	query := `
+		WITH deleted AS (
+			DELETE FROM follow_requests WHERE target_id = $1
+			RETURNING requester_id, created_at
+		)
+		SELECT requester_id FROM deleted ORDER BY created_at, requester_id
+	`
+	var requesters []int64
+	if err := r.db.SelectContext(ctx, &requesters, query, targetID); err != nil {
+		return nil, fmt.Errorf("failed to delete follow requests: %w", err)
+	}
+	return requesters, nil
+}
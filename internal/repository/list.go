@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// ListRepository handles list-related database operations
+type ListRepository struct {
+	db *sqlx.DB
+}
+
+// NewListRepository creates a new ListRepository
+func NewListRepository(db *sqlx.DB) *ListRepository {
+	return &ListRepository{db: db}
+}
+
+// listColumns selects a list row with its member count
+const listColumns = `
+	l.id, l.owner_id, l.name, l.created_at,
+	(SELECT COUNT(*) FROM list_members m WHERE m.list_id = l.id) AS member_count
+`
+
+// Create creates a new empty list owned by ownerID
+func (r *ListRepository) Create(ctx context.Context, ownerID int64, name string) (*models.List, error) {
+	query := `INSERT INTO lists (owner_id, name) VALUES ($1, $2) RETURNING id, owner_id, name, created_at`
+	list := &models.List{}
+	if err := r.db.GetContext(ctx, list, query, ownerID, name); err != nil {
+		return nil, fmt.Errorf("failed to create list: %w", err)
+	}
+	return list, nil
+}
+
+// GetByID retrieves a list by ID
+func (r *ListRepository) GetByID(ctx context.Context, id int64) (*models.List, error) {
+	query := `SELECT ` + listColumns + ` FROM lists l WHERE l.id = $1`
+	list := &models.List{}
+	if err := r.db.GetContext(ctx, list, query, id); err != nil {
+		return nil, fmt.Errorf("failed to get list: %w", err)
+	}
+	return list, nil
+}
+
+// GetByOwner retrieves the lists ownerID owns, oldest first
+func (r *ListRepository) GetByOwner(ctx context.Context, ownerID int64) ([]*models.List, error) {
+	query := `SELECT ` + listColumns + ` FROM lists l WHERE l.owner_id = $1 ORDER BY l.id`
+	lists := []*models.List{}
+	if err := r.db.SelectContext(ctx, &lists, query, ownerID); err != nil {
+		return nil, fmt.Errorf("failed to get lists: %w", err)
+	}
+	return lists, nil
+}
+
+// Delete removes a list and its members
+func (r *ListRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM lists WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete list: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("failed to delete list: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// AddMember adds userID to a list
+func (r *ListRepository) AddMember(ctx context.Context, listID, userID int64) error {
+	query := `INSERT INTO list_members (list_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, query, listID, userID); err != nil {
+		return fmt.Errorf("failed to add list member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember removes userID from a list
+func (r *ListRepository) RemoveMember(ctx context.Context, listID, userID int64) error {
+	query := `DELETE FROM list_members WHERE list_id = $1 AND user_id = $2`
+	if _, err := r.db.ExecContext(ctx, query, listID, userID); err != nil {
+		return fmt.Errorf("failed to remove list member: %w", err)
+	}
+	return nil
+}
+
+// GetMembers retrieves a list's members with full user data
+func (r *ListRepository) GetMembers(ctx context.Context, listID int64) ([]*models.User, error) {
+	query := `
+		SELECT u.id, u.username, u.follower_count, u.following_count, u.protected, u.created_at
+		FROM users u
+		JOIN list_members m ON u.id = m.user_id
+		WHERE m.list_id = $1
+		ORDER BY u.id
+	`
+	users := []*models.User{}
+	if err := r.db.SelectContext(ctx, &users, query, listID); err != nil {
+		return nil, fmt.Errorf("failed to get list members: %w", err)
+	}
+	return users, nil
+}
+
+// GetListsByMember retrieves the IDs of the lists containing userID, which
+// a tweet of theirs fans out to
+func (r *ListRepository) GetListsByMember(ctx context.Context, userID int64) ([]int64, error) {
+	query := `SELECT list_id FROM list_members WHERE user_id = $1`
+	var listIDs []int64
+	if err := r.db.SelectContext(ctx, &listIDs, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get member lists: %w", err)
+	}
+	return listIDs, nil
+}
@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Migration files are named NNN_description.sql and split into sections by
+// marker comments:
+//
+//	-- +migrate Up
+//	CREATE TABLE ...;
+//
+//	-- +migrate Down
+//	DROP TABLE ...;
+const (
+	migrateUpMarker   = "-- +migrate up"
+	migrateDownMarker = "-- +migrate down"
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+
+// migrationLockID keys the advisory lock held while migrating, so a server
+// and CLI starting together don't both apply the same migration
+const migrationLockID = 7213500101
+
+// Migration is one numbered schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+// ErrDirtyMigration is returned when a migration was started but never
+// finished, leaving the schema in an unknown state
+var ErrDirtyMigration = errors.New("database schema is dirty")
+
+// Migrator applies and rolls back the migrations in a directory, recording
+// the current version in the schema_migrations table
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+// NewMigrator loads the migrations in dir
+func NewMigrator(db *sqlx.DB, dir string) (*Migrator, error) {
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// LoadMigrations reads and parses the migration files in dir, ordered by version
+func LoadMigrations(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file: %w", err)
+		}
+		up, down, err := splitMigration(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: match[2], Up: up, Down: down})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations found in %s", dir)
+	}
+	return migrations, nil
+}
+
+// splitMigration separates the up and down sections of a migration file
+func splitMigration(content string) (string, string, error) {
+	var up, down strings.Builder
+	var section *strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case migrateUpMarker:
+			section = &up
+			continue
+		case migrateDownMarker:
+			section = &down
+			continue
+		}
+		if section == nil {
+			// Only comments may come before the first section
+			if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return "", "", fmt.Errorf("statement before %q", "-- +migrate Up")
+			}
+			continue
+		}
+		section.WriteString(line)
+		section.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(up.String()) == "" {
+		return "", "", fmt.Errorf("missing %q section", "-- +migrate Up")
+	}
+	return up.String(), down.String(), nil
+}
+
+// Migrations returns the known migrations, oldest first
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest returns the newest known version
+func (m *Migrator) Latest() int64 {
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the applied version, 0 if none, and whether the last
+// migration was left unfinished
+func (m *Migrator) Version(ctx context.Context) (int64, bool, error) {
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return 0, false, err
+	}
+	return readVersion(ctx, m.db)
+}
+
+// Check returns an error if the database can't be used with these
+// migrations: a migration was left unfinished, or the database is at a
+// version this build doesn't know about
+func (m *Migrator) Check(ctx context.Context) error {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	return m.check(version, dirty)
+}
+
+func (m *Migrator) check(version int64, dirty bool) error {
+	if dirty {
+		return fmt.Errorf("%w at version %d: fix the schema by hand, then run `fanout migrate force VERSION`", ErrDirtyMigration, version)
+	}
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("database is at unknown version %d (latest known is %d)", version, m.Latest())
+	}
+	return nil
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	version, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{Migration: migration, Applied: migration.Version <= version}
+	}
+	return statuses, nil
+}
+
+// Up applies up to steps pending migrations, or all of them if steps is 0,
+// and returns the ones applied
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sqlx.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.check(version, dirty); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if steps > 0 && len(applied) == steps {
+				break
+			}
+			if err := m.run(ctx, conn, version, migration.Version, migration.Up); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			version = migration.Version
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back up to steps applied migrations, or all of them if steps is
+// 0, and returns the ones rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var rolledBack []Migration
+	err := m.locked(ctx, func(conn *sqlx.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.check(version, dirty); err != nil {
+			return err
+		}
+
+		for i := m.index(version); i >= 0; i-- {
+			if steps > 0 && len(rolledBack) == steps {
+				break
+			}
+			migration := m.migrations[i]
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("migration %d_%s has no down section", migration.Version, migration.Name)
+			}
+			var previous int64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := m.run(ctx, conn, version, previous, migration.Down); err != nil {
+				return fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			version = previous
+			rolledBack = append(rolledBack, migration)
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Force records version as the current, clean version without running any
+// migrations. Use it after repairing a dirty schema by hand.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.locked(ctx, func(conn *sqlx.Conn) error {
+		return setVersion(ctx, conn, version, false)
+	})
+}
+
+// run moves the schema from one version to another. The version is first
+// recorded as dirty, so a process that dies part way leaves a marker behind;
+// the SQL and the clean version then commit together.
+func (m *Migrator) run(ctx context.Context, conn *sqlx.Conn, from, to int64, script string) error {
+	if err := setVersion(ctx, conn, to, true); err != nil {
+		return err
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		// Rolling back undoes the script, so the schema is still at from
+		tx.Rollback()
+		if resetErr := setVersion(ctx, conn, from, false); resetErr != nil {
+			return fmt.Errorf("%w (and failed to reset version: %v)", err, resetErr)
+		}
+		return err
+	}
+	if err := setVersion(ctx, tx, to, false); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// locked runs fn on a single connection holding the migration lock
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// index returns the position of version in the migrations, or -1
+func (m *Migrator) index(version int64) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, db sqlx.ExecerContext) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// readVersion reads the single schema_migrations row; an empty table is version 0
+func readVersion(ctx context.Context, db sqlx.QueryerContext) (int64, bool, error) {
+	var row struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+	err := sqlx.GetContext(ctx, db, &row, "SELECT version, dirty FROM schema_migrations LIMIT 1")
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return row.Version, row.Dirty, nil
+}
+
+// setVersion replaces the schema_migrations row
+func setVersion(ctx context.Context, db sqlx.ExecerContext, version int64, dirty bool) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	if version == 0 && !dirty {
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)", version, dirty); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return nil
+}
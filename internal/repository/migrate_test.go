@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func writeMigrations(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadMigrations(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"010_later.sql":   "-- +migrate Up\nCREATE TABLE b ();\n",
+		"002_first.sql":   "-- Adds a\n\n-- +Migrate UP\nCREATE TABLE a ();\n-- +migrate Down\nDROP TABLE a;\n",
+		"README.md":       "not a migration",
+		"notes.sql":       "not numbered",
+		"003_skipped.txt": "wrong extension",
+	})
+
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("loaded %d migrations, want 2: %+v", len(migrations), migrations)
+	}
+	first, second := migrations[0], migrations[1]
+	if first.Version != 2 || first.Name != "first" || second.Version != 10 || second.Name != "later" {
+		t.Errorf("loaded %d_%s and %d_%s, want 2_first then 10_later", first.Version, first.Name, second.Version, second.Name)
+	}
+	if first.Up != "CREATE TABLE a ();\n" || first.Down != "DROP TABLE a;\n" {
+		t.Errorf("split 002_first into up %q and down %q", first.Up, first.Down)
+	}
+	if second.Down != "" {
+		t.Errorf("010_later has no down section but got %q", second.Down)
+	}
+}
+
+func TestLoadMigrationsRejectsBadFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{"empty directory", nil, "no migrations"},
+		{"duplicate version", map[string]string{
+			"001_a.sql":  "-- +migrate Up\nSELECT 1;\n",
+			"0001_b.sql": "-- +migrate Up\nSELECT 1;\n",
+		}, "duplicate migration version 1"},
+		{"version zero", map[string]string{"000_a.sql": "-- +migrate Up\nSELECT 1;\n"}, "invalid migration version"},
+		{"no up section", map[string]string{"001_a.sql": "-- +migrate Down\nSELECT 1;\n"}, "missing"},
+		{"statement before up", map[string]string{"001_a.sql": "SELECT 1;\n-- +migrate Up\nSELECT 1;\n"}, "statement before"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMigrations(writeMigrations(t, tt.files))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadMigrations = %v, want an error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRepoMigrationsAreSequentialAndReversible(t *testing.T) {
+	migrations, err := LoadMigrations(filepath.Join("..", "..", "migrations"))
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("migration %d_%s is out of sequence, want version %d", migration.Version, migration.Name, i+1)
+		}
+		if strings.TrimSpace(migration.Down) == "" {
+			t.Errorf("migration %d_%s has no down section", migration.Version, migration.Name)
+		}
+	}
+}
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "a", Up: "CREATE TABLE a ();", Down: "DROP TABLE a;"},
+	{Version: 2, Name: "b", Up: "CREATE TABLE b ();", Down: "DROP TABLE b;"},
+	{Version: 3, Name: "c", Up: "CREATE TABLE c ();", Down: "DROP TABLE c;"},
+}
+
+func newTestMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	db, mock := newMockDB(t)
+	return &Migrator{db: db, migrations: testMigrations}, mock
+}
+
+// expectLocked expects the migration lock to be taken and the version table
+// read, returning a function that expects the lock's release
+func expectLocked(mock sqlmock.Sqlmock, version int64, dirty bool) func() {
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version", "dirty"})
+	if version != 0 || dirty {
+		rows.AddRow(version, dirty)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, dirty FROM schema_migrations LIMIT 1")).WillReturnRows(rows)
+	return func() {
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+			WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+}
+
+func expectSetVersion(mock sqlmock.Sqlmock, version int64, dirty bool) {
+	mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+	if version == 0 && !dirty {
+		return
+	}
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)")).
+		WithArgs(version, dirty).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectRun expects script to run between a dirty and a clean record of to
+func expectRun(mock sqlmock.Sqlmock, to int64, script string) {
+	expectSetVersion(mock, to, true)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(script)).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectSetVersion(mock, to, false)
+	mock.ExpectCommit()
+}
+
+func versions(migrations []Migration) []int64 {
+	out := make([]int64, len(migrations))
+	for i, m := range migrations {
+		out[i] = m.Version
+	}
+	return out
+}
+
+func TestMigratorUp(t *testing.T) {
+	m, mock := newTestMigrator(t)
+
+	unlock := expectLocked(mock, 1, false)
+	expectRun(mock, 2, "CREATE TABLE b ();")
+	expectRun(mock, 3, "CREATE TABLE c ();")
+	unlock()
+
+	applied, err := m.Up(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if got := versions(applied); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Errorf("applied %v, want [2 3]", got)
+	}
+}
+
+func TestMigratorUpSteps(t *testing.T) {
+	m, mock := newTestMigrator(t)
+
+	unlock := expectLocked(mock, 0, false)
+	expectRun(mock, 1, "CREATE TABLE a ();")
+	unlock()
+
+	applied, err := m.Up(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if got := versions(applied); len(got) != 1 || got[0] != 1 {
+		t.Errorf("applied %v, want [1]", got)
+	}
+}
+
+func TestMigratorUpResetsTheVersionWhenAScriptFails(t *testing.T) {
+	m, mock := newTestMigrator(t)
+	failure := errors.New(`relation "b" already exists`)
+
+	unlock := expectLocked(mock, 1, false)
+	expectSetVersion(mock, 2, true)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b ();")).WillReturnError(failure)
+	mock.ExpectRollback()
+	// The script rolled back, so the schema is still cleanly at version 1
+	expectSetVersion(mock, 1, false)
+	unlock()
+
+	applied, err := m.Up(context.Background(), 0)
+	if !errors.Is(err, failure) || !strings.Contains(err.Error(), "2_b") {
+		t.Fatalf("Up = %v, want the script's error naming 2_b", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("reported %v as applied", versions(applied))
+	}
+}
+
+func TestMigratorRefusesADirtySchema(t *testing.T) {
+	for name, migrate := range map[string]func(*Migrator) ([]Migration, error){
+		"up":   func(m *Migrator) ([]Migration, error) { return m.Up(context.Background(), 0) },
+		"down": func(m *Migrator) ([]Migration, error) { return m.Down(context.Background(), 0) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			m, mock := newTestMigrator(t)
+			// No scripts are expected to run
+			unlock := expectLocked(mock, 2, true)
+			unlock()
+
+			if _, err := migrate(m); !errors.Is(err, ErrDirtyMigration) {
+				t.Fatalf("migrating a dirty schema = %v, want ErrDirtyMigration", err)
+			}
+		})
+	}
+}
+
+func TestMigratorRefusesAnUnknownVersion(t *testing.T) {
+	m, mock := newTestMigrator(t)
+	unlock := expectLocked(mock, 9, false)
+	unlock()
+
+	if _, err := m.Up(context.Background(), 0); err == nil || !strings.Contains(err.Error(), "unknown version 9") {
+		t.Fatalf("Up = %v, want an unknown version error", err)
+	}
+}
+
+func TestMigratorDown(t *testing.T) {
+	m, mock := newTestMigrator(t)
+
+	unlock := expectLocked(mock, 3, false)
+	expectRun(mock, 2, "DROP TABLE c;")
+	expectRun(mock, 1, "DROP TABLE b;")
+	unlock()
+
+	rolledBack, err := m.Down(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if got := versions(rolledBack); len(got) != 2 || got[0] != 3 || got[1] != 2 {
+		t.Errorf("rolled back %v, want [3 2]", got)
+	}
+}
+
+func TestMigratorDownToNothing(t *testing.T) {
+	m, mock := newTestMigrator(t)
+
+	unlock := expectLocked(mock, 1, false)
+	// Rolling back the first migration clears the version row
+	expectSetVersion(mock, 0, true)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE a;")).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectSetVersion(mock, 0, false)
+	mock.ExpectCommit()
+	unlock()
+
+	rolledBack, err := m.Down(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if got := versions(rolledBack); len(got) != 1 || got[0] != 1 {
+		t.Errorf("rolled back %v, want [1]", got)
+	}
+}
+
+func TestMigratorDownNeedsADownSection(t *testing.T) {
+	db, mock := newMockDB(t)
+	m := &Migrator{db: db, migrations: []Migration{{Version: 1, Name: "a", Up: "CREATE TABLE a ();"}}}
+
+	unlock := expectLocked(mock, 1, false)
+	unlock()
+
+	if _, err := m.Down(context.Background(), 0); err == nil || !strings.Contains(err.Error(), "no down section") {
+		t.Fatalf("Down = %v, want a missing down section error", err)
+	}
+}
+
+func TestMigratorForce(t *testing.T) {
+	m, mock := newTestMigrator(t)
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectSetVersion(mock, 2, false)
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := m.Force(context.Background(), 2); err != nil {
+		t.Fatalf("Force: %v", err)
+	}
+	if err := m.Force(context.Background(), 9); err == nil {
+		t.Error("forcing an unknown version succeeded")
+	}
+}
+
+func TestMigratorCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int64
+		dirty   bool
+		wantErr error
+		ok      bool
+	}{
+		{name: "empty", version: 0, ok: true},
+		{name: "behind", version: 1, ok: true},
+		{name: "latest", version: 3, ok: true},
+		{name: "dirty", version: 2, dirty: true, wantErr: ErrDirtyMigration},
+		{name: "unknown", version: 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, mock := newTestMigrator(t)
+			mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+			rows := sqlmock.NewRows([]string{"version", "dirty"})
+			if tt.version != 0 {
+				rows.AddRow(tt.version, tt.dirty)
+			}
+			mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnRows(rows)
+
+			err := m.Check(context.Background())
+			switch {
+			case tt.ok && err != nil:
+				t.Errorf("Check = %v, want nil", err)
+			case !tt.ok && err == nil:
+				t.Error("Check = nil, want an error")
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("Check = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
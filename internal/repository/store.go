@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// The store interfaces let the timeline strategies, API and CLI run against
+// PostgreSQL (the *Repository types in this package) or the in-process
+// backend in internal/memory.
+
+// UserStore persists users
+type UserStore interface {
+	Create(ctx context.Context, username string) (*models.User, error)
+	GetByID(ctx context.Context, id int64) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	GetByUsernames(ctx context.Context, usernames []string) ([]*models.User, error)
+	GetAll(ctx context.Context, limit, offset int) ([]*models.User, error)
+	GetCelebrities(ctx context.Context, threshold int) ([]*models.User, error)
+	GetRandomUsers(ctx context.Context, count int) ([]*models.User, error)
+	Count(ctx context.Context) (int, error)
+	CountCelebrities(ctx context.Context, threshold int) (int, error)
+	SetProtected(ctx context.Context, id int64, protected bool) error
+	BulkCreate(ctx context.Context, usernames []string) error
+	Delete(ctx context.Context, id int64) error
+	Truncate(ctx context.Context) error
+}
+
+// TweetStore persists tweets
+type TweetStore interface {
+	Create(ctx context.Context, userID int64, content string) (*models.Tweet, error)
+	GetByID(ctx context.Context, id int64) (*models.Tweet, error)
+	GetByIDs(ctx context.Context, ids []int64) ([]*models.Tweet, error)
+	GetByUserID(ctx context.Context, userID int64, limit int) ([]*models.Tweet, error)
+	GetByUserIDs(ctx context.Context, userIDs []int64, page models.Page) ([]*models.Tweet, error)
+	GetRecentByUserIDs(ctx context.Context, userIDs []int64, perUserLimit int, page models.Page) ([]*models.Tweet, error)
+	CreateReply(ctx context.Context, userID, inReplyToID int64, content string) (*models.Tweet, error)
+	GetAncestors(ctx context.Context, tweetID int64) ([]*models.Tweet, error)
+	GetDescendants(ctx context.Context, tweetID int64, page models.Page) ([]*models.Tweet, error)
+	Retweet(ctx context.Context, userID, tweetID int64) (*models.Tweet, error)
+	GetRetweets(ctx context.Context, tweetID int64) ([]*models.Tweet, error)
+	Like(ctx context.Context, userID, tweetID int64) (int64, error)
+	Unlike(ctx context.Context, userID, tweetID int64) (int64, error)
+	GetCounts(ctx context.Context, tweetIDs []int64) (map[int64]models.TweetCounts, error)
+	SetCounts(ctx context.Context, counts map[int64]models.TweetCounts) error
+	Count(ctx context.Context) (int, error)
+	BulkCreate(ctx context.Context, tweets []struct {
+		UserID  int64
+		Content string
+	}) error
+	Delete(ctx context.Context, id int64) error
+	Truncate(ctx context.Context) error
+}
+
+// FollowStore persists the follow graph
+type FollowStore interface {
+	Create(ctx context.Context, followerID, followeeID int64) error
+	Delete(ctx context.Context, followerID, followeeID int64) error
+	GetFollowers(ctx context.Context, userID int64) ([]int64, error)
+	GetFollowing(ctx context.Context, userID int64) ([]int64, error)
+	GetFollowingUsers(ctx context.Context, userID int64) ([]*models.User, error)
+	GetFollowingCelebrities(ctx context.Context, userID int64, threshold int) ([]*models.User, error)
+	GetFollowingNonCelebrities(ctx context.Context, userID int64, threshold int) ([]int64, error)
+	IsFollowing(ctx context.Context, followerID, followeeID int64) (bool, error)
+	Count(ctx context.Context) (int, error)
+	BulkCreate(ctx context.Context, follows []struct {
+		FollowerID int64
+		FolloweeID int64
+	}) error
+	Truncate(ctx context.Context) error
+}
+
+// FollowRequestStore persists requests to follow protected users
+type FollowRequestStore interface {
+	Create(ctx context.Context, requesterID, targetID int64) error
+	Delete(ctx context.Context, requesterID, targetID int64) error
+	GetPending(ctx context.Context, targetID int64, limit int) ([]*models.FollowRequest, error)
+	DeleteAll(ctx context.Context, targetID int64) ([]int64, error)
+}
+
+// BlockStore persists blocks and mutes
+type BlockStore interface {
+	Block(ctx context.Context, blockerID, blockedID int64) error
+	Unblock(ctx context.Context, blockerID, blockedID int64) error
+	Mute(ctx context.Context, muterID, mutedID int64) error
+	Unmute(ctx context.Context, muterID, mutedID int64) error
+	GetHidden(ctx context.Context, viewerID int64) ([]int64, error)
+	GetHiddenFrom(ctx context.Context, authorID int64) ([]int64, error)
+}
+
+// ListStore persists lists and their members
+type ListStore interface {
+	Create(ctx context.Context, ownerID int64, name string) (*models.List, error)
+	GetByID(ctx context.Context, id int64) (*models.List, error)
+	GetByOwner(ctx context.Context, ownerID int64) ([]*models.List, error)
+	Delete(ctx context.Context, id int64) error
+	AddMember(ctx context.Context, listID, userID int64) error
+	RemoveMember(ctx context.Context, listID, userID int64) error
+	GetMembers(ctx context.Context, listID int64) ([]*models.User, error)
+	GetListsByMember(ctx context.Context, userID int64) ([]int64, error)
+}
+
+var (
+	_ UserStore          = (*UserRepository)(nil)
+	_ TweetStore         = (*TweetRepository)(nil)
+	_ FollowStore        = (*FollowRepository)(nil)
+	_ FollowRequestStore = (*FollowRequestRepository)(nil)
+	_ BlockStore         = (*BlockRepository)(nil)
+	_ ListStore          = (*ListRepository)(nil)
+)
@@ -2,13 +2,49 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/ritik/twitter-fan-out/internal/models"
 )
 
+var (
+	// ErrAlreadyRetweeted is returned when a user retweets a tweet twice
+	ErrAlreadyRetweeted = errors.New("tweet already retweeted")
+	// ErrProtectedTweet is returned when a user retweets a protected user's
+	// tweet, which would show it to people the author hasn't approved
+	ErrProtectedTweet = errors.New("tweet is protected")
+	// ErrAlreadyLiked is returned when a user likes a tweet twice
+	ErrAlreadyLiked = errors.New("tweet already liked")
+	// ErrNotLiked is returned when a user unlikes a tweet they haven't liked
+	ErrNotLiked = errors.New("tweet not liked")
+)
+
+// tweetColumns selects a tweet aliased t, joined by tweetJoins with its
+// author and, for retweets, the original tweet and its author. A retweet
+// carries its original's counts.
+const tweetColumns = `t.id, t.user_id, t.content, t.created_at, u.username,
+		CASE
+			WHEN t.original_id IS NOT NULL THEN 'retweet'
+			WHEN t.in_reply_to_user_id IS NOT NULL THEN 'reply'
+			ELSE 'tweet'
+		END AS kind,
+		COALESCE(t.original_id, 0) AS original_id,
+		COALESCE(t.in_reply_to_id, 0) AS in_reply_to_id,
+		COALESCE(t.in_reply_to_user_id, 0) AS in_reply_to_user_id,
+		COALESCE(o.user_id, 0) AS original_user_id,
+		COALESCE(ou.username, '') AS original_username,
+		o.created_at AS original_created_at,
+		COALESCE(o.like_count, t.like_count) AS like_count,
+		COALESCE(o.retweet_count, t.retweet_count) AS retweet_count`
+
+const tweetJoins = `JOIN users u ON t.user_id = u.id
+		LEFT JOIN tweets o ON t.original_id = o.id
+		LEFT JOIN users ou ON o.user_id = ou.id`
+
 // TweetRepository handles tweet-related database operations
 type TweetRepository struct {
 	db *sqlx.DB
@@ -24,7 +60,7 @@ func (r *TweetRepository) Create(ctx context.Context, userID int64, content stri
 	query := `
 		INSERT INTO tweets (user_id, content)
 		VALUES ($1, $2)
-		RETURNING id, user_id, content, created_at
+		RETURNING id, user_id, content, created_at, 'tweet' AS kind
 	`
 	tweet := &models.Tweet{}
 	err := r.db.QueryRowxContext(ctx, query, userID, content).StructScan(tweet)
@@ -37,9 +73,9 @@ func (r *TweetRepository) Create(ctx context.Context, userID int64, content stri
 // GetByID retrieves a tweet by ID
 func (r *TweetRepository) GetByID(ctx context.Context, id int64) (*models.Tweet, error) {
 	query := `
-		SELECT t.id, t.user_id, t.content, t.created_at, u.username
+		SELECT ` + tweetColumns + `
 		FROM tweets t
-		JOIN users u ON t.user_id = u.id
+		` + tweetJoins + `
 		WHERE t.id = $1
 	`
 	tweet := &models.Tweet{}
@@ -57,9 +93,9 @@ func (r *TweetRepository) GetByIDs(ctx context.Context, ids []int64) ([]*models.
 	}
 
 	query := `
-		SELECT t.id, t.user_id, t.content, t.created_at, u.username
+		SELECT ` + tweetColumns + `
 		FROM tweets t
-		JOIN users u ON t.user_id = u.id
+		` + tweetJoins + `
 		WHERE t.id = ANY($1)
 		ORDER BY t.created_at DESC
 	`
@@ -74,9 +110,9 @@ func (r *TweetRepository) GetByIDs(ctx context.Context, ids []int64) ([]*models.
 // GetByUserID retrieves tweets by user ID
 func (r *TweetRepository) GetByUserID(ctx context.Context, userID int64, limit int) ([]*models.Tweet, error) {
 	query := `
-		SELECT t.id, t.user_id, t.content, t.created_at, u.username
+		SELECT ` + tweetColumns + `
 		FROM tweets t
-		JOIN users u ON t.user_id = u.id
+		` + tweetJoins + `
 		WHERE t.user_id = $1
 		ORDER BY t.created_at DESC
 		LIMIT $2
@@ -89,58 +125,331 @@ func (r *TweetRepository) GetByUserID(ctx context.Context, userID int64, limit i
 	return tweets, nil
 }
 
-// GetByUserIDs retrieves tweets from multiple users (for fan-out-read)
-func (r *TweetRepository) GetByUserIDs(ctx context.Context, userIDs []int64, limit int) ([]*models.Tweet, error) {
+// keysetClause filters on (created_at, id) against the page's cursors. It
+// expects the bounds as parameters $n..$n+3, NULL meaning unbounded.
+func keysetClause(alias string, n int) string {
+	return fmt.Sprintf(`($%[2]d::timestamptz IS NULL OR (%[1]s.created_at, %[1]s.id) < ($%[2]d::timestamptz, $%[3]d::bigint))
+		AND ($%[4]d::timestamptz IS NULL OR (%[1]s.created_at, %[1]s.id) > ($%[4]d::timestamptz, $%[5]d::bigint))`,
+		alias, n, n+1, n+2, n+3)
+}
+
+// keysetArgs returns the bound parameters for keysetClause
+func keysetArgs(page models.Page) []interface{} {
+	args := []interface{}{nil, nil, nil, nil}
+	if page.MaxID != nil {
+		args[0], args[1] = page.MaxID.CreatedAt, page.MaxID.ID
+	}
+	if page.SinceID != nil {
+		args[2], args[3] = page.SinceID.CreatedAt, page.SinceID.ID
+	}
+	return args
+}
+
+// GetByUserIDs retrieves a keyset page of tweets from multiple users (for fan-out-read)
+func (r *TweetRepository) GetByUserIDs(ctx context.Context, userIDs []int64, page models.Page) ([]*models.Tweet, error) {
 	if len(userIDs) == 0 {
 		return []*models.Tweet{}, nil
 	}
 
 	query := `
-		SELECT t.id, t.user_id, t.content, t.created_at, u.username
+		SELECT ` + tweetColumns + `
 		FROM tweets t
-		JOIN users u ON t.user_id = u.id
-		WHERE t.user_id = ANY($1)
-		ORDER BY t.created_at DESC
+		` + tweetJoins + `
+		WHERE t.user_id = ANY($1) AND ` + keysetClause("t", 3) + `
+		ORDER BY t.created_at DESC, t.id DESC
 		LIMIT $2
 	`
+	args := append([]interface{}{userIDs, page.Limit}, keysetArgs(page)...)
 	tweets := []*models.Tweet{}
-	err := r.db.SelectContext(ctx, &tweets, query, userIDs, limit)
+	err := r.db.SelectContext(ctx, &tweets, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tweets: %w", err)
 	}
 	return tweets, nil
 }
 
-// GetRecentByUserIDs retrieves recent tweets from multiple users with per-user limit
+// GetRecentByUserIDs retrieves a keyset page of tweets from multiple users with per-user limit
 // This is more efficient for fan-out-read when we need recent tweets from many users
-func (r *TweetRepository) GetRecentByUserIDs(ctx context.Context, userIDs []int64, perUserLimit, totalLimit int) ([]*models.Tweet, error) {
+func (r *TweetRepository) GetRecentByUserIDs(ctx context.Context, userIDs []int64, perUserLimit int, page models.Page) ([]*models.Tweet, error) {
 	if len(userIDs) == 0 {
 		return []*models.Tweet{}, nil
 	}
 
 	// Use a lateral join to get top N tweets per user, then sort overall
 	query := `
-		SELECT t.id, t.user_id, t.content, t.created_at, u.username
+		SELECT ` + tweetColumns + `
 		FROM unnest($1::bigint[]) AS uid(id)
 		CROSS JOIN LATERAL (
-			SELECT id, user_id, content, created_at
-			FROM tweets
-			WHERE user_id = uid.id
-			ORDER BY created_at DESC
+			SELECT tw.id, tw.user_id, tw.content, tw.created_at, tw.original_id, tw.in_reply_to_id, tw.in_reply_to_user_id, tw.like_count, tw.retweet_count
+			FROM tweets tw
+			WHERE tw.user_id = uid.id AND ` + keysetClause("tw", 4) + `
+			ORDER BY tw.created_at DESC, tw.id DESC
 			LIMIT $2
 		) t
-		JOIN users u ON t.user_id = u.id
-		ORDER BY t.created_at DESC
+		` + tweetJoins + `
+		ORDER BY t.created_at DESC, t.id DESC
 		LIMIT $3
 	`
+	args := append([]interface{}{userIDs, perUserLimit, page.Limit}, keysetArgs(page)...)
 	tweets := []*models.Tweet{}
-	err := r.db.SelectContext(ctx, &tweets, query, userIDs, perUserLimit, totalLimit)
+	err := r.db.SelectContext(ctx, &tweets, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recent tweets: %w", err)
 	}
 	return tweets, nil
 }
 
+// CreateReply creates userID's reply to inReplyToID. Replying to a retweet
+// replies to its original.
+func (r *TweetRepository) CreateReply(ctx context.Context, userID, inReplyToID int64, content string) (*models.Tweet, error) {
+	query := `
+		INSERT INTO tweets (user_id, content, in_reply_to_id, in_reply_to_user_id)
+		SELECT $1, $2, p.id, p.user_id
+		FROM tweets t
+		JOIN tweets p ON p.id = COALESCE(t.original_id, t.id)
+		WHERE t.id = $3
+		RETURNING id, user_id, content, created_at, in_reply_to_id, in_reply_to_user_id, 'reply' AS kind
+	`
+	tweet := &models.Tweet{}
+	err := r.db.QueryRowxContext(ctx, query, userID, content, inReplyToID).StructScan(tweet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reply: %w", err)
+	}
+	return tweet, nil
+}
+
+// GetAncestors retrieves the chain of tweets a reply answers, starting from
+// the conversation's first tweet. It stops early where a parent was deleted.
+func (r *TweetRepository) GetAncestors(ctx context.Context, tweetID int64) ([]*models.Tweet, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT p.id, p.in_reply_to_id, 1 AS depth
+			FROM tweets c
+			JOIN tweets p ON p.id = c.in_reply_to_id
+			WHERE c.id = $1
+			UNION ALL
+			SELECT p.id, p.in_reply_to_id, chain.depth + 1
+			FROM chain
+			JOIN tweets p ON p.id = chain.in_reply_to_id
+		)
+		SELECT ` + tweetColumns + `
+		FROM chain
+		JOIN tweets t ON t.id = chain.id
+		` + tweetJoins + `
+		ORDER BY chain.depth DESC
+	`
+	tweets := []*models.Tweet{}
+	err := r.db.SelectContext(ctx, &tweets, query, tweetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ancestors: %w", err)
+	}
+	return tweets, nil
+}
+
+// GetDescendants retrieves a keyset page of the replies below a tweet, at
+// any depth, oldest first. The page's SinceID is the cursor.
+func (r *TweetRepository) GetDescendants(ctx context.Context, tweetID int64, page models.Page) ([]*models.Tweet, error) {
+	query := `
+		WITH RECURSIVE thread AS (
+			SELECT id FROM tweets WHERE in_reply_to_id = $1
+			UNION ALL
+			SELECT c.id FROM tweets c JOIN thread ON c.in_reply_to_id = thread.id
+		)
+		SELECT ` + tweetColumns + `
+		FROM thread
+		JOIN tweets t ON t.id = thread.id
+		` + tweetJoins + `
+		WHERE ` + keysetClause("t", 3) + `
+		ORDER BY t.created_at, t.id
+		LIMIT $2
+	`
+	args := append([]interface{}{tweetID, page.Limit}, keysetArgs(page)...)
+	tweets := []*models.Tweet{}
+	err := r.db.SelectContext(ctx, &tweets, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get descendants: %w", err)
+	}
+	return tweets, nil
+}
+
+// Retweet creates userID's retweet of tweetID. Retweeting a retweet
+// retweets its original. The retweet is a tweet row of its own, authored by
+// userID, carrying the original's content. Only the author may retweet a
+// protected user's tweet.
+func (r *TweetRepository) Retweet(ctx context.Context, userID, tweetID int64) (*models.Tweet, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var original struct {
+		ID        int64 `db:"id"`
+		UserID    int64 `db:"user_id"`
+		Protected bool  `db:"protected"`
+	}
+	err = tx.GetContext(ctx, &original, `
+		SELECT o.id, o.user_id, u.protected
+		FROM tweets t
+		JOIN tweets o ON o.id = COALESCE(t.original_id, t.id)
+		JOIN users u ON u.id = o.user_id
+		WHERE t.id = $1
+	`, tweetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tweet: %w", err)
+	}
+	if original.Protected && original.UserID != userID {
+		return nil, ErrProtectedTweet
+	}
+	originalID := original.ID
+
+	var retweetID int64
+	err = tx.GetContext(ctx, &retweetID, `
+		INSERT INTO tweets (user_id, content, original_id)
+		SELECT $1, content, id FROM tweets WHERE id = $2
+		RETURNING id
+	`, userID, originalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retweet: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO retweets (user_id, tweet_id, retweet_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, tweet_id) DO NOTHING
+	`, userID, originalID, retweetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retweet: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return nil, ErrAlreadyRetweeted
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to create retweet: %w", err)
+	}
+	return r.GetByID(ctx, retweetID)
+}
+
+// GetRetweets retrieves every retweet of a tweet, newest first
+func (r *TweetRepository) GetRetweets(ctx context.Context, tweetID int64) ([]*models.Tweet, error) {
+	query := `
+		SELECT ` + tweetColumns + `
+		FROM tweets t
+		` + tweetJoins + `
+		WHERE t.original_id = $1
+		ORDER BY t.created_at DESC, t.id DESC
+	`
+	tweets := []*models.Tweet{}
+	err := r.db.SelectContext(ctx, &tweets, query, tweetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retweets: %w", err)
+	}
+	return tweets, nil
+}
+
+// Like records userID's like of tweetID and returns the ID of the tweet
+// liked. Liking a retweet likes its original. The counts are left to the
+// caller, which keeps them in the counter store.
+func (r *TweetRepository) Like(ctx context.Context, userID, tweetID int64) (int64, error) {
+	query := `
+		WITH target AS (
+			SELECT COALESCE(original_id, id) AS id FROM tweets WHERE id = $2
+		), liked AS (
+			INSERT INTO likes (user_id, tweet_id)
+			SELECT $1, id FROM target
+			ON CONFLICT (user_id, tweet_id) DO NOTHING
+			RETURNING tweet_id
+		)
+		SELECT target.id, EXISTS (SELECT 1 FROM liked) AS inserted
+		FROM target
+	`
+	var row struct {
+		ID       int64 `db:"id"`
+		Inserted bool  `db:"inserted"`
+	}
+	err := r.db.GetContext(ctx, &row, query, userID, tweetID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to like tweet: %w", err)
+	}
+	if !row.Inserted {
+		return 0, ErrAlreadyLiked
+	}
+	return row.ID, nil
+}
+
+// Unlike removes userID's like of tweetID and returns the ID of the tweet
+// that was liked
+func (r *TweetRepository) Unlike(ctx context.Context, userID, tweetID int64) (int64, error) {
+	query := `
+		DELETE FROM likes
+		WHERE user_id = $1
+			AND tweet_id = (SELECT COALESCE(original_id, id) FROM tweets WHERE id = $2)
+		RETURNING tweet_id
+	`
+	var likedID int64
+	err := r.db.GetContext(ctx, &likedID, query, userID, tweetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotLiked
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to unlike tweet: %w", err)
+	}
+	return likedID, nil
+}
+
+// GetCounts retrieves the like and retweet counts last written back for
+// tweets. Tweets that don't exist are left out.
+func (r *TweetRepository) GetCounts(ctx context.Context, tweetIDs []int64) (map[int64]models.TweetCounts, error) {
+	counts := make(map[int64]models.TweetCounts, len(tweetIDs))
+	if len(tweetIDs) == 0 {
+		return counts, nil
+	}
+
+	rows := []struct {
+		ID       int64 `db:"id"`
+		Likes    int64 `db:"like_count"`
+		Retweets int64 `db:"retweet_count"`
+	}{}
+	query := "SELECT id, like_count, retweet_count FROM tweets WHERE id = ANY($1)"
+	if err := r.db.SelectContext(ctx, &rows, query, pq.Array(tweetIDs)); err != nil {
+		return nil, fmt.Errorf("failed to get tweet counts: %w", err)
+	}
+	for _, row := range rows {
+		counts[row.ID] = models.TweetCounts{Likes: row.Likes, Retweets: row.Retweets}
+	}
+	return counts, nil
+}
+
+// SetCounts writes back a batch of tweets' like and retweet counts in one
+// statement
+func (r *TweetRepository) SetCounts(ctx context.Context, counts map[int64]models.TweetCounts) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(counts))
+	likes := make([]int64, 0, len(counts))
+	retweets := make([]int64, 0, len(counts))
+	for id, c := range counts {
+		ids = append(ids, id)
+		likes = append(likes, c.Likes)
+		retweets = append(retweets, c.Retweets)
+	}
+
+	query := `
+		UPDATE tweets t
+		SET like_count = c.likes, retweet_count = c.retweets
+		FROM unnest($1::bigint[], $2::bigint[], $3::bigint[]) AS c(id, likes, retweets)
+		WHERE t.id = c.id
+	`
+	_, err := r.db.ExecContext(ctx, query, pq.Array(ids), pq.Array(likes), pq.Array(retweets))
+	if err != nil {
+		return fmt.Errorf("failed to set tweet counts: %w", err)
+	}
+	return nil
+}
+
 // Count returns the total number of tweets
 func (r *TweetRepository) Count(ctx context.Context) (int, error) {
 	var count int
@@ -151,7 +460,7 @@ func (r *TweetRepository) Count(ctx context.Context) (int, error) {
 	return count, nil
 }
 
-// BulkCreate creates multiple tweets efficiently
+// BulkCreate creates multiple tweets efficiently with COPY
 func (r *TweetRepository) BulkCreate(ctx context.Context, tweets []struct {
 	UserID  int64
 	Content string
@@ -160,21 +469,19 @@ func (r *TweetRepository) BulkCreate(ctx context.Context, tweets []struct {
 		return nil
 	}
 
-	// Build bulk insert query
-	valueStrings := make([]string, 0, len(tweets))
-	valueArgs := make([]interface{}, 0, len(tweets)*2)
-	
-	for i, t := range tweets {
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2))
-		valueArgs = append(valueArgs, t.UserID, t.Content)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	query := fmt.Sprintf("INSERT INTO tweets (user_id, content) VALUES %s", strings.Join(valueStrings, ","))
-	_, err := r.db.ExecContext(ctx, query, valueArgs...)
+	err = copyRows(ctx, tx, "tweets", []string{"user_id", "content"}, len(tweets), func(i int) []interface{} {
+		return []interface{}{tweets[i].UserID, tweets[i].Content}
+	})
 	if err != nil {
 		return fmt.Errorf("failed to bulk create tweets: %w", err)
 	}
-	return nil
+	return tx.Commit()
 }
 
 // Delete deletes a tweet
@@ -2,9 +2,11 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/ritik/twitter-fan-out/internal/models"
 )
 
@@ -23,7 +25,7 @@ func (r *UserRepository) Create(ctx context.Context, username string) (*models.U
 	query := `
 		INSERT INTO users (username)
 		VALUES ($1)
-		RETURNING id, username, follower_count, following_count, created_at
+		RETURNING id, username, follower_count, following_count, protected, created_at
 	`
 	user := &models.User{}
 	err := r.db.QueryRowxContext(ctx, query, username).StructScan(user)
@@ -35,7 +37,7 @@ func (r *UserRepository) Create(ctx context.Context, username string) (*models.U
 
 // GetByID retrieves a user by ID
 func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
-	query := `SELECT id, username, follower_count, following_count, created_at FROM users WHERE id = $1`
+	query := `SELECT id, username, follower_count, following_count, protected, created_at FROM users WHERE id = $1`
 	user := &models.User{}
 	err := r.db.GetContext(ctx, user, query, id)
 	if err != nil {
@@ -46,7 +48,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, e
 
 // GetByUsername retrieves a user by username
 func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
-	query := `SELECT id, username, follower_count, following_count, created_at FROM users WHERE username = $1`
+	query := `SELECT id, username, follower_count, following_count, protected, created_at FROM users WHERE username = $1`
 	user := &models.User{}
 	err := r.db.GetContext(ctx, user, query, username)
 	if err != nil {
@@ -55,9 +57,24 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*m
 	return user, nil
 }
 
+// GetByUsernames retrieves the users with the given usernames, skipping any that don't exist
+func (r *UserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]*models.User, error) {
+	if len(usernames) == 0 {
+		return []*models.User{}, nil
+	}
+
+	query := `SELECT id, username, follower_count, following_count, protected, created_at FROM users WHERE username = ANY($1)`
+	users := []*models.User{}
+	err := r.db.SelectContext(ctx, &users, query, pq.Array(usernames))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	return users, nil
+}
+
 // GetAll retrieves all users with pagination
 func (r *UserRepository) GetAll(ctx context.Context, limit, offset int) ([]*models.User, error) {
-	query := `SELECT id, username, follower_count, following_count, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2`
+	query := `SELECT id, username, follower_count, following_count, protected, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2`
 	users := []*models.User{}
 	err := r.db.SelectContext(ctx, &users, query, limit, offset)
 	if err != nil {
@@ -69,7 +86,7 @@ func (r *UserRepository) GetAll(ctx context.Context, limit, offset int) ([]*mode
 // GetCelebrities retrieves users with follower count above threshold
 func (r *UserRepository) GetCelebrities(ctx context.Context, threshold int) ([]*models.User, error) {
 	query := `
-		SELECT id, username, follower_count, following_count, created_at 
+		SELECT id, username, follower_count, following_count, protected, created_at 
 		FROM users 
 		WHERE follower_count >= $1 
 		ORDER BY follower_count DESC
@@ -85,7 +102,7 @@ func (r *UserRepository) GetCelebrities(ctx context.Context, threshold int) ([]*
 // GetRandomUsers retrieves random users for benchmarking
 func (r *UserRepository) GetRandomUsers(ctx context.Context, count int) ([]*models.User, error) {
 	query := `
-		SELECT id, username, follower_count, following_count, created_at 
+		SELECT id, username, follower_count, following_count, protected, created_at 
 		FROM users 
 		ORDER BY RANDOM() 
 		LIMIT $1
@@ -98,6 +115,18 @@ func (r *UserRepository) GetRandomUsers(ctx context.Context, count int) ([]*mode
 	return users, nil
 }
 
+// SetProtected sets whether only approved followers see a user's tweets
+func (r *UserRepository) SetProtected(ctx context.Context, id int64, protected bool) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET protected = $2 WHERE id = $1", id, protected)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("failed to update user: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
 // Count returns the total number of users
 func (r *UserRepository) Count(ctx context.Context) (int, error) {
 	var count int
@@ -118,32 +147,20 @@ func (r *UserRepository) CountCelebrities(ctx context.Context, threshold int) (i
 	return count, nil
 }
 
-// BulkCreate creates multiple users efficiently
+// BulkCreate creates multiple users efficiently, skipping usernames that
+// already exist. Rows are loaded with COPY through a staging table.
 func (r *UserRepository) BulkCreate(ctx context.Context, usernames []string) error {
 	if len(usernames) == 0 {
 		return nil
 	}
 
-	tx, err := r.db.BeginTxx(ctx, nil)
+	err := copyViaStaging(ctx, r.db, "users_staging", "username VARCHAR(50) NOT NULL", []string{"username"},
+		len(usernames), func(i int) []interface{} { return []interface{}{usernames[i]} },
+		"INSERT INTO users (username) SELECT username FROM users_staging ON CONFLICT (username) DO NOTHING")
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to bulk create users: %w", err)
 	}
-	defer tx.Rollback()
-
-	stmt, err := tx.PreparexContext(ctx, "INSERT INTO users (username) VALUES ($1) ON CONFLICT (username) DO NOTHING")
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
-
-	for _, username := range usernames {
-		_, err := stmt.ExecContext(ctx, username)
-		if err != nil {
-			return fmt.Errorf("failed to insert user %s: %w", username, err)
-		}
-	}
-
-	return tx.Commit()
+	return nil
 }
 
 // Delete deletes a user
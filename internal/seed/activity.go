@@ -0,0 +1,128 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Activity distributions for the number of tweets per user
+const (
+	// ActivityConstant gives every user exactly TweetsPerUser tweets
+	ActivityConstant = "constant"
+	// ActivityPoisson draws each user's tweet count from a Poisson
+	// distribution with mean TweetsPerUser
+	ActivityPoisson = "poisson"
+	// ActivityPareto draws tweet counts from a Pareto distribution with mean
+	// TweetsPerUser and shape ActivityExponent (default 2, must be > 1): most
+	// users post little and a few post a lot
+	ActivityPareto = "pareto"
+)
+
+// ActivityModels returns the supported activity distribution names
+func ActivityModels() []string {
+	return []string{ActivityConstant, ActivityPoisson, ActivityPareto}
+}
+
+// maxActivityMultiple caps a Pareto tweet count at this multiple of the mean,
+// so a single draw from the tail can't dominate the data set
+const maxActivityMultiple = 100
+
+// activityCounter returns a function drawing one user's tweet count
+func activityCounter(model string, mean int, exponent float64) (func(*rand.Rand) int, error) {
+	switch model {
+	case ActivityConstant:
+		return func(*rand.Rand) int { return mean }, nil
+
+	case ActivityPoisson:
+		lambda := float64(mean)
+		return func(rng *rand.Rand) int {
+			if lambda <= 0 {
+				return 0
+			}
+			// Knuth's method is exact but linear in the mean; past that the
+			// normal approximation is close enough
+			if lambda > 30 {
+				return max(0, int(math.Round(lambda+math.Sqrt(lambda)*rng.NormFloat64())))
+			}
+			limit := math.Exp(-lambda)
+			k, p := 0, rng.Float64()
+			for p > limit {
+				k++
+				p *= rng.Float64()
+			}
+			return k
+		}, nil
+
+	case ActivityPareto:
+		alpha := exponent
+		if alpha == 0 {
+			alpha = 2
+		}
+		if alpha <= 1 {
+			return nil, fmt.Errorf("pareto activity exponent must be greater than 1, got %g", exponent)
+		}
+		// The scale that gives the requested mean: E[X] = alpha*xm/(alpha-1)
+		xm := float64(mean) * (alpha - 1) / alpha
+		limit := mean * maxActivityMultiple
+		return func(rng *rand.Rand) int {
+			u := 1 - rng.Float64() // (0, 1]
+			return min(limit, int(xm/math.Pow(u, 1/alpha)))
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown activity distribution %q (expected one of %s)", model, strings.Join(ActivityModels(), ", "))
+	}
+}
+
+// createTweets writes tweets for every user in userIDs, drawing each user's
+// count from tweetCount. Tweets are written in batches as they are generated,
+// so the full set is never held in memory.
+func createTweets(ctx context.Context, stores Stores, userIDs []int64, tweetCount func(*rand.Rand) int, rng *rand.Rand, batchSize int, out io.Writer) error {
+	fmt.Fprintf(out, "📝 Creating tweets...\n")
+	start := time.Now()
+
+	batch := make([]struct {
+		UserID  int64
+		Content string
+	}, 0, batchSize)
+	created := 0
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := stores.Tweets.BulkCreate(ctx, batch); err != nil {
+			fmt.Fprintf(out, "⚠️  Warning: Some tweets failed: %v\n", err)
+		}
+		created += len(batch)
+		batch = batch[:0]
+		fmt.Fprintf(out, "   Created %d tweets\r", created)
+	}
+
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for j, n := 0, tweetCount(rng); j < n; j++ {
+			batch = append(batch, struct {
+				UserID  int64
+				Content string
+			}{
+				UserID:  userID,
+				Content: sampleTweets[rng.Intn(len(sampleTweets))],
+			})
+			if len(batch) == batchSize {
+				flush()
+			}
+		}
+	}
+	flush()
+
+	fmt.Fprintf(out, "   Created %d tweets in %v\n", created, time.Since(start))
+	return nil
+}
@@ -0,0 +1,262 @@
+package seed
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Graph models for the follow graph
+const (
+	// GraphUniform picks followees uniformly, except that the first
+	// Celebrities users receive 30% of all follows
+	GraphUniform = "uniform"
+	// GraphZipf makes the user at popularity rank k receive follows with
+	// probability proportional to 1/k^Exponent (default 1)
+	GraphZipf = "zipf"
+	// GraphBarabasiAlbert grows the graph one user at a time, each following
+	// AvgFollowers existing users chosen by preferential attachment. Follower
+	// counts follow a power law with exponent Exponent (default 3, must be > 2).
+	GraphBarabasiAlbert = "barabasi-albert"
+)
+
+// GraphModels returns the supported graph model names
+func GraphModels() []string {
+	return []string{GraphZipf, GraphBarabasiAlbert, GraphUniform}
+}
+
+// maxFollowerRetries bounds how often a follow is redrawn when the pair already exists
+const maxFollowerRetries = 10
+
+// edge is a follow between two users, by index into the user list
+type edge struct {
+	follower int
+	followee int
+}
+
+// edgeSet tracks generated follows so none is created twice
+type edgeSet struct {
+	users int
+	seen  map[int64]struct{}
+	edges []edge
+}
+
+func newEdgeSet(users, capacity int) *edgeSet {
+	return &edgeSet{
+		users: users,
+		seen:  make(map[int64]struct{}, capacity),
+		edges: make([]edge, 0, capacity),
+	}
+}
+
+// add records a follow, reporting false for self-follows and duplicates
+func (s *edgeSet) add(follower, followee int) bool {
+	if follower == followee {
+		return false
+	}
+	key := int64(follower)*int64(s.users) + int64(followee)
+	if _, ok := s.seen[key]; ok {
+		return false
+	}
+	s.seen[key] = struct{}{}
+	s.edges = append(s.edges, edge{follower: follower, followee: followee})
+	return true
+}
+
+// graphExponent returns the exponent for the graph model, checking both are valid
+func graphExponent(opts Options) (float64, error) {
+	switch opts.GraphModel {
+	case GraphUniform:
+		return 0, nil
+	case GraphZipf:
+		if opts.Exponent == 0 {
+			return 1, nil
+		}
+		if opts.Exponent < 0 {
+			return 0, fmt.Errorf("zipf exponent must be positive, got %g", opts.Exponent)
+		}
+		return opts.Exponent, nil
+	case GraphBarabasiAlbert:
+		if opts.Exponent == 0 {
+			return 3, nil
+		}
+		if opts.Exponent <= 2 {
+			return 0, fmt.Errorf("barabasi-albert exponent must be greater than 2, got %g", opts.Exponent)
+		}
+		return opts.Exponent, nil
+	default:
+		return 0, fmt.Errorf("unknown graph model %q (expected one of %s)", opts.GraphModel, strings.Join(GraphModels(), ", "))
+	}
+}
+
+// generateFollows builds the follow graph for n users according to opts.GraphModel
+func generateFollows(rng *rand.Rand, n int, opts Options) ([]edge, error) {
+	exponent, err := graphExponent(opts)
+	if err != nil {
+		return nil, err
+	}
+	if n < 2 || opts.AvgFollowers <= 0 {
+		return nil, nil
+	}
+
+	switch opts.GraphModel {
+	case GraphZipf:
+		return zipfFollows(rng, n, opts.AvgFollowers, exponent), nil
+	case GraphBarabasiAlbert:
+		return barabasiAlbertFollows(rng, n, opts.AvgFollowers, exponent), nil
+	default:
+		return uniformFollows(rng, n, opts), nil
+	}
+}
+
+// drawFollows draws n*avgFollowers follows whose followee comes from
+// pickFollowee and whose follower is uniform. A follower that already
+// follows the followee is redrawn a few times before the follow is dropped,
+// so the most popular users saturate instead of looping forever.
+func drawFollows(rng *rand.Rand, n, avgFollowers int, pickFollowee func() int) []edge {
+	total := n * avgFollowers
+	set := newEdgeSet(n, total)
+	for i := 0; i < total; i++ {
+		followee := pickFollowee()
+		for try := 0; try < maxFollowerRetries; try++ {
+			if set.add(rng.Intn(n), followee) {
+				break
+			}
+		}
+	}
+	return set.edges
+}
+
+func uniformFollows(rng *rand.Rand, n int, opts Options) []edge {
+	celebrities := min(opts.Celebrities, n)
+	return drawFollows(rng, n, opts.AvgFollowers, func() int {
+		// Celebrities have higher chance of being followed
+		if celebrities > 0 && rng.Float64() < 0.3 {
+			return rng.Intn(celebrities)
+		}
+		return rng.Intn(n)
+	})
+}
+
+// zipfFollows ranks users by index: user 0 is the most followed
+func zipfFollows(rng *rand.Rand, n, avgFollowers int, s float64) []edge {
+	// Cumulative weights, sampled by binary search. Unlike rand.Zipf this
+	// allows exponents of 1 and below.
+	cumulative := make([]float64, n)
+	total := 0.0
+	for k := 0; k < n; k++ {
+		total += 1 / math.Pow(float64(k+1), s)
+		cumulative[k] = total
+	}
+
+	return drawFollows(rng, n, avgFollowers, func() int {
+		return sort.SearchFloat64s(cumulative, rng.Float64()*total)
+	})
+}
+
+// barabasiAlbertFollows implements Price's model, the directed form of
+// Barabási-Albert: user i follows m of users 0..i-1, each chosen with
+// probability proportional to its follower count plus a. Follower counts then
+// follow a power law with exponent 2 + a/m, so a is derived from gamma.
+// Users follow only those who joined before them, so the earliest users are
+// the most followed and follow the fewest.
+func barabasiAlbertFollows(rng *rand.Rand, n, m int, gamma float64) []edge {
+	a := (gamma - 2) * float64(m)
+	set := newEdgeSet(n, n*m)
+
+	// Every follow's followee, so a uniform pick is proportional to follower count
+	var targets []int
+
+	for i := 1; i < n; i++ {
+		want := min(m, i)
+		added := 0
+		for attempts := 0; added < want && attempts < want*maxFollowerRetries; attempts++ {
+			// Total weight is len(targets) + a*i; pick the attachment term
+			// with probability len(targets) / total
+			var followee int
+			if len(targets) > 0 && rng.Float64()*(float64(len(targets))+a*float64(i)) < float64(len(targets)) {
+				followee = targets[rng.Intn(len(targets))]
+			} else {
+				followee = rng.Intn(i)
+			}
+			if set.add(i, followee) {
+				added++
+			}
+		}
+
+		// Make this user's follows visible to later users
+		for _, e := range set.edges[len(set.edges)-added:] {
+			targets = append(targets, e.followee)
+		}
+	}
+	return set.edges
+}
+
+// followerCounts returns the number of followers of each of n users
+func followerCounts(n int, edges []edge) []int {
+	followers := make([]int, n)
+	for _, e := range edges {
+		followers[e.followee]++
+	}
+	return followers
+}
+
+// printDegreeStats writes follower-count percentiles and a histogram with
+// power-of-two buckets. It sorts followers in place.
+func printDegreeStats(out io.Writer, followers []int) {
+	n := len(followers)
+	if n == 0 {
+		return
+	}
+	sort.Ints(followers)
+
+	at := func(p float64) int {
+		idx := int(p / 100 * float64(n))
+		if idx >= n {
+			idx = n - 1
+		}
+		return followers[idx]
+	}
+
+	fmt.Fprintf(out, "📈 Follower counts: p50=%d p90=%d p99=%d p99.9=%d max=%d\n",
+		at(50), at(90), at(99), at(99.9), followers[n-1])
+
+	// Bucket 0 holds users with no followers; bucket b >= 1 holds [2^(b-1), 2^b)
+	var buckets []int
+	for _, f := range followers {
+		b := 0
+		for f > 0 {
+			b++
+			f >>= 1
+		}
+		for len(buckets) <= b {
+			buckets = append(buckets, 0)
+		}
+		buckets[b]++
+	}
+
+	largest := 0
+	for _, c := range buckets {
+		largest = max(largest, c)
+	}
+
+	const barWidth = 40
+	for b, c := range buckets {
+		label := "0"
+		if b > 0 {
+			lo, hi := 1<<(b-1), 1<<b-1
+			label = fmt.Sprintf("%d", lo)
+			if hi > lo {
+				label = fmt.Sprintf("%d-%d", lo, hi)
+			}
+		}
+		bar := 0
+		if c > 0 {
+			bar = max(1, c*barWidth/largest)
+		}
+		fmt.Fprintf(out, "   %13s │ %-*s %d\n", label, barWidth, strings.Repeat("█", bar), c)
+	}
+}
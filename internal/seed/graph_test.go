@@ -0,0 +1,165 @@
+package seed
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ritik/twitter-fan-out/internal/memory"
+)
+
+func TestGraphExponent(t *testing.T) {
+	tests := []struct {
+		model    string
+		exponent float64
+		want     float64
+		err      bool
+	}{
+		{GraphUniform, 0, 0, false},
+		{GraphZipf, 0, 1, false},
+		{GraphZipf, 0.8, 0.8, false},
+		{GraphZipf, -1, 0, true},
+		{GraphBarabasiAlbert, 0, 3, false},
+		{GraphBarabasiAlbert, 2.5, 2.5, false},
+		{GraphBarabasiAlbert, 2, 0, true},
+		{"smallworld", 0, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := graphExponent(Options{GraphModel: tt.model, Exponent: tt.exponent})
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("graphExponent(%s, %g) = %g, %v; want %g, error %v", tt.model, tt.exponent, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestGenerateFollows(t *testing.T) {
+	const n, avg = 2000, 10
+	for _, model := range GraphModels() {
+		t.Run(model, func(t *testing.T) {
+			opts := Options{GraphModel: model, AvgFollowers: avg, Celebrities: 20}
+			edges, err := generateFollows(rand.New(rand.NewSource(1)), n, opts)
+			if err != nil {
+				t.Fatalf("generateFollows: %v", err)
+			}
+
+			seen := make(map[edge]bool, len(edges))
+			for _, e := range edges {
+				if e.follower == e.followee {
+					t.Fatalf("self-follow %+v", e)
+				}
+				if seen[e] {
+					t.Fatalf("duplicate follow %+v", e)
+				}
+				seen[e] = true
+				if e.follower < 0 || e.follower >= n || e.followee < 0 || e.followee >= n {
+					t.Fatalf("follow %+v out of range", e)
+				}
+			}
+			// Saturated popular users drop a few draws, but not many
+			if len(edges) < n*avg*9/10 || len(edges) > n*avg {
+				t.Errorf("%d follows, want close to %d", len(edges), n*avg)
+			}
+
+			again, _ := generateFollows(rand.New(rand.NewSource(1)), n, opts)
+			if !reflect.DeepEqual(edges, again) {
+				t.Error("the same seed generated a different graph")
+			}
+		})
+	}
+}
+
+// The skewed models give the earliest users far more followers than the median user
+func TestGraphModelsAreSkewed(t *testing.T) {
+	const n, avg = 5000, 10
+	for _, model := range []string{GraphZipf, GraphBarabasiAlbert} {
+		edges, err := generateFollows(rand.New(rand.NewSource(7)), n, Options{GraphModel: model, AvgFollowers: avg})
+		if err != nil {
+			t.Fatalf("%s: %v", model, err)
+		}
+		followers := followerCounts(n, edges)
+		top := followers[0]
+		sorted := append([]int(nil), followers...)
+		sort.Ints(sorted)
+		median := sorted[n/2]
+		if top < 20*max(median, 1) {
+			t.Errorf("%s: user 0 has %d followers against a median of %d, want a heavy head", model, top, median)
+		}
+	}
+}
+
+func TestBarabasiAlbertFollowsEarlierUsers(t *testing.T) {
+	const n, m = 500, 4
+	edges := barabasiAlbertFollows(rand.New(rand.NewSource(3)), n, m, 3)
+
+	following := make([]int, n)
+	for _, e := range edges {
+		if e.followee >= e.follower {
+			t.Fatalf("user %d follows later user %d", e.follower, e.followee)
+		}
+		following[e.follower]++
+	}
+	for i, got := range following {
+		if want := min(m, i); got != want {
+			t.Fatalf("user %d follows %d users, want %d", i, got, want)
+		}
+	}
+}
+
+func TestGenerateFollowsWithNothingToDo(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	if edges, err := generateFollows(rng, 1, Options{GraphModel: GraphZipf, AvgFollowers: 5}); err != nil || len(edges) != 0 {
+		t.Errorf("one user: %d follows, %v; want none", len(edges), err)
+	}
+	if edges, err := generateFollows(rng, 100, Options{GraphModel: GraphZipf}); err != nil || len(edges) != 0 {
+		t.Errorf("no followers wanted: %d follows, %v; want none", len(edges), err)
+	}
+}
+
+// The same seed and options give the same data set
+func TestRunIsReproducible(t *testing.T) {
+	graph := func() map[int64][]int64 {
+		db := memory.NewDB()
+		stores := Stores{
+			Users:   memory.NewUserRepository(db),
+			Tweets:  memory.NewTweetRepository(db),
+			Follows: memory.NewFollowRepository(db),
+		}
+		opts := DefaultOptions()
+		opts.Users = 200
+		opts.AvgFollowers = 5
+		opts.TweetsPerUser = 1
+		opts.RandSeed = 42
+		if err := Run(context.Background(), stores, opts, io.Discard); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		users, _ := stores.Users.GetAll(context.Background(), opts.Users, 0)
+		following := make(map[int64][]int64, len(users))
+		for _, u := range users {
+			following[u.ID], _ = stores.Follows.GetFollowing(context.Background(), u.ID)
+		}
+		return following
+	}
+
+	first := graph()
+	if len(first) != 200 {
+		t.Fatalf("seeded %d users, want 200", len(first))
+	}
+	if second := graph(); !reflect.DeepEqual(first, second) {
+		t.Error("the same seed produced a different follow graph")
+	}
+}
+
+func TestPrintDegreeStats(t *testing.T) {
+	var out strings.Builder
+	printDegreeStats(&out, []int{0, 0, 1, 2, 3, 8})
+	got := out.String()
+	for _, want := range []string{"p50=2", "max=8", "0 │", "1 │", "2-3 │", "8-15 │"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("degree stats missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package seed
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Edge-list formats accepted by Import
+const (
+	// FormatSNAP is a SNAP edge list: one "follower followee" pair per line,
+	// separated by whitespace, with # comments
+	FormatSNAP = "snap-edgelist"
+	// FormatCSV is a comma-separated file whose first two columns are the
+	// follower and followee. A header row naming the columns (follower/source,
+	// followee/target) is detected and may put them in any position.
+	FormatCSV = "csv"
+)
+
+// ImportFormats returns the supported edge-list formats
+func ImportFormats() []string {
+	return []string{FormatSNAP, FormatCSV}
+}
+
+// maxUsernameLength matches users.username
+const maxUsernameLength = 50
+
+// ImportOptions controls how an edge-list file is loaded
+type ImportOptions struct {
+	Path             string // Edge-list file; a .gz suffix is decompressed
+	Format           string // One of ImportFormats()
+	Reverse          bool   // Edges point from followee to follower
+	UsernamePrefix   string // Prepended to each node ID to form its username
+	TweetsPerUser    int    // Mean tweets per user
+	Activity         string // One of ActivityModels()
+	ActivityExponent float64
+	BatchSize        int
+	RandSeed         int64 // Same seed and options give the same tweets; 0 picks one
+}
+
+// DefaultImportOptions returns the defaults used by `fanout seed import`
+func DefaultImportOptions() ImportOptions {
+	return ImportOptions{
+		Format:         FormatSNAP,
+		UsernamePrefix: "node_",
+		TweetsPerUser:  10,
+		Activity:       ActivityPoisson,
+		BatchSize:      10000,
+	}
+}
+
+// Import loads the follow graph in an edge-list file into the stores, creating
+// a user for each node, then generates tweets for every imported user. The
+// file is streamed twice - once to find the nodes and once to write the
+// follows - so only the node IDs are held in memory, never the edges.
+// Self-follows are skipped; duplicate edges are written once.
+func Import(ctx context.Context, stores Stores, opts ImportOptions, out io.Writer) error {
+	defaults := DefaultImportOptions()
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaults.BatchSize
+	}
+	if opts.Format == "" {
+		opts.Format = defaults.Format
+	}
+	if opts.Format != FormatSNAP && opts.Format != FormatCSV {
+		return fmt.Errorf("unknown format %q (expected one of %s)", opts.Format, strings.Join(ImportFormats(), ", "))
+	}
+	if opts.Activity == "" {
+		opts.Activity = ActivityConstant
+	}
+	tweetCount, err := activityCounter(opts.Activity, opts.TweetsPerUser, opts.ActivityExponent)
+	if err != nil {
+		return err
+	}
+
+	if opts.RandSeed == 0 {
+		opts.RandSeed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(opts.RandSeed))
+	fmt.Fprintf(out, "🎲 Random seed: %d\n", opts.RandSeed)
+
+	// 1. Find the distinct nodes, in order of first appearance
+	fmt.Fprintf(out, "📂 Reading nodes from %s...\n", opts.Path)
+	start := time.Now()
+
+	var nodes []string
+	index := make(map[string]int)
+	edges, selfFollows := 0, 0
+	err = forEachEdge(opts, func(follower, followee string) error {
+		if follower == followee {
+			selfFollows++
+			return nil
+		}
+		for _, node := range [2]string{follower, followee} {
+			if _, ok := index[node]; !ok {
+				if len(opts.UsernamePrefix)+len(node) > maxUsernameLength {
+					return fmt.Errorf("username for node %q is longer than %d characters", node, maxUsernameLength)
+				}
+				index[node] = len(nodes)
+				nodes = append(nodes, node)
+			}
+		}
+		edges++
+		if edges%100000 == 0 {
+			fmt.Fprintf(out, "   Read %d edges\r", edges)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "   Found %d nodes and %d edges in %v\n", len(nodes), edges, time.Since(start))
+	if selfFollows > 0 {
+		fmt.Fprintf(out, "   Skipped %d self-follows\n", selfFollows)
+	}
+
+	// 2. Create a user per node and look up the IDs they were given
+	fmt.Fprintf(out, "👤 Creating %d users...\n", len(nodes))
+	start = time.Now()
+
+	userIDs := make([]int64, len(nodes))
+	for i := 0; i < len(nodes); i += opts.BatchSize {
+		end := min(i+opts.BatchSize, len(nodes))
+		usernames := usernamesFor(nodes[i:end], opts.UsernamePrefix)
+
+		if err := stores.Users.BulkCreate(ctx, usernames); err != nil {
+			return fmt.Errorf("failed to create users: %w", err)
+		}
+		users, err := stores.Users.GetByUsernames(ctx, usernames)
+		if err != nil {
+			return err
+		}
+		if len(users) != len(usernames) {
+			return fmt.Errorf("created %d users but found %d", len(usernames), len(users))
+		}
+		for _, user := range users {
+			userIDs[index[strings.TrimPrefix(user.Username, opts.UsernamePrefix)]] = user.ID
+		}
+		fmt.Fprintf(out, "   Created %d/%d users\r", end, len(nodes))
+	}
+	fmt.Fprintf(out, "   Created %d users in %v\n", len(nodes), time.Since(start))
+
+	// 3. Stream the edges again, writing follows a batch at a time. Duplicate
+	// edges are dropped by BulkCreate, so what was created is counted from
+	// the store afterwards rather than from the file.
+	fmt.Fprintf(out, "🔗 Creating follows from %d edges...\n", edges)
+	start = time.Now()
+
+	existing, err := stores.Follows.Count(ctx)
+	if err != nil {
+		return err
+	}
+	batch := make([]struct {
+		FollowerID int64
+		FolloweeID int64
+	}, 0, opts.BatchSize)
+	written := 0
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := stores.Follows.BulkCreate(ctx, batch); err != nil {
+			fmt.Fprintf(out, "⚠️  Warning: Some follows failed: %v\n", err)
+		}
+		written += len(batch)
+		batch = batch[:0]
+		fmt.Fprintf(out, "   Wrote %d/%d edges\r", written, edges)
+	}
+
+	err = forEachEdge(opts, func(follower, followee string) error {
+		if follower == followee {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		from, to := index[follower], index[followee]
+		batch = append(batch, struct {
+			FollowerID int64
+			FolloweeID int64
+		}{FollowerID: userIDs[from], FolloweeID: userIDs[to]})
+		if len(batch) == opts.BatchSize {
+			flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	flush()
+	total, err := stores.Follows.Count(ctx)
+	if err != nil {
+		return err
+	}
+	created := total - existing
+	fmt.Fprintf(out, "   Created %d follows in %v\n", created, time.Since(start))
+	if duplicates := edges - created; duplicates > 0 {
+		fmt.Fprintf(out, "   Skipped %d duplicate or existing follows\n", duplicates)
+	}
+
+	// The follower counts the store keeps only count each follow once
+	followers := make([]int, len(nodes))
+	for i := 0; i < len(nodes); i += opts.BatchSize {
+		end := min(i+opts.BatchSize, len(nodes))
+		usernames := usernamesFor(nodes[i:end], opts.UsernamePrefix)
+		users, err := stores.Users.GetByUsernames(ctx, usernames)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			followers[index[strings.TrimPrefix(user.Username, opts.UsernamePrefix)]] = user.FollowerCount
+		}
+	}
+	printDegreeStats(out, followers)
+
+	// 4. Generate tweets with the requested activity distribution
+	fmt.Fprintf(out, "   Activity: %s, mean %d tweets per user\n", opts.Activity, opts.TweetsPerUser)
+	return createTweets(ctx, stores, userIDs, tweetCount, rng, opts.BatchSize, out)
+}
+
+// usernamesFor returns the username of each node
+func usernamesFor(nodes []string, prefix string) []string {
+	usernames := make([]string, len(nodes))
+	for i, node := range nodes {
+		usernames[i] = prefix + node
+	}
+	return usernames
+}
+
+// edgeReader reads follower, followee node ID pairs, returning io.EOF at the end
+type edgeReader interface {
+	next() (string, string, error)
+}
+
+// forEachEdge opens the file in opts and calls fn for every edge in it, in
+// file order, with the follower first
+func forEachEdge(opts ImportOptions, fn func(follower, followee string) error) error {
+	f, err := os.Open(opts.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open edge list: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReaderSize(f, 1<<20)
+	if strings.HasSuffix(opts.Path, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("failed to open edge list: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var edges edgeReader
+	if opts.Format == FormatCSV {
+		edges = newCSVEdgeReader(r)
+	} else {
+		edges = newSNAPEdgeReader(r)
+	}
+
+	for {
+		from, to, err := edges.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", opts.Path, err)
+		}
+		if opts.Reverse {
+			from, to = to, from
+		}
+		if err := fn(from, to); err != nil {
+			return err
+		}
+	}
+}
+
+// snapEdgeReader reads whitespace-separated pairs, skipping blank lines and
+// # or % comments. Columns after the first two (such as weights) are ignored.
+type snapEdgeReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newSNAPEdgeReader(r io.Reader) *snapEdgeReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	return &snapEdgeReader{scanner: scanner}
+}
+
+func (r *snapEdgeReader) next() (string, string, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '%' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return "", "", fmt.Errorf("line %d: expected two node IDs, got %q", r.line, line)
+		}
+		return fields[0], fields[1], nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", "", err
+	}
+	return "", "", io.EOF
+}
+
+// csvEdgeReader reads the follower and followee columns of a CSV file
+type csvEdgeReader struct {
+	reader   *csv.Reader
+	started  bool
+	from, to int
+	pending  []string
+}
+
+func newCSVEdgeReader(r io.Reader) *csvEdgeReader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	return &csvEdgeReader{reader: reader, from: 0, to: 1}
+}
+
+// Header names recognized for each column
+var (
+	csvFollowerColumns = []string{"follower", "follower_id", "source", "src", "from"}
+	csvFolloweeColumns = []string{"followee", "followee_id", "target", "dst", "to"}
+)
+
+// readHeader looks at the first row. A row naming the columns, or one whose
+// first two fields aren't both integers, is taken as a header; otherwise it
+// is the first edge.
+func (r *csvEdgeReader) readHeader() error {
+	r.started = true
+	record, err := r.reader.Read()
+	if err != nil {
+		return err
+	}
+
+	named := false
+	for i, field := range record {
+		name := strings.ToLower(strings.TrimSpace(field))
+		for _, c := range csvFollowerColumns {
+			if name == c {
+				r.from, named = i, true
+			}
+		}
+		for _, c := range csvFolloweeColumns {
+			if name == c {
+				r.to, named = i, true
+			}
+		}
+	}
+	if named {
+		return nil
+	}
+
+	if len(record) >= 2 {
+		_, errFrom := strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+		_, errTo := strconv.ParseInt(strings.TrimSpace(record[1]), 10, 64)
+		if errFrom != nil || errTo != nil {
+			return nil
+		}
+	}
+	r.pending = record
+	return nil
+}
+
+func (r *csvEdgeReader) next() (string, string, error) {
+	if !r.started {
+		if err := r.readHeader(); err != nil {
+			return "", "", err
+		}
+	}
+
+	record := r.pending
+	r.pending = nil
+	if record == nil {
+		var err error
+		if record, err = r.reader.Read(); err != nil {
+			return "", "", err
+		}
+	}
+
+	if len(record) <= max(r.from, r.to) {
+		line, _ := r.reader.FieldPos(0)
+		return "", "", fmt.Errorf("line %d: expected at least %d columns, got %d", line, max(r.from, r.to)+1, len(record))
+	}
+	from, to := strings.TrimSpace(record[r.from]), strings.TrimSpace(record[r.to])
+	if from == "" || to == "" {
+		line, _ := r.reader.FieldPos(0)
+		return "", "", fmt.Errorf("line %d: empty node ID", line)
+	}
+	return from, to, nil
+}
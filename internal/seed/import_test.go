@@ -0,0 +1,383 @@
+package seed
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ritik/twitter-fan-out/internal/memory"
+)
+
+// readEdges drains r, returning the edges it read and the error it stopped on
+// (nil at a clean EOF)
+func readEdges(r edgeReader) ([][2]string, error) {
+	var edges [][2]string
+	for {
+		from, to, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return edges, nil
+		}
+		if err != nil {
+			return edges, err
+		}
+		edges = append(edges, [2]string{from, to})
+	}
+}
+
+func TestSNAPEdgeReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    [][2]string
+		wantErr string
+	}{
+		{
+			name:  "comments, blank lines and extra columns",
+			input: "# Directed graph\n% konect header\n\n1\t2\n  3 4 0.5\n\n5 6",
+			want:  [][2]string{{"1", "2"}, {"3", "4"}, {"5", "6"}},
+		},
+		{
+			name:  "node IDs need not be numeric",
+			input: "alice bob\n",
+			want:  [][2]string{{"alice", "bob"}},
+		},
+		{
+			name:    "a single column is reported with its line",
+			input:   "# header\n1 2\n3\n",
+			want:    [][2]string{{"1", "2"}},
+			wantErr: "line 3",
+		},
+		{
+			name:  "empty file",
+			input: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEdges(newSNAPEdgeReader(strings.NewReader(tt.input)))
+			checkEdges(t, got, err, tt.want, tt.wantErr)
+		})
+	}
+}
+
+func TestCSVEdgeReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    [][2]string
+		wantErr string
+	}{
+		{
+			name:  "no header",
+			input: "1,2\n3,4\n",
+			want:  [][2]string{{"1", "2"}, {"3", "4"}},
+		},
+		{
+			name:  "named columns in any position",
+			input: "weight,Target,source\n0.5,2,1\n0.1, 4 ,3\n",
+			want:  [][2]string{{"1", "2"}, {"3", "4"}},
+		},
+		{
+			name:  "named follower and followee columns",
+			input: "followee_id,follower_id\n2,1\n",
+			want:  [][2]string{{"1", "2"}},
+		},
+		{
+			name:  "an unnamed header that isn't numeric is skipped",
+			input: "a,b\n1,2\n",
+			want:  [][2]string{{"1", "2"}},
+		},
+		{
+			name:  "comments and extra columns",
+			input: "# exported edges\n1,2,2024-01-01\n",
+			want:  [][2]string{{"1", "2"}},
+		},
+		{
+			name:    "a row missing a named column",
+			input:   "weight,source,target\n1,2,3\n4,5\n",
+			want:    [][2]string{{"2", "3"}},
+			wantErr: "line 3",
+		},
+		{
+			name:    "an empty node ID",
+			input:   "1,2\n3,\n",
+			want:    [][2]string{{"1", "2"}},
+			wantErr: "empty node ID",
+		},
+		{
+			name:  "empty file",
+			input: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEdges(newCSVEdgeReader(strings.NewReader(tt.input)))
+			checkEdges(t, got, err, tt.want, tt.wantErr)
+		})
+	}
+}
+
+func checkEdges(t *testing.T, got [][2]string, err error, want [][2]string, wantErr string) {
+	t.Helper()
+	if wantErr == "" && err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+		t.Fatalf("error = %v, want one mentioning %q", err, wantErr)
+	}
+	if len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+		t.Errorf("edges = %v, want %v", got, want)
+	}
+}
+
+// writeEdgeList writes content to a file named name in a temporary directory,
+// gzipping it if the name ends in .gz
+func writeEdgeList(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	if strings.HasSuffix(name, ".gz") {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		w = gz
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestForEachEdge(t *testing.T) {
+	path := writeEdgeList(t, "edges.txt.gz", "1 2\n3 4\n")
+
+	collect := func(opts ImportOptions) [][2]string {
+		var edges [][2]string
+		err := forEachEdge(opts, func(follower, followee string) error {
+			edges = append(edges, [2]string{follower, followee})
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("forEachEdge: %v", err)
+		}
+		return edges
+	}
+
+	if got, want := collect(ImportOptions{Path: path, Format: FormatSNAP}), [][2]string{{"1", "2"}, {"3", "4"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("gzipped edges = %v, want %v", got, want)
+	}
+	if got, want := collect(ImportOptions{Path: path, Format: FormatSNAP, Reverse: true}), [][2]string{{"2", "1"}, {"4", "3"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reversed edges = %v, want %v", got, want)
+	}
+
+	// An error from the callback stops the walk and is returned as is
+	stop := errors.New("stop")
+	calls := 0
+	err := forEachEdge(ImportOptions{Path: path}, func(string, string) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("forEachEdge = %v after %d calls, want the callback's error after 1", err, calls)
+	}
+
+	if err := forEachEdge(ImportOptions{Path: filepath.Join(t.TempDir(), "missing")}, nil); err == nil {
+		t.Error("forEachEdge on a missing file succeeded")
+	}
+}
+
+func newImportStores() Stores {
+	db := memory.NewDB()
+	return Stores{
+		Users:   memory.NewUserRepository(db),
+		Tweets:  memory.NewTweetRepository(db),
+		Follows: memory.NewFollowRepository(db),
+	}
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	stores := newImportStores()
+	path := writeEdgeList(t, "edges.csv", "source,target\n10,20\n10,30\n20,30\n30,30\n10,20\n30,10\n")
+
+	opts := ImportOptions{
+		Path:           path,
+		Format:         FormatCSV,
+		UsernamePrefix: "n_",
+		TweetsPerUser:  2,
+		Activity:       ActivityConstant,
+		BatchSize:      2, // Smaller than the file, so several batches are written
+		RandSeed:       1,
+	}
+	if err := Import(ctx, stores, opts, io.Discard); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	users, err := stores.Users.GetByUsernames(ctx, []string{"n_10", "n_20", "n_30"})
+	if err != nil || len(users) != 3 {
+		t.Fatalf("found %d imported users (%v), want 3", len(users), err)
+	}
+	if n, _ := stores.Users.Count(ctx); n != 3 {
+		t.Errorf("created %d users, want 3", n)
+	}
+	id := make(map[string]int64, len(users))
+	for _, u := range users {
+		id[strings.TrimPrefix(u.Username, "n_")] = u.ID
+	}
+
+	// The self-follow is skipped and the duplicate edge written once
+	want := map[string][]string{"10": {"20", "30"}, "20": {"30"}, "30": {"10"}}
+	for follower, followees := range want {
+		got, err := stores.Follows.GetFollowing(ctx, id[follower])
+		if err != nil {
+			t.Fatalf("GetFollowing: %v", err)
+		}
+		wantIDs := make(map[int64]bool, len(followees))
+		for _, f := range followees {
+			wantIDs[id[f]] = true
+		}
+		if len(got) != len(wantIDs) {
+			t.Errorf("node %s follows %v, want %v", follower, got, followees)
+			continue
+		}
+		for _, g := range got {
+			if !wantIDs[g] {
+				t.Errorf("node %s follows %v, want %v", follower, got, followees)
+				break
+			}
+		}
+	}
+	if n, _ := stores.Follows.Count(ctx); n != 4 {
+		t.Errorf("created %d follows, want 4", n)
+	}
+	if n, _ := stores.Tweets.Count(ctx); n != 6 {
+		t.Errorf("created %d tweets, want 2 for each of 3 users", n)
+	}
+}
+
+// Repeated edges are only counted once in the summary and degree stats
+func TestImportCountsDuplicateEdgesOnce(t *testing.T) {
+	stores := newImportStores()
+	path := writeEdgeList(t, "edges.txt", "1 2\n1 2\n1 2\n3 2\n")
+
+	var out strings.Builder
+	opts := ImportOptions{Path: path, Format: FormatSNAP, BatchSize: 2, RandSeed: 1}
+	if err := Import(context.Background(), stores, opts, &out); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	for _, want := range []string{"Created 2 follows", "Skipped 2 duplicate", "max=2\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output doesn't mention %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestImportRejectsBadInput(t *testing.T) {
+	path := writeEdgeList(t, "edges.txt", "1 "+strings.Repeat("9", maxUsernameLength)+"\n")
+
+	tests := []struct {
+		name    string
+		opts    ImportOptions
+		wantErr string
+	}{
+		{"unknown format", ImportOptions{Path: path, Format: "graphml"}, "unknown format"},
+		{"unknown activity", ImportOptions{Path: path, Activity: "bursty"}, "unknown activity"},
+		{"username too long", ImportOptions{Path: path, UsernamePrefix: "node_"}, "longer than"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := newImportStores()
+			err := Import(context.Background(), stores, tt.opts, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Import = %v, want an error mentioning %q", err, tt.wantErr)
+			}
+			if n, _ := stores.Users.Count(context.Background()); n != 0 {
+				t.Errorf("created %d users before failing, want 0", n)
+			}
+		})
+	}
+}
+
+func TestActivityCounter(t *testing.T) {
+	const draws = 20000
+
+	tests := []struct {
+		model    string
+		mean     int
+		exponent float64
+	}{
+		{ActivityConstant, 7, 0},
+		{ActivityPoisson, 5, 0},
+		{ActivityPoisson, 50, 0}, // Normal approximation
+		{ActivityPareto, 10, 0},
+		{ActivityPareto, 10, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			count, err := activityCounter(tt.model, tt.mean, tt.exponent)
+			if err != nil {
+				t.Fatalf("activityCounter: %v", err)
+			}
+			rng := rand.New(rand.NewSource(1))
+			total, largest := 0, 0
+			for i := 0; i < draws; i++ {
+				n := count(rng)
+				if n < 0 {
+					t.Fatalf("drew a negative tweet count %d", n)
+				}
+				total += n
+				largest = max(largest, n)
+			}
+
+			mean := float64(total) / draws
+			if tt.model == ActivityConstant && (mean != float64(tt.mean) || largest != tt.mean) {
+				t.Errorf("constant activity drew a mean of %g and a max of %d, want exactly %d", mean, largest, tt.mean)
+			}
+			// Truncating and capping the Pareto draws pulls its mean down a little
+			if lo, hi := 0.85*float64(tt.mean), 1.05*float64(tt.mean); mean < lo || mean > hi {
+				t.Errorf("mean of %d draws = %g, want about %d", draws, mean, tt.mean)
+			}
+			if largest > tt.mean*maxActivityMultiple {
+				t.Errorf("drew %d tweets, more than the cap of %d", largest, tt.mean*maxActivityMultiple)
+			}
+		})
+	}
+}
+
+func TestActivityCounterZeroMean(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, model := range ActivityModels() {
+		count, err := activityCounter(model, 0, 0)
+		if err != nil {
+			t.Fatalf("activityCounter(%s): %v", model, err)
+		}
+		if n := count(rng); n != 0 {
+			t.Errorf("%s activity with a mean of 0 drew %d tweets", model, n)
+		}
+	}
+}
+
+func TestActivityCounterRejectsBadOptions(t *testing.T) {
+	if _, err := activityCounter(ActivityPareto, 10, 1); err == nil {
+		t.Error("a Pareto exponent of 1 was accepted")
+	}
+	if _, err := activityCounter("bursty", 10, 0); err == nil {
+		t.Error("an unknown activity model was accepted")
+	}
+}
@@ -0,0 +1,156 @@
+// Package seed generates test users, follows and tweets for benchmarking.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// Options controls the size and shape of the generated data
+type Options struct {
+	Users         int
+	AvgFollowers  int
+	Celebrities   int // Only used by the uniform graph model
+	TweetsPerUser int
+	BatchSize     int
+	GraphModel    string  // One of GraphModels()
+	Exponent      float64 // Skew of the graph model; 0 uses the model's default
+	RandSeed      int64   // Same seed and options give the same data set; 0 picks one
+}
+
+// DefaultOptions returns the defaults used by `fanout seed`
+func DefaultOptions() Options {
+	return Options{
+		Users:         10000,
+		AvgFollowers:  150,
+		Celebrities:   50,
+		TweetsPerUser: 10,
+		BatchSize:     10000,
+		GraphModel:    GraphZipf,
+	}
+}
+
+// Stores are the stores the generated data is written to
+type Stores struct {
+	Users   repository.UserStore
+	Tweets  repository.TweetStore
+	Follows repository.FollowStore
+}
+
+var sampleTweets = []string{
+	"Just had the best coffee! ☕",
+	"Working on something exciting...",
+	"Beautiful day outside! 🌞",
+	"Can't believe this happened today",
+	"Learning new things every day",
+	"Just finished a great book 📚",
+	"Thinking about the future...",
+	"Great meeting with the team today",
+	"Weekend vibes! 🎉",
+	"Grateful for all the support",
+	"New project coming soon!",
+	"Just hit a major milestone 🎯",
+	"Coffee and code, perfect combo",
+	"Exploring new ideas today",
+	"Thankful for this community",
+}
+
+// Run creates users, follow relationships and tweets, writing progress to out
+func Run(ctx context.Context, stores Stores, opts Options, out io.Writer) error {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultOptions().BatchSize
+	}
+	batchSize := opts.BatchSize
+
+	if opts.GraphModel == "" {
+		opts.GraphModel = GraphZipf
+	}
+	if _, err := graphExponent(opts); err != nil {
+		return err
+	}
+
+	if opts.RandSeed == 0 {
+		opts.RandSeed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(opts.RandSeed))
+	fmt.Fprintf(out, "🎲 Random seed: %d\n", opts.RandSeed)
+
+	// Seed users
+	fmt.Fprintf(out, "👤 Creating %d users...\n", opts.Users)
+	start := time.Now()
+
+	usernames := make([]string, opts.Users)
+	for i := 0; i < opts.Users; i++ {
+		usernames[i] = fmt.Sprintf("user_%d", i+1)
+	}
+
+	// Batch create users
+	for i := 0; i < len(usernames); i += batchSize {
+		end := i + batchSize
+		if end > len(usernames) {
+			end = len(usernames)
+		}
+		if err := stores.Users.BulkCreate(ctx, usernames[i:end]); err != nil {
+			return fmt.Errorf("failed to create users: %w", err)
+		}
+		fmt.Fprintf(out, "   Created %d/%d users\r", end, opts.Users)
+	}
+	fmt.Fprintf(out, "   Created %d users in %v\n", opts.Users, time.Since(start))
+
+	// Get all users for follow relationships
+	users, err := stores.Users.GetAll(ctx, opts.Users, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	// Create follow relationships
+	fmt.Fprintf(out, "🔗 Creating follow relationships (%s graph)...\n", opts.GraphModel)
+	start = time.Now()
+
+	edges, err := generateFollows(rng, len(users), opts)
+	if err != nil {
+		return err
+	}
+
+	// Batch create follows, converting one batch of edges at a time so the
+	// graph isn't held twice
+	follows := make([]struct {
+		FollowerID int64
+		FolloweeID int64
+	}, 0, min(batchSize, len(edges)))
+	for i := 0; i < len(edges); i += batchSize {
+		end := i + batchSize
+		if end > len(edges) {
+			end = len(edges)
+		}
+		follows = follows[:0]
+		for _, e := range edges[i:end] {
+			follows = append(follows, struct {
+				FollowerID int64
+				FolloweeID int64
+			}{users[e.follower].ID, users[e.followee].ID})
+		}
+		if err := stores.Follows.BulkCreate(ctx, follows); err != nil {
+			fmt.Fprintf(out, "⚠️  Warning: Some follows failed: %v\n", err)
+		}
+		fmt.Fprintf(out, "   Created %d/%d follows\r", end, len(edges))
+	}
+	fmt.Fprintf(out, "   Created %d follows in %v\n", len(edges), time.Since(start))
+	printDegreeStats(out, followerCounts(len(users), edges))
+
+	// Create tweets
+	userIDs := make([]int64, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	tweetCount, _ := activityCounter(ActivityConstant, opts.TweetsPerUser, 0)
+	return createTweets(ctx, stores, userIDs, tweetCount, rng, batchSize, out)
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/memory"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// EnableEvents makes the stores announce new timeline items on an event bus:
+// fan-out writes to the timeline cache, and every new tweet on its author's
+// channel. It's opt-in so the CLI and benchmarks don't pay for publishing.
+func (s *Stores) EnableEvents() cache.EventBus {
+	if s.Events != nil {
+		return s.Events
+	}
+
+	if s.Redis != nil {
+		s.Events = cache.NewRedisEventBus(s.Redis)
+	} else {
+		s.Events = memory.NewEventBus()
+	}
+	s.Cache = cache.WithDeliveryEvents(s.Cache, s.Events)
+	s.Tweets = &eventTweetStore{TweetStore: s.Tweets, events: s.Events}
+	return s.Events
+}
+
+// eventTweetStore publishes every tweet, reply and retweet it creates on
+// the author's channel
+type eventTweetStore struct {
+	repository.TweetStore
+	events cache.EventBus
+}
+
+func (s *eventTweetStore) Create(ctx context.Context, userID int64, content string) (*models.Tweet, error) {
+	tweet, err := s.TweetStore.Create(ctx, userID, content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.events.PublishPosted(ctx, tweet); err != nil {
+		fmt.Printf("Warning: failed to publish tweet: %v\n", err)
+	}
+	return tweet, nil
+}
+
+func (s *eventTweetStore) CreateReply(ctx context.Context, userID, inReplyToID int64, content string) (*models.Tweet, error) {
+	tweet, err := s.TweetStore.CreateReply(ctx, userID, inReplyToID, content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.events.PublishPosted(ctx, tweet); err != nil {
+		fmt.Printf("Warning: failed to publish reply: %v\n", err)
+	}
+	return tweet, nil
+}
+
+func (s *eventTweetStore) Retweet(ctx context.Context, userID, tweetID int64) (*models.Tweet, error) {
+	tweet, err := s.TweetStore.Retweet(ctx, userID, tweetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.events.PublishPosted(ctx, tweet); err != nil {
+		fmt.Printf("Warning: failed to publish retweet: %v\n", err)
+	}
+	return tweet, nil
+}
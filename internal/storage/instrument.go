@@ -0,0 +1,451 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+var (
+	dbCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fanout_db_calls_total",
+		Help: "Calls to the user, tweet, follow, follow request, block and list stores.",
+	}, []string{"backend", "store", "method", "status"})
+	redisCommands = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fanout_redis_commands_total",
+		Help: "Redis commands sent, including those inside pipelines.",
+	}, []string{"command", "status"})
+	redisRoundTrips = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fanout_redis_round_trips_total",
+		Help: "Redis round trips: single commands or whole pipelines.",
+	}, []string{"kind"})
+)
+
+// callStatus labels the outcome of a store call
+func callStatus(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, sql.ErrNoRows):
+		return "not_found"
+	default:
+		return "error"
+	}
+}
+
+// counter counts calls to one store
+type counter struct {
+	backend string
+	store   string
+}
+
+func (c counter) count(method string, err *error) {
+	dbCalls.WithLabelValues(c.backend, c.store, method, callStatus(*err)).Inc()
+}
+
+// instrument wraps the user, tweet, follow, follow request, block and list
+// stores so every call is counted. The wrappers implement each method rather
+// than embedding the store, so a method added to an interface has to be
+// counted before it compiles.
+func instrument(s *Stores) {
+	s.Users = &userStore{next: s.Users, counter: counter{s.Backend, "users"}}
+	s.Tweets = &tweetStore{next: s.Tweets, counter: counter{s.Backend, "tweets"}}
+	s.Follows = &followStore{next: s.Follows, counter: counter{s.Backend, "follows"}}
+	s.Blocks = &blockStore{next: s.Blocks, counter: counter{s.Backend, "blocks"}}
+	s.FollowRequests = &followRequestStore{next: s.FollowRequests, counter: counter{s.Backend, "follow_requests"}}
+	s.Lists = &listStore{next: s.Lists, counter: counter{s.Backend, "lists"}}
+}
+
+var (
+	_ repository.UserStore          = (*userStore)(nil)
+	_ repository.TweetStore         = (*tweetStore)(nil)
+	_ repository.FollowStore        = (*followStore)(nil)
+	_ repository.FollowRequestStore = (*followRequestStore)(nil)
+	_ repository.BlockStore         = (*blockStore)(nil)
+	_ repository.ListStore          = (*listStore)(nil)
+)
+
+type userStore struct {
+	next repository.UserStore
+	counter
+}
+
+func (s *userStore) Create(ctx context.Context, username string) (_ *models.User, err error) {
+	defer s.count("Create", &err)
+	return s.next.Create(ctx, username)
+}
+
+func (s *userStore) GetByID(ctx context.Context, id int64) (_ *models.User, err error) {
+	defer s.count("GetByID", &err)
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *userStore) GetByUsername(ctx context.Context, username string) (_ *models.User, err error) {
+	defer s.count("GetByUsername", &err)
+	return s.next.GetByUsername(ctx, username)
+}
+
+func (s *userStore) GetByUsernames(ctx context.Context, usernames []string) (_ []*models.User, err error) {
+	defer s.count("GetByUsernames", &err)
+	return s.next.GetByUsernames(ctx, usernames)
+}
+
+func (s *userStore) GetAll(ctx context.Context, limit, offset int) (_ []*models.User, err error) {
+	defer s.count("GetAll", &err)
+	return s.next.GetAll(ctx, limit, offset)
+}
+
+func (s *userStore) GetCelebrities(ctx context.Context, threshold int) (_ []*models.User, err error) {
+	defer s.count("GetCelebrities", &err)
+	return s.next.GetCelebrities(ctx, threshold)
+}
+
+func (s *userStore) GetRandomUsers(ctx context.Context, count int) (_ []*models.User, err error) {
+	defer s.count("GetRandomUsers", &err)
+	return s.next.GetRandomUsers(ctx, count)
+}
+
+func (s *userStore) Count(ctx context.Context) (_ int, err error) {
+	defer s.count("Count", &err)
+	return s.next.Count(ctx)
+}
+
+func (s *userStore) CountCelebrities(ctx context.Context, threshold int) (_ int, err error) {
+	defer s.count("CountCelebrities", &err)
+	return s.next.CountCelebrities(ctx, threshold)
+}
+
+func (s *userStore) SetProtected(ctx context.Context, id int64, protected bool) (err error) {
+	defer s.count("SetProtected", &err)
+	return s.next.SetProtected(ctx, id, protected)
+}
+
+func (s *userStore) BulkCreate(ctx context.Context, usernames []string) (err error) {
+	defer s.count("BulkCreate", &err)
+	return s.next.BulkCreate(ctx, usernames)
+}
+
+func (s *userStore) Delete(ctx context.Context, id int64) (err error) {
+	defer s.count("Delete", &err)
+	return s.next.Delete(ctx, id)
+}
+
+func (s *userStore) Truncate(ctx context.Context) (err error) {
+	defer s.count("Truncate", &err)
+	return s.next.Truncate(ctx)
+}
+
+type tweetStore struct {
+	next repository.TweetStore
+	counter
+}
+
+func (s *tweetStore) Create(ctx context.Context, userID int64, content string) (_ *models.Tweet, err error) {
+	defer s.count("Create", &err)
+	return s.next.Create(ctx, userID, content)
+}
+
+func (s *tweetStore) GetByID(ctx context.Context, id int64) (_ *models.Tweet, err error) {
+	defer s.count("GetByID", &err)
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *tweetStore) GetByIDs(ctx context.Context, ids []int64) (_ []*models.Tweet, err error) {
+	defer s.count("GetByIDs", &err)
+	return s.next.GetByIDs(ctx, ids)
+}
+
+func (s *tweetStore) GetByUserID(ctx context.Context, userID int64, limit int) (_ []*models.Tweet, err error) {
+	defer s.count("GetByUserID", &err)
+	return s.next.GetByUserID(ctx, userID, limit)
+}
+
+func (s *tweetStore) GetByUserIDs(ctx context.Context, userIDs []int64, page models.Page) (_ []*models.Tweet, err error) {
+	defer s.count("GetByUserIDs", &err)
+	return s.next.GetByUserIDs(ctx, userIDs, page)
+}
+
+func (s *tweetStore) GetRecentByUserIDs(ctx context.Context, userIDs []int64, perUserLimit int, page models.Page) (_ []*models.Tweet, err error) {
+	defer s.count("GetRecentByUserIDs", &err)
+	return s.next.GetRecentByUserIDs(ctx, userIDs, perUserLimit, page)
+}
+
+func (s *tweetStore) CreateReply(ctx context.Context, userID, inReplyToID int64, content string) (_ *models.Tweet, err error) {
+	defer s.count("CreateReply", &err)
+	return s.next.CreateReply(ctx, userID, inReplyToID, content)
+}
+
+func (s *tweetStore) GetAncestors(ctx context.Context, tweetID int64) (_ []*models.Tweet, err error) {
+	defer s.count("GetAncestors", &err)
+	return s.next.GetAncestors(ctx, tweetID)
+}
+
+func (s *tweetStore) GetDescendants(ctx context.Context, tweetID int64, page models.Page) (_ []*models.Tweet, err error) {
+	defer s.count("GetDescendants", &err)
+	return s.next.GetDescendants(ctx, tweetID, page)
+}
+
+func (s *tweetStore) Retweet(ctx context.Context, userID, tweetID int64) (_ *models.Tweet, err error) {
+	defer s.count("Retweet", &err)
+	return s.next.Retweet(ctx, userID, tweetID)
+}
+
+func (s *tweetStore) GetRetweets(ctx context.Context, tweetID int64) (_ []*models.Tweet, err error) {
+	defer s.count("GetRetweets", &err)
+	return s.next.GetRetweets(ctx, tweetID)
+}
+
+func (s *tweetStore) Like(ctx context.Context, userID, tweetID int64) (_ int64, err error) {
+	defer s.count("Like", &err)
+	return s.next.Like(ctx, userID, tweetID)
+}
+
+func (s *tweetStore) Unlike(ctx context.Context, userID, tweetID int64) (_ int64, err error) {
+	defer s.count("Unlike", &err)
+	return s.next.Unlike(ctx, userID, tweetID)
+}
+
+func (s *tweetStore) GetCounts(ctx context.Context, tweetIDs []int64) (_ map[int64]models.TweetCounts, err error) {
+	defer s.count("GetCounts", &err)
+	return s.next.GetCounts(ctx, tweetIDs)
+}
+
+func (s *tweetStore) SetCounts(ctx context.Context, counts map[int64]models.TweetCounts) (err error) {
+	defer s.count("SetCounts", &err)
+	return s.next.SetCounts(ctx, counts)
+}
+
+func (s *tweetStore) Count(ctx context.Context) (_ int, err error) {
+	defer s.count("Count", &err)
+	return s.next.Count(ctx)
+}
+
+func (s *tweetStore) BulkCreate(ctx context.Context, tweets []struct {
+	UserID  int64
+	Content string
+}) (err error) {
+	defer s.count("BulkCreate", &err)
+	return s.next.BulkCreate(ctx, tweets)
+}
+
+func (s *tweetStore) Delete(ctx context.Context, id int64) (err error) {
+	defer s.count("Delete", &err)
+	return s.next.Delete(ctx, id)
+}
+
+func (s *tweetStore) Truncate(ctx context.Context) (err error) {
+	defer s.count("Truncate", &err)
+	return s.next.Truncate(ctx)
+}
+
+type followStore struct {
+	next repository.FollowStore
+	counter
+}
+
+func (s *followStore) Create(ctx context.Context, followerID, followeeID int64) (err error) {
+	defer s.count("Create", &err)
+	return s.next.Create(ctx, followerID, followeeID)
+}
+
+func (s *followStore) Delete(ctx context.Context, followerID, followeeID int64) (err error) {
+	defer s.count("Delete", &err)
+	return s.next.Delete(ctx, followerID, followeeID)
+}
+
+func (s *followStore) GetFollowers(ctx context.Context, userID int64) (_ []int64, err error) {
+	defer s.count("GetFollowers", &err)
+	return s.next.GetFollowers(ctx, userID)
+}
+
+func (s *followStore) GetFollowing(ctx context.Context, userID int64) (_ []int64, err error) {
+	defer s.count("GetFollowing", &err)
+	return s.next.GetFollowing(ctx, userID)
+}
+
+func (s *followStore) GetFollowingUsers(ctx context.Context, userID int64) (_ []*models.User, err error) {
+	defer s.count("GetFollowingUsers", &err)
+	return s.next.GetFollowingUsers(ctx, userID)
+}
+
+func (s *followStore) GetFollowingCelebrities(ctx context.Context, userID int64, threshold int) (_ []*models.User, err error) {
+	defer s.count("GetFollowingCelebrities", &err)
+	return s.next.GetFollowingCelebrities(ctx, userID, threshold)
+}
+
+func (s *followStore) GetFollowingNonCelebrities(ctx context.Context, userID int64, threshold int) (_ []int64, err error) {
+	defer s.count("GetFollowingNonCelebrities", &err)
+	return s.next.GetFollowingNonCelebrities(ctx, userID, threshold)
+}
+
+func (s *followStore) IsFollowing(ctx context.Context, followerID, followeeID int64) (_ bool, err error) {
+	defer s.count("IsFollowing", &err)
+	return s.next.IsFollowing(ctx, followerID, followeeID)
+}
+
+func (s *followStore) Count(ctx context.Context) (_ int, err error) {
+	defer s.count("Count", &err)
+	return s.next.Count(ctx)
+}
+
+func (s *followStore) BulkCreate(ctx context.Context, follows []struct {
+	FollowerID int64
+	FolloweeID int64
+}) (err error) {
+	defer s.count("BulkCreate", &err)
+	return s.next.BulkCreate(ctx, follows)
+}
+
+func (s *followStore) Truncate(ctx context.Context) (err error) {
+	defer s.count("Truncate", &err)
+	return s.next.Truncate(ctx)
+}
+
+type followRequestStore struct {
+	next repository.FollowRequestStore
+	counter
+}
+
+func (s *followRequestStore) Create(ctx context.Context, requesterID, targetID int64) (err error) {
+	defer s.count("Create", &err)
+	return s.next.Create(ctx, requesterID, targetID)
+}
+
+func (s *followRequestStore) Delete(ctx context.Context, requesterID, targetID int64) (err error) {
+	defer s.count("Delete", &err)
+	return s.next.Delete(ctx, requesterID, targetID)
+}
+
+func (s *followRequestStore) GetPending(ctx context.Context, targetID int64, limit int) (_ []*models.FollowRequest, err error) {
+	defer s.count("GetPending", &err)
+	return s.next.GetPending(ctx, targetID, limit)
+}
+
+func (s *followRequestStore) DeleteAll(ctx context.Context, targetID int64) (_ []int64, err error) {
+	defer s.count("DeleteAll", &err)
+	return s.next.DeleteAll(ctx, targetID)
+}
+
+type blockStore struct {
+	next repository.BlockStore
+	counter
+}
+
+func (s *blockStore) Block(ctx context.Context, blockerID, blockedID int64) (err error) {
+	defer s.count("Block", &err)
+	return s.next.Block(ctx, blockerID, blockedID)
+}
+
+func (s *blockStore) Unblock(ctx context.Context, blockerID, blockedID int64) (err error) {
+	defer s.count("Unblock", &err)
+	return s.next.Unblock(ctx, blockerID, blockedID)
+}
+
+func (s *blockStore) Mute(ctx context.Context, muterID, mutedID int64) (err error) {
+	defer s.count("Mute", &err)
+	return s.next.Mute(ctx, muterID, mutedID)
+}
+
+func (s *blockStore) Unmute(ctx context.Context, muterID, mutedID int64) (err error) {
+	defer s.count("Unmute", &err)
+	return s.next.Unmute(ctx, muterID, mutedID)
+}
+
+func (s *blockStore) GetHidden(ctx context.Context, viewerID int64) (_ []int64, err error) {
+	defer s.count("GetHidden", &err)
+	return s.next.GetHidden(ctx, viewerID)
+}
+
+func (s *blockStore) GetHiddenFrom(ctx context.Context, authorID int64) (_ []int64, err error) {
+	defer s.count("GetHiddenFrom", &err)
+	return s.next.GetHiddenFrom(ctx, authorID)
+}
+
+type listStore struct {
+	next repository.ListStore
+	counter
+}
+
+func (s *listStore) Create(ctx context.Context, ownerID int64, name string) (_ *models.List, err error) {
+	defer s.count("Create", &err)
+	return s.next.Create(ctx, ownerID, name)
+}
+
+func (s *listStore) GetByID(ctx context.Context, id int64) (_ *models.List, err error) {
+	defer s.count("GetByID", &err)
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *listStore) GetByOwner(ctx context.Context, ownerID int64) (_ []*models.List, err error) {
+	defer s.count("GetByOwner", &err)
+	return s.next.GetByOwner(ctx, ownerID)
+}
+
+func (s *listStore) Delete(ctx context.Context, id int64) (err error) {
+	defer s.count("Delete", &err)
+	return s.next.Delete(ctx, id)
+}
+
+func (s *listStore) AddMember(ctx context.Context, listID, userID int64) (err error) {
+	defer s.count("AddMember", &err)
+	return s.next.AddMember(ctx, listID, userID)
+}
+
+func (s *listStore) RemoveMember(ctx context.Context, listID, userID int64) (err error) {
+	defer s.count("RemoveMember", &err)
+	return s.next.RemoveMember(ctx, listID, userID)
+}
+
+func (s *listStore) GetMembers(ctx context.Context, listID int64) (_ []*models.User, err error) {
+	defer s.count("GetMembers", &err)
+	return s.next.GetMembers(ctx, listID)
+}
+
+func (s *listStore) GetListsByMember(ctx context.Context, userID int64) (_ []int64, err error) {
+	defer s.count("GetListsByMember", &err)
+	return s.next.GetListsByMember(ctx, userID)
+}
+
+// redisHook counts the commands and round trips made by a Redis client
+type redisHook struct{}
+
+func (redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		redisRoundTrips.WithLabelValues("command").Inc()
+		redisCommands.WithLabelValues(cmd.Name(), redisStatus(err)).Inc()
+		return err
+	}
+}
+
+func (redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		redisRoundTrips.WithLabelValues("pipeline").Inc()
+		for _, cmd := range cmds {
+			redisCommands.WithLabelValues(cmd.Name(), redisStatus(cmd.Err())).Inc()
+		}
+		return err
+	}
+}
+
+// redisStatus labels the outcome of a Redis command; a missing key is not an error
+func redisStatus(err error) string {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return "ok"
+	}
+	return "error"
+}
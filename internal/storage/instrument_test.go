@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/ritik/twitter-fan-out/internal/config"
+)
+
+// dbCallCount sums a store method's calls over every status
+func dbCallCount(store, method string) float64 {
+	var total float64
+	for _, status := range []string{"ok", "not_found", "error"} {
+		total += testutil.ToFloat64(dbCalls.WithLabelValues(BackendMemory, store, method, status))
+	}
+	return total
+}
+
+// Every method of every instrumented store is counted once per call
+func TestInstrumentCountsEveryMethod(t *testing.T) {
+	cfg := config.Default()
+	cfg.Backend = BackendMemory
+	stores, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer stores.Close()
+
+	wrapped := []struct {
+		store string
+		impl  any
+		iface any
+	}{
+		{"users", stores.Users, &stores.Users},
+		{"tweets", stores.Tweets, &stores.Tweets},
+		{"follows", stores.Follows, &stores.Follows},
+		{"follow_requests", stores.FollowRequests, &stores.FollowRequests},
+		{"blocks", stores.Blocks, &stores.Blocks},
+		{"lists", stores.Lists, &stores.Lists},
+	}
+	ctx := reflect.ValueOf(context.Background())
+	for _, w := range wrapped {
+		iface := reflect.TypeOf(w.iface).Elem()
+		impl := reflect.ValueOf(w.impl)
+		for i := 0; i < iface.NumMethod(); i++ {
+			name := iface.Method(i).Name
+			method := impl.MethodByName(name)
+
+			// Zero arguments are enough - failed calls are counted too
+			args := []reflect.Value{ctx}
+			for j := 1; j < method.Type().NumIn(); j++ {
+				args = append(args, reflect.Zero(method.Type().In(j)))
+			}
+
+			before := dbCallCount(w.store, name)
+			method.Call(args)
+			if got := dbCallCount(w.store, name) - before; got != 1 {
+				t.Errorf("%s.%s counted %v times, want 1", w.store, name, got)
+			}
+		}
+	}
+}
+
+func TestCallStatus(t *testing.T) {
+	cfg := config.Default()
+	cfg.Backend = BackendMemory
+	stores, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer stores.Close()
+	ctx := context.Background()
+
+	notFound := dbCallCount("tweets", "GetByID")
+	before := testutil.ToFloat64(dbCalls.WithLabelValues(BackendMemory, "tweets", "GetByID", "not_found"))
+	stores.Tweets.GetByID(ctx, 404)
+	if got := testutil.ToFloat64(dbCalls.WithLabelValues(BackendMemory, "tweets", "GetByID", "not_found")) - before; got != 1 {
+		t.Errorf("missing tweet counted %v times as not_found, want 1", got)
+	}
+	if dbCallCount("tweets", "GetByID")-notFound != 1 {
+		t.Error("missing tweet counted under another status too")
+	}
+
+	before = testutil.ToFloat64(dbCalls.WithLabelValues(BackendMemory, "tweets", "Create", "error"))
+	stores.Tweets.Create(ctx, 404, "nobody")
+	if got := testutil.ToFloat64(dbCalls.WithLabelValues(BackendMemory, "tweets", "Create", "error")) - before; got != 1 {
+		t.Errorf("tweet by a missing user counted %v times as an error, want 1", got)
+	}
+}
@@ -0,0 +1,193 @@
+// Package storage opens the stores for the configured backend so the server
+// and CLI don't need to know whether they run on PostgreSQL and Redis or
+// entirely in-process.
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/config"
+	"github.com/ritik/twitter-fan-out/internal/memory"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// Supported backends
+const (
+	BackendPostgres = "postgres" // PostgreSQL for data, Redis for timelines
+	BackendMemory   = "memory"   // Everything in-process; lost on exit
+)
+
+// Backends returns the supported backend names
+func Backends() []string {
+	return []string{BackendPostgres, BackendMemory}
+}
+
+// Stores is the set of stores for one backend
+type Stores struct {
+	Backend string
+	Users   repository.UserStore
+	Tweets  repository.TweetStore
+	Follows repository.FollowStore
+	Blocks  repository.BlockStore
+	Cache   cache.TimelineStore
+
+	// Requests to follow protected users
+	FollowRequests repository.FollowRequestStore
+
+	// Lists and their members
+	Lists repository.ListStore
+
+	// Like and retweet counts, kept by the same cache
+	Counters cache.CounterStore
+
+	// Set by EnableEvents
+	Events cache.EventBus
+
+	// Only set for the postgres backend
+	DB    *sqlx.DB
+	Redis *redis.Client
+
+	memCache *memory.TimelineCache
+}
+
+// Open connects to the backend named in cfg.Backend. Calls to the stores
+// are counted in the Prometheus metrics.
+func Open(cfg *config.Config) (*Stores, error) {
+	var stores *Stores
+	switch cfg.Backend {
+	case BackendPostgres, "":
+		var err error
+		if stores, err = openPostgres(cfg); err != nil {
+			return nil, err
+		}
+	case BackendMemory:
+		db := memory.NewDB()
+		timelineCache := memory.NewTimelineCache(cfg.TimelineCacheSize)
+		stores = &Stores{
+			Backend:  BackendMemory,
+			Users:    memory.NewUserRepository(db),
+			Tweets:   memory.NewTweetRepository(db),
+			Follows:  memory.NewFollowRepository(db),
+			Blocks:   memory.NewBlockRepository(db),
+			Cache:    timelineCache,
+			Counters: timelineCache,
+			memCache: timelineCache,
+
+			FollowRequests: memory.NewFollowRequestRepository(db),
+			Lists:          memory.NewListRepository(db),
+		}
+	default:
+		return nil, fmt.Errorf("unknown backend %q (expected one of %v)", cfg.Backend, Backends())
+	}
+
+	instrument(stores)
+	return stores, nil
+}
+
+func openPostgres(cfg *config.Config) (*Stores, error) {
+	db, err := repository.InitDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	redisClient, err := cache.InitRedis(cfg)
+	if err != nil {
+		repository.Close()
+		return nil, err
+	}
+	redisClient.AddHook(redisHook{})
+
+	timelineCache := cache.NewTimelineCache(redisClient, cfg.TimelineCacheSize)
+	timelineCache.SetCounterShards(counterShards(cfg))
+
+	return &Stores{
+		Backend:  BackendPostgres,
+		Users:    repository.NewUserRepository(db),
+		Tweets:   repository.NewTweetRepository(db),
+		Follows:  repository.NewFollowRepository(db),
+		Blocks:   repository.NewBlockRepository(db),
+		Cache:    timelineCache,
+		Counters: timelineCache,
+		DB:       db,
+		Redis:    redisClient,
+
+		FollowRequests: repository.NewFollowRequestRepository(db),
+		Lists:          repository.NewListRepository(db),
+	}, nil
+}
+
+// counterShards returns how many Redis hashes each tweet's counts are spread
+// over. Only the sharded strategy spreads them; write-behind already turns a
+// hot tweet's likes into one write per flush.
+func counterShards(cfg *config.Config) int {
+	if cfg.CounterStrategy != cache.CounterSharded || cfg.CounterShards < 1 {
+		return 1
+	}
+	return cfg.CounterShards
+}
+
+// Migrate applies any pending SQL migrations. It fails without changing
+// anything if a previous migration was left unfinished or the database is at
+// a version newer than the migrations in migrationsPath. The memory backend
+// has no schema.
+func (s *Stores) Migrate(migrationsPath string) error {
+	migrator, err := s.Migrator(migrationsPath)
+	if err != nil || migrator == nil {
+		return err
+	}
+	_, err = migrator.Up(context.Background(), 0)
+	return err
+}
+
+// Migrator returns the migrator for the backend's schema, or nil for the
+// memory backend
+func (s *Stores) Migrator(migrationsPath string) (*repository.Migrator, error) {
+	if s.DB == nil {
+		return nil, nil
+	}
+	return repository.NewMigrator(s.DB, migrationsPath)
+}
+
+// NewFanOutQueue returns a fan-out job queue, or nil if the backend has no
+// durable queue to offer
+func (s *Stores) NewFanOutQueue() *cache.FanOutQueue {
+	if s.Redis == nil {
+		return nil
+	}
+	return cache.NewFanOutQueue(s.Redis)
+}
+
+// Reset removes all users, tweets, follows, follow requests, blocks, lists
+// and cached timelines
+func (s *Stores) Reset(ctx context.Context) error {
+	if err := s.Follows.Truncate(ctx); err != nil {
+		return err
+	}
+	if err := s.Tweets.Truncate(ctx); err != nil {
+		return err
+	}
+	if err := s.Users.Truncate(ctx); err != nil {
+		return err
+	}
+	if s.memCache != nil {
+		s.memCache.Flush()
+	}
+	if s.Redis != nil {
+		return s.Redis.FlushAll(ctx).Err()
+	}
+	return nil
+}
+
+// Close releases any connections held by the stores
+func (s *Stores) Close() {
+	if s.DB != nil {
+		repository.Close()
+	}
+	if s.Redis != nil {
+		cache.Close()
+	}
+}
@@ -0,0 +1,180 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// ErrProtectedProfile is returned when reading a protected user's tweets as
+// someone other than them or one of their approved followers
+var ErrProtectedProfile = errors.New("protected users' tweets are only visible to their followers")
+
+// authorTweets fetches one page of several authors' tweets, merged, before
+// retweets are collapsed. Every strategy adds each author's tweets to their
+// cached tweet set as they're posted, so authors whose sets cover the page
+// are read from the cache and only the rest from the database. Those are
+// warmed from the first page they're read with, when the set's TTL or trim
+// has let it fall behind. fromCache reports whether the database was
+// skipped entirely.
+func authorTweets(ctx context.Context, tc cache.TimelineStore, tweetRepo repository.TweetStore, authorIDs []int64, page models.Page) ([]*models.Tweet, bool, error) {
+	if len(authorIDs) == 0 || page.Limit <= 0 {
+		return []*models.Tweet{}, true, nil
+	}
+
+	// 1. The page from the authors' tweet sets, and the authors it can't vouch for
+	tweetIDs, uncovered, err := tc.GetAuthorTweetsPage(ctx, authorIDs, page)
+	if err != nil {
+		fmt.Printf("Warning: failed to get author tweets: %v\n", err)
+		tweetIDs, uncovered = []int64{}, authorIDs
+	}
+
+	// 2. Hydrate the cached IDs
+	tweets := []*models.Tweet{}
+	if len(tweetIDs) > 0 {
+		var missingIDs []int64
+		tweets, missingIDs, err = tc.GetCachedTweets(ctx, tweetIDs)
+		if err != nil {
+			missingIDs = tweetIDs
+			tweets = []*models.Tweet{}
+		}
+		if len(missingIDs) > 0 {
+			dbTweets, err := tweetRepo.GetByIDs(ctx, missingIDs)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to get tweets from DB: %w", err)
+			}
+			tweets = append(tweets, dbTweets...)
+		}
+	}
+
+	// 3. Read the uncovered authors from the database. Each author can
+	// contribute at most a full page, so a per-user limit of page.Limit is
+	// exact, and an author with fewer than that on the first page has no
+	// more to cache.
+	if len(uncovered) > 0 {
+		dbTweets, err := tweetRepo.GetRecentByUserIDs(ctx, uncovered, page.Limit, page)
+		if err == nil && page.MaxID == nil && page.SinceID == nil {
+			warmAuthorTweets(ctx, tc, uncovered, dbTweets, page.Limit)
+		}
+		if err != nil {
+			// Fall back to simpler query, which can't warm the sets
+			dbTweets, err = tweetRepo.GetByUserIDs(ctx, uncovered, page)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to get tweets: %w", err)
+			}
+		}
+		tweets = append(tweets, dbTweets...)
+	}
+
+	// 4. Merge
+	tweets = deduplicateTweets(tweets)
+	sortTweetsByTime(tweets)
+	if len(tweets) > page.Limit {
+		tweets = tweets[:page.Limit]
+	}
+	tc.CacheTweetsBatch(ctx, tweets)
+
+	return tweets, len(uncovered) == 0, nil
+}
+
+// warmAuthorTweets adds each author's newest tweets, read with a per-user
+// limit of limit, to their tweet set
+func warmAuthorTweets(ctx context.Context, tc cache.TimelineStore, authorIDs []int64, tweets []*models.Tweet, limit int) {
+	byAuthor := make(map[int64][]*models.Tweet, len(authorIDs))
+	for _, t := range tweets {
+		byAuthor[t.UserID] = append(byAuthor[t.UserID], t)
+	}
+	for _, authorID := range authorIDs {
+		own := byAuthor[authorID]
+		if err := tc.AddAuthorTweets(ctx, authorID, own, len(own) < limit); err != nil {
+			fmt.Printf("Warning: failed to warm author tweets: %v\n", err)
+		}
+	}
+}
+
+// UserTweets serves a user's own tweets, retweets and replies - their
+// profile - from their author tweet set, whichever strategy posted them
+type UserTweets struct {
+	tweetRepo  repository.TweetStore
+	userRepo   repository.UserStore
+	followRepo repository.FollowStore
+	cache      cache.TimelineStore
+	counters   *Counters // Optional - embeds like and retweet counts
+}
+
+// NewUserTweets creates a UserTweets
+func NewUserTweets(
+	tweetRepo repository.TweetStore,
+	userRepo repository.UserStore,
+	followRepo repository.FollowStore,
+	cache cache.TimelineStore,
+) *UserTweets {
+	return &UserTweets{
+		tweetRepo:  tweetRepo,
+		userRepo:   userRepo,
+		followRepo: followRepo,
+		cache:      cache,
+	}
+}
+
+// SetCounters makes profiles embed like and retweet counts
+func (u *UserTweets) SetCounters(c *Counters) {
+	u.counters = c
+}
+
+// Get returns one page of userID's tweets as viewerID sees them. A
+// protected user's tweets are only shown to them and their followers, and
+// retweets of other protected users' tweets only to those users' followers.
+func (u *UserTweets) Get(ctx context.Context, userID, viewerID int64, page models.Page) ([]*models.Tweet, *OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Operation: "get_user_tweets",
+		StartTime: time.Now(),
+	}
+
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return nil, metrics, err
+	}
+	if user.Protected && viewerID != userID {
+		following, err := u.followRepo.IsFollowing(ctx, viewerID, userID)
+		if err == nil && !following {
+			err = ErrProtectedProfile
+		}
+		if err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return nil, metrics, err
+		}
+	}
+
+	privacy := NewPrivacy(u.userRepo, u.followRepo, viewerID)
+	fromCache := true
+	tweets, err := collectPage(page, func(p models.Page) ([]*models.Tweet, error) {
+		tweets, hit, err := authorTweets(ctx, u.cache, u.tweetRepo, []int64{userID}, p)
+		fromCache = fromCache && hit
+		return tweets, err
+	}, func(t *models.Tweet) (bool, error) {
+		return privacy.Visible(ctx, t)
+	})
+	if err == nil {
+		embedCounts(ctx, u.counters, tweets)
+	}
+	metrics.PrivacyCheck = privacy.Elapsed()
+	metrics.EndTime = time.Now()
+	if err != nil {
+		metrics.Error = err
+		return nil, metrics, err
+	}
+
+	metrics.Success = true
+	metrics.CacheHit = fromCache
+
+	return tweets, metrics, nil
+}
@@ -0,0 +1,270 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// Block filter modes. A blocked or muted author's tweets, and retweets of
+// them, never show in the viewer's home timeline; the modes decide how the
+// tweets pushed before the block are kept out.
+const (
+	// BlockFilterRead leaves pushed timelines alone and drops hidden
+	// authors' tweets every time a timeline is read
+	BlockFilterRead = "read"
+	// BlockFilterPurge removes a hidden author's tweets from the viewer's
+	// cached timeline when they block or mute, and leaves the viewer out
+	// when the author's later tweets are pushed, so reads of pushed entries
+	// skip the check. Tweets merged at read time are still filtered then.
+	BlockFilterPurge = "purge"
+)
+
+// BlockFilters returns the block filter modes
+func BlockFilters() []string {
+	return []string{BlockFilterRead, BlockFilterPurge}
+}
+
+// IsValidBlockFilter checks if a block filter mode is valid
+func IsValidBlockFilter(mode string) bool {
+	for _, valid := range BlockFilters() {
+		if valid == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// HiddenAuthors decides which tweets one viewer's home timeline hides. The
+// viewer's blocks and mutes are loaded on the first tweet it checks.
+type HiddenAuthors struct {
+	blockRepo repository.BlockStore
+	viewerID  int64
+	hidden    map[int64]bool
+}
+
+// NewHiddenAuthors creates a HiddenAuthors for viewerID
+func NewHiddenAuthors(blockRepo repository.BlockStore, viewerID int64) *HiddenAuthors {
+	return &HiddenAuthors{blockRepo: blockRepo, viewerID: viewerID}
+}
+
+// Visible reports whether the viewer may see t: neither its author nor, for
+// a retweet, the original's author is hidden from them. The viewer's own
+// tweets and retweets are always visible.
+func (h *HiddenAuthors) Visible(ctx context.Context, t *models.Tweet) (bool, error) {
+	if t.UserID == h.viewerID {
+		return true, nil
+	}
+	if h.hidden == nil {
+		hidden, err := h.blockRepo.GetHidden(ctx, h.viewerID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get hidden users: %w", err)
+		}
+		h.hidden = make(map[int64]bool, len(hidden))
+		for _, id := range hidden {
+			h.hidden[id] = true
+		}
+	}
+	return !h.hidden[t.UserID] && !h.hidden[t.OriginalUserID], nil
+}
+
+// filter drops the tweets in tweets that the viewer mustn't see
+func (h *HiddenAuthors) filter(ctx context.Context, tweets []*models.Tweet) ([]*models.Tweet, error) {
+	result := make([]*models.Tweet, 0, len(tweets))
+	for _, t := range tweets {
+		visible, err := h.Visible(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// Blocks records blocks and mutes and keeps them out of home timelines.
+// The strategies use it to filter reads and, when purging, to leave viewers
+// who hid an author out of the author's fan-out. A nil *Blocks filters
+// nothing.
+type Blocks struct {
+	blockRepo repository.BlockStore
+	tweetRepo repository.TweetStore
+	cache     cache.TimelineStore
+	mode      string
+}
+
+// NewBlocks creates a Blocks. The mode is fixed: switching it would leave
+// timelines pushed under the other mode unfiltered.
+func NewBlocks(blockRepo repository.BlockStore, tweetRepo repository.TweetStore, cache cache.TimelineStore, mode string) *Blocks {
+	if mode == "" {
+		mode = BlockFilterRead
+	}
+	return &Blocks{blockRepo: blockRepo, tweetRepo: tweetRepo, cache: cache, mode: mode}
+}
+
+// Mode returns the block filter mode
+func (b *Blocks) Mode() string {
+	return b.mode
+}
+
+// purging reports whether hidden authors are kept out of pushed timelines
+func (b *Blocks) purging() bool {
+	return b != nil && b.mode == BlockFilterPurge
+}
+
+// Block records that blockerID blocked blockedID. Blocks work both ways, so
+// when purging each user's tweets are removed from the other's timeline. It
+// returns the number of timeline entries purged.
+func (b *Blocks) Block(ctx context.Context, blockerID, blockedID int64) (int, error) {
+	if err := b.blockRepo.Block(ctx, blockerID, blockedID); err != nil {
+		return 0, err
+	}
+	if !b.purging() {
+		return 0, nil
+	}
+
+	purged, err := b.purge(ctx, blockerID, blockedID)
+	if err != nil {
+		return purged, err
+	}
+	n, err := b.purge(ctx, blockedID, blockerID)
+	return purged + n, err
+}
+
+// Unblock removes a block. Purged tweets don't come back until the timeline
+// is rebuilt; new ones are pushed again at once.
+func (b *Blocks) Unblock(ctx context.Context, blockerID, blockedID int64) error {
+	return b.blockRepo.Unblock(ctx, blockerID, blockedID)
+}
+
+// Mute records that muterID muted mutedID and, when purging, removes the
+// muted user's tweets from the muter's timeline. It returns the number of
+// timeline entries purged.
+func (b *Blocks) Mute(ctx context.Context, muterID, mutedID int64) (int, error) {
+	if err := b.blockRepo.Mute(ctx, muterID, mutedID); err != nil {
+		return 0, err
+	}
+	if !b.purging() {
+		return 0, nil
+	}
+	return b.purge(ctx, muterID, mutedID)
+}
+
+// Unmute removes a mute. As with Unblock, purged tweets stay out until the
+// timeline is rebuilt.
+func (b *Blocks) Unmute(ctx context.Context, muterID, mutedID int64) error {
+	return b.blockRepo.Unmute(ctx, muterID, mutedID)
+}
+
+// purge removes authorID's tweets, and retweets of them other than userID's
+// own, from userID's cached timeline. Retweets are pushed under the
+// retweeter's name, so the whole timeline is read to find them.
+func (b *Blocks) purge(ctx context.Context, userID, authorID int64) (int, error) {
+	tweetIDs, err := b.cache.GetTimeline(ctx, userID, b.cache.MaxTimelineSize(), 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get timeline: %w", err)
+	}
+	if len(tweetIDs) == 0 {
+		return 0, nil
+	}
+
+	tweets, missingIDs, err := b.cache.GetCachedTweets(ctx, tweetIDs)
+	if err != nil {
+		tweets, missingIDs = []*models.Tweet{}, tweetIDs
+	}
+	if len(missingIDs) > 0 {
+		dbTweets, err := b.tweetRepo.GetByIDs(ctx, missingIDs)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get tweets: %w", err)
+		}
+		tweets = append(tweets, dbTweets...)
+	}
+
+	purged := make([]int64, 0)
+	for _, t := range tweets {
+		if t.UserID == authorID || (t.OriginalUserID == authorID && t.UserID != userID) {
+			purged = append(purged, t.ID)
+		}
+	}
+	if len(purged) == 0 {
+		return 0, nil
+	}
+	if err := b.cache.RemoveTweetsFromTimeline(ctx, userID, purged); err != nil {
+		return 0, fmt.Errorf("failed to purge timeline: %w", err)
+	}
+	return len(purged), nil
+}
+
+// HiddenAuthors returns the read-time check for viewerID's timeline, or nil
+// without blocks
+func (b *Blocks) HiddenAuthors(viewerID int64) *HiddenAuthors {
+	if b == nil {
+		return nil
+	}
+	return NewHiddenAuthors(b.blockRepo, viewerID)
+}
+
+// audience drops the followers who mustn't see t from its fan-out: those
+// hiding its author or, for a retweet, the original's author. Only purging
+// filters at fan-out.
+func (b *Blocks) audience(ctx context.Context, t *models.Tweet, followers []int64) ([]int64, error) {
+	if !b.purging() || len(followers) == 0 {
+		return followers, nil
+	}
+
+	hiding := make(map[int64]bool)
+	for _, authorID := range []int64{t.UserID, t.OriginalUserID} {
+		if authorID == 0 {
+			continue
+		}
+		ids, err := b.blockRepo.GetHiddenFrom(ctx, authorID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hiding users: %w", err)
+		}
+		for _, id := range ids {
+			hiding[id] = true
+		}
+	}
+	if len(hiding) == 0 {
+		return followers, nil
+	}
+
+	audience := make([]int64, 0, len(followers))
+	for _, id := range followers {
+		if !hiding[id] {
+			audience = append(audience, id)
+		}
+	}
+	return audience, nil
+}
+
+// pushable drops the tweets userID mustn't see from tweets about to be
+// pushed into their timeline outside of a normal fan-out, e.g. when
+// backfilling a follow. Only purging filters at push time.
+func (b *Blocks) pushable(ctx context.Context, userID int64, tweets []*models.Tweet) ([]*models.Tweet, error) {
+	if !b.purging() {
+		return tweets, nil
+	}
+	return b.HiddenAuthors(userID).filter(ctx, tweets)
+}
+
+// keepBoth combines two collectPage filters; a nil filter keeps everything
+func keepBoth(a, b func(t *models.Tweet) (bool, error)) func(t *models.Tweet) (bool, error) {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return func(t *models.Tweet) (bool, error) {
+		ok, err := a(t)
+		if err != nil || !ok {
+			return false, err
+		}
+		return b(t)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/ritik/twitter-fan-out/internal/cache"
 	"github.com/ritik/twitter-fan-out/internal/models"
 )
 
@@ -12,20 +13,69 @@ import (
 type Strategy interface {
 	Name() string
 	PostTweet(ctx context.Context, userID int64, content string) (*models.Tweet, *OperationMetrics, error)
-	GetTimeline(ctx context.Context, userID int64, limit, offset int) ([]*models.Tweet, *OperationMetrics, error)
+	PostReply(ctx context.Context, userID, inReplyToID int64, content string) (*models.Tweet, *OperationMetrics, error)
+	Retweet(ctx context.Context, userID, tweetID int64) (*models.Tweet, *OperationMetrics, error)
+	GetTimeline(ctx context.Context, userID int64, page models.Page) ([]*models.Tweet, *OperationMetrics, error)
+	DeleteTweet(ctx context.Context, tweetID int64, userID int64) (*OperationMetrics, error)
+	Follow(ctx context.Context, followerID, followeeID int64) (*OperationMetrics, error)
+	Unfollow(ctx context.Context, followerID, followeeID int64) (*OperationMetrics, error)
+}
+
+// ThresholdAware is implemented by strategies that treat celebrities differently
+type ThresholdAware interface {
+	SetCelebrityThreshold(threshold int)
+}
+
+// AsyncFanOut is implemented by strategies that can defer fan-out to the worker pool
+type AsyncFanOut interface {
+	FanOutQueue() *cache.FanOutQueue
+}
+
+// Precomputed is implemented by strategies that keep per-user timelines in the cache
+type Precomputed interface {
+	Strategy
+	// TimelineSources returns the users whose tweets belong in userID's cached timeline
+	TimelineSources(ctx context.Context, userID int64) ([]int64, error)
+	// RebuildTimeline replaces userID's cached timeline with the newest limit tweets from its sources
+	RebuildTimeline(ctx context.Context, userID int64, limit int) error
+}
+
+// PullSources is implemented by strategies that merge some authors' tweets at read time
+type PullSources interface {
+	// PullSources returns the users whose new tweets reach userID's timeline
+	// without being pushed into its cache
+	PullSources(ctx context.Context, userID int64) ([]int64, error)
+}
+
+// Ranked is implemented by strategies that rank timelines with a Scorer
+type Ranked interface {
+	SetScorer(scorer Scorer)
+	Scorer() Scorer
+	// GetRankedTimeline returns a page like GetTimeline and the cursor to
+	// pass as the next page's MaxID, nil after the last page. A ranked page
+	// isn't newest first, so it can't be continued from its oldest tweet.
+	GetRankedTimeline(ctx context.Context, userID int64, page models.Page) ([]*models.Tweet, *models.Cursor, *OperationMetrics, error)
 }
 
 // OperationMetrics holds metrics for a single operation
 type OperationMetrics struct {
-	Strategy       string
-	Operation      string
-	StartTime      time.Time
-	EndTime        time.Time
-	FanOutCount    int           // Number of users fanned out to
-	FanOutDuration time.Duration // Time spent on fan-out
-	CacheHit       bool
-	Success        bool
-	Error          error
+	Strategy        string
+	Operation       string
+	StartTime       time.Time
+	EndTime         time.Time
+	FanOutCount     int           // Number of timelines fanned out to, list timelines included
+	ListFanOutCount int           // Posts: how many of FanOutCount were list timelines
+	FanOutDuration  time.Duration // Time spent on fan-out
+	FanOutQueued    bool          // Fan-out was handed off to the async worker pool
+	QueueLag        time.Duration // Time a fan-out job waited in the queue before a worker picked it up
+	PrivacyCheck    time.Duration // Reads: time spent checking retweets of protected tweets
+	CandidateCount  int           // Ranked reads: tweets considered for the page
+	CandidateTime   time.Duration // Ranked reads: time spent gathering candidates
+	ScoringTime     time.Duration // Ranked reads: time spent scoring candidates
+	TruncationTime  time.Duration // Ranked reads: time spent ordering and cutting candidates to the page
+	CacheHit        bool
+	Success         bool
+	Error           error
 }
 
 // Duration returns the total operation duration
@@ -33,9 +83,13 @@ func (m *OperationMetrics) Duration() time.Duration {
 	return m.EndTime.Sub(m.StartTime)
 }
 
-// sortTweetsByTime sorts tweets by created_at in descending order (most recent first)
+// sortTweetsByTime sorts tweets by created_at in descending order (most recent first).
+// Ties are broken by ID so the order matches the keyset used for cursors.
 func sortTweetsByTime(tweets []*models.Tweet) {
 	sort.Slice(tweets, func(i, j int) bool {
+		if tweets[i].CreatedAt.Equal(tweets[j].CreatedAt) {
+			return tweets[i].ID > tweets[j].ID
+		}
 		return tweets[i].CreatedAt.After(tweets[j].CreatedAt)
 	})
 }
@@ -69,6 +123,60 @@ func deduplicateTweets(tweets []*models.Tweet) []*models.Tweet {
 	return result
 }
 
+// collectPage builds a timeline page from the raw entries returned by fetch,
+// dropping those keep rejects (nil keeps everything) and collapsing every
+// entry that shows the same tweet - the original and any retweets of it -
+// into the newest one, with the retweeters listed in RetweetedBy. Filtering
+// and collapsing can leave the page short, so older raw entries are fetched
+// until the page is full or the timeline runs out. Entries are only
+// collapsed within a page.
+func collectPage(page models.Page, fetch func(page models.Page) ([]*models.Tweet, error), keep func(t *models.Tweet) (bool, error)) ([]*models.Tweet, error) {
+	result := make([]*models.Tweet, 0, page.Limit)
+	byRoot := make(map[int64]*models.Tweet)
+
+	raw := page
+	for len(result) < page.Limit {
+		raw.Limit = page.Limit - len(result)
+		tweets, err := fetch(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tweets {
+			if keep != nil {
+				ok, err := keep(t)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+			}
+			if shown, ok := byRoot[t.RootID()]; ok {
+				if t.IsRetweet() {
+					shown.RetweetedBy = append(shown.RetweetedBy, t.Username)
+				}
+				continue
+			}
+			// Copy so the merged attribution never leaks into cached tweets
+			entry := *t
+			entry.RetweetedBy = nil
+			if t.IsRetweet() {
+				entry.RetweetedBy = []string{t.Username}
+			}
+			byRoot[t.RootID()] = &entry
+			result = append(result, &entry)
+		}
+
+		if len(tweets) < raw.Limit {
+			break
+		}
+		raw.MaxID = models.CursorFor(tweets[len(tweets)-1])
+	}
+
+	return result, nil
+}
+
 // StrategyType represents the type of timeline strategy
 type StrategyType string
 
@@ -76,15 +184,16 @@ const (
 	StrategyFanOutWrite StrategyType = "fanout_write"
 	StrategyFanOutRead  StrategyType = "fanout_read"
 	StrategyHybrid      StrategyType = "hybrid"
+	StrategyForYou      StrategyType = "for_you"
 )
 
-// ValidStrategies returns all valid strategy types
+// ValidStrategies returns all registered strategy types
 func ValidStrategies() []StrategyType {
-	return []StrategyType{
-		StrategyFanOutWrite,
-		StrategyFanOutRead,
-		StrategyHybrid,
+	types := make([]StrategyType, len(registrations))
+	for i, r := range registrations {
+		types[i] = r.strategyType
 	}
+	return types
 }
 
 // IsValidStrategy checks if a strategy type is valid
@@ -0,0 +1,369 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// CountersConfig controls how like and retweet counts are kept
+type CountersConfig struct {
+	Strategy      string        // cache.CounterSharded or cache.CounterWriteBehind
+	FlushInterval time.Duration // How often changed counts are written back to the database
+	BatchSize     int           // Tweets written back per statement
+}
+
+// DefaultCountersConfig returns sensible defaults for the counters
+func DefaultCountersConfig() CountersConfig {
+	return CountersConfig{
+		Strategy:      cache.CounterSharded,
+		FlushInterval: time.Second,
+		BatchSize:     500,
+	}
+}
+
+// CounterStats reports what the counters have done since they were created
+type CounterStats struct {
+	Strategy    string `json:"strategy"`
+	Pending     int    `json:"pending"` // Write-behind: tweets with changes not yet flushed to the store
+	Flushes     int64  `json:"flushes"`
+	WrittenBack int64  `json:"written_back"` // Tweets whose counts were written to the database
+}
+
+// Counters keeps the like and retweet counts that timelines embed. The
+// counts live in the counter store, loaded from the database the first time
+// a tweet is counted or read, and a background flush writes the ones that
+// changed back in batches - so a celebrity tweet liked thousands of times a
+// second doesn't rewrite its row thousands of times a second. The cached
+// tweet JSON is never touched, so likes don't invalidate it.
+type Counters struct {
+	tweetRepo repository.TweetStore
+	store     cache.CounterStore
+	cfg       CountersConfig
+
+	mu      sync.Mutex
+	pending map[int64]models.TweetCounts // Write-behind: changes not yet applied to the store
+
+	flushes     atomic.Int64
+	writtenBack atomic.Int64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewCounters creates a new Counters
+func NewCounters(tweetRepo repository.TweetStore, store cache.CounterStore, cfg CountersConfig) *Counters {
+	defaults := DefaultCountersConfig()
+	if cfg.Strategy == "" {
+		cfg.Strategy = defaults.Strategy
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaults.FlushInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+
+	return &Counters{
+		tweetRepo: tweetRepo,
+		store:     store,
+		cfg:       cfg,
+		pending:   make(map[int64]models.TweetCounts),
+	}
+}
+
+// Strategy returns the counter strategy
+func (c *Counters) Strategy() string {
+	return c.cfg.Strategy
+}
+
+// Stats returns the counters' statistics
+func (c *Counters) Stats() CounterStats {
+	c.mu.Lock()
+	pending := len(c.pending)
+	c.mu.Unlock()
+
+	return CounterStats{
+		Strategy:    c.cfg.Strategy,
+		Pending:     pending,
+		Flushes:     c.flushes.Load(),
+		WrittenBack: c.writtenBack.Load(),
+	}
+}
+
+// Start launches the background flush
+func (c *Counters) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.run(ctx)
+	}()
+}
+
+// Stop stops the background flush and writes back whatever is left
+func (c *Counters) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+
+	if _, err := c.Flush(context.Background()); err != nil {
+		fmt.Printf("Warning: failed to flush counts: %v\n", err)
+	}
+}
+
+// run is the main loop of the background flush
+func (c *Counters) run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := c.Flush(ctx); err != nil && ctx.Err() == nil {
+			fmt.Printf("Warning: failed to flush counts: %v\n", err)
+		}
+	}
+}
+
+// Like records userID's like of tweetID and counts it. It returns the ID of
+// the tweet liked: the original, for a retweet.
+func (c *Counters) Like(ctx context.Context, userID, tweetID int64) (int64, error) {
+	likedID, err := c.tweetRepo.Like(ctx, userID, tweetID)
+	if err != nil {
+		return 0, err
+	}
+	c.add(ctx, likedID, models.TweetCounts{Likes: 1})
+	return likedID, nil
+}
+
+// Unlike removes userID's like of tweetID and uncounts it. It returns the
+// ID of the tweet that was liked.
+func (c *Counters) Unlike(ctx context.Context, userID, tweetID int64) (int64, error) {
+	likedID, err := c.tweetRepo.Unlike(ctx, userID, tweetID)
+	if err != nil {
+		return 0, err
+	}
+	c.add(ctx, likedID, models.TweetCounts{Likes: -1})
+	return likedID, nil
+}
+
+// Retweeted counts a new retweet
+func (c *Counters) Retweeted(ctx context.Context, retweet *models.Tweet) {
+	c.add(ctx, retweet.OriginalID, models.TweetCounts{Retweets: 1})
+}
+
+// Deleted updates the counts once tweet has been deleted: a retweet stops
+// counting towards its original, and any other tweet's counts are dropped
+func (c *Counters) Deleted(ctx context.Context, tweet *models.Tweet) {
+	if tweet.IsRetweet() {
+		c.add(ctx, tweet.OriginalID, models.TweetCounts{Retweets: -1})
+		return
+	}
+
+	c.mu.Lock()
+	delete(c.pending, tweet.ID)
+	c.mu.Unlock()
+	if err := c.store.RemoveCounts(ctx, tweet.ID); err != nil {
+		fmt.Printf("Warning: failed to remove counts: %v\n", err)
+	}
+}
+
+// embedCounts embeds counts in a timeline page if counters are enabled. The
+// page is still served without them if they can't be read.
+func embedCounts(ctx context.Context, counters *Counters, tweets []*models.Tweet) {
+	if counters == nil {
+		return
+	}
+	if err := counters.Embed(ctx, tweets); err != nil {
+		fmt.Printf("Warning: failed to embed counts: %v\n", err)
+	}
+}
+
+// countDeletion looks up a tweet that's about to be deleted and returns a
+// function that updates the counts once it has been. Without counters, or
+// if the tweet can't be found, the function does nothing.
+func countDeletion(ctx context.Context, counters *Counters, tweetRepo repository.TweetStore, tweetID int64) func() {
+	if counters == nil {
+		return func() {}
+	}
+	tweet, err := tweetRepo.GetByID(ctx, tweetID)
+	if err != nil {
+		return func() {}
+	}
+	return func() {
+		counters.Deleted(ctx, tweet)
+	}
+}
+
+// add changes a tweet's counts: straight away in the store, or for
+// write-behind, in the buffer the next flush applies. A failure is only
+// logged - the like or retweet itself has been recorded.
+func (c *Counters) add(ctx context.Context, tweetID int64, delta models.TweetCounts) {
+	if c.cfg.Strategy == cache.CounterWriteBehind {
+		c.mu.Lock()
+		c.pending[tweetID] = c.pending[tweetID].Add(delta)
+		c.mu.Unlock()
+		return
+	}
+
+	if err := c.incr(ctx, tweetID, delta); err != nil {
+		fmt.Printf("Warning: failed to update counts: %v\n", err)
+	}
+}
+
+// incr adds delta to a tweet's counts in the store, loading them from the
+// database first if they aren't loaded
+func (c *Counters) incr(ctx context.Context, tweetID int64, delta models.TweetCounts) error {
+	ok, err := c.store.IncrCounts(ctx, tweetID, delta)
+	if err != nil || ok {
+		return err
+	}
+
+	counts, err := c.load(ctx, []int64{tweetID})
+	if err != nil {
+		return err
+	}
+	if _, exists := counts[tweetID]; !exists {
+		return nil // The tweet has been deleted
+	}
+
+	ok, err = c.store.IncrCounts(ctx, tweetID, delta)
+	if err == nil && !ok {
+		err = fmt.Errorf("counts for tweet %d were not loaded", tweetID)
+	}
+	return err
+}
+
+// load reads tweets' counts from the database into the store
+func (c *Counters) load(ctx context.Context, tweetIDs []int64) (map[int64]models.TweetCounts, error) {
+	counts, err := c.tweetRepo.GetCounts(ctx, tweetIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.store.LoadCounts(ctx, counts); err != nil {
+		fmt.Printf("Warning: failed to load counts into cache: %v\n", err)
+	}
+	return counts, nil
+}
+
+// Get returns tweets' counts, including changes this process hasn't flushed
+// yet. Tweets that don't exist are left out.
+func (c *Counters) Get(ctx context.Context, tweetIDs []int64) (map[int64]models.TweetCounts, error) {
+	counts, missingIDs, err := c.store.GetCounts(ctx, tweetIDs)
+	if err != nil {
+		// Fall back to the database
+		fmt.Printf("Warning: failed to get counts from cache: %v\n", err)
+		counts, missingIDs = make(map[int64]models.TweetCounts, len(tweetIDs)), tweetIDs
+	}
+
+	if len(missingIDs) > 0 {
+		loaded, err := c.load(ctx, missingIDs)
+		if err != nil {
+			return nil, err
+		}
+		for id, count := range loaded {
+			counts[id] = count
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, count := range counts {
+		if delta, ok := c.pending[id]; ok {
+			counts[id] = count.Add(delta)
+		}
+	}
+	return counts, nil
+}
+
+// Embed sets each tweet's like and retweet counts - for a retweet, its
+// original's
+func (c *Counters) Embed(ctx context.Context, tweets []*models.Tweet) error {
+	if len(tweets) == 0 {
+		return nil
+	}
+
+	tweetIDs := make([]int64, len(tweets))
+	for i, t := range tweets {
+		tweetIDs[i] = t.RootID()
+	}
+	counts, err := c.Get(ctx, tweetIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tweets {
+		if count, ok := counts[t.RootID()]; ok {
+			t.LikeCount, t.RetweetCount = count.Likes, count.Retweets
+		}
+	}
+	return nil
+}
+
+// Flush applies buffered write-behind changes to the store, then writes the
+// counts that changed back to the database in batches. It returns the
+// number of tweets written back.
+func (c *Counters) Flush(ctx context.Context) (int, error) {
+	c.flushes.Add(1)
+
+	// 1. Apply buffered changes, one increment per tweet however many
+	// likes it got
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[int64]models.TweetCounts)
+	c.mu.Unlock()
+
+	var applyErr error
+	for id, delta := range pending {
+		if err := c.incr(ctx, id, delta); err != nil {
+			// Keep the change for the next flush
+			c.mu.Lock()
+			c.pending[id] = c.pending[id].Add(delta)
+			c.mu.Unlock()
+			applyErr = fmt.Errorf("failed to apply buffered counts: %w", err)
+		}
+	}
+
+	// 2. Write changed counts back
+	written := 0
+	for {
+		tweetIDs, err := c.store.TakeChanged(ctx, c.cfg.BatchSize)
+		if err != nil {
+			return written, fmt.Errorf("failed to get changed counts: %w", err)
+		}
+		if len(tweetIDs) == 0 {
+			break
+		}
+
+		counts, _, err := c.store.GetCounts(ctx, tweetIDs)
+		if err == nil {
+			err = c.tweetRepo.SetCounts(ctx, counts)
+		}
+		if err != nil {
+			if markErr := c.store.MarkChanged(ctx, tweetIDs); markErr != nil {
+				fmt.Printf("Warning: failed to requeue changed counts: %v\n", markErr)
+			}
+			return written, fmt.Errorf("failed to write back counts: %w", err)
+		}
+
+		written += len(counts)
+		c.writtenBack.Add(int64(len(counts)))
+		if len(tweetIDs) < c.cfg.BatchSize {
+			break
+		}
+	}
+
+	return written, applyErr
+}
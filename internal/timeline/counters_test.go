@@ -0,0 +1,208 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/memory"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// writeBackRecorder records the size of every batch of counts written back,
+// failing the first failures of them
+type writeBackRecorder struct {
+	repository.TweetStore
+	batches  []int
+	failures int
+}
+
+func (r *writeBackRecorder) SetCounts(ctx context.Context, counts map[int64]models.TweetCounts) error {
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("connection reset")
+	}
+	r.batches = append(r.batches, len(counts))
+	return r.TweetStore.SetCounts(ctx, counts)
+}
+
+// incrRecorder records every increment applied to a tweet's loaded counts
+type incrRecorder struct {
+	*memory.TimelineCache
+	applied []models.TweetCounts
+}
+
+func (r *incrRecorder) IncrCounts(ctx context.Context, tweetID int64, delta models.TweetCounts) (bool, error) {
+	ok, err := r.TimelineCache.IncrCounts(ctx, tweetID, delta)
+	if ok {
+		r.applied = append(r.applied, delta)
+	}
+	return ok, err
+}
+
+func newTestCounters(f *fixture, strategy string, batchSize int) (*Counters, *writeBackRecorder, *incrRecorder) {
+	tweets := &writeBackRecorder{TweetStore: f.tweets}
+	store := &incrRecorder{TimelineCache: f.cache}
+	return NewCounters(tweets, store, CountersConfig{Strategy: strategy, BatchSize: batchSize}), tweets, store
+}
+
+// storedLikes returns the like counts last written back to the database
+func storedLikes(t *testing.T, f *fixture, tweetIDs ...int64) []int64 {
+	t.Helper()
+	counts, err := f.tweets.GetCounts(f.ctx, tweetIDs)
+	if err != nil {
+		t.Fatalf("GetCounts: %v", err)
+	}
+	likes := make([]int64, len(tweetIDs))
+	for i, id := range tweetIDs {
+		likes[i] = counts[id].Likes
+	}
+	return likes
+}
+
+func TestCountersFlushWritesBackInBatches(t *testing.T) {
+	tests := []struct {
+		tweets int
+		want   []int
+	}{
+		{tweets: 1, want: []int{1}},
+		{tweets: 4, want: []int{2, 2}},
+		{tweets: 5, want: []int{2, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		f := newFixture(t)
+		counters, written, _ := newTestCounters(f, cache.CounterSharded, 2)
+		users := f.newUsers(t, 1)
+
+		tweetIDs := make([]int64, tt.tweets)
+		for i := range tweetIDs {
+			tweet, err := f.tweets.Create(f.ctx, users[0], "hello")
+			if err != nil {
+				t.Fatalf("create tweet: %v", err)
+			}
+			tweetIDs[i] = tweet.ID
+			if _, err := counters.Like(f.ctx, users[0], tweet.ID); err != nil {
+				t.Fatalf("Like: %v", err)
+			}
+		}
+
+		n, err := counters.Flush(f.ctx)
+		if err != nil || n != tt.tweets {
+			t.Fatalf("%d tweets: Flush = %d, %v; want %d", tt.tweets, n, err, tt.tweets)
+		}
+		if !reflect.DeepEqual(written.batches, tt.want) {
+			t.Errorf("%d tweets: wrote back batches of %v, want %v", tt.tweets, written.batches, tt.want)
+		}
+		for i, likes := range storedLikes(t, f, tweetIDs...) {
+			if likes != 1 {
+				t.Errorf("%d tweets: tweet %d has %d likes stored, want 1", tt.tweets, tweetIDs[i], likes)
+			}
+		}
+
+		// Nothing has changed since
+		if n, err := counters.Flush(f.ctx); err != nil || n != 0 {
+			t.Errorf("%d tweets: second Flush = %d, %v; want 0", tt.tweets, n, err)
+		}
+		if s := counters.Stats(); s.Flushes != 2 || s.WrittenBack != int64(tt.tweets) {
+			t.Errorf("%d tweets: stats %+v, want 2 flushes writing back %d", tt.tweets, s, tt.tweets)
+		}
+	}
+}
+
+func TestCountersWriteBehindAppliesOneIncrementPerTweet(t *testing.T) {
+	f := newFixture(t)
+	counters, _, store := newTestCounters(f, cache.CounterWriteBehind, 0)
+	users := f.newUsers(t, 10)
+	tweet, err := f.tweets.Create(f.ctx, users[0], "hello")
+	if err != nil {
+		t.Fatalf("create tweet: %v", err)
+	}
+
+	for _, userID := range users {
+		if _, err := counters.Like(f.ctx, userID, tweet.ID); err != nil {
+			t.Fatalf("Like: %v", err)
+		}
+	}
+	if len(store.applied) != 0 {
+		t.Fatalf("applied %v to the store before flushing, want nothing", store.applied)
+	}
+	if s := counters.Stats(); s.Pending != 1 {
+		t.Errorf("stats %+v, want 1 tweet pending", s)
+	}
+
+	// Reads see the buffered likes before they are flushed
+	counts, err := counters.Get(f.ctx, []int64{tweet.ID})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if counts[tweet.ID].Likes != 10 {
+		t.Errorf("Get = %+v before flushing, want 10 likes", counts[tweet.ID])
+	}
+
+	if _, err := counters.Flush(f.ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if want := []models.TweetCounts{{Likes: 10}}; !reflect.DeepEqual(store.applied, want) {
+		t.Errorf("applied %v to the store, want a single %v", store.applied, want)
+	}
+	if got := storedLikes(t, f, tweet.ID); got[0] != 10 {
+		t.Errorf("stored %d likes, want 10", got[0])
+	}
+	if s := counters.Stats(); s.Pending != 0 {
+		t.Errorf("stats %+v after flushing, want nothing pending", s)
+	}
+}
+
+func TestCountersFlushRequeuesAFailedWriteBack(t *testing.T) {
+	f := newFixture(t)
+	counters, written, _ := newTestCounters(f, cache.CounterSharded, 0)
+	written.failures = 1
+	users := f.newUsers(t, 1)
+	tweet, err := f.tweets.Create(f.ctx, users[0], "hello")
+	if err != nil {
+		t.Fatalf("create tweet: %v", err)
+	}
+	if _, err := counters.Like(f.ctx, users[0], tweet.ID); err != nil {
+		t.Fatalf("Like: %v", err)
+	}
+
+	if n, err := counters.Flush(f.ctx); err == nil || n != 0 {
+		t.Fatalf("Flush = %d, %v; want the write-back error", n, err)
+	}
+	if got := storedLikes(t, f, tweet.ID); got[0] != 0 {
+		t.Fatalf("stored %d likes after a failed write-back, want 0", got[0])
+	}
+
+	// The tweet is still marked changed, so the next flush writes it
+	if n, err := counters.Flush(f.ctx); err != nil || n != 1 {
+		t.Fatalf("retry Flush = %d, %v; want 1", n, err)
+	}
+	if got := storedLikes(t, f, tweet.ID); got[0] != 1 {
+		t.Errorf("stored %d likes after the retry, want 1", got[0])
+	}
+}
+
+func TestCountersDeletedDropsBufferedChanges(t *testing.T) {
+	f := newFixture(t)
+	counters, written, _ := newTestCounters(f, cache.CounterWriteBehind, 0)
+	users := f.newUsers(t, 1)
+	tweet, err := f.tweets.Create(f.ctx, users[0], "hello")
+	if err != nil {
+		t.Fatalf("create tweet: %v", err)
+	}
+	if _, err := counters.Like(f.ctx, users[0], tweet.ID); err != nil {
+		t.Fatalf("Like: %v", err)
+	}
+
+	counters.Deleted(f.ctx, tweet)
+	if s := counters.Stats(); s.Pending != 0 {
+		t.Errorf("stats %+v after deleting the tweet, want nothing pending", s)
+	}
+	if n, err := counters.Flush(f.ctx); err != nil || n != 0 || len(written.batches) != 0 {
+		t.Errorf("Flush = %d, %v writing %v; want nothing written back", n, err, written.batches)
+	}
+}
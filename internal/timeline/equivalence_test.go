@@ -0,0 +1,751 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/memory"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+var (
+	equivalenceRuns = flag.Int("equivalence.runs", 100, "random operation sequences to try")
+	equivalenceOps  = flag.Int("equivalence.ops", 80, "operations per sequence")
+	equivalenceSeed = flag.Int64("equivalence.seed", 0, "run only this seed (0 runs seeds 1..runs)")
+)
+
+// The harness drives a small graph that starts with two celebrities. Follows,
+// unfollows and threshold changes move users across the threshold, and the
+// hybrid strategy's reclassifier is drained after every step.
+const (
+	eqUsers       = 8
+	eqCelebrities = 2
+	eqThreshold   = 4
+	eqPageSize    = 3 // Small pages so every comparison exercises the cursors
+	eqLists       = 2 // Lists owned by eqListOwners, which start empty
+)
+
+// eqMode picks how replies and hidden authors are kept out of timelines:
+// filtered when they're read, or left out when they're pushed
+type eqMode struct {
+	replies string
+	blocks  string
+}
+
+// follows reports whether sequences may follow and unfollow. Replies pushed
+// at fan-out reflect the follow graph at posting time while pulled ones are
+// filtered against the current graph, so that mode keeps the graph from setup.
+func (m eqMode) follows() bool {
+	return m.replies != ReplyFilterFanOut
+}
+
+func (m eqMode) String() string {
+	return fmt.Sprintf("replies=%s,blocks=%s", m.replies, m.blocks)
+}
+
+// eqModes are every combination of the reply and block filter modes
+var eqModes = []eqMode{
+	{ReplyFilterRead, BlockFilterPurge},
+	{ReplyFilterRead, BlockFilterRead},
+	{ReplyFilterFanOut, BlockFilterPurge},
+	{ReplyFilterFanOut, BlockFilterRead},
+}
+
+// eqListOwners are the user indexes owning each list: a celebrity and a
+// regular user
+var eqListOwners = [eqLists]int{0, 3}
+
+type opKind int
+
+const (
+	opPost opKind = iota
+	opFollow
+	opUnfollow
+	opDelete
+	opThreshold
+	opRetweet
+	opReply
+	opMute
+	opBlock
+	opProtect
+	opListAdd
+	opListRemove
+)
+
+// op is one step of a sequence. Users are referenced by index; deletes,
+// retweets and replies pick a live tweet by target modulo the number of live tweets,
+// list changes pick a list by target modulo eqLists, and threshold changes use
+// target as the new threshold.
+type op struct {
+	kind   opKind
+	user   int
+	target int
+	tick   bool // Advance the clock first; consecutive ops without a tick share created_at
+}
+
+func (o op) String() string {
+	clock := ""
+	if !o.tick {
+		clock = " (same timestamp)"
+	}
+	switch o.kind {
+	case opPost:
+		return fmt.Sprintf("user_%d posts%s", o.user+1, clock)
+	case opFollow:
+		return fmt.Sprintf("user_%d follows user_%d", o.user+1, o.target+1)
+	case opUnfollow:
+		return fmt.Sprintf("user_%d unfollows user_%d", o.user+1, o.target+1)
+	case opThreshold:
+		return fmt.Sprintf("celebrity threshold set to %d", o.target)
+	case opRetweet:
+		return fmt.Sprintf("user_%d retweets live tweet #%d%s", o.user+1, o.target, clock)
+	case opReply:
+		return fmt.Sprintf("user_%d replies to live tweet #%d%s", o.user+1, o.target, clock)
+	case opMute:
+		return fmt.Sprintf("user_%d mutes user_%d", o.user+1, o.target+1)
+	case opBlock:
+		return fmt.Sprintf("user_%d blocks user_%d", o.user+1, o.target+1)
+	case opProtect:
+		return fmt.Sprintf("user_%d toggles protection", o.user+1)
+	case opListAdd:
+		return fmt.Sprintf("user_%d added to list %d", o.user+1, o.target%eqLists+1)
+	case opListRemove:
+		return fmt.Sprintf("user_%d removed from list %d", o.user+1, o.target%eqLists+1)
+	default:
+		return fmt.Sprintf("delete live tweet #%d", o.target)
+	}
+}
+
+func randomOps(rng *rand.Rand, n int, follows bool) []op {
+	ops := make([]op, n)
+	for i := range ops {
+		o := op{user: rng.Intn(eqUsers), target: rng.Intn(eqUsers), tick: rng.Float64() < 0.7}
+		switch r := rng.Float64(); {
+		case r < 0.28:
+			o.kind = opPost
+		case r < 0.37:
+			o.kind = opReply
+			o.target = rng.Intn(1 << 16)
+		case r < 0.45:
+			o.kind = opRetweet
+			o.target = rng.Intn(1 << 16)
+		case r < 0.65:
+			o.kind = opFollow
+		case r < 0.77:
+			o.kind = opUnfollow
+		case r < 0.79:
+			o.kind = opMute
+		case r < 0.8:
+			o.kind = opBlock
+		case r < 0.82:
+			o.kind = opProtect
+		case r < 0.86:
+			o.kind = opThreshold
+			o.target = 2 + rng.Intn(eqThreshold+1)
+		case r < 0.9:
+			o.kind = opListAdd
+		case r < 0.92:
+			o.kind = opListRemove
+		default:
+			o.kind = opDelete
+			o.target = rng.Intn(1 << 16)
+		}
+		if !follows && (o.kind == opFollow || o.kind == opUnfollow) {
+			o.kind = opPost
+		}
+		ops[i] = o
+	}
+	return ops
+}
+
+// world is one strategy running on its own in-memory backend. When blocks
+// purge pushed timelines or replies are filtered at fan-out, the strategies
+// only agree if every push path leaves hidden authors and replies out. Follows go through follow requests, so protected users
+// only gain the followers they approve.
+type world struct {
+	strategy     Strategy
+	reclassifier *Reclassifier // Only set for hybrid
+	blocks       *Blocks
+	requests     *FollowRequests
+	lists        *Lists
+	listIDs      [eqLists]int64
+	userTweets   *UserTweets
+	coldTweets   *UserTweets           // Reads profiles through coldCache
+	coldCache    *memory.TimelineCache // Flushed before every read
+}
+
+type liveTweet struct {
+	id     int64
+	author int
+	root   int64 // The original tweet's ID for a retweet, id otherwise (replies included)
+}
+
+// harness applies the same operations to every world and tracks the follow
+// graph and live tweets needed to resolve them
+type harness struct {
+	ctx    context.Context
+	worlds []*world
+	users  []int64
+	now    time.Time
+
+	following [eqUsers][eqUsers]bool
+	requested [eqUsers][eqUsers]bool // Pending follow requests
+	protected [eqUsers]bool
+	listed    [eqLists][eqUsers]bool
+	live      []liveTweet
+	retweeted map[[2]int64]bool // (user index, original tweet ID)
+}
+
+func newHarness(mode eqMode) *harness {
+	h := &harness{
+		ctx:       context.Background(),
+		now:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		retweeted: make(map[[2]int64]bool),
+	}
+
+	constructors := []func(*memory.TweetRepository, *memory.FollowRepository, *memory.UserRepository, *memory.TimelineCache) *world{
+		func(t *memory.TweetRepository, f *memory.FollowRepository, u *memory.UserRepository, c *memory.TimelineCache) *world {
+			return &world{strategy: NewFanOutWriteStrategy(t, f, u, c)}
+		},
+		func(t *memory.TweetRepository, f *memory.FollowRepository, u *memory.UserRepository, c *memory.TimelineCache) *world {
+			return &world{strategy: NewFanOutReadStrategy(t, f, u, c)}
+		},
+		func(t *memory.TweetRepository, f *memory.FollowRepository, u *memory.UserRepository, c *memory.TimelineCache) *world {
+			s := NewHybridStrategy(t, f, u, c, eqThreshold)
+			r := NewReclassifier(t, f, u, c, eqThreshold, ReclassifierConfig{ChunkSize: 2})
+			s.SetReclassifier(r)
+			return &world{strategy: s, reclassifier: r}
+		},
+	}
+
+	for _, newWorld := range constructors {
+		db := memory.NewDB()
+		db.SetClock(func() time.Time { return h.now })
+
+		userRepo := memory.NewUserRepository(db)
+		for i := 0; i < eqUsers; i++ {
+			user, err := userRepo.Create(h.ctx, fmt.Sprintf("user_%d", i+1))
+			if err != nil {
+				panic(err)
+			}
+			if len(h.users) < eqUsers {
+				h.users = append(h.users, user.ID)
+			}
+		}
+
+		tweetRepo, followRepo, timelineCache := memory.NewTweetRepository(db), memory.NewFollowRepository(db), memory.NewTimelineCache(800)
+		w := newWorld(tweetRepo, followRepo, userRepo, timelineCache)
+		w.requests = NewFollowRequests(userRepo, followRepo, memory.NewFollowRequestRepository(db))
+		w.blocks = NewBlocks(memory.NewBlockRepository(db), tweetRepo, timelineCache, mode.blocks)
+		if rf, ok := w.strategy.(ReplyFiltering); ok {
+			rf.SetReplyFilterMode(mode.replies)
+		}
+		w.strategy.(interface{ SetBlocks(*Blocks) }).SetBlocks(w.blocks)
+		w.lists = NewLists(memory.NewListRepository(db), tweetRepo, userRepo, followRepo, timelineCache)
+		w.lists.SetBlocks(w.blocks)
+		w.strategy.(interface{ SetLists(*Lists) }).SetLists(w.lists)
+		w.userTweets = NewUserTweets(tweetRepo, userRepo, followRepo, timelineCache)
+		w.coldCache = memory.NewTimelineCache(800)
+		w.coldTweets = NewUserTweets(tweetRepo, userRepo, followRepo, w.coldCache)
+		if w.reclassifier != nil {
+			w.reclassifier.SetBlocks(w.blocks)
+			w.reclassifier.SetLists(w.lists)
+		}
+		for i, owner := range eqListOwners {
+			list, err := w.lists.Create(h.ctx, h.users[owner], fmt.Sprintf("list_%d", i+1))
+			if err != nil {
+				panic(err)
+			}
+			w.listIDs[i] = list.ID
+		}
+		h.worlds = append(h.worlds, w)
+	}
+	return h
+}
+
+// setup makes the first eqCelebrities users celebrities
+func (h *harness) setup() error {
+	for c := 0; c < eqCelebrities; c++ {
+		for u := eqCelebrities; u < eqCelebrities+eqThreshold+1; u++ {
+			if err := h.follow(u, c); err != nil {
+				return err
+			}
+		}
+	}
+	return h.reclassify()
+}
+
+// reclassify runs the migrations queued by the last operation
+func (h *harness) reclassify() error {
+	for _, w := range h.worlds {
+		if w.reclassifier == nil {
+			continue
+		}
+		if err := w.reclassifier.RunPending(h.ctx); err != nil {
+			return fmt.Errorf("%s: reclassification failed: %w", w.strategy.Name(), err)
+		}
+		if status := w.reclassifier.Status(); status.Failed > 0 {
+			return fmt.Errorf("%s: %d migrations failed", w.strategy.Name(), status.Failed)
+		}
+	}
+	return nil
+}
+
+func (h *harness) follow(follower, followee int) error {
+	for _, w := range h.worlds {
+		if _, err := w.strategy.Follow(h.ctx, h.users[follower], h.users[followee]); err != nil {
+			return fmt.Errorf("%s: follow failed: %w", w.strategy.Name(), err)
+		}
+	}
+	h.following[follower][followee] = true
+	return nil
+}
+
+// apply runs one operation against every world and lets the hybrid strategy
+// reclassify anyone it moved across the threshold
+func (h *harness) apply(o op) error {
+	if err := h.applyOp(o); err != nil {
+		return err
+	}
+	return h.reclassify()
+}
+
+// applyOp runs one operation against every world. Operations whose
+// preconditions don't hold are skipped in all of them.
+func (h *harness) applyOp(o op) error {
+	if o.tick {
+		h.now = h.now.Add(time.Millisecond)
+	}
+
+	switch o.kind {
+	case opPost:
+		var id int64
+		for i, w := range h.worlds {
+			tweet, _, err := w.strategy.PostTweet(h.ctx, h.users[o.user], "tweet")
+			if err != nil {
+				return fmt.Errorf("%s: post failed: %w", w.strategy.Name(), err)
+			}
+			if i > 0 && tweet.ID != id {
+				return fmt.Errorf("%s: assigned tweet ID %d, expected %d", w.strategy.Name(), tweet.ID, id)
+			}
+			id = tweet.ID
+		}
+		h.live = append(h.live, liveTweet{id: id, author: o.user, root: id})
+
+	case opRetweet:
+		if len(h.live) == 0 {
+			return nil
+		}
+		root := h.live[o.target%len(h.live)].root
+		key := [2]int64{int64(o.user), root}
+		if h.retweeted[key] {
+			return nil
+		}
+		if author := h.author(root); h.protected[author] && author != o.user {
+			return nil
+		}
+		var id int64
+		for i, w := range h.worlds {
+			tweet, _, err := w.strategy.Retweet(h.ctx, h.users[o.user], root)
+			if err != nil {
+				return fmt.Errorf("%s: retweet failed: %w", w.strategy.Name(), err)
+			}
+			if i > 0 && tweet.ID != id {
+				return fmt.Errorf("%s: assigned retweet ID %d, expected %d", w.strategy.Name(), tweet.ID, id)
+			}
+			id = tweet.ID
+		}
+		h.retweeted[key] = true
+		h.live = append(h.live, liveTweet{id: id, author: o.user, root: root})
+
+	case opReply:
+		if len(h.live) == 0 {
+			return nil
+		}
+		parent := h.live[o.target%len(h.live)].id
+		var id int64
+		for i, w := range h.worlds {
+			tweet, _, err := w.strategy.PostReply(h.ctx, h.users[o.user], parent, "reply")
+			if err != nil {
+				return fmt.Errorf("%s: reply failed: %w", w.strategy.Name(), err)
+			}
+			if i > 0 && tweet.ID != id {
+				return fmt.Errorf("%s: assigned reply ID %d, expected %d", w.strategy.Name(), tweet.ID, id)
+			}
+			id = tweet.ID
+		}
+		// Replies outlive the tweet they answer
+		h.live = append(h.live, liveTweet{id: id, author: o.user, root: id})
+
+	case opFollow:
+		if o.user == o.target || h.following[o.user][o.target] || h.requested[o.user][o.target] {
+			return nil
+		}
+		for _, w := range h.worlds {
+			_, requested, err := w.requests.Follow(h.ctx, w.strategy, h.users[o.user], h.users[o.target])
+			if err != nil {
+				return fmt.Errorf("%s: follow failed: %w", w.strategy.Name(), err)
+			}
+			if requested != h.protected[o.target] {
+				return fmt.Errorf("%s: follow of user_%d requested=%v, expected %v", w.strategy.Name(), o.target+1, requested, h.protected[o.target])
+			}
+		}
+		if h.protected[o.target] {
+			h.requested[o.user][o.target] = true
+		} else {
+			h.following[o.user][o.target] = true
+		}
+
+	case opUnfollow:
+		if h.requested[o.user][o.target] {
+			// Cancel the pending request
+			for _, w := range h.worlds {
+				if err := w.requests.Deny(h.ctx, h.users[o.target], h.users[o.user]); err != nil {
+					return fmt.Errorf("%s: cancelling follow request failed: %w", w.strategy.Name(), err)
+				}
+			}
+			h.requested[o.user][o.target] = false
+			return nil
+		}
+		if !h.following[o.user][o.target] {
+			return nil
+		}
+		for _, w := range h.worlds {
+			if _, err := w.requests.Unfollow(h.ctx, w.strategy, h.users[o.user], h.users[o.target]); err != nil {
+				return fmt.Errorf("%s: unfollow failed: %w", w.strategy.Name(), err)
+			}
+		}
+		h.following[o.user][o.target] = false
+
+	case opProtect:
+		// Unprotecting approves every pending request
+		protected := !h.protected[o.user]
+		for _, w := range h.worlds {
+			if _, err := w.requests.SetProtected(h.ctx, w.strategy, h.users[o.user], protected); err != nil {
+				return fmt.Errorf("%s: %s failed: %w", w.strategy.Name(), o, err)
+			}
+		}
+		h.protected[o.user] = protected
+		if !protected {
+			for requester := range h.requested {
+				if h.requested[requester][o.user] {
+					h.requested[requester][o.user] = false
+					h.following[requester][o.user] = true
+				}
+			}
+		}
+
+	case opMute, opBlock:
+		if o.user == o.target {
+			return nil
+		}
+		for _, w := range h.worlds {
+			var err error
+			if o.kind == opMute {
+				_, err = w.blocks.Mute(h.ctx, h.users[o.user], h.users[o.target])
+			} else {
+				_, err = w.blocks.Block(h.ctx, h.users[o.user], h.users[o.target])
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %s failed: %w", w.strategy.Name(), o, err)
+			}
+		}
+
+	case opListAdd:
+		list := o.target % eqLists
+		if h.listed[list][o.user] {
+			return nil
+		}
+		owner := eqListOwners[list]
+		denied := h.protected[o.user] && o.user != owner && !h.following[owner][o.user]
+		for _, w := range h.worlds {
+			_, err := w.lists.AddMember(h.ctx, w.strategy.(ListTimelines), w.listIDs[list], h.users[o.user])
+			if denied && errors.Is(err, ErrProtectedListMember) {
+				continue
+			}
+			if err != nil || denied {
+				return fmt.Errorf("%s: %s returned %v, denied=%v", w.strategy.Name(), o, err, denied)
+			}
+		}
+		h.listed[list][o.user] = !denied
+
+	case opListRemove:
+		list := o.target % eqLists
+		if !h.listed[list][o.user] {
+			return nil
+		}
+		for _, w := range h.worlds {
+			if _, err := w.lists.RemoveMember(h.ctx, w.strategy.(ListTimelines), w.listIDs[list], h.users[o.user]); err != nil {
+				return fmt.Errorf("%s: %s failed: %w", w.strategy.Name(), o, err)
+			}
+		}
+		h.listed[list][o.user] = false
+
+	case opThreshold:
+		for _, w := range h.worlds {
+			if ta, ok := w.strategy.(ThresholdAware); ok {
+				ta.SetCelebrityThreshold(o.target)
+			}
+		}
+
+	case opDelete:
+		if len(h.live) == 0 {
+			return nil
+		}
+		i := o.target % len(h.live)
+		t := h.live[i]
+		for _, w := range h.worlds {
+			if _, err := w.strategy.DeleteTweet(h.ctx, t.id, h.users[t.author]); err != nil {
+				return fmt.Errorf("%s: delete failed: %w", w.strategy.Name(), err)
+			}
+		}
+		// Deleting an original deletes its retweets too
+		live := h.live[:0]
+		for _, l := range h.live {
+			if l.id == t.id || l.root == t.id {
+				delete(h.retweeted, [2]int64{int64(l.author), l.root})
+				continue
+			}
+			live = append(live, l)
+		}
+		h.live = live
+	}
+	return nil
+}
+
+// author returns the index of the user who posted a live tweet
+func (h *harness) author(id int64) int {
+	for _, l := range h.live {
+		if l.id == id {
+			return l.author
+		}
+	}
+	return -1
+}
+
+// timeline pages through a user's whole timeline and returns the entries as
+// tweet IDs, with the retweeters of collapsed retweets
+func (h *harness) timeline(w *world, user int) ([]string, error) {
+	return pageAll(func(page models.Page) ([]*models.Tweet, error) {
+		tweets, _, err := w.strategy.GetTimeline(h.ctx, h.users[user], page)
+		return tweets, err
+	})
+}
+
+// listTimeline pages through a list's whole timeline like timeline
+func (h *harness) listTimeline(w *world, list int) ([]string, error) {
+	return pageAll(func(page models.Page) ([]*models.Tweet, error) {
+		tweets, _, err := w.strategy.(ListTimelines).GetListTimeline(h.ctx, w.listIDs[list], page)
+		return tweets, err
+	})
+}
+
+// profile pages through a user's own tweets as viewer sees them like
+// timeline, or reports that viewer may not see them. Every strategy keeps
+// the same author tweet sets, so the profile is also checked against one
+// read from the database.
+func (h *harness) profile(w *world, user, viewer int) ([]string, error) {
+	read := func(u *UserTweets) ([]string, error) {
+		ids, err := pageAll(func(page models.Page) ([]*models.Tweet, error) {
+			tweets, _, err := u.Get(h.ctx, h.users[user], h.users[viewer], page)
+			return tweets, err
+		})
+		if errors.Is(err, ErrProtectedProfile) {
+			return []string{"protected"}, nil
+		}
+		return ids, err
+	}
+
+	ids, err := read(w.userTweets)
+	if err != nil {
+		return nil, err
+	}
+	w.coldCache.Flush()
+	want, err := read(w.coldTweets)
+	if err != nil {
+		return nil, fmt.Errorf("uncached read failed: %w", err)
+	}
+	if !reflect.DeepEqual(ids, want) {
+		return nil, fmt.Errorf("cached %v, database %v", ids, want)
+	}
+	return ids, nil
+}
+
+func pageAll(get func(models.Page) ([]*models.Tweet, error)) ([]string, error) {
+	ids := []string{}
+	page := models.Page{Limit: eqPageSize}
+	for pages := 0; pages < 1000; pages++ {
+		tweets, err := get(page)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tweets {
+			entry := fmt.Sprint(t.ID)
+			if len(t.RetweetedBy) > 0 {
+				entry += "<-" + strings.Join(t.RetweetedBy, ",")
+			}
+			ids = append(ids, entry)
+		}
+		if len(tweets) < page.Limit {
+			return ids, nil
+		}
+		page.MaxID = models.CursorFor(tweets[len(tweets)-1])
+	}
+	return nil, fmt.Errorf("timeline did not terminate")
+}
+
+// check compares every user's timeline and profile, and every list's
+// timeline, across the worlds. Profiles are read by their owner and by the
+// next user, who may not be allowed to see them.
+func (h *harness) check() error {
+	for user := 0; user < eqUsers; user++ {
+		err := h.compare(fmt.Sprintf("timelines for user_%d", user+1), func(w *world) ([]string, error) {
+			return h.timeline(w, user)
+		})
+		if err != nil {
+			return err
+		}
+		for _, viewer := range []int{user, (user + 1) % eqUsers} {
+			err := h.compare(fmt.Sprintf("user_%d's profiles for user_%d", user+1, viewer+1), func(w *world) ([]string, error) {
+				return h.profile(w, user, viewer)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for list := 0; list < eqLists; list++ {
+		err := h.compare(fmt.Sprintf("list %d timelines", list+1), func(w *world) ([]string, error) {
+			return h.listTimeline(w, list)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compare reads one timeline from every world and reports if they differ
+func (h *harness) compare(what string, read func(*world) ([]string, error)) error {
+	results := make([][]string, len(h.worlds))
+	for i, w := range h.worlds {
+		ids, err := read(w)
+		if err != nil {
+			return fmt.Errorf("%s: %s failed: %w", w.strategy.Name(), what, err)
+		}
+		results[i] = ids
+	}
+
+	for i := 1; i < len(results); i++ {
+		if !reflect.DeepEqual(results[0], results[i]) {
+			var b strings.Builder
+			fmt.Fprintf(&b, "%s differ:", what)
+			for j, w := range h.worlds {
+				fmt.Fprintf(&b, "\n    %-12s %v", w.strategy.Name(), results[j])
+			}
+			return fmt.Errorf("%s", b.String())
+		}
+	}
+	return nil
+}
+
+// runOps replays a sequence from a fresh state. It returns the index of the
+// step after which the strategies diverged, or -1 if they always agreed or
+// already disagreed during setup.
+func runOps(mode eqMode, ops []op) (int, error) {
+	h := newHarness(mode)
+	if err := h.setup(); err != nil {
+		return -1, err
+	}
+	if err := h.check(); err != nil {
+		return -1, fmt.Errorf("after setup: %w", err)
+	}
+	for i, o := range ops {
+		if err := h.apply(o); err != nil {
+			return i, err
+		}
+		if err := h.check(); err != nil {
+			return i, err
+		}
+	}
+	return -1, nil
+}
+
+// shrink removes operations from a failing sequence for as long as it keeps failing
+func shrink(mode eqMode, ops []op) []op {
+	fails := func(candidate []op) bool {
+		step, _ := runOps(mode, candidate)
+		return step >= 0
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for chunk := len(ops) / 2; chunk >= 1; chunk /= 2 {
+			for i := 0; i+chunk <= len(ops); {
+				candidate := append(append([]op{}, ops[:i]...), ops[i+chunk:]...)
+				if fails(candidate) {
+					ops = candidate
+					changed = true
+				} else {
+					i += chunk
+				}
+			}
+		}
+	}
+	return ops
+}
+
+func formatOps(ops []op) string {
+	var b strings.Builder
+	for i, o := range ops {
+		fmt.Fprintf(&b, "  %2d. %s\n", i+1, o)
+	}
+	return b.String()
+}
+
+// TestStrategiesAgree drives random posts, replies, retweets, follows, unfollows,
+// deletes, list changes and threshold changes through all three strategies and checks that every
+// user's timeline and every list's, paged by cursor, has the same tweets in the same order after each step
+func TestStrategiesAgree(t *testing.T) {
+	seeds := make([]int64, 0, *equivalenceRuns)
+	if *equivalenceSeed != 0 {
+		seeds = append(seeds, *equivalenceSeed)
+	} else {
+		runs := *equivalenceRuns
+		if testing.Short() {
+			runs = 10
+		}
+		for seed := int64(1); seed <= int64(runs); seed++ {
+			seeds = append(seeds, seed)
+		}
+	}
+
+	for _, mode := range eqModes {
+		t.Run(mode.String(), func(t *testing.T) {
+			t.Parallel()
+			for _, seed := range seeds {
+				ops := randomOps(rand.New(rand.NewSource(seed)), *equivalenceOps, mode.follows())
+
+				step, err := runOps(mode, ops)
+				if step < 0 && err == nil {
+					continue
+				}
+				if step < 0 {
+					t.Fatalf("seed %d: %v", seed, err)
+				}
+
+				minimal := shrink(mode, ops[:step+1])
+				_, minErr := runOps(mode, minimal)
+				t.Fatalf("seed %d: strategies diverged after step %d: %v\n\nshortest failing sequence (%d ops, after setup):\n%s\n%v",
+					seed, step+1, err, len(minimal), formatOps(minimal), minErr)
+			}
+		})
+	}
+}
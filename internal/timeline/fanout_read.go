@@ -13,18 +13,21 @@ import (
 // FanOutReadStrategy implements the fan-out-on-read approach
 // Tweets are stored once, and timelines are computed at read time
 type FanOutReadStrategy struct {
-	tweetRepo  *repository.TweetRepository
-	followRepo *repository.FollowRepository
-	userRepo   *repository.UserRepository
-	cache      *cache.TimelineCache
+	tweetRepo  repository.TweetStore
+	followRepo repository.FollowStore
+	userRepo   repository.UserStore
+	cache      cache.TimelineStore
+	counters   *Counters // Optional - embeds like and retweet counts
+	blocks     *Blocks   // Optional - hides blocked and muted authors
+	lists      *Lists    // Optional - serves list timelines
 }
 
 // NewFanOutReadStrategy creates a new FanOutReadStrategy
 func NewFanOutReadStrategy(
-	tweetRepo *repository.TweetRepository,
-	followRepo *repository.FollowRepository,
-	userRepo *repository.UserRepository,
-	cache *cache.TimelineCache,
+	tweetRepo repository.TweetStore,
+	followRepo repository.FollowStore,
+	userRepo repository.UserStore,
+	cache cache.TimelineStore,
 ) *FanOutReadStrategy {
 	return &FanOutReadStrategy{
 		tweetRepo:  tweetRepo,
@@ -34,11 +37,35 @@ func NewFanOutReadStrategy(
 	}
 }
 
+func init() {
+	Register(StrategyFanOutRead, 20, func(deps Dependencies) Strategy {
+		return withOptional(NewFanOutReadStrategy(deps.TweetRepo, deps.FollowRepo, deps.UserRepo, deps.Cache), deps)
+	})
+}
+
 // Name returns the strategy name
 func (s *FanOutReadStrategy) Name() string {
 	return "fanout_read"
 }
 
+// SetCounters makes timelines embed like and retweet counts and keeps the
+// retweet counts up to date
+func (s *FanOutReadStrategy) SetCounters(c *Counters) {
+	s.counters = c
+}
+
+// SetBlocks hides blocked and muted authors from timelines. Every tweet is
+// pulled, so they're always filtered at read time.
+func (s *FanOutReadStrategy) SetBlocks(b *Blocks) {
+	s.blocks = b
+}
+
+// SetLists enables list timelines. They're merged from the members' tweets
+// at read time like home timelines, so posting never touches them.
+func (s *FanOutReadStrategy) SetLists(l *Lists) {
+	s.lists = l
+}
+
 // PostTweet creates a tweet - simple O(1) operation
 func (s *FanOutReadStrategy) PostTweet(ctx context.Context, userID int64, content string) (*models.Tweet, *OperationMetrics, error) {
 	metrics := &OperationMetrics{
@@ -61,8 +88,61 @@ func (s *FanOutReadStrategy) PostTweet(ctx context.Context, userID int64, conten
 		tweet.Username = user.Username
 	}
 
-	// Optionally cache the tweet for faster retrieval
+	return s.deliver(ctx, tweet, metrics)
+}
+
+// PostReply creates a reply - like a tweet, it is merged into timelines at
+// read time, where the viewers who shouldn't see it filter it out
+func (s *FanOutReadStrategy) PostReply(ctx context.Context, userID, inReplyToID int64, content string) (*models.Tweet, *OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "post_reply",
+		StartTime: time.Now(),
+	}
+
+	tweet, err := s.tweetRepo.CreateReply(ctx, userID, inReplyToID, content)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return nil, metrics, fmt.Errorf("failed to create reply: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err == nil {
+		tweet.Username = user.Username
+	}
+
+	return s.deliver(ctx, tweet, metrics)
+}
+
+// Retweet creates a retweet - like a tweet, it is merged into timelines at read time
+func (s *FanOutReadStrategy) Retweet(ctx context.Context, userID, tweetID int64) (*models.Tweet, *OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "retweet",
+		StartTime: time.Now(),
+	}
+
+	tweet, err := s.tweetRepo.Retweet(ctx, userID, tweetID)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return nil, metrics, fmt.Errorf("failed to create retweet: %w", err)
+	}
+	if s.counters != nil {
+		s.counters.Retweeted(ctx, tweet)
+	}
+
+	return s.deliver(ctx, tweet, metrics)
+}
+
+// deliver caches a new tweet and adds it to the author's tweets, which
+// timelines are merged from - there are no timelines to push it into
+func (s *FanOutReadStrategy) deliver(ctx context.Context, tweet *models.Tweet, metrics *OperationMetrics) (*models.Tweet, *OperationMetrics, error) {
 	s.cache.CacheTweet(ctx, tweet)
+	if err := s.cache.AddAuthorTweet(ctx, tweet.UserID, tweet); err != nil {
+		fmt.Printf("Warning: failed to add author tweet: %v\n", err)
+	}
 
 	metrics.EndTime = time.Now()
 	metrics.Success = true
@@ -71,20 +151,54 @@ func (s *FanOutReadStrategy) PostTweet(ctx context.Context, userID int64, conten
 	return tweet, metrics, nil
 }
 
-// GetTimeline computes the timeline at read time by fetching from all followed users
-func (s *FanOutReadStrategy) GetTimeline(ctx context.Context, userID int64, limit, offset int) ([]*models.Tweet, *OperationMetrics, error) {
+// GetTimeline computes a page of the timeline at read time by fetching from all followed users
+func (s *FanOutReadStrategy) GetTimeline(ctx context.Context, userID int64, page models.Page) ([]*models.Tweet, *OperationMetrics, error) {
 	metrics := &OperationMetrics{
 		Strategy:  s.Name(),
 		Operation: "get_timeline",
 		StartTime: time.Now(),
 	}
 
+	// Every tweet is pulled, so replies, hidden authors and retweets of
+	// protected tweets are always filtered here
+	visibility := NewReplyVisibility(s.followRepo, userID)
+	keep := func(t *models.Tweet) (bool, error) {
+		return visibility.Visible(ctx, t)
+	}
+	if hidden := s.blocks.HiddenAuthors(userID); hidden != nil {
+		keep = keepBoth(func(t *models.Tweet) (bool, error) {
+			return hidden.Visible(ctx, t)
+		}, keep)
+	}
+	privacy := NewPrivacy(s.userRepo, s.followRepo, userID)
+	keep = keepBoth(keep, func(t *models.Tweet) (bool, error) {
+		return privacy.Visible(ctx, t)
+	})
+	tweets, err := collectPage(page, func(p models.Page) ([]*models.Tweet, error) {
+		return s.timelinePage(ctx, userID, p, metrics)
+	}, keep)
+	if err == nil {
+		embedCounts(ctx, s.counters, tweets)
+	}
+	metrics.PrivacyCheck = privacy.Elapsed()
+	metrics.EndTime = time.Now()
+	if err != nil {
+		metrics.Error = err
+		return nil, metrics, err
+	}
+
+	metrics.Success = true
+
+	return tweets, metrics, nil
+}
+
+// timelinePage merges one page of timeline entries from the followed users'
+// tweets, before retweets are collapsed
+func (s *FanOutReadStrategy) timelinePage(ctx context.Context, userID int64, page models.Page, metrics *OperationMetrics) ([]*models.Tweet, error) {
 	// 1. Get list of users this person follows
 	following, err := s.followRepo.GetFollowing(ctx, userID)
 	if err != nil {
-		metrics.Error = err
-		metrics.EndTime = time.Now()
-		return nil, metrics, fmt.Errorf("failed to get following: %w", err)
+		return nil, fmt.Errorf("failed to get following: %w", err)
 	}
 
 	// Include user's own tweets in their timeline
@@ -92,51 +206,123 @@ func (s *FanOutReadStrategy) GetTimeline(ctx context.Context, userID int64, limi
 
 	metrics.FanOutCount = len(following) // In read strategy, this represents the merge count
 
-	if len(following) == 0 {
-		metrics.EndTime = time.Now()
-		metrics.Success = true
-		return []*models.Tweet{}, metrics, nil
+	// 2. Fetch the page from all followed users' author tweet caches,
+	// falling back to the database for those that don't cover it. It's a
+	// cache hit if none of them had to.
+	tweets, fromCache, err := authorTweets(ctx, s.cache, s.tweetRepo, following, page)
+	if err != nil {
+		return nil, err
 	}
+	metrics.CacheHit = fromCache
+
+	return tweets, nil
+}
 
-	// 2. Fetch recent tweets from all followed users
-	// Using a lateral join query for efficiency
-	tweets, err := s.tweetRepo.GetRecentByUserIDs(ctx, following, 10, limit+offset)
+// PullSources returns everyone userID follows plus themselves - every tweet is merged at read time
+func (s *FanOutReadStrategy) PullSources(ctx context.Context, userID int64) ([]int64, error) {
+	following, err := s.followRepo.GetFollowing(ctx, userID)
 	if err != nil {
-		// Fall back to simpler query
-		tweets, err = s.tweetRepo.GetByUserIDs(ctx, following, limit+offset)
-		if err != nil {
-			metrics.Error = err
-			metrics.EndTime = time.Now()
-			return nil, metrics, fmt.Errorf("failed to get tweets: %w", err)
-		}
+		return nil, fmt.Errorf("failed to get following: %w", err)
+	}
+	return append(following, userID), nil
+}
+
+// DeleteTweet deletes the tweet and its retweets and purges their cached
+// data - there are no timelines to invalidate
+func (s *FanOutReadStrategy) DeleteTweet(ctx context.Context, tweetID int64, userID int64) (*OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "delete_tweet",
+		StartTime: time.Now(),
 	}
 
-	// 3. Sort by time (should already be sorted from DB, but ensure)
-	sortTweetsByTime(tweets)
+	deleted := countDeletion(ctx, s.counters, s.tweetRepo, tweetID)
 
-	// 4. Apply offset and limit
-	if offset > 0 {
-		if offset >= len(tweets) {
-			tweets = []*models.Tweet{}
-		} else {
-			tweets = tweets[offset:]
-		}
+	// Retweets are deleted along with the tweet
+	retweets, err := s.tweetRepo.GetRetweets(ctx, tweetID)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, fmt.Errorf("failed to get retweets: %w", err)
 	}
-	if len(tweets) > limit {
-		tweets = tweets[:limit]
+
+	// Cached tweet data would otherwise be served for up to 24h
+	for _, rt := range retweets {
+		s.cache.RemoveAuthorTweet(ctx, rt.UserID, rt.ID)
+		s.cache.InvalidateTweet(ctx, rt.ID)
 	}
+	s.cache.RemoveAuthorTweet(ctx, userID, tweetID)
+	s.cache.InvalidateTweet(ctx, tweetID)
 
-	// 5. Cache tweets for potential future use
-	s.cache.CacheTweetsBatch(ctx, tweets)
+	if err := s.tweetRepo.Delete(ctx, tweetID); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+	deleted()
 
 	metrics.EndTime = time.Now()
 	metrics.Success = true
-	metrics.CacheHit = false // Fan-out-read doesn't use timeline cache
 
-	return tweets, metrics, nil
+	return metrics, nil
+}
+
+// Follow creates a follow relationship - timelines are computed at read time,
+// so there is nothing to backfill
+func (s *FanOutReadStrategy) Follow(ctx context.Context, followerID, followeeID int64) (*OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "follow",
+		StartTime: time.Now(),
+	}
+
+	if err := s.followRepo.Create(ctx, followerID, followeeID); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return metrics, nil
+}
+
+// Unfollow removes a follow relationship - nothing cached to evict
+func (s *FanOutReadStrategy) Unfollow(ctx context.Context, followerID, followeeID int64) (*OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "unfollow",
+		StartTime: time.Now(),
+	}
+
+	if err := s.followRepo.Delete(ctx, followerID, followeeID); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return metrics, nil
+}
+
+// GetListTimeline computes a page of a list's timeline at read time from
+// its members' tweets
+func (s *FanOutReadStrategy) GetListTimeline(ctx context.Context, listID int64, page models.Page) ([]*models.Tweet, *OperationMetrics, error) {
+	return s.lists.timeline(ctx, s.Name(), s.counters, listID, page, func(members []*models.User, p models.Page, metrics *OperationMetrics) ([]*models.Tweet, error) {
+		metrics.FanOutCount = len(members) // The merge count, as for home timelines
+		return s.lists.pulledPage(ctx, memberIDs(members), p)
+	})
+}
+
+// AddListMember adds a user to a list - nothing cached to backfill
+func (s *FanOutReadStrategy) AddListMember(ctx context.Context, listID, userID int64) (*OperationMetrics, error) {
+	return s.lists.addMember(ctx, s.Name(), listID, userID, false)
 }
 
-// DeleteTweet simply deletes the tweet - no cache invalidation needed
-func (s *FanOutReadStrategy) DeleteTweet(ctx context.Context, tweetID int64, userID int64) error {
-	return s.tweetRepo.Delete(ctx, tweetID)
+// RemoveListMember removes a user from a list - nothing cached to evict
+func (s *FanOutReadStrategy) RemoveListMember(ctx context.Context, listID, userID int64) (*OperationMetrics, error) {
+	return s.lists.removeMember(ctx, s.Name(), listID, userID, false)
 }
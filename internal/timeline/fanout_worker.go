@@ -0,0 +1,312 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// FanOutWorkerConfig controls the async fan-out worker pool
+type FanOutWorkerConfig struct {
+	Workers      int           // Number of concurrent workers
+	ChunkSize    int           // Followers written per Redis pipeline
+	MaxRetries   int           // Attempts before a job is dead-lettered
+	RetryBackoff time.Duration // Wait before the first retry, doubled for each one after
+	BlockTimeout time.Duration // How long a worker waits for new jobs per poll
+	ClaimIdle    time.Duration // Unacked jobs idle longer than this are reclaimed
+}
+
+// DefaultFanOutWorkerConfig returns sensible defaults for the worker pool
+func DefaultFanOutWorkerConfig() FanOutWorkerConfig {
+	return FanOutWorkerConfig{
+		Workers:      8,
+		ChunkSize:    1000,
+		MaxRetries:   3,
+		RetryBackoff: time.Second,
+		BlockTimeout: 2 * time.Second,
+		ClaimIdle:    30 * time.Second,
+	}
+}
+
+// FanOutWorkerPool consumes fan-out jobs from the queue and pushes tweets
+// into followers' timelines in chunks
+type FanOutWorkerPool struct {
+	queue      *cache.FanOutQueue
+	tweetRepo  repository.TweetStore
+	followRepo repository.FollowStore
+	cache      cache.TimelineStore
+	cfg        FanOutWorkerConfig
+	onComplete func(*OperationMetrics)
+	blocks     *Blocks // Optional - leaves viewers who hid the author out when purging
+	lists      *Lists  // Optional - pushes tweets into list timelines too
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewFanOutWorkerPool creates a new FanOutWorkerPool
+func NewFanOutWorkerPool(
+	queue *cache.FanOutQueue,
+	tweetRepo repository.TweetStore,
+	followRepo repository.FollowStore,
+	cache cache.TimelineStore,
+	cfg FanOutWorkerConfig,
+) *FanOutWorkerPool {
+	defaults := DefaultFanOutWorkerConfig()
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaults.Workers
+	}
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = defaults.ChunkSize
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = defaults.MaxRetries
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaults.RetryBackoff
+	}
+	if cfg.BlockTimeout <= 0 {
+		cfg.BlockTimeout = defaults.BlockTimeout
+	}
+	if cfg.ClaimIdle <= 0 {
+		cfg.ClaimIdle = defaults.ClaimIdle
+	}
+
+	return &FanOutWorkerPool{
+		queue:      queue,
+		tweetRepo:  tweetRepo,
+		followRepo: followRepo,
+		cache:      cache,
+		cfg:        cfg,
+	}
+}
+
+// SetBlocks leaves the followers who blocked or muted a tweet's author out
+// of its fan-out when purging. Call it before Start.
+func (p *FanOutWorkerPool) SetBlocks(b *Blocks) {
+	p.blocks = b
+}
+
+// SetLists makes jobs push tweets into the timelines of the lists
+// containing their author as well. Call it before Start.
+func (p *FanOutWorkerPool) SetLists(l *Lists) {
+	p.lists = l
+}
+
+// OnComplete registers a callback that receives metrics for every processed job
+func (p *FanOutWorkerPool) OnComplete(fn func(*OperationMetrics)) {
+	p.onComplete = fn
+}
+
+// Start launches the workers. They run until Stop is called or ctx is cancelled.
+func (p *FanOutWorkerPool) Start(ctx context.Context) error {
+	if err := p.queue.EnsureGroup(ctx); err != nil {
+		return err
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	for i := 0; i < p.cfg.Workers; i++ {
+		consumer := fmt.Sprintf("worker-%d-%d", os.Getpid(), i)
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.run(ctx, consumer)
+		}()
+	}
+
+	return nil
+}
+
+// Stop signals the workers to exit and waits for in-flight jobs to finish
+func (p *FanOutWorkerPool) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+// run is the main loop of a single worker
+func (p *FanOutWorkerPool) run(ctx context.Context, consumer string) {
+	lastClaim := time.Now()
+
+	for ctx.Err() == nil {
+		// Periodically pick up jobs abandoned by crashed workers
+		if time.Since(lastClaim) >= p.cfg.ClaimIdle {
+			lastClaim = time.Now()
+			if jobs, err := p.queue.Reclaim(ctx, consumer, p.cfg.ClaimIdle, 10); err == nil {
+				p.handle(ctx, jobs)
+			}
+		}
+
+		// Put retries whose backoff has passed back on the queue
+		if _, err := p.queue.PromoteDue(ctx); err != nil && ctx.Err() == nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+
+		jobs, err := p.queue.Read(ctx, consumer, 10, p.cfg.BlockTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Printf("Warning: failed to read fan-out jobs: %v\n", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		p.handle(ctx, jobs)
+	}
+}
+
+// handle processes a batch of jobs and acks, retries or dead-letters each one
+func (p *FanOutWorkerPool) handle(ctx context.Context, jobs []*cache.QueuedJob) {
+	for _, qj := range jobs {
+		metrics := p.process(ctx, qj.Job)
+
+		switch {
+		case metrics.Success:
+			if err := p.queue.Ack(ctx, qj.MessageID); err != nil {
+				fmt.Printf("Warning: failed to ack fan-out job: %v\n", err)
+			}
+		case qj.Job.Attempt+1 >= p.cfg.MaxRetries:
+			fmt.Printf("Warning: fan-out for tweet %d failed after %d attempts: %v\n", qj.Job.TweetID, qj.Job.Attempt+1, metrics.Error)
+			if err := p.queue.DeadLetter(ctx, qj); err != nil {
+				fmt.Printf("Warning: failed to dead-letter fan-out job: %v\n", err)
+			}
+		default:
+			if err := p.queue.Retry(ctx, qj, p.retryDelay(qj.Job.Attempt)); err != nil {
+				fmt.Printf("Warning: failed to retry fan-out job: %v\n", err)
+			}
+		}
+
+		if p.onComplete != nil {
+			p.onComplete(metrics)
+		}
+	}
+}
+
+// maxRetryBackoff caps the wait between retries however many are allowed
+const maxRetryBackoff = 5 * time.Minute
+
+// retryDelay returns how long a job that failed on the given attempt (0 for
+// the first) waits before it's retried: RetryBackoff, doubled per attempt
+func (p *FanOutWorkerPool) retryDelay(attempt int) time.Duration {
+	delay := p.cfg.RetryBackoff
+	for i := 0; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
+
+// process fans a single tweet out to the author's followers chunk by chunk,
+// then to the author's lists.
+// Re-running a partially completed job is safe since ZADD with the same score is idempotent.
+func (p *FanOutWorkerPool) process(ctx context.Context, job *models.FanOutJob) *OperationMetrics {
+	metrics := &OperationMetrics{
+		Strategy:  job.Strategy,
+		Operation: "fan_out",
+		StartTime: time.Now(),
+	}
+	metrics.QueueLag = metrics.StartTime.Sub(job.EnqueuedAt)
+
+	// A tweet deleted while its job waited in the queue, or for a retry, has
+	// already been purged from timelines, so pushing it would bring it back
+	gone, err := p.deleted(ctx, job.TweetID)
+	if err != nil || gone {
+		metrics.Error = err
+		metrics.Success = err == nil
+		metrics.EndTime = time.Now()
+		return metrics
+	}
+
+	followers, err := p.followRepo.GetFollowers(ctx, job.AuthorID)
+	if err != nil {
+		metrics.Error = fmt.Errorf("failed to get followers: %w", err)
+		metrics.EndTime = time.Now()
+		return metrics
+	}
+
+	// A reply filtered at fan-out only goes to followers of both participants
+	if job.ReplyAudience {
+		if followers, err = narrowAudience(ctx, p.followRepo, followers, job.InReplyToUserID); err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return metrics
+		}
+	}
+
+	// Only the ID and timestamp are needed to score the timeline entry; the
+	// reply target and original author travel with it so viewers who hid
+	// someone, and streaming clients, can filter it
+	tweet := &models.Tweet{ID: job.TweetID, UserID: job.AuthorID, InReplyToUserID: job.InReplyToUserID, OriginalUserID: job.OriginalUserID, CreatedAt: job.CreatedAt}
+
+	if followers, err = p.blocks.audience(ctx, tweet, followers); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics
+	}
+
+	metrics.FanOutCount = len(followers)
+
+	fanOutStart := time.Now()
+	for start := 0; start < len(followers); start += p.cfg.ChunkSize {
+		end := start + p.cfg.ChunkSize
+		if end > len(followers) {
+			end = len(followers)
+		}
+		if err := p.cache.AddToTimelineBatch(ctx, followers[start:end], tweet); err != nil {
+			metrics.Error = err
+			metrics.FanOutDuration = time.Since(fanOutStart)
+			metrics.EndTime = time.Now()
+			return metrics
+		}
+	}
+
+	// Then to the lists containing the author
+	listCount, err := p.lists.fanOut(ctx, tweet)
+	if err != nil {
+		metrics.Error = err
+		metrics.FanOutDuration = time.Since(fanOutStart)
+		metrics.EndTime = time.Now()
+		return metrics
+	}
+	metrics.ListFanOutCount = listCount
+	metrics.FanOutCount += listCount
+
+	// A delete that landed while the tweet was being fanned out may have
+	// purged these timelines before they were written, so take it back out.
+	// DeleteTweet removes the row before purging, so either it sees these
+	// entries or this check sees the tweet gone.
+	if gone, err := p.deleted(ctx, job.TweetID); err == nil && gone {
+		if err := p.cache.RemoveFromTimelineBatch(ctx, followers, job.TweetID); err != nil {
+			fmt.Printf("Warning: failed to remove deleted tweet %d from some timelines: %v\n", job.TweetID, err)
+		}
+		if _, err := p.lists.unpublish(ctx, job.TweetID, job.AuthorID); err != nil {
+			fmt.Printf("Warning: failed to remove deleted tweet %d from list timelines: %v\n", job.TweetID, err)
+		}
+	}
+	metrics.FanOutDuration = time.Since(fanOutStart)
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return metrics
+}
+
+// deleted reports whether the tweet a job fans out no longer exists
+func (p *FanOutWorkerPool) deleted(ctx context.Context, tweetID int64) (bool, error) {
+	_, err := p.tweetRepo.GetByID(ctx, tweetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	return false, err
+}
@@ -0,0 +1,113 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+func TestRetryDelayDoublesUpToCap(t *testing.T) {
+	p := NewFanOutWorkerPool(nil, nil, nil, nil, FanOutWorkerConfig{RetryBackoff: time.Second})
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{8, 256 * time.Second},
+		{9, maxRetryBackoff},
+		{100, maxRetryBackoff},
+	}
+	for _, tt := range tests {
+		if got := p.retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// jobFor returns the fan-out job that posting tweet asynchronously would queue
+func jobFor(tweet *models.Tweet) *models.FanOutJob {
+	return &models.FanOutJob{
+		TweetID:    tweet.ID,
+		AuthorID:   tweet.UserID,
+		Strategy:   "fanout_write",
+		CreatedAt:  tweet.CreatedAt,
+		EnqueuedAt: tweet.CreatedAt,
+	}
+}
+
+func TestFanOutJobForADeletedTweetPushesNothing(t *testing.T) {
+	f := newFixture(t)
+	s := NewFanOutWriteStrategy(f.tweets, f.follows, f.users, f.cache)
+	p := NewFanOutWorkerPool(nil, f.tweets, f.follows, f.cache, FanOutWorkerConfig{})
+	u := f.newUsers(t, 2)
+	author, follower := u[0], u[1]
+	f.follow(t, s, follower, author)
+
+	// The tweet is deleted while its job is still queued
+	tweet, err := f.tweets.Create(f.ctx, author, "hello")
+	if err != nil {
+		t.Fatalf("create tweet: %v", err)
+	}
+	if _, err := s.DeleteTweet(f.ctx, tweet.ID, author); err != nil {
+		t.Fatalf("DeleteTweet: %v", err)
+	}
+
+	metrics := p.process(f.ctx, jobFor(tweet))
+	if !metrics.Success || metrics.FanOutCount != 0 {
+		t.Errorf("process = %+v, want success without fanning out, so the job is acked", metrics)
+	}
+	if got := f.cached(t, follower); len(got) != 0 {
+		t.Errorf("follower's timeline %v, want the deleted tweet left out", got)
+	}
+}
+
+// deleteDuringFanOut deletes a tweet just before its fan-out writes the
+// first chunk, like a delete landing while a worker is part way through
+type deleteDuringFanOut struct {
+	cache.TimelineStore
+	delete func()
+}
+
+func (d *deleteDuringFanOut) AddToTimelineBatch(ctx context.Context, userIDs []int64, tweet *models.Tweet) error {
+	if d.delete != nil {
+		d.delete()
+		d.delete = nil
+	}
+	return d.TimelineStore.AddToTimelineBatch(ctx, userIDs, tweet)
+}
+
+func TestFanOutJobTakesBackATweetDeletedMidway(t *testing.T) {
+	f := newFixture(t)
+	s := NewFanOutWriteStrategy(f.tweets, f.follows, f.users, f.cache)
+	store := &deleteDuringFanOut{TimelineStore: f.cache}
+	p := NewFanOutWorkerPool(nil, f.tweets, f.follows, store, FanOutWorkerConfig{ChunkSize: 1})
+	u := f.newUsers(t, 3)
+	author, followers := u[0], u[1:]
+	for _, id := range followers {
+		f.follow(t, s, id, author)
+	}
+
+	tweet, err := f.tweets.Create(f.ctx, author, "hello")
+	if err != nil {
+		t.Fatalf("create tweet: %v", err)
+	}
+	store.delete = func() {
+		if _, err := s.DeleteTweet(f.ctx, tweet.ID, author); err != nil {
+			t.Errorf("DeleteTweet: %v", err)
+		}
+	}
+
+	if metrics := p.process(f.ctx, jobFor(tweet)); !metrics.Success {
+		t.Fatalf("process failed: %v", metrics.Error)
+	}
+	for _, id := range followers {
+		if got := f.cached(t, id); len(got) != 0 {
+			t.Errorf("follower %d's timeline %v, want the deleted tweet taken back out", id, got)
+		}
+	}
+}
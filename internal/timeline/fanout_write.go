@@ -13,32 +13,84 @@ import (
 // FanOutWriteStrategy implements the fan-out-on-write approach
 // When a user posts a tweet, it's immediately pushed to all followers' timeline caches
 type FanOutWriteStrategy struct {
-	tweetRepo  *repository.TweetRepository
-	followRepo *repository.FollowRepository
-	userRepo   *repository.UserRepository
-	cache      *cache.TimelineCache
+	tweetRepo  repository.TweetStore
+	followRepo repository.FollowStore
+	userRepo   repository.UserStore
+	cache      cache.TimelineStore
+	queue      *cache.FanOutQueue // When set, fan-out is deferred to the worker pool
+	replyMode  string             // ReplyFilterRead or ReplyFilterFanOut
+	counters   *Counters          // Optional - embeds like and retweet counts
+	blocks     *Blocks            // Optional - hides blocked and muted authors
+	lists      *Lists             // Optional - pushes tweets into list timelines too
 }
 
 // NewFanOutWriteStrategy creates a new FanOutWriteStrategy
 func NewFanOutWriteStrategy(
-	tweetRepo *repository.TweetRepository,
-	followRepo *repository.FollowRepository,
-	userRepo *repository.UserRepository,
-	cache *cache.TimelineCache,
+	tweetRepo repository.TweetStore,
+	followRepo repository.FollowStore,
+	userRepo repository.UserStore,
+	cache cache.TimelineStore,
 ) *FanOutWriteStrategy {
 	return &FanOutWriteStrategy{
 		tweetRepo:  tweetRepo,
 		followRepo: followRepo,
 		userRepo:   userRepo,
 		cache:      cache,
+		replyMode:  ReplyFilterRead,
 	}
 }
 
+func init() {
+	Register(StrategyFanOutWrite, 10, func(deps Dependencies) Strategy {
+		return withOptional(NewFanOutWriteStrategy(deps.TweetRepo, deps.FollowRepo, deps.UserRepo, deps.Cache), deps)
+	})
+}
+
 // Name returns the strategy name
 func (s *FanOutWriteStrategy) Name() string {
 	return "fanout_write"
 }
 
+// SetFanOutQueue enables async fan-out: PostTweet enqueues a job instead of
+// writing to followers' timelines inline. Pass nil to go back to inline fan-out.
+func (s *FanOutWriteStrategy) SetFanOutQueue(queue *cache.FanOutQueue) {
+	s.queue = queue
+}
+
+// FanOutQueue returns the async fan-out queue, or nil when fanning out inline
+func (s *FanOutWriteStrategy) FanOutQueue() *cache.FanOutQueue {
+	return s.queue
+}
+
+// SetReplyFilterMode sets whether replies are filtered when they're pushed
+// or when timelines are read
+func (s *FanOutWriteStrategy) SetReplyFilterMode(mode string) {
+	s.replyMode = mode
+}
+
+// ReplyFilterMode returns the reply filter mode
+func (s *FanOutWriteStrategy) ReplyFilterMode() string {
+	return s.replyMode
+}
+
+// SetCounters makes timelines embed like and retweet counts and keeps the
+// retweet counts up to date
+func (s *FanOutWriteStrategy) SetCounters(c *Counters) {
+	s.counters = c
+}
+
+// SetBlocks hides blocked and muted authors from timelines: at read time,
+// or when purging, by leaving the viewers who hid them out of fan-out
+func (s *FanOutWriteStrategy) SetBlocks(b *Blocks) {
+	s.blocks = b
+}
+
+// SetLists pushes tweets into the timelines of the lists containing their
+// author as well as into followers' timelines
+func (s *FanOutWriteStrategy) SetLists(l *Lists) {
+	s.lists = l
+}
+
 // PostTweet creates a tweet and fans out to all followers' caches
 func (s *FanOutWriteStrategy) PostTweet(ctx context.Context, userID int64, content string) (*models.Tweet, *OperationMetrics, error) {
 	metrics := &OperationMetrics{
@@ -47,7 +99,7 @@ func (s *FanOutWriteStrategy) PostTweet(ctx context.Context, userID int64, conte
 		StartTime: time.Now(),
 	}
 
-	// 1. Create the tweet in PostgreSQL
+	// Create the tweet in PostgreSQL
 	tweet, err := s.tweetRepo.Create(ctx, userID, content)
 	if err != nil {
 		metrics.Error = err
@@ -61,11 +113,97 @@ func (s *FanOutWriteStrategy) PostTweet(ctx context.Context, userID int64, conte
 		tweet.Username = user.Username
 	}
 
-	// 2. Cache the tweet data
+	return s.deliver(ctx, tweet, metrics)
+}
+
+// PostReply creates a reply and fans it out to the author's followers like
+// a new tweet, or only to those who should see it when filtering at fan-out
+func (s *FanOutWriteStrategy) PostReply(ctx context.Context, userID, inReplyToID int64, content string) (*models.Tweet, *OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "post_reply",
+		StartTime: time.Now(),
+	}
+
+	tweet, err := s.tweetRepo.CreateReply(ctx, userID, inReplyToID, content)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return nil, metrics, fmt.Errorf("failed to create reply: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err == nil {
+		tweet.Username = user.Username
+	}
+
+	return s.deliver(ctx, tweet, metrics)
+}
+
+// Retweet creates a retweet and fans it out to the retweeter's followers
+// like a new tweet
+func (s *FanOutWriteStrategy) Retweet(ctx context.Context, userID, tweetID int64) (*models.Tweet, *OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "retweet",
+		StartTime: time.Now(),
+	}
+
+	tweet, err := s.tweetRepo.Retweet(ctx, userID, tweetID)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return nil, metrics, fmt.Errorf("failed to create retweet: %w", err)
+	}
+	if s.counters != nil {
+		s.counters.Retweeted(ctx, tweet)
+	}
+
+	return s.deliver(ctx, tweet, metrics)
+}
+
+// deliver caches a new tweet and pushes it into the author's and their
+// followers' timelines, and those of the lists containing the author
+func (s *FanOutWriteStrategy) deliver(ctx context.Context, tweet *models.Tweet, metrics *OperationMetrics) (*models.Tweet, *OperationMetrics, error) {
+	userID := tweet.UserID
+	participant := replyParticipant(tweet)
+	filterAtFanOut := participant != 0 && s.replyMode == ReplyFilterFanOut
+
+	// 1. Cache the tweet data and add it to the author's tweets
 	if err := s.cache.CacheTweet(ctx, tweet); err != nil {
 		// Log but don't fail - tweet is already persisted
 		fmt.Printf("Warning: failed to cache tweet: %v\n", err)
 	}
+	if err := s.cache.AddAuthorTweet(ctx, userID, tweet); err != nil {
+		fmt.Printf("Warning: failed to add author tweet: %v\n", err)
+	}
+
+	// 2. Async mode: hand the fan-out to the worker pool and return at once
+	if s.queue != nil {
+		job := &models.FanOutJob{
+			TweetID:         tweet.ID,
+			AuthorID:        userID,
+			InReplyToUserID: tweet.InReplyToUserID,
+			OriginalUserID:  tweet.OriginalUserID,
+			ReplyAudience:   filterAtFanOut,
+			Strategy:        s.Name(),
+			CreatedAt:       tweet.CreatedAt,
+		}
+		if err := s.queue.Enqueue(ctx, job); err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return tweet, metrics, fmt.Errorf("failed to enqueue fan-out: %w", err)
+		}
+		metrics.FanOutQueued = true
+
+		if err := s.cache.AddToTimeline(ctx, userID, tweet); err != nil {
+			fmt.Printf("Warning: failed to add to author's timeline: %v\n", err)
+		}
+
+		metrics.EndTime = time.Now()
+		metrics.Success = true
+		return tweet, metrics, nil
+	}
 
 	// 3. Get all followers
 	followers, err := s.followRepo.GetFollowers(ctx, userID)
@@ -75,6 +213,22 @@ func (s *FanOutWriteStrategy) PostTweet(ctx context.Context, userID int64, conte
 		return tweet, metrics, fmt.Errorf("failed to get followers: %w", err)
 	}
 
+	// A reply filtered at fan-out only goes to followers of both participants
+	if filterAtFanOut {
+		if followers, err = narrowAudience(ctx, s.followRepo, followers, participant); err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return tweet, metrics, err
+		}
+	}
+
+	// Viewers who hid the author are left out when purging
+	if followers, err = s.blocks.audience(ctx, tweet, followers); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return tweet, metrics, err
+	}
+
 	metrics.FanOutCount = len(followers)
 
 	// 4. Fan out to all followers' timelines
@@ -87,7 +241,17 @@ func (s *FanOutWriteStrategy) PostTweet(ctx context.Context, userID int64, conte
 		metrics.FanOutDuration = time.Since(fanOutStart)
 	}
 
-	// 5. Also add to the author's own timeline
+	// 5. Fan out to the timelines of lists containing the author
+	listStart := time.Now()
+	listCount, err := s.lists.fanOut(ctx, tweet)
+	if err != nil {
+		fmt.Printf("Warning: failed to fan out to list timelines: %v\n", err)
+	}
+	metrics.ListFanOutCount = listCount
+	metrics.FanOutCount += listCount
+	metrics.FanOutDuration += time.Since(listStart)
+
+	// 6. Also add to the author's own timeline
 	if err := s.cache.AddToTimeline(ctx, userID, tweet); err != nil {
 		fmt.Printf("Warning: failed to add to author's timeline: %v\n", err)
 	}
@@ -98,28 +262,64 @@ func (s *FanOutWriteStrategy) PostTweet(ctx context.Context, userID int64, conte
 	return tweet, metrics, nil
 }
 
-// GetTimeline retrieves a user's timeline from cache
-func (s *FanOutWriteStrategy) GetTimeline(ctx context.Context, userID int64, limit, offset int) ([]*models.Tweet, *OperationMetrics, error) {
+// GetTimeline retrieves a page of a user's timeline from cache
+func (s *FanOutWriteStrategy) GetTimeline(ctx context.Context, userID int64, page models.Page) ([]*models.Tweet, *OperationMetrics, error) {
 	metrics := &OperationMetrics{
 		Strategy:  s.Name(),
 		Operation: "get_timeline",
 		StartTime: time.Now(),
 	}
 
-	// 1. Get tweet IDs from cache
-	tweetIDs, err := s.cache.GetTimeline(ctx, userID, limit, offset)
+	// Replies filtered at fan-out were only pushed to those who should see
+	// them, and purged timelines hold no hidden authors. Retweets of
+	// protected tweets are always checked.
+	var keep func(t *models.Tweet) (bool, error)
+	if s.replyMode != ReplyFilterFanOut {
+		visibility := NewReplyVisibility(s.followRepo, userID)
+		keep = func(t *models.Tweet) (bool, error) {
+			return visibility.Visible(ctx, t)
+		}
+	}
+	if hidden := s.blocks.HiddenAuthors(userID); hidden != nil && !s.blocks.purging() {
+		keep = keepBoth(keep, func(t *models.Tweet) (bool, error) {
+			return hidden.Visible(ctx, t)
+		})
+	}
+	privacy := NewPrivacy(s.userRepo, s.followRepo, userID)
+	keep = keepBoth(keep, func(t *models.Tweet) (bool, error) {
+		return privacy.Visible(ctx, t)
+	})
+
+	tweets, err := collectPage(page, func(p models.Page) ([]*models.Tweet, error) {
+		return s.timelinePage(ctx, userID, p, metrics)
+	}, keep)
+	if err == nil {
+		embedCounts(ctx, s.counters, tweets)
+	}
+	metrics.PrivacyCheck = privacy.Elapsed()
+	metrics.EndTime = time.Now()
 	if err != nil {
 		metrics.Error = err
-		metrics.EndTime = time.Now()
-		return nil, metrics, fmt.Errorf("failed to get timeline from cache: %w", err)
+		return nil, metrics, err
+	}
+
+	metrics.Success = true
+
+	return tweets, metrics, nil
+}
+
+// timelinePage reads one page of timeline entries from cache, before
+// retweets are collapsed
+func (s *FanOutWriteStrategy) timelinePage(ctx context.Context, userID int64, page models.Page, metrics *OperationMetrics) ([]*models.Tweet, error) {
+	// 1. Get tweet IDs from cache
+	tweetIDs, err := s.cache.GetTimelinePage(ctx, userID, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timeline from cache: %w", err)
 	}
 
 	if len(tweetIDs) == 0 {
 		// Timeline is empty or not cached - could rebuild from DB
-		metrics.CacheHit = false
-		metrics.EndTime = time.Now()
-		metrics.Success = true
-		return []*models.Tweet{}, metrics, nil
+		return []*models.Tweet{}, nil
 	}
 
 	metrics.CacheHit = true
@@ -136,9 +336,7 @@ func (s *FanOutWriteStrategy) GetTimeline(ctx context.Context, userID int64, lim
 	if len(missingIDs) > 0 {
 		dbTweets, err := s.tweetRepo.GetByIDs(ctx, missingIDs)
 		if err != nil {
-			metrics.Error = err
-			metrics.EndTime = time.Now()
-			return tweets, metrics, fmt.Errorf("failed to get tweets from DB: %w", err)
+			return nil, fmt.Errorf("failed to get tweets from DB: %w", err)
 		}
 		tweets = append(tweets, dbTweets...)
 
@@ -149,37 +347,45 @@ func (s *FanOutWriteStrategy) GetTimeline(ctx context.Context, userID int64, lim
 	// 4. Sort tweets by created_at (most recent first)
 	sortTweetsByTime(tweets)
 
-	metrics.EndTime = time.Now()
-	metrics.Success = true
+	return tweets, nil
+}
 
-	return tweets, metrics, nil
+// TimelineSources returns the users whose tweets are pushed into userID's timeline:
+// everyone they follow plus themselves
+func (s *FanOutWriteStrategy) TimelineSources(ctx context.Context, userID int64) ([]int64, error) {
+	following, err := s.followRepo.GetFollowing(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get following: %w", err)
+	}
+	return append(following, userID), nil
 }
 
 // RebuildTimeline rebuilds a user's timeline cache from scratch
 func (s *FanOutWriteStrategy) RebuildTimeline(ctx context.Context, userID int64, limit int) error {
-	// Get users this person follows
-	following, err := s.followRepo.GetFollowing(ctx, userID)
+	// Get users this person follows, including themselves
+	sources, err := s.TimelineSources(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("failed to get following: %w", err)
+		return err
 	}
 
-	// Include user's own tweets
-	following = append(following, userID)
-
 	// Get recent tweets from all followed users
-	tweets, err := s.tweetRepo.GetByUserIDs(ctx, following, limit)
+	tweets, err := s.tweetRepo.GetByUserIDs(ctx, sources, models.Page{Limit: limit})
 	if err != nil {
 		return fmt.Errorf("failed to get tweets: %w", err)
 	}
+	if tweets, err = pushable(ctx, s.replyMode, s.followRepo, userID, tweets); err != nil {
+		return err
+	}
+	if tweets, err = s.blocks.pushable(ctx, userID, tweets); err != nil {
+		return err
+	}
 
 	// Clear existing timeline
 	s.cache.ClearTimeline(ctx, userID)
 
 	// Add tweets to timeline
-	for _, tweet := range tweets {
-		if err := s.cache.AddToTimeline(ctx, userID, tweet); err != nil {
-			return fmt.Errorf("failed to add tweet to timeline: %w", err)
-		}
+	if err := s.cache.AddTweetsToTimeline(ctx, userID, tweets); err != nil {
+		return fmt.Errorf("failed to add tweets to timeline: %w", err)
 	}
 
 	// Cache tweet data
@@ -188,24 +394,200 @@ func (s *FanOutWriteStrategy) RebuildTimeline(ctx context.Context, userID int64,
 	return nil
 }
 
-// DeleteTweet removes a tweet and updates all followers' caches
-func (s *FanOutWriteStrategy) DeleteTweet(ctx context.Context, tweetID int64, userID int64) error {
-	// Get followers to update their caches
+// DeleteTweet removes a tweet and its retweets and updates all followers' caches
+func (s *FanOutWriteStrategy) DeleteTweet(ctx context.Context, tweetID int64, userID int64) (*OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "delete_tweet",
+		StartTime: time.Now(),
+	}
+
+	deleted := countDeletion(ctx, s.counters, s.tweetRepo, tweetID)
+
+	// 1. Retweets are deleted along with the tweet, so pull them out of
+	// their retweeters' followers' timelines too
+	retweets, err := s.tweetRepo.GetRetweets(ctx, tweetID)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, fmt.Errorf("failed to get retweets: %w", err)
+	}
+
+	// 2. Delete from database first: a fan-out job still in flight checks
+	// that the tweet exists after writing, so it either sees it gone and
+	// takes its entries back out, or wrote them before they're purged below
+	if err := s.tweetRepo.Delete(ctx, tweetID); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+	deleted()
+
+	// 3. Remove the retweets and the tweet itself from every timeline
+	for _, rt := range retweets {
+		if err := s.unpublish(ctx, rt.ID, rt.UserID, metrics); err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return metrics, err
+		}
+	}
+	if err := s.unpublish(ctx, tweetID, userID, metrics); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return metrics, nil
+}
+
+// unpublish removes a tweet from its author's and their followers' timelines
+// and those of the lists containing the author, and purges the cached tweet
+func (s *FanOutWriteStrategy) unpublish(ctx context.Context, tweetID, userID int64, metrics *OperationMetrics) error {
+	// 1. Get followers to update their caches
 	followers, err := s.followRepo.GetFollowers(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get followers: %w", err)
 	}
 
-	// Remove from all followers' timelines
+	metrics.FanOutCount += len(followers)
+
+	// 2. Remove from all followers' timelines
 	if len(followers) > 0 {
+		fanOutStart := time.Now()
 		if err := s.cache.RemoveFromTimelineBatch(ctx, followers, tweetID); err != nil {
 			fmt.Printf("Warning: failed to remove from some timelines: %v\n", err)
 		}
+		metrics.FanOutDuration += time.Since(fanOutStart)
 	}
 
-	// Remove from author's timeline
+	// 3. Remove from the timelines of lists containing the author
+	listStart := time.Now()
+	listCount, err := s.lists.unpublish(ctx, tweetID, userID)
+	if err != nil {
+		return err
+	}
+	metrics.ListFanOutCount += listCount
+	metrics.FanOutCount += listCount
+	metrics.FanOutDuration += time.Since(listStart)
+
+	// 4. Remove from author's timeline and tweets and purge the cached tweet
 	s.cache.RemoveFromTimeline(ctx, userID, tweetID)
+	s.cache.RemoveAuthorTweet(ctx, userID, tweetID)
+	s.cache.InvalidateTweet(ctx, tweetID)
+
+	return nil
+}
+
+// Follow creates a follow relationship and backfills the followee's recent
+// tweets into the follower's timeline cache
+func (s *FanOutWriteStrategy) Follow(ctx context.Context, followerID, followeeID int64) (*OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "follow",
+		StartTime: time.Now(),
+	}
+
+	// 1. Update the graph
+	if err := s.followRepo.Create(ctx, followerID, followeeID); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+
+	// 2. Merge the followee's recent tweets into the follower's timeline
+	tweets, err := s.tweetRepo.GetByUserID(ctx, followeeID, s.cache.MaxTimelineSize())
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, fmt.Errorf("failed to get followee tweets: %w", err)
+	}
+	if tweets, err = pushable(ctx, s.replyMode, s.followRepo, followerID, tweets); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+	if tweets, err = s.blocks.pushable(ctx, followerID, tweets); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+
+	metrics.FanOutCount = len(tweets)
+
+	fanOutStart := time.Now()
+	if err := s.cache.AddTweetsToTimeline(ctx, followerID, tweets); err != nil {
+		fmt.Printf("Warning: failed to backfill timeline: %v\n", err)
+	}
+	s.cache.CacheTweetsBatch(ctx, tweets)
+	metrics.FanOutDuration = time.Since(fanOutStart)
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return metrics, nil
+}
+
+// Unfollow removes a follow relationship and evicts the followee's tweets
+// from the follower's timeline cache
+func (s *FanOutWriteStrategy) Unfollow(ctx context.Context, followerID, followeeID int64) (*OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "unfollow",
+		StartTime: time.Now(),
+	}
+
+	// 1. Update the graph
+	if err := s.followRepo.Delete(ctx, followerID, followeeID); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+
+	// 2. Evict anything of the followee's that could still be in the timeline
+	tweets, err := s.tweetRepo.GetByUserID(ctx, followeeID, s.cache.MaxTimelineSize())
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, fmt.Errorf("failed to get followee tweets: %w", err)
+	}
+
+	tweetIDs := make([]int64, len(tweets))
+	for i, t := range tweets {
+		tweetIDs[i] = t.ID
+	}
+
+	metrics.FanOutCount = len(tweetIDs)
+
+	fanOutStart := time.Now()
+	if err := s.cache.RemoveTweetsFromTimeline(ctx, followerID, tweetIDs); err != nil {
+		fmt.Printf("Warning: failed to evict from timeline: %v\n", err)
+	}
+	metrics.FanOutDuration = time.Since(fanOutStart)
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return metrics, nil
+}
+
+// GetListTimeline retrieves a page of a list's timeline from cache
+func (s *FanOutWriteStrategy) GetListTimeline(ctx context.Context, listID int64, page models.Page) ([]*models.Tweet, *OperationMetrics, error) {
+	return s.lists.timeline(ctx, s.Name(), s.counters, listID, page, func(members []*models.User, p models.Page, metrics *OperationMetrics) ([]*models.Tweet, error) {
+		return s.lists.pushedPage(ctx, listID, p, metrics)
+	})
+}
+
+// AddListMember adds a user to a list and backfills their recent tweets
+// into the list's timeline cache
+func (s *FanOutWriteStrategy) AddListMember(ctx context.Context, listID, userID int64) (*OperationMetrics, error) {
+	return s.lists.addMember(ctx, s.Name(), listID, userID, true)
+}
 
-	// Delete from database
-	return s.tweetRepo.Delete(ctx, tweetID)
+// RemoveListMember removes a user from a list and evicts their tweets from
+// the list's timeline cache
+func (s *FanOutWriteStrategy) RemoveListMember(ctx context.Context, listID, userID int64) (*OperationMetrics, error) {
+	return s.lists.removeMember(ctx, s.Name(), listID, userID, true)
 }
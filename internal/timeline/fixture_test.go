@@ -0,0 +1,95 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/memory"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// fixture is an in-memory backend with a clock that only moves when a test
+// posts, so every tweet gets its own timestamp
+type fixture struct {
+	ctx     context.Context
+	now     time.Time
+	db      *memory.DB
+	users   *memory.UserRepository
+	tweets  *memory.TweetRepository
+	follows *memory.FollowRepository
+	cache   *memory.TimelineCache
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		ctx: context.Background(),
+		now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		db:  memory.NewDB(),
+	}
+	f.db.SetClock(func() time.Time { return f.now })
+	f.users = memory.NewUserRepository(f.db)
+	f.tweets = memory.NewTweetRepository(f.db)
+	f.follows = memory.NewFollowRepository(f.db)
+	f.cache = memory.NewTimelineCache(800)
+	return f
+}
+
+// newUsers creates n users and returns their IDs
+func (f *fixture) newUsers(t *testing.T, n int) []int64 {
+	t.Helper()
+	ids := make([]int64, n)
+	for i := range ids {
+		user, err := f.users.Create(f.ctx, fmt.Sprintf("user_%d", i+1))
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		ids[i] = user.ID
+	}
+	return ids
+}
+
+// post advances the clock a minute and posts a tweet through s
+func (f *fixture) post(t *testing.T, s Strategy, userID int64) *models.Tweet {
+	t.Helper()
+	f.now = f.now.Add(time.Minute)
+	tweet, _, err := s.PostTweet(f.ctx, userID, fmt.Sprintf("tweet at %s", f.now.Format(time.Kitchen)))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	return tweet
+}
+
+// follow makes follower follow followee through s
+func (f *fixture) follow(t *testing.T, s Strategy, follower, followee int64) {
+	t.Helper()
+	if _, err := s.Follow(f.ctx, follower, followee); err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+}
+
+// cached returns the tweet IDs in userID's cached timeline, newest first
+func (f *fixture) cached(t *testing.T, userID int64) []int64 {
+	t.Helper()
+	ids, err := f.cache.GetTimeline(f.ctx, userID, 800, 0)
+	if err != nil {
+		t.Fatalf("get cached timeline: %v", err)
+	}
+	return ids
+}
+
+// ids returns the IDs of tweets
+func ids(tweets ...*models.Tweet) []int64 {
+	out := make([]int64, len(tweets))
+	for i, t := range tweets {
+		out[i] = t.ID
+	}
+	return out
+}
+
+// pageOf returns the first page of limit tweets
+func pageOf(limit int) models.Page {
+	return models.Page{Limit: limit}
+}
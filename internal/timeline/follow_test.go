@@ -0,0 +1,84 @@
+package timeline
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFollowBackfillsAndUnfollowEvicts(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func(f *fixture) Strategy
+	}{
+		{"fanout_write", func(f *fixture) Strategy {
+			return NewFanOutWriteStrategy(f.tweets, f.follows, f.users, f.cache)
+		}},
+		{"hybrid", func(f *fixture) Strategy {
+			return NewHybridStrategy(f.tweets, f.follows, f.users, f.cache, 10)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			s := tt.new(f)
+			u := f.newUsers(t, 3)
+			reader, followee, other := u[0], u[1], u[2]
+
+			f.follow(t, s, reader, other)
+			first := f.post(t, s, followee)
+			mine := f.post(t, s, other)
+			second := f.post(t, s, followee)
+
+			// Following merges the followee's earlier tweets by time
+			metrics, err := s.Follow(f.ctx, reader, followee)
+			if err != nil {
+				t.Fatalf("follow: %v", err)
+			}
+			if metrics.FanOutCount != 2 {
+				t.Errorf("follow backfilled %d tweets, want 2", metrics.FanOutCount)
+			}
+			if got, want := f.cached(t, reader), ids(second, mine, first); !reflect.DeepEqual(got, want) {
+				t.Errorf("after follow: cached timeline %v, want %v", got, want)
+			}
+
+			// Unfollowing takes them out again and leaves the rest alone
+			if _, err := s.Unfollow(f.ctx, reader, followee); err != nil {
+				t.Fatalf("unfollow: %v", err)
+			}
+			if got, want := f.cached(t, reader), ids(mine); !reflect.DeepEqual(got, want) {
+				t.Errorf("after unfollow: cached timeline %v, want %v", got, want)
+			}
+			following, err := f.follows.IsFollowing(f.ctx, reader, followee)
+			if err != nil || following {
+				t.Errorf("IsFollowing after unfollow = %v, %v; want false", following, err)
+			}
+		})
+	}
+}
+
+func TestHybridFollowDoesNotBackfillCelebrities(t *testing.T) {
+	f := newFixture(t)
+	s := NewHybridStrategy(f.tweets, f.follows, f.users, f.cache, 2)
+	u := f.newUsers(t, 4)
+	celebrity, reader := u[0], u[3]
+	f.follow(t, s, u[1], celebrity)
+	f.follow(t, s, u[2], celebrity)
+	tweet := f.post(t, s, celebrity)
+
+	metrics, err := s.Follow(f.ctx, reader, celebrity)
+	if err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	if metrics.FanOutCount != 0 || len(f.cached(t, reader)) != 0 {
+		t.Errorf("following a celebrity pushed %d tweets, want none", metrics.FanOutCount)
+	}
+
+	// Their tweets are merged at read time instead
+	timeline, _, err := s.GetTimeline(f.ctx, reader, pageOf(10))
+	if err != nil {
+		t.Fatalf("get timeline: %v", err)
+	}
+	if got, want := ids(timeline...), ids(tweet); !reflect.DeepEqual(got, want) {
+		t.Errorf("timeline %v, want %v", got, want)
+	}
+}
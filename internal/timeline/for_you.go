@@ -0,0 +1,423 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// Candidate generation limits for for_you timelines
+const (
+	// forYouPoolFactor is how many in-network candidates are gathered for
+	// every tweet on the page
+	forYouPoolFactor = 3
+	// forYouFolloweesExplored caps the followees whose follows are searched
+	// for second-degree authors
+	forYouFolloweesExplored = 50
+	// forYouSecondDegreeAuthors caps the second-degree authors whose tweets
+	// are candidates
+	forYouSecondDegreeAuthors = 20
+)
+
+// ForYouStrategy ranks home timelines instead of showing them newest
+// first. Tweets are laid out exactly as hybrid lays them out - every write
+// goes through the embedded HybridStrategy - and each read gathers
+// candidates from the pushed timeline, followed celebrities' tweets and
+// second-degree follows' tweets, scores them with a Scorer and keeps the
+// best page. The time spent in each stage is reported in the read's
+// metrics. List timelines aren't ranked.
+type ForYouStrategy struct {
+	*HybridStrategy
+	scorer Scorer
+}
+
+// NewForYouStrategy creates a new ForYouStrategy
+func NewForYouStrategy(
+	tweetRepo repository.TweetStore,
+	followRepo repository.FollowStore,
+	userRepo repository.UserStore,
+	cache cache.TimelineStore,
+	celebrityThreshold int,
+	scorer Scorer,
+) *ForYouStrategy {
+	return &ForYouStrategy{
+		HybridStrategy: NewHybridStrategy(tweetRepo, followRepo, userRepo, cache, celebrityThreshold),
+		scorer:         scorer,
+	}
+}
+
+// for_you is wired exactly like hybrid: the optional dependencies are
+// handed to the embedded HybridStrategy through its promoted setters
+func init() {
+	Register(StrategyForYou, 40, func(deps Dependencies) Strategy {
+		scorer := NewScorer(deps.Config.RankingScorer, deps.FollowRepo)
+		return withOptional(NewForYouStrategy(deps.TweetRepo, deps.FollowRepo, deps.UserRepo, deps.Cache, deps.Config.CelebrityThreshold, scorer), deps)
+	})
+}
+
+// Name returns the strategy name
+func (s *ForYouStrategy) Name() string {
+	return "for_you"
+}
+
+// SetScorer changes how timelines are ranked
+func (s *ForYouStrategy) SetScorer(scorer Scorer) {
+	s.scorer = scorer
+}
+
+// Scorer returns the scorer timelines are ranked with
+func (s *ForYouStrategy) Scorer() Scorer {
+	return s.scorer
+}
+
+// relabel attributes metrics from the embedded HybridStrategy to for_you
+func (s *ForYouStrategy) relabel(metrics *OperationMetrics) *OperationMetrics {
+	if metrics != nil {
+		metrics.Strategy = s.Name()
+	}
+	return metrics
+}
+
+// PostTweet creates a tweet and delivers it as hybrid does
+func (s *ForYouStrategy) PostTweet(ctx context.Context, userID int64, content string) (*models.Tweet, *OperationMetrics, error) {
+	tweet, metrics, err := s.HybridStrategy.PostTweet(ctx, userID, content)
+	return tweet, s.relabel(metrics), err
+}
+
+// PostReply creates a reply and delivers it as hybrid does
+func (s *ForYouStrategy) PostReply(ctx context.Context, userID, inReplyToID int64, content string) (*models.Tweet, *OperationMetrics, error) {
+	tweet, metrics, err := s.HybridStrategy.PostReply(ctx, userID, inReplyToID, content)
+	return tweet, s.relabel(metrics), err
+}
+
+// Retweet creates a retweet and delivers it as hybrid does
+func (s *ForYouStrategy) Retweet(ctx context.Context, userID, tweetID int64) (*models.Tweet, *OperationMetrics, error) {
+	tweet, metrics, err := s.HybridStrategy.Retweet(ctx, userID, tweetID)
+	return tweet, s.relabel(metrics), err
+}
+
+// DeleteTweet deletes a tweet and unpublishes it as hybrid does
+func (s *ForYouStrategy) DeleteTweet(ctx context.Context, tweetID int64, userID int64) (*OperationMetrics, error) {
+	metrics, err := s.HybridStrategy.DeleteTweet(ctx, tweetID, userID)
+	return s.relabel(metrics), err
+}
+
+// Follow creates a follow relationship and backfills as hybrid does
+func (s *ForYouStrategy) Follow(ctx context.Context, followerID, followeeID int64) (*OperationMetrics, error) {
+	metrics, err := s.HybridStrategy.Follow(ctx, followerID, followeeID)
+	return s.relabel(metrics), err
+}
+
+// Unfollow removes a follow relationship and evicts as hybrid does
+func (s *ForYouStrategy) Unfollow(ctx context.Context, followerID, followeeID int64) (*OperationMetrics, error) {
+	metrics, err := s.HybridStrategy.Unfollow(ctx, followerID, followeeID)
+	return s.relabel(metrics), err
+}
+
+// GetListTimeline reads a list's timeline as hybrid does, newest first
+func (s *ForYouStrategy) GetListTimeline(ctx context.Context, listID int64, page models.Page) ([]*models.Tweet, *OperationMetrics, error) {
+	tweets, metrics, err := s.HybridStrategy.GetListTimeline(ctx, listID, page)
+	return tweets, s.relabel(metrics), err
+}
+
+// AddListMember adds a list member as hybrid does
+func (s *ForYouStrategy) AddListMember(ctx context.Context, listID, userID int64) (*OperationMetrics, error) {
+	metrics, err := s.HybridStrategy.AddListMember(ctx, listID, userID)
+	return s.relabel(metrics), err
+}
+
+// RemoveListMember removes a list member as hybrid does
+func (s *ForYouStrategy) RemoveListMember(ctx context.Context, listID, userID int64) (*OperationMetrics, error) {
+	metrics, err := s.HybridStrategy.RemoveListMember(ctx, listID, userID)
+	return s.relabel(metrics), err
+}
+
+// GetTimeline ranks one page of the timeline; see GetRankedTimeline
+func (s *ForYouStrategy) GetTimeline(ctx context.Context, userID int64, page models.Page) ([]*models.Tweet, *OperationMetrics, error) {
+	tweets, _, metrics, err := s.GetRankedTimeline(ctx, userID, page)
+	return tweets, metrics, err
+}
+
+// GetRankedTimeline ranks one page of the timeline and returns the cursor
+// for the next. Candidates are gathered in pools, each a stretch of the
+// timeline, and a pool is ranked as a whole and shown a page at a time, so
+// a candidate that isn't picked for one page is still there for the next.
+// The cursor pins the pool's newest candidate and counts how much of it has
+// been shown; a page that runs out of its pool carries on into the next,
+// older one.
+func (s *ForYouStrategy) GetRankedTimeline(ctx context.Context, userID int64, page models.Page) ([]*models.Tweet, *models.Cursor, *OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "get_timeline",
+		StartTime: time.Now(),
+		CacheHit:  true,
+	}
+
+	top, offset := page.MaxID, 0
+	if top != nil {
+		top, offset = &models.Cursor{CreatedAt: top.CreatedAt, ID: top.ID}, top.Offset
+	}
+
+	tweets := make([]*models.Tweet, 0, page.Limit)
+	var next *models.Cursor
+	for {
+		pool, err := s.rankPool(ctx, userID, models.Page{Limit: page.Limit, MaxID: top, SinceID: page.SinceID}, metrics)
+		if err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return nil, nil, metrics, err
+		}
+		if top == nil {
+			// Pin the newest pool, so tweets posted since don't change it
+			top = pool.top
+		}
+
+		rest := pool.ranked[min(offset, len(pool.ranked)):]
+		n := min(len(rest), page.Limit-len(tweets))
+		tweets = append(tweets, rest[:n]...)
+		if n < len(rest) {
+			next = &models.Cursor{CreatedAt: top.CreatedAt, ID: top.ID, Offset: offset + n}
+			break
+		}
+		if pool.next == nil {
+			break // The timeline has run out
+		}
+		top, offset = pool.next, 0
+		if len(tweets) == page.Limit {
+			next = top
+			break
+		}
+	}
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return tweets, next, metrics, nil
+}
+
+// rankedPool is one pool of candidates, best first
+type rankedPool struct {
+	ranked []*models.Tweet
+	top    *models.Cursor // A MaxID that includes the newest candidate
+	next   *models.Cursor // The next pool's MaxID, nil if this is the last
+}
+
+// rankPool gathers and ranks the pool of candidates below page.MaxID,
+// adding the time spent in each stage to metrics
+func (s *ForYouStrategy) rankPool(ctx context.Context, userID int64, page models.Page, metrics *OperationMetrics) (*rankedPool, error) {
+	// 1. Candidate generation
+	stageStart := time.Now()
+	candidates, next, err := s.candidates(ctx, userID, page, metrics)
+	metrics.CandidateTime += time.Since(stageStart)
+	if err != nil {
+		return nil, err
+	}
+	metrics.CandidateCount += len(candidates)
+
+	// 2. Scoring
+	stageStart = time.Now()
+	scores, err := s.scorer.Score(ctx, userID, candidates)
+	if err == nil && len(scores) != len(candidates) {
+		err = fmt.Errorf("%s scorer returned %d scores for %d candidates", s.scorer.Name(), len(scores), len(candidates))
+	}
+	metrics.ScoringTime += time.Since(stageStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to score candidates: %w", err)
+	}
+
+	// 3. Ordering
+	stageStart = time.Now()
+	pool := &rankedPool{ranked: topCandidates(candidates, scores, len(candidates)), next: next}
+	var newest *models.Tweet
+	for _, c := range candidates {
+		if newest == nil || models.CursorFor(newest).Newer(c.Tweet) {
+			newest = c.Tweet
+		}
+	}
+	if newest != nil {
+		pool.top = &models.Cursor{CreatedAt: newest.CreatedAt, ID: newest.ID + 1}
+	}
+	metrics.TruncationTime += time.Since(stageStart)
+	return pool, nil
+}
+
+// candidates gathers a pool of candidates: the newest forYouPoolFactor
+// pages of the timeline hybrid would show, plus up to a page of
+// second-degree authors' tweets from the same stretch of time. It also
+// returns where the next pool starts: below the oldest candidate of
+// whichever source was cut short, or nil if neither was.
+func (s *ForYouStrategy) candidates(ctx context.Context, userID int64, page models.Page, metrics *OperationMetrics) ([]Candidate, *models.Cursor, error) {
+	// 1. In network - the pushed timeline merged with followed celebrities'
+	// tweets, filtered, collapsed and counted as hybrid shows them
+	pool := page
+	pool.Limit = page.Limit * forYouPoolFactor
+	inNetwork, poolMetrics, err := s.HybridStrategy.GetTimeline(ctx, userID, pool)
+	if err != nil {
+		return nil, nil, err
+	}
+	metrics.CacheHit = metrics.CacheHit && poolMetrics.CacheHit
+	metrics.FanOutCount = poolMetrics.FanOutCount
+	metrics.PrivacyCheck += poolMetrics.PrivacyCheck
+
+	var next *models.Cursor
+	if len(inNetwork) == pool.Limit {
+		next = models.CursorFor(inNetwork[len(inNetwork)-1])
+	}
+
+	candidates := make([]Candidate, 0, len(inNetwork)+page.Limit)
+	shown := make(map[int64]bool, len(inNetwork))
+	for _, t := range inNetwork {
+		candidates = append(candidates, Candidate{Tweet: t, InNetwork: true})
+		shown[t.RootID()] = true
+	}
+
+	// 2. Out of network - the accounts followed by those the user follows
+	via, err := s.secondDegree(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(via) == 0 {
+		return candidates, next, nil
+	}
+	authorIDs := make([]int64, 0, len(via))
+	for id := range via {
+		authorIDs = append(authorIDs, id)
+	}
+	sort.Slice(authorIDs, func(i, j int) bool { return authorIDs[i] < authorIDs[j] })
+	metrics.FanOutCount += len(authorIDs) // Merged at read time like celebrities
+
+	// Their tweets are only candidates if no older than the in-network ones
+	window := page
+	if next != nil {
+		window.SinceID = next
+	}
+
+	// Replies and protected or hidden authors are left out, as are tweets
+	// already shown in network
+	hidden := s.blocks.HiddenAuthors(userID)
+	privacy := NewListPrivacy(s.userRepo, s.followRepo, userID)
+	keep := func(t *models.Tweet) (bool, error) {
+		if t.IsReply() || shown[t.RootID()] {
+			return false, nil
+		}
+		if hidden != nil {
+			visible, err := hidden.Visible(ctx, t)
+			if err != nil || !visible {
+				return false, err
+			}
+		}
+		return privacy.Visible(ctx, t)
+	}
+	outOfNetwork, err := collectPage(window, func(p models.Page) ([]*models.Tweet, error) {
+		tweets, _, err := authorTweets(ctx, s.cache, s.tweetRepo, authorIDs, p)
+		return tweets, err
+	}, keep)
+	metrics.PrivacyCheck += privacy.Elapsed()
+	if err != nil {
+		return nil, nil, err
+	}
+	embedCounts(ctx, s.counters, outOfNetwork)
+
+	for _, t := range outOfNetwork {
+		candidates = append(candidates, Candidate{Tweet: t, Via: via[t.UserID]})
+	}
+
+	// A full page of second-degree tweets may have left older ones out, so
+	// the pool ends at the oldest of them and older in-network tweets wait
+	// for the next pool. They are all newer than the in-network cut.
+	if len(outOfNetwork) == window.Limit {
+		next = models.CursorFor(outOfNetwork[len(outOfNetwork)-1])
+		kept := candidates[:0]
+		for _, c := range candidates {
+			if !next.Older(c.Tweet) {
+				kept = append(kept, c)
+			}
+		}
+		candidates = kept
+	}
+	return candidates, next, nil
+}
+
+// secondDegree returns up to forYouSecondDegreeAuthors accounts that the
+// accounts userID follows follow, but userID doesn't, with how many of
+// userID's followees follow each. Only the first forYouFolloweesExplored
+// followees are searched, and the authors most of them follow are kept.
+func (s *ForYouStrategy) secondDegree(ctx context.Context, userID int64) (map[int64]int, error) {
+	following, err := s.followRepo.GetFollowing(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get following: %w", err)
+	}
+	followed := make(map[int64]bool, len(following)+1)
+	followed[userID] = true
+	for _, id := range following {
+		followed[id] = true
+	}
+
+	if len(following) > forYouFolloweesExplored {
+		following = following[:forYouFolloweesExplored]
+	}
+	via := make(map[int64]int)
+	for _, followeeID := range following {
+		theirs, err := s.followRepo.GetFollowing(ctx, followeeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get following: %w", err)
+		}
+		for _, id := range theirs {
+			if !followed[id] {
+				via[id]++
+			}
+		}
+	}
+	if len(via) <= forYouSecondDegreeAuthors {
+		return via, nil
+	}
+
+	ids := make([]int64, 0, len(via))
+	for id := range via {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if via[ids[i]] != via[ids[j]] {
+			return via[ids[i]] > via[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids[forYouSecondDegreeAuthors:] {
+		delete(via, id)
+	}
+	return via, nil
+}
+
+// topCandidates orders candidates by score, newest first among equal
+// scores, and returns the best limit of their tweets
+func topCandidates(candidates []Candidate, scores []float64, limit int) []*models.Tweet {
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if scores[i] != scores[j] {
+			return scores[i] > scores[j]
+		}
+		ti, tj := candidates[i].Tweet, candidates[j].Tweet
+		if ti.CreatedAt.Equal(tj.CreatedAt) {
+			return ti.ID > tj.ID
+		}
+		return ti.CreatedAt.After(tj.CreatedAt)
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	tweets := make([]*models.Tweet, len(order))
+	for k, i := range order {
+		tweets[k] = candidates[i].Tweet
+	}
+	return tweets
+}
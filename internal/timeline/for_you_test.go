@@ -0,0 +1,273 @@
+package timeline
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+func TestRecencyScorer(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := &RecencyScorer{HalfLife: 6 * time.Hour, now: func() time.Time { return now }}
+
+	tests := []struct {
+		age  time.Duration
+		want float64
+	}{
+		{0, 1},
+		{6 * time.Hour, 0.5},
+		{12 * time.Hour, 0.25},
+		{3 * time.Hour, math.Sqrt(0.5)},
+	}
+	candidates := make([]Candidate, len(tests))
+	for i, tt := range tests {
+		candidates[i] = Candidate{Tweet: &models.Tweet{ID: int64(i + 1), CreatedAt: now.Add(-tt.age)}}
+	}
+
+	scores, err := s.Score(context.Background(), 1, candidates)
+	if err != nil {
+		t.Fatalf("Score: %v", err)
+	}
+	for i, tt := range tests {
+		if math.Abs(scores[i]-tt.want) > 1e-9 {
+			t.Errorf("age %v: score %g, want %g", tt.age, scores[i], tt.want)
+		}
+	}
+}
+
+func TestEngagementScorer(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewEngagementScorer()
+	s.now = func() time.Time { return now }
+
+	tests := []struct {
+		name     string
+		likes    int64
+		retweets int64
+		age      time.Duration
+		want     float64
+	}{
+		{"fresh and ignored", 0, 0, 0, 1 / math.Pow(2, 1.8)},
+		{"likes", 9, 0, 0, 10 / math.Pow(2, 1.8)},
+		{"retweets count double", 0, 3, 0, 7 / math.Pow(2, 1.8)},
+		{"age discounts", 9, 0, 2 * time.Hour, 10 / math.Pow(4, 1.8)},
+		{"clock skew counts as fresh", 0, 0, -time.Hour, 1 / math.Pow(2, 1.8)},
+	}
+	candidates := make([]Candidate, len(tests))
+	for i, tt := range tests {
+		candidates[i] = Candidate{Tweet: &models.Tweet{
+			ID:           int64(i + 1),
+			CreatedAt:    now.Add(-tt.age),
+			LikeCount:    tt.likes,
+			RetweetCount: tt.retweets,
+		}}
+	}
+
+	scores, err := s.Score(context.Background(), 1, candidates)
+	if err != nil {
+		t.Fatalf("Score: %v", err)
+	}
+	for i, tt := range tests {
+		if math.Abs(scores[i]-tt.want) > 1e-9 {
+			t.Errorf("%s: score %g, want %g", tt.name, scores[i], tt.want)
+		}
+	}
+}
+
+func TestAffinityScorer(t *testing.T) {
+	f := newFixture(t)
+	u := f.newUsers(t, 4)
+	viewer, mutual, followed, stranger := u[0], u[1], u[2], u[3]
+	for _, pair := range [][2]int64{{viewer, mutual}, {mutual, viewer}, {viewer, followed}} {
+		if err := f.follows.Create(f.ctx, pair[0], pair[1]); err != nil {
+			t.Fatalf("follow: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		author    int64
+		inNetwork bool
+		via       int
+		want      float64
+	}{
+		{"own tweet", viewer, true, 0, 1.5},
+		{"mutual follow", mutual, true, 0, 1.5},
+		{"followed", followed, true, 0, 1},
+		{"second degree via one", stranger, false, 1, 0.25},
+		{"second degree via three", stranger, false, 3, 0.375},
+		// A mutual follow's tweet found out of network isn't boosted
+		{"follower out of network", mutual, false, 1, 0.25},
+	}
+	candidates := make([]Candidate, len(tests))
+	for i, tt := range tests {
+		candidates[i] = Candidate{Tweet: &models.Tweet{ID: int64(i + 1), UserID: tt.author}, InNetwork: tt.inNetwork, Via: tt.via}
+	}
+
+	scores, err := NewAffinityScorer(f.follows).Score(f.ctx, viewer, candidates)
+	if err != nil {
+		t.Fatalf("Score: %v", err)
+	}
+	for i, tt := range tests {
+		if scores[i] != tt.want {
+			t.Errorf("%s: score %g, want %g", tt.name, scores[i], tt.want)
+		}
+	}
+}
+
+func TestTopCandidates(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	candidate := func(id int64, age time.Duration) Candidate {
+		return Candidate{Tweet: &models.Tweet{ID: id, CreatedAt: at.Add(-age)}}
+	}
+	candidates := []Candidate{
+		candidate(1, 3*time.Minute),
+		candidate(2, 2*time.Minute),
+		candidate(3, time.Minute),
+		candidate(4, 0),
+		candidate(5, 0),
+	}
+	// 2 scores highest; 1 and 3 tie, so the newer 3 goes first; 4 and 5
+	// tie at the same instant, so the higher ID goes first
+	scores := []float64{0.5, 0.9, 0.5, 0.1, 0.1}
+
+	tests := []struct {
+		limit int
+		want  []int64
+	}{
+		{limit: 5, want: []int64{2, 3, 1, 5, 4}},
+		{limit: 2, want: []int64{2, 3}},
+		{limit: 10, want: []int64{2, 3, 1, 5, 4}},
+		{limit: 0, want: []int64{}},
+	}
+	for _, tt := range tests {
+		if got := ids(topCandidates(candidates, scores, tt.limit)...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("limit %d: %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestSecondDegreeCaps(t *testing.T) {
+	f := newFixture(t)
+	s := NewForYouStrategy(f.tweets, f.follows, f.users, f.cache, 1000, NewRecencyScorer(time.Hour))
+
+	u := f.newUsers(t, 1+forYouFolloweesExplored+1+forYouSecondDegreeAuthors+5)
+	viewer := u[0]
+	followees := u[1 : 2+forYouFolloweesExplored]
+	authors := u[2+forYouFolloweesExplored:]
+	follow := func(follower, followee int64) {
+		t.Helper()
+		if err := f.follows.Create(f.ctx, follower, followee); err != nil {
+			t.Fatalf("follow: %v", err)
+		}
+	}
+
+	for _, id := range followees {
+		follow(viewer, id)
+	}
+	// Author i is followed by max(1, 20-i) followees, so the last six tie
+	for i, author := range authors[:len(authors)-1] {
+		for _, followee := range followees[:max(1, forYouSecondDegreeAuthors-i)] {
+			follow(followee, author)
+		}
+	}
+	// Only the first forYouFolloweesExplored followees are searched
+	unexplored := authors[len(authors)-1]
+	follow(followees[len(followees)-1], unexplored)
+	// The viewer and accounts they already follow aren't second degree
+	follow(followees[0], viewer)
+	follow(followees[0], followees[1])
+
+	via, err := s.secondDegree(f.ctx, viewer)
+	if err != nil {
+		t.Fatalf("secondDegree: %v", err)
+	}
+	if len(via) != forYouSecondDegreeAuthors {
+		t.Fatalf("found %d second-degree authors, want %d: %v", len(via), forYouSecondDegreeAuthors, via)
+	}
+	// The most followed authors are kept, and the lowest ID among the tie
+	for i, author := range authors[:forYouSecondDegreeAuthors] {
+		if want := max(1, forYouSecondDegreeAuthors-i); via[author] != want {
+			t.Errorf("author %d via %d followees, want %d", i, via[author], want)
+		}
+	}
+	if _, ok := via[unexplored]; ok {
+		t.Error("an author only an unexplored followee follows was found")
+	}
+}
+
+// scrambledScorer ranks candidates in an order unrelated to their age
+type scrambledScorer struct{}
+
+func (scrambledScorer) Name() string { return "scrambled" }
+
+func (scrambledScorer) Score(ctx context.Context, viewerID int64, candidates []Candidate) ([]float64, error) {
+	scores := make([]float64, len(candidates))
+	for i, c := range candidates {
+		scores[i] = float64(c.Tweet.ID * 7919 % 101)
+	}
+	return scores, nil
+}
+
+func TestForYouPagesShowEveryCandidateOnce(t *testing.T) {
+	f := newFixture(t)
+	s := NewForYouStrategy(f.tweets, f.follows, f.users, f.cache, 1000, scrambledScorer{})
+	u := f.newUsers(t, 5)
+	viewer, friend, other, secondA, secondB := u[0], u[1], u[2], u[3], u[4]
+
+	f.follow(t, s, viewer, friend)
+	f.follow(t, s, viewer, other)
+	f.follow(t, s, friend, secondA)
+	f.follow(t, s, friend, secondB)
+
+	want := make(map[int64]bool)
+	for i := 0; i < 40; i++ {
+		author := []int64{friend, other, secondA, secondB, secondA}[i%5]
+		want[f.post(t, s, author).ID] = true
+	}
+
+	const limit = 4
+	seen := make(map[int64]bool)
+	var page models.Page
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatal("paging didn't end")
+		}
+		page.Limit = limit
+		tweets, next, _, err := s.GetRankedTimeline(f.ctx, viewer, page)
+		if err != nil {
+			t.Fatalf("GetRankedTimeline: %v", err)
+		}
+		if next != nil && len(tweets) != limit {
+			t.Errorf("page %d has %d tweets and a next page, want %d", pages, len(tweets), limit)
+		}
+		for _, tweet := range tweets {
+			if seen[tweet.ID] {
+				t.Errorf("tweet %d shown twice", tweet.ID)
+			}
+			seen[tweet.ID] = true
+		}
+
+		// Tweets posted while paging don't shift the pages
+		if pages == 0 {
+			f.post(t, s, friend)
+		}
+		if next == nil {
+			break
+		}
+		page.MaxID = next
+	}
+
+	for id := range want {
+		if !seen[id] {
+			t.Errorf("tweet %d was never shown", id)
+		}
+	}
+	if len(seen) != len(want) {
+		t.Errorf("showed %d tweets, want %d", len(seen), len(want))
+	}
+}
@@ -14,19 +14,24 @@ import (
 // - Regular users (< threshold followers): fan-out on write
 // - Celebrities (>= threshold followers): fan-out on read
 type HybridStrategy struct {
-	tweetRepo          *repository.TweetRepository
-	followRepo         *repository.FollowRepository
-	userRepo           *repository.UserRepository
-	cache              *cache.TimelineCache
+	tweetRepo          repository.TweetStore
+	followRepo         repository.FollowStore
+	userRepo           repository.UserStore
+	cache              cache.TimelineStore
 	celebrityThreshold int
+	reclassifier       *Reclassifier // Optional - migrates tweets when users cross the threshold
+	replyMode          string        // ReplyFilterRead or ReplyFilterFanOut
+	counters           *Counters     // Optional - embeds like and retweet counts
+	blocks             *Blocks       // Optional - hides blocked and muted authors
+	lists              *Lists        // Optional - pushes regular users' tweets into list timelines too
 }
 
 // NewHybridStrategy creates a new HybridStrategy
 func NewHybridStrategy(
-	tweetRepo *repository.TweetRepository,
-	followRepo *repository.FollowRepository,
-	userRepo *repository.UserRepository,
-	cache *cache.TimelineCache,
+	tweetRepo repository.TweetStore,
+	followRepo repository.FollowStore,
+	userRepo repository.UserStore,
+	cache cache.TimelineStore,
 	celebrityThreshold int,
 ) *HybridStrategy {
 	return &HybridStrategy{
@@ -35,17 +40,141 @@ func NewHybridStrategy(
 		userRepo:           userRepo,
 		cache:              cache,
 		celebrityThreshold: celebrityThreshold,
+		replyMode:          ReplyFilterRead,
 	}
 }
 
+func init() {
+	Register(StrategyHybrid, 30, func(deps Dependencies) Strategy {
+		return withOptional(NewHybridStrategy(deps.TweetRepo, deps.FollowRepo, deps.UserRepo, deps.Cache, deps.Config.CelebrityThreshold), deps)
+	})
+}
+
 // Name returns the strategy name
 func (s *HybridStrategy) Name() string {
 	return "hybrid"
 }
 
+// SetReclassifier enables migrating tweets between the pushed timelines and
+// the author tweet cache when follows or threshold changes reclassify a user
+func (s *HybridStrategy) SetReclassifier(r *Reclassifier) {
+	s.reclassifier = r
+}
+
+// Reclassifier returns the reclassifier, or nil if reclassification is disabled
+func (s *HybridStrategy) Reclassifier() *Reclassifier {
+	return s.reclassifier
+}
+
+// isCelebrity reports whether user's tweets are laid out as celebrity tweets,
+// read from their author tweet cache rather than pushed. With a reclassifier
+// that's the layout it has migrated them to, which lags the follower count;
+// without one the threshold decides.
+func (s *HybridStrategy) isCelebrity(user *models.User) bool {
+	if s.reclassifier != nil {
+		return s.reclassifier.IsCelebrity(user.ID)
+	}
+	return user.IsCelebrity(s.celebrityThreshold)
+}
+
+// moving reports whether the reclassifier is still moving userID's tweets,
+// so some can be pushed and some only in their author tweet cache
+func (s *HybridStrategy) moving(userID int64) bool {
+	return s.reclassifier != nil && s.reclassifier.Moving(userID)
+}
+
+// isPulled reports whether user's tweets are merged at read time: they're a
+// celebrity, or are being moved either way
+func (s *HybridStrategy) isPulled(user *models.User) bool {
+	return s.isCelebrity(user) || s.moving(user.ID)
+}
+
+// followingCelebrities returns the users userID follows whose tweets are
+// merged at read time
+func (s *HybridStrategy) followingCelebrities(ctx context.Context, userID int64) ([]*models.User, error) {
+	if s.reclassifier == nil {
+		celebrities, err := s.followRepo.GetFollowingCelebrities(ctx, userID, s.celebrityThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get following celebrities: %w", err)
+		}
+		return celebrities, nil
+	}
+	return s.followingWhere(ctx, userID, s.isPulled)
+}
+
+// followingNonCelebrities returns the IDs of the users userID follows whose
+// tweets are pushed
+func (s *HybridStrategy) followingNonCelebrities(ctx context.Context, userID int64) ([]int64, error) {
+	if s.reclassifier == nil {
+		ids, err := s.followRepo.GetFollowingNonCelebrities(ctx, userID, s.celebrityThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get following non-celebrities: %w", err)
+		}
+		return ids, nil
+	}
+	users, err := s.followingWhere(ctx, userID, func(u *models.User) bool { return !s.isCelebrity(u) })
+	if err != nil {
+		return nil, err
+	}
+	return memberIDs(users), nil
+}
+
+// followingWhere returns the users userID follows that keep accepts
+func (s *HybridStrategy) followingWhere(ctx context.Context, userID int64, keep func(u *models.User) bool) ([]*models.User, error) {
+	following, err := s.followRepo.GetFollowingUsers(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get following: %w", err)
+	}
+	users := []*models.User{}
+	for _, u := range following {
+		if keep(u) {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+// SetCounters makes timelines embed like and retweet counts and keeps the
+// retweet counts up to date
+func (s *HybridStrategy) SetCounters(c *Counters) {
+	s.counters = c
+}
+
+// SetBlocks hides blocked and muted authors from timelines: at read time,
+// or when purging, by leaving the viewers who hid them out of fan-out.
+// Celebrities' tweets are pulled, so they're always filtered at read time.
+func (s *HybridStrategy) SetBlocks(b *Blocks) {
+	s.blocks = b
+}
+
+// SetLists enables list timelines, laid out like home timelines: regular
+// users' tweets are pushed into the lists containing them, and celebrity
+// members' tweets are merged at read time
+func (s *HybridStrategy) SetLists(l *Lists) {
+	s.lists = l
+}
+
 // SetCelebrityThreshold updates the celebrity threshold
 func (s *HybridStrategy) SetCelebrityThreshold(threshold int) {
 	s.celebrityThreshold = threshold
+	if s.reclassifier != nil {
+		s.reclassifier.SetCelebrityThreshold(threshold)
+	}
+}
+
+// SetReplyFilterMode sets whether pushed replies are filtered when they're
+// pushed or when timelines are read. Celebrities' replies are pulled, so
+// they're always filtered at read time.
+func (s *HybridStrategy) SetReplyFilterMode(mode string) {
+	s.replyMode = mode
+	if s.reclassifier != nil {
+		s.reclassifier.SetReplyFilterMode(mode)
+	}
+}
+
+// ReplyFilterMode returns the reply filter mode
+func (s *HybridStrategy) ReplyFilterMode() string {
+	return s.replyMode
 }
 
 // GetCelebrityThreshold returns the current celebrity threshold
@@ -69,7 +198,56 @@ func (s *HybridStrategy) PostTweet(ctx context.Context, userID int64, content st
 		return nil, metrics, fmt.Errorf("failed to create tweet: %w", err)
 	}
 
-	// 2. Get the author's info to check if they're a celebrity
+	return s.deliver(ctx, tweet, metrics)
+}
+
+// PostReply creates a reply and delivers it by the author's follower count,
+// like a tweet they posted
+func (s *HybridStrategy) PostReply(ctx context.Context, userID, inReplyToID int64, content string) (*models.Tweet, *OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "post_reply",
+		StartTime: time.Now(),
+	}
+
+	tweet, err := s.tweetRepo.CreateReply(ctx, userID, inReplyToID, content)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return nil, metrics, fmt.Errorf("failed to create reply: %w", err)
+	}
+
+	return s.deliver(ctx, tweet, metrics)
+}
+
+// Retweet creates a retweet and delivers it by the retweeter's follower
+// count, exactly like a tweet they posted
+func (s *HybridStrategy) Retweet(ctx context.Context, userID, tweetID int64) (*models.Tweet, *OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "retweet",
+		StartTime: time.Now(),
+	}
+
+	tweet, err := s.tweetRepo.Retweet(ctx, userID, tweetID)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return nil, metrics, fmt.Errorf("failed to create retweet: %w", err)
+	}
+	if s.counters != nil {
+		s.counters.Retweeted(ctx, tweet)
+	}
+
+	return s.deliver(ctx, tweet, metrics)
+}
+
+// deliver caches a new tweet and either pushes it to the author's followers
+// and lists or, for a celebrity, leaves it to be merged at read time
+func (s *HybridStrategy) deliver(ctx context.Context, tweet *models.Tweet, metrics *OperationMetrics) (*models.Tweet, *OperationMetrics, error) {
+	userID := tweet.UserID
+
+	// 1. Get the author's info to check if they're a celebrity
 	author, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		metrics.Error = err
@@ -79,17 +257,19 @@ func (s *HybridStrategy) PostTweet(ctx context.Context, userID int64, content st
 
 	tweet.Username = author.Username
 
-	// 3. Cache the tweet data
+	// 2. Cache the tweet data and add it to the author's tweets, which
+	// followers of a celebrity read it from
 	s.cache.CacheTweet(ctx, tweet)
+	if err := s.cache.AddAuthorTweet(ctx, userID, tweet); err != nil {
+		fmt.Printf("Warning: failed to add author tweet: %v\n", err)
+	}
 
-	// 4. Decide fan-out strategy based on follower count
-	isCelebrity := author.IsCelebrity(s.celebrityThreshold)
+	// 3. Decide fan-out strategy based on how the author's tweets are laid
+	// out, so new tweets land where readers look for the existing ones
+	isCelebrity := s.isCelebrity(author)
 
 	if isCelebrity {
-		// Celebrity: store in celebrity tweets cache, don't fan out
-		if err := s.cache.AddCelebrityTweet(ctx, userID, tweet); err != nil {
-			fmt.Printf("Warning: failed to add celebrity tweet: %v\n", err)
-		}
+		// Celebrity: don't fan out
 		metrics.FanOutCount = 0
 	} else {
 		// Regular user: fan out to all followers
@@ -100,6 +280,22 @@ func (s *HybridStrategy) PostTweet(ctx context.Context, userID int64, content st
 			return tweet, metrics, fmt.Errorf("failed to get followers: %w", err)
 		}
 
+		// A reply filtered at fan-out only goes to followers of both participants
+		if participant := replyParticipant(tweet); participant != 0 && s.replyMode == ReplyFilterFanOut {
+			if followers, err = narrowAudience(ctx, s.followRepo, followers, participant); err != nil {
+				metrics.Error = err
+				metrics.EndTime = time.Now()
+				return tweet, metrics, err
+			}
+		}
+
+		// Viewers who hid the author are left out when purging
+		if followers, err = s.blocks.audience(ctx, tweet, followers); err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return tweet, metrics, err
+		}
+
 		metrics.FanOutCount = len(followers)
 
 		if len(followers) > 0 {
@@ -109,9 +305,33 @@ func (s *HybridStrategy) PostTweet(ctx context.Context, userID int64, content st
 			}
 			metrics.FanOutDuration = time.Since(fanOutStart)
 		}
+
+		// The lists containing the author get it too
+		listStart := time.Now()
+		listCount, err := s.lists.fanOut(ctx, tweet)
+		if err != nil {
+			fmt.Printf("Warning: failed to fan out to list timelines: %v\n", err)
+		}
+		metrics.ListFanOutCount = listCount
+		metrics.FanOutCount += listCount
+		metrics.FanOutDuration += time.Since(listStart)
+
+		// If the reclassifier made the author a celebrity meanwhile, its
+		// eviction may already have passed these timelines, so take the
+		// tweet back out; followers read it from the author's tweets now.
+		// Either this sees the flip or the push finished before it and the
+		// migration evicts the tweet.
+		if s.isCelebrity(author) {
+			if err := s.cache.RemoveFromTimelineBatch(ctx, followers, tweet.ID); err != nil {
+				fmt.Printf("Warning: failed to remove from some timelines: %v\n", err)
+			}
+			if _, err := s.lists.unpublish(ctx, tweet.ID, userID); err != nil {
+				fmt.Printf("Warning: failed to remove from list timelines: %v\n", err)
+			}
+		}
 	}
 
-	// 5. Add to author's own timeline
+	// 4. Add to author's own timeline
 	if err := s.cache.AddToTimeline(ctx, userID, tweet); err != nil {
 		fmt.Printf("Warning: failed to add to author's timeline: %v\n", err)
 	}
@@ -122,16 +342,90 @@ func (s *HybridStrategy) PostTweet(ctx context.Context, userID int64, content st
 	return tweet, metrics, nil
 }
 
-// GetTimeline retrieves a user's timeline using hybrid approach
-func (s *HybridStrategy) GetTimeline(ctx context.Context, userID int64, limit, offset int) ([]*models.Tweet, *OperationMetrics, error) {
+// GetTimeline retrieves a page of a user's timeline using hybrid approach
+func (s *HybridStrategy) GetTimeline(ctx context.Context, userID int64, page models.Page) ([]*models.Tweet, *OperationMetrics, error) {
 	metrics := &OperationMetrics{
 		Strategy:  s.Name(),
 		Operation: "get_timeline",
 		StartTime: time.Now(),
 	}
 
+	// Whatever was filtered at fan-out only needs checking again if it was
+	// merged from a celebrity at read time
+	var pulled map[int64]bool
+	isPulled := func(t *models.Tweet) (bool, error) {
+		if pulled == nil {
+			ids, err := s.PullSources(ctx, userID)
+			if err != nil {
+				return false, err
+			}
+			pulled = make(map[int64]bool, len(ids))
+			for _, id := range ids {
+				pulled[id] = true
+			}
+		}
+		return pulled[t.UserID], nil
+	}
+
+	visibility := NewReplyVisibility(s.followRepo, userID)
+	keep := func(t *models.Tweet) (bool, error) {
+		if replyParticipant(t) == 0 {
+			return true, nil
+		}
+		if s.replyMode == ReplyFilterFanOut {
+			fromCelebrity, err := isPulled(t)
+			if err != nil {
+				return false, err
+			}
+			if !fromCelebrity {
+				return true, nil
+			}
+		}
+		return visibility.Visible(ctx, t)
+	}
+	if hidden := s.blocks.HiddenAuthors(userID); hidden != nil {
+		keep = keepBoth(func(t *models.Tweet) (bool, error) {
+			if s.blocks.purging() {
+				fromCelebrity, err := isPulled(t)
+				if err != nil {
+					return false, err
+				}
+				if !fromCelebrity {
+					return true, nil
+				}
+			}
+			return hidden.Visible(ctx, t)
+		}, keep)
+	}
+	// Retweets of protected tweets are checked whether pushed or pulled
+	privacy := NewPrivacy(s.userRepo, s.followRepo, userID)
+	keep = keepBoth(keep, func(t *models.Tweet) (bool, error) {
+		return privacy.Visible(ctx, t)
+	})
+
+	tweets, err := collectPage(page, func(p models.Page) ([]*models.Tweet, error) {
+		return s.timelinePage(ctx, userID, p, metrics)
+	}, keep)
+	if err == nil {
+		embedCounts(ctx, s.counters, tweets)
+	}
+	metrics.PrivacyCheck = privacy.Elapsed()
+	metrics.EndTime = time.Now()
+	if err != nil {
+		metrics.Error = err
+		return nil, metrics, err
+	}
+
+	metrics.Success = true
+
+	return tweets, metrics, nil
+}
+
+// timelinePage merges one page of the pushed timeline with celebrity
+// tweets, before retweets are collapsed
+func (s *HybridStrategy) timelinePage(ctx context.Context, userID int64, page models.Page, metrics *OperationMetrics) ([]*models.Tweet, error) {
 	// 1. Get pre-computed timeline from cache (tweets from non-celebrities)
-	cachedTweetIDs, err := s.cache.GetTimeline(ctx, userID, limit*2, 0) // Get more to account for merging
+	cachedTweetIDs, err := s.cache.GetTimelinePage(ctx, userID, page)
 	if err != nil {
 		fmt.Printf("Warning: failed to get cached timeline: %v\n", err)
 		cachedTweetIDs = []int64{}
@@ -153,40 +447,21 @@ func (s *HybridStrategy) GetTimeline(ctx context.Context, userID int64, limit, o
 	}
 
 	// 2. Get celebrities this user follows
-	celebrities, err := s.followRepo.GetFollowingCelebrities(ctx, userID, s.celebrityThreshold)
+	celebrities, err := s.followingCelebrities(ctx, userID)
 	if err != nil {
-		fmt.Printf("Warning: failed to get following celebrities: %v\n", err)
+		fmt.Printf("Warning: %v\n", err)
 		celebrities = []*models.User{}
 	}
 
 	// 3. Fetch recent tweets from celebrities (fan-out on read for celebrities)
 	var celebrityTweets []*models.Tweet
 	if len(celebrities) > 0 {
-		celebrityIDs := make([]int64, len(celebrities))
-		for i, c := range celebrities {
-			celebrityIDs[i] = c.ID
-		}
-
-		// Try to get from celebrity cache first
-		celebrityTweetIDs, err := s.cache.GetCelebrityTweetsBatch(ctx, celebrityIDs, 20)
-		if err == nil && len(celebrityTweetIDs) > 0 {
-			celebrityTweets, _, _ = s.cache.GetCachedTweets(ctx, celebrityTweetIDs)
-		}
-
-		// If cache miss or incomplete, fetch from DB
-		if len(celebrityTweets) < len(celebrities)*5 { // Expect at least some tweets per celebrity
-			dbTweets, err := s.tweetRepo.GetRecentByUserIDs(ctx, celebrityIDs, 10, limit)
-			if err == nil {
-				celebrityTweets = append(celebrityTweets, dbTweets...)
-				celebrityTweets = deduplicateTweets(celebrityTweets)
-			}
-		}
-
+		celebrityTweets = s.celebrityTweets(ctx, memberIDs(celebrities), page)
 		metrics.FanOutCount = len(celebrities) // Number of celebrities merged at read time
 	}
 
 	// 4. Merge cached timeline with celebrity tweets
-	allTweets := mergeTweets(cachedTweets, celebrityTweets, limit*2)
+	allTweets := mergeTweets(cachedTweets, celebrityTweets, len(cachedTweets)+len(celebrityTweets))
 
 	// 5. Deduplicate (in case of any overlap)
 	allTweets = deduplicateTweets(allTweets)
@@ -194,59 +469,74 @@ func (s *HybridStrategy) GetTimeline(ctx context.Context, userID int64, limit, o
 	// 6. Sort by time
 	sortTweetsByTime(allTweets)
 
-	// 7. Apply offset and limit
-	if offset > 0 {
-		if offset >= len(allTweets) {
-			allTweets = []*models.Tweet{}
-		} else {
-			allTweets = allTweets[offset:]
-		}
-	}
-	if len(allTweets) > limit {
-		allTweets = allTweets[:limit]
+	// 7. Apply limit
+	if len(allTweets) > page.Limit {
+		allTweets = allTweets[:page.Limit]
 	}
 
 	// 8. Cache any tweets we fetched from DB
 	s.cache.CacheTweetsBatch(ctx, allTweets)
 
-	metrics.EndTime = time.Now()
-	metrics.Success = true
+	return allTweets, nil
+}
 
-	return allTweets, metrics, nil
+// celebrityTweets fetches one page of celebrities' recent tweets, from
+// their author tweet caches where they cover it
+func (s *HybridStrategy) celebrityTweets(ctx context.Context, celebrityIDs []int64, page models.Page) []*models.Tweet {
+	tweets, _, err := authorTweets(ctx, s.cache, s.tweetRepo, celebrityIDs, page)
+	if err != nil {
+		fmt.Printf("Warning: failed to get celebrity tweets: %v\n", err)
+		return []*models.Tweet{}
+	}
+	return tweets
 }
 
-// RebuildTimeline rebuilds a user's timeline cache (for non-celebrity tweets only)
-func (s *HybridStrategy) RebuildTimeline(ctx context.Context, userID int64, limit int) error {
-	// Get non-celebrity users this person follows
-	nonCelebrityIDs, err := s.followRepo.GetFollowingNonCelebrities(ctx, userID, s.celebrityThreshold)
+// TimelineSources returns the users whose tweets are pushed into userID's timeline:
+// the non-celebrities they follow plus themselves. Authors always get their
+// own tweets pushed, celebrity or not.
+func (s *HybridStrategy) TimelineSources(ctx context.Context, userID int64) ([]int64, error) {
+	nonCelebrityIDs, err := s.followingNonCelebrities(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("failed to get following non-celebrities: %w", err)
+		return nil, err
 	}
+	return append(nonCelebrityIDs, userID), nil
+}
 
-	// Include user's own tweets if they're not a celebrity
-	user, err := s.userRepo.GetByID(ctx, userID)
-	if err == nil && !user.IsCelebrity(s.celebrityThreshold) {
-		nonCelebrityIDs = append(nonCelebrityIDs, userID)
+// PullSources returns the celebrities userID follows, whose tweets are merged at read time
+func (s *HybridStrategy) PullSources(ctx context.Context, userID int64) ([]int64, error) {
+	celebrities, err := s.followingCelebrities(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
+	return memberIDs(celebrities), nil
+}
 
-	if len(nonCelebrityIDs) == 0 {
-		return nil
+// RebuildTimeline rebuilds a user's timeline cache (for non-celebrity tweets only)
+func (s *HybridStrategy) RebuildTimeline(ctx context.Context, userID int64, limit int) error {
+	// Get non-celebrity users this person follows, including themselves
+	sources, err := s.TimelineSources(ctx, userID)
+	if err != nil {
+		return err
 	}
 
 	// Get recent tweets from non-celebrities
-	tweets, err := s.tweetRepo.GetByUserIDs(ctx, nonCelebrityIDs, limit)
+	tweets, err := s.tweetRepo.GetByUserIDs(ctx, sources, models.Page{Limit: limit})
 	if err != nil {
 		return fmt.Errorf("failed to get tweets: %w", err)
 	}
+	if tweets, err = pushable(ctx, s.replyMode, s.followRepo, userID, tweets); err != nil {
+		return err
+	}
+	if tweets, err = s.blocks.pushable(ctx, userID, tweets); err != nil {
+		return err
+	}
 
 	// Clear existing timeline
 	s.cache.ClearTimeline(ctx, userID)
 
 	// Add tweets to timeline
-	for _, tweet := range tweets {
-		if err := s.cache.AddToTimeline(ctx, userID, tweet); err != nil {
-			return fmt.Errorf("failed to add tweet to timeline: %w", err)
-		}
+	if err := s.cache.AddTweetsToTimeline(ctx, userID, tweets); err != nil {
+		return fmt.Errorf("failed to add tweets to timeline: %w", err)
 	}
 
 	// Cache tweet data
@@ -255,16 +545,68 @@ func (s *HybridStrategy) RebuildTimeline(ctx context.Context, userID int64, limi
 	return nil
 }
 
-// DeleteTweet removes a tweet with appropriate cache invalidation
-func (s *HybridStrategy) DeleteTweet(ctx context.Context, tweetID int64, userID int64) error {
-	// Get author info
+// DeleteTweet removes a tweet and its retweets with appropriate cache invalidation
+func (s *HybridStrategy) DeleteTweet(ctx context.Context, tweetID int64, userID int64) (*OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "delete_tweet",
+		StartTime: time.Now(),
+	}
+
+	deleted := countDeletion(ctx, s.counters, s.tweetRepo, tweetID)
+
+	// 1. Retweets are deleted along with the tweet, and each was delivered
+	// by its retweeter's follower count
+	retweets, err := s.tweetRepo.GetRetweets(ctx, tweetID)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, fmt.Errorf("failed to get retweets: %w", err)
+	}
+	for _, rt := range retweets {
+		if err := s.unpublish(ctx, rt.ID, rt.UserID, metrics); err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return metrics, err
+		}
+	}
+
+	// 2. Remove the tweet itself
+	if err := s.unpublish(ctx, tweetID, userID, metrics); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+
+	// 3. Delete from database
+	if err := s.tweetRepo.Delete(ctx, tweetID); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+	deleted()
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return metrics, nil
+}
+
+// unpublish removes a tweet from wherever it was delivered: its followers'
+// and lists' timelines, and its author's timeline and tweets
+func (s *HybridStrategy) unpublish(ctx context.Context, tweetID, userID int64, metrics *OperationMetrics) error {
+	// 1. Get author info
 	author, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get author: %w", err)
 	}
 
-	if author.IsCelebrity(s.celebrityThreshold) {
-		// Celebrity: just delete from DB and celebrity cache
+	// While the reclassifier is moving the author either way, their tweets
+	// can be in followers' timelines whichever way the layout has flipped
+	pushed := !s.isCelebrity(author) || s.moving(userID)
+
+	if !pushed {
+		// Celebrity: just delete from DB and the author's tweets
 		// Followers will naturally not see it on next read
 	} else {
 		// Regular user: need to remove from all followers' caches
@@ -273,16 +615,195 @@ func (s *HybridStrategy) DeleteTweet(ctx context.Context, tweetID int64, userID
 			return fmt.Errorf("failed to get followers: %w", err)
 		}
 
+		metrics.FanOutCount += len(followers)
+
 		if len(followers) > 0 {
+			fanOutStart := time.Now()
 			if err := s.cache.RemoveFromTimelineBatch(ctx, followers, tweetID); err != nil {
 				fmt.Printf("Warning: failed to remove from some timelines: %v\n", err)
 			}
+			metrics.FanOutDuration += time.Since(fanOutStart)
+		}
+
+		listStart := time.Now()
+		listCount, err := s.lists.unpublish(ctx, tweetID, userID)
+		if err != nil {
+			return err
 		}
+		metrics.ListFanOutCount += listCount
+		metrics.FanOutCount += listCount
+		metrics.FanOutDuration += time.Since(listStart)
 	}
 
-	// Remove from author's timeline
+	// 2. Remove from the author's tweets
+	s.cache.RemoveAuthorTweet(ctx, userID, tweetID)
+
+	// 3. Remove from author's timeline and purge the cached tweet
 	s.cache.RemoveFromTimeline(ctx, userID, tweetID)
+	s.cache.InvalidateTweet(ctx, tweetID)
+
+	return nil
+}
+
+// Follow creates a follow relationship. Regular followees are backfilled into
+// the follower's timeline cache; celebrities are merged at read time anyway.
+func (s *HybridStrategy) Follow(ctx context.Context, followerID, followeeID int64) (*OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "follow",
+		StartTime: time.Now(),
+	}
+
+	// 1. Update the graph
+	if err := s.followRepo.Create(ctx, followerID, followeeID); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+
+	// 2. Celebrities are pulled at read time - nothing to backfill
+	followee, err := s.userRepo.GetByID(ctx, followeeID)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, fmt.Errorf("failed to get followee: %w", err)
+	}
+
+	if s.reclassifier != nil {
+		s.reclassifier.Observe(followee)
+	}
+
+	if !s.isCelebrity(followee) {
+		// 3. Merge the followee's recent tweets into the follower's timeline
+		tweets, err := s.tweetRepo.GetByUserID(ctx, followeeID, s.cache.MaxTimelineSize())
+		if err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return metrics, fmt.Errorf("failed to get followee tweets: %w", err)
+		}
+		if tweets, err = pushable(ctx, s.replyMode, s.followRepo, followerID, tweets); err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return metrics, err
+		}
+		if tweets, err = s.blocks.pushable(ctx, followerID, tweets); err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return metrics, err
+		}
+
+		metrics.FanOutCount = len(tweets)
+
+		fanOutStart := time.Now()
+		if err := s.cache.AddTweetsToTimeline(ctx, followerID, tweets); err != nil {
+			fmt.Printf("Warning: failed to backfill timeline: %v\n", err)
+		}
+		s.cache.CacheTweetsBatch(ctx, tweets)
+		metrics.FanOutDuration = time.Since(fanOutStart)
+	}
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return metrics, nil
+}
+
+// Unfollow removes a follow relationship and evicts the followee's tweets from
+// the follower's timeline cache. This runs for celebrities too, since they may
+// have been pushed before crossing the threshold.
+func (s *HybridStrategy) Unfollow(ctx context.Context, followerID, followeeID int64) (*OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  s.Name(),
+		Operation: "unfollow",
+		StartTime: time.Now(),
+	}
+
+	// 1. Update the graph
+	if err := s.followRepo.Delete(ctx, followerID, followeeID); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+
+	// 2. The followee may have dropped below the threshold
+	if s.reclassifier != nil {
+		if followee, err := s.userRepo.GetByID(ctx, followeeID); err == nil {
+			s.reclassifier.Observe(followee)
+		}
+	}
+
+	// 3. Evict anything of the followee's that could still be in the timeline
+	tweets, err := s.tweetRepo.GetByUserID(ctx, followeeID, s.cache.MaxTimelineSize())
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, fmt.Errorf("failed to get followee tweets: %w", err)
+	}
+
+	tweetIDs := make([]int64, len(tweets))
+	for i, t := range tweets {
+		tweetIDs[i] = t.ID
+	}
+
+	metrics.FanOutCount = len(tweetIDs)
+
+	fanOutStart := time.Now()
+	if err := s.cache.RemoveTweetsFromTimeline(ctx, followerID, tweetIDs); err != nil {
+		fmt.Printf("Warning: failed to evict from timeline: %v\n", err)
+	}
+	metrics.FanOutDuration = time.Since(fanOutStart)
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return metrics, nil
+}
+
+// GetListTimeline retrieves a page of a list's timeline, merging the pushed
+// list timeline with celebrity members' tweets
+func (s *HybridStrategy) GetListTimeline(ctx context.Context, listID int64, page models.Page) ([]*models.Tweet, *OperationMetrics, error) {
+	return s.lists.timeline(ctx, s.Name(), s.counters, listID, page, func(members []*models.User, p models.Page, metrics *OperationMetrics) ([]*models.Tweet, error) {
+		pushed, err := s.lists.pushedPage(ctx, listID, p, metrics)
+		if err != nil {
+			fmt.Printf("Warning: failed to get cached list timeline: %v\n", err)
+			pushed = []*models.Tweet{}
+		}
+
+		var celebrityIDs []int64
+		for _, u := range members {
+			if s.isPulled(u) {
+				celebrityIDs = append(celebrityIDs, u.ID)
+			}
+		}
+		var celebrityTweets []*models.Tweet
+		if len(celebrityIDs) > 0 {
+			celebrityTweets = s.celebrityTweets(ctx, celebrityIDs, p)
+			metrics.FanOutCount = len(celebrityIDs) // Number of celebrities merged at read time
+		}
+
+		tweets := deduplicateTweets(mergeTweets(pushed, celebrityTweets, len(pushed)+len(celebrityTweets)))
+		if len(tweets) > p.Limit {
+			tweets = tweets[:p.Limit]
+		}
+		s.cache.CacheTweetsBatch(ctx, tweets)
+		return tweets, nil
+	})
+}
+
+// AddListMember adds a user to a list. Regular users' recent tweets are
+// backfilled into the list's timeline cache; celebrities are merged at read
+// time anyway.
+func (s *HybridStrategy) AddListMember(ctx context.Context, listID, userID int64) (*OperationMetrics, error) {
+	member, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+	return s.lists.addMember(ctx, s.Name(), listID, userID, !s.isCelebrity(member))
+}
 
-	// Delete from database
-	return s.tweetRepo.Delete(ctx, tweetID)
+// RemoveListMember removes a user from a list and evicts their tweets from
+// the list's timeline cache. This runs for celebrities too, since they may
+// have been pushed before crossing the threshold.
+func (s *HybridStrategy) RemoveListMember(ctx context.Context, listID, userID int64) (*OperationMetrics, error) {
+	return s.lists.removeMember(ctx, s.Name(), listID, userID, true)
 }
@@ -0,0 +1,291 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/memory"
+	"github.com/ritik/twitter-fan-out/internal/models"
+)
+
+// Crossing the threshold doesn't move anyone's tweets until the reclassifier
+// has migrated them, so posts, reads and verification all follow the layout
+// it reports rather than the follower count
+func TestHybridFollowsTheReclassifiedLayout(t *testing.T) {
+	f := newFixture(t)
+	s := NewHybridStrategy(f.tweets, f.follows, f.users, f.cache, 2)
+	r := NewReclassifier(f.tweets, f.follows, f.users, f.cache, 2, ReclassifierConfig{})
+	s.SetReclassifier(r)
+	v := NewTimelineVerifier(f.tweets, f.follows, f.cache)
+	u := f.newUsers(t, 3)
+	star, reader, other := u[0], u[1], u[2]
+
+	f.follow(t, s, reader, star)
+	f.follow(t, s, other, star)
+
+	timeline := func() []int64 {
+		t.Helper()
+		tweets, _, err := s.GetTimeline(f.ctx, reader, pageOf(10))
+		if err != nil {
+			t.Fatalf("get timeline: %v", err)
+		}
+		return ids(tweets...)
+	}
+	consistent := func(stage string) {
+		t.Helper()
+		diff, err := v.Verify(f.ctx, s, reader)
+		if err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+		if !diff.Consistent() {
+			t.Errorf("%s: verify = %+v, want consistent", stage, diff)
+		}
+	}
+
+	// Over the threshold but not yet migrated: still pushed
+	before := f.post(t, s, star)
+	if got, want := f.cached(t, reader), ids(before); !reflect.DeepEqual(got, want) {
+		t.Fatalf("before migration cached = %v, want %v pushed", got, want)
+	}
+	if got, want := timeline(), ids(before); !reflect.DeepEqual(got, want) {
+		t.Errorf("before migration timeline = %v, want %v", got, want)
+	}
+	consistent("before migration")
+
+	if err := r.RunPending(f.ctx); err != nil {
+		t.Fatalf("RunPending: %v", err)
+	}
+	if !r.IsCelebrity(star) {
+		t.Fatal("star not migrated to a celebrity")
+	}
+
+	// Migrated: pulled at read time, including what was pushed before
+	after := f.post(t, s, star)
+	if got := f.cached(t, reader); len(got) != 0 {
+		t.Errorf("after migration cached = %v, want nothing pushed", got)
+	}
+	if got, want := timeline(), ids(after, before); !reflect.DeepEqual(got, want) {
+		t.Errorf("after migration timeline = %v, want %v", got, want)
+	}
+	if pulled, _ := s.PullSources(f.ctx, reader); !reflect.DeepEqual(pulled, []int64{star}) {
+		t.Errorf("pull sources = %v, want [%d]", pulled, star)
+	}
+	consistent("after migration")
+
+	// Dropping back under the threshold keeps them pulled until migrated back
+	if _, err := s.Unfollow(f.ctx, other, star); err != nil {
+		t.Fatalf("unfollow: %v", err)
+	}
+	pending := f.post(t, s, star)
+	if got := f.cached(t, reader); len(got) != 0 {
+		t.Errorf("before migrating back cached = %v, want nothing pushed", got)
+	}
+	if got, want := timeline(), ids(pending, after, before); !reflect.DeepEqual(got, want) {
+		t.Errorf("before migrating back timeline = %v, want %v", got, want)
+	}
+	consistent("before migrating back")
+}
+
+// onMove runs a hook once, the first time the reclassifier moves tweets into
+// or out of a follower's timeline
+type onMove struct {
+	cache.TimelineStore
+	hook func()
+}
+
+func (m *onMove) run() {
+	if hook := m.hook; hook != nil {
+		m.hook = nil
+		hook()
+	}
+}
+
+func (m *onMove) AddTweetsToTimeline(ctx context.Context, userID int64, tweets []*models.Tweet) error {
+	m.run()
+	return m.TimelineStore.AddTweetsToTimeline(ctx, userID, tweets)
+}
+
+func (m *onMove) RemoveTweetsFromTimeline(ctx context.Context, userID int64, tweetIDs []int64) error {
+	m.run()
+	return m.TimelineStore.RemoveTweetsFromTimeline(ctx, userID, tweetIDs)
+}
+
+// A tweet posted while a former celebrity's tweets are being pushed is
+// pushed too, and every tweet stays readable throughout
+func TestHybridPostDuringMigrationToRegular(t *testing.T) {
+	f := newFixture(t)
+	s := NewHybridStrategy(f.tweets, f.follows, f.users, f.cache, 2)
+	moves := &onMove{TimelineStore: f.cache}
+	r := NewReclassifier(f.tweets, f.follows, f.users, moves, 2, ReclassifierConfig{})
+	s.SetReclassifier(r)
+	u := f.newUsers(t, 3)
+	star, reader, other := u[0], u[1], u[2]
+
+	timeline := func() []int64 {
+		t.Helper()
+		tweets, _, err := s.GetTimeline(f.ctx, reader, pageOf(10))
+		if err != nil {
+			t.Fatalf("get timeline: %v", err)
+		}
+		return ids(tweets...)
+	}
+
+	f.follow(t, s, reader, star)
+	f.follow(t, s, other, star)
+	if err := r.RunPending(f.ctx); err != nil {
+		t.Fatalf("RunPending: %v", err)
+	}
+	old := f.post(t, s, star)
+
+	if _, err := s.Unfollow(f.ctx, other, star); err != nil {
+		t.Fatalf("unfollow: %v", err)
+	}
+	var during *models.Tweet
+	moves.hook = func() {
+		during = f.post(t, s, star)
+		if got, want := timeline(), ids(during, old); !reflect.DeepEqual(got, want) {
+			t.Errorf("timeline during the migration = %v, want %v", got, want)
+		}
+	}
+	if err := r.RunPending(f.ctx); err != nil {
+		t.Fatalf("RunPending: %v", err)
+	}
+	if during == nil {
+		t.Fatal("the migration didn't move anything")
+	}
+
+	if r.IsCelebrity(star) || r.Moving(star) {
+		t.Fatal("star not migrated back to a regular user")
+	}
+	if got, want := f.cached(t, reader), ids(during, old); !reflect.DeepEqual(got, want) {
+		t.Errorf("cached = %v, want %v pushed", got, want)
+	}
+	if got, want := timeline(), ids(during, old); !reflect.DeepEqual(got, want) {
+		t.Errorf("timeline = %v, want %v", got, want)
+	}
+}
+
+// pushHook runs a hook once, just before a tweet is pushed to followers
+type pushHook struct {
+	cache.TimelineStore
+	hook func()
+}
+
+func (p *pushHook) AddToTimelineBatch(ctx context.Context, userIDs []int64, tweet *models.Tweet) error {
+	if hook := p.hook; hook != nil {
+		p.hook = nil
+		hook()
+	}
+	return p.TimelineStore.AddToTimelineBatch(ctx, userIDs, tweet)
+}
+
+// A tweet whose push lands after its author was migrated to a celebrity, and
+// their followers' timelines evicted, is taken back out
+func TestHybridPushLandingAfterMigrationToCelebrity(t *testing.T) {
+	f := newFixture(t)
+	pushes := &pushHook{TimelineStore: f.cache}
+	s := NewHybridStrategy(f.tweets, f.follows, f.users, pushes, 2)
+	r := NewReclassifier(f.tweets, f.follows, f.users, f.cache, 2, ReclassifierConfig{})
+	s.SetReclassifier(r)
+	u := f.newUsers(t, 3)
+	star, reader, other := u[0], u[1], u[2]
+
+	f.follow(t, s, reader, star)
+	f.follow(t, s, other, star)
+	pushes.hook = func() {
+		if err := r.RunPending(f.ctx); err != nil {
+			t.Fatalf("RunPending: %v", err)
+		}
+	}
+	tweet := f.post(t, s, star)
+	if !r.IsCelebrity(star) || r.Moving(star) {
+		t.Fatal("star not migrated to a celebrity")
+	}
+
+	for _, id := range []int64{reader, other} {
+		if got := f.cached(t, id); len(got) != 0 {
+			t.Errorf("user %d cached = %v, want the tweet taken back out", id, got)
+		}
+	}
+	tweets, _, err := s.GetTimeline(f.ctx, reader, pageOf(10))
+	if err != nil {
+		t.Fatalf("get timeline: %v", err)
+	}
+	if got, want := ids(tweets...), ids(tweet); !reflect.DeepEqual(got, want) {
+		t.Errorf("timeline = %v, want %v read from the author's tweets", got, want)
+	}
+}
+
+// A blocked author's tweets are dropped from pulled celebrity pages in either
+// block mode, including a celebrity's retweet of them, and the page is
+// filled from older tweets
+func TestHybridHidesBlockedAuthorsInPulledPages(t *testing.T) {
+	for _, mode := range BlockFilters() {
+		t.Run(mode, func(t *testing.T) {
+			f := newFixture(t)
+			s := NewHybridStrategy(f.tweets, f.follows, f.users, f.cache, 2)
+			blocks := NewBlocks(memory.NewBlockRepository(f.db), f.tweets, f.cache, mode)
+			s.SetBlocks(blocks)
+			u := f.newUsers(t, 4)
+			star, reader, other, troll := u[0], u[1], u[2], u[3]
+
+			f.follow(t, s, reader, star)
+			f.follow(t, s, other, star)
+			old := f.post(t, s, star)
+			trolling := f.post(t, s, troll)
+			f.now = f.now.Add(time.Minute)
+			if _, _, err := s.Retweet(f.ctx, star, trolling.ID); err != nil {
+				t.Fatalf("retweet: %v", err)
+			}
+			if _, err := blocks.Block(f.ctx, reader, troll); err != nil {
+				t.Fatalf("block: %v", err)
+			}
+
+			tweets, _, err := s.GetTimeline(f.ctx, reader, pageOf(1))
+			if err != nil {
+				t.Fatalf("get timeline: %v", err)
+			}
+			if got, want := ids(tweets...), ids(old); !reflect.DeepEqual(got, want) {
+				t.Errorf("timeline = %v, want %v without the blocked author's tweet", got, want)
+			}
+		})
+	}
+}
+
+// Retweets pushed by regular users collapse into one entry with the
+// original pulled from a celebrity
+func TestHybridCollapsesRetweetsOfPulledTweets(t *testing.T) {
+	f := newFixture(t)
+	s := NewHybridStrategy(f.tweets, f.follows, f.users, f.cache, 2)
+	u := f.newUsers(t, 5)
+	star, reader, other, first, second := u[0], u[1], u[2], u[3], u[4]
+
+	f.follow(t, s, reader, star)
+	f.follow(t, s, other, star)
+	f.follow(t, s, reader, first)
+	f.follow(t, s, reader, second)
+	tweet := f.post(t, s, star)
+	var latest *models.Tweet
+	for _, id := range []int64{first, second} {
+		f.now = f.now.Add(time.Minute)
+		retweet, _, err := s.Retweet(f.ctx, id, tweet.ID)
+		if err != nil {
+			t.Fatalf("retweet: %v", err)
+		}
+		latest = retweet
+	}
+
+	tweets, _, err := s.GetTimeline(f.ctx, reader, pageOf(10))
+	if err != nil {
+		t.Fatalf("get timeline: %v", err)
+	}
+	if got, want := ids(tweets...), ids(latest); !reflect.DeepEqual(got, want) {
+		t.Fatalf("timeline = %v, want only the newest retweet %v", got, want)
+	}
+	if got, want := tweets[0].RetweetedBy, []string{"user_5", "user_4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("retweeted by = %v, want %v", got, want)
+	}
+}
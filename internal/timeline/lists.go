@@ -0,0 +1,414 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// ErrProtectedListMember is returned when adding a protected user to a list
+// whose owner isn't one of their approved followers
+var ErrProtectedListMember = errors.New("protected users can only be listed by their followers")
+
+// errNoLists is returned by list operations on a strategy built without lists
+var errNoLists = errors.New("lists are not enabled")
+
+// ListTimelines is implemented by strategies that serve list timelines. A
+// list timeline is laid out like a home timeline with the list's members
+// as the followed users: pushed into the cache, merged at read time, or
+// both.
+type ListTimelines interface {
+	GetListTimeline(ctx context.Context, listID int64, page models.Page) ([]*models.Tweet, *OperationMetrics, error)
+	AddListMember(ctx context.Context, listID, userID int64) (*OperationMetrics, error)
+	RemoveListMember(ctx context.Context, listID, userID int64) (*OperationMetrics, error)
+}
+
+// Lists manages lists and the parts of list timelines every strategy
+// shares: the second fan-out dimension - a pushed tweet goes to every list
+// containing its author as well as to the author's followers - backfill
+// and eviction when members change, and the read-time filters. A list
+// timeline shows its owner what their home timeline would if they followed
+// exactly the members, so replies only show when they answer another
+// member, and the owner's blocks, mutes and missing approvals from
+// protected members apply. All of that is checked at read time, whatever
+// the strategy's filter modes. A nil *Lists fans out to no lists.
+type Lists struct {
+	listRepo   repository.ListStore
+	tweetRepo  repository.TweetStore
+	userRepo   repository.UserStore
+	followRepo repository.FollowStore
+	cache      cache.TimelineStore
+	blocks     *Blocks // Optional - hides the owner's blocked and muted authors
+}
+
+// NewLists creates a Lists
+func NewLists(
+	listRepo repository.ListStore,
+	tweetRepo repository.TweetStore,
+	userRepo repository.UserStore,
+	followRepo repository.FollowStore,
+	cache cache.TimelineStore,
+) *Lists {
+	return &Lists{
+		listRepo:   listRepo,
+		tweetRepo:  tweetRepo,
+		userRepo:   userRepo,
+		followRepo: followRepo,
+		cache:      cache,
+	}
+}
+
+// SetBlocks hides the authors a list's owner blocked or muted, or who
+// blocked them, from the list's timeline
+func (l *Lists) SetBlocks(b *Blocks) {
+	l.blocks = b
+}
+
+// Create creates an empty list owned by ownerID
+func (l *Lists) Create(ctx context.Context, ownerID int64, name string) (*models.List, error) {
+	return l.listRepo.Create(ctx, ownerID, name)
+}
+
+// Get returns a list
+func (l *Lists) Get(ctx context.Context, listID int64) (*models.List, error) {
+	return l.listRepo.GetByID(ctx, listID)
+}
+
+// ForOwner returns the lists ownerID owns, oldest first
+func (l *Lists) ForOwner(ctx context.Context, ownerID int64) ([]*models.List, error) {
+	return l.listRepo.GetByOwner(ctx, ownerID)
+}
+
+// Members returns a list's members
+func (l *Lists) Members(ctx context.Context, listID int64) ([]*models.User, error) {
+	return l.listRepo.GetMembers(ctx, listID)
+}
+
+// Delete removes a list and its cached timeline
+func (l *Lists) Delete(ctx context.Context, listID int64) error {
+	if err := l.listRepo.Delete(ctx, listID); err != nil {
+		return err
+	}
+	if err := l.cache.ClearListTimeline(ctx, listID); err != nil {
+		fmt.Printf("Warning: failed to clear list timeline: %v\n", err)
+	}
+	return nil
+}
+
+// AddMember adds userID to a list through strategy, which backfills the
+// list's timeline if it pushes. A protected user can only be added by one
+// of their followers.
+func (l *Lists) AddMember(ctx context.Context, strategy ListTimelines, listID, userID int64) (*OperationMetrics, error) {
+	list, err := l.listRepo.GetByID(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := l.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Protected && member.ID != list.OwnerID {
+		following, err := l.followRepo.IsFollowing(ctx, list.OwnerID, member.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !following {
+			return nil, ErrProtectedListMember
+		}
+	}
+	return strategy.AddListMember(ctx, listID, userID)
+}
+
+// RemoveMember removes userID from a list through strategy, which evicts
+// their tweets from the list's timeline if it pushes
+func (l *Lists) RemoveMember(ctx context.Context, strategy ListTimelines, listID, userID int64) (*OperationMetrics, error) {
+	if _, err := l.listRepo.GetByID(ctx, listID); err != nil {
+		return nil, err
+	}
+	return strategy.RemoveListMember(ctx, listID, userID)
+}
+
+// fanOut pushes a tweet into the timelines of every list containing its
+// author and returns how many there were
+func (l *Lists) fanOut(ctx context.Context, tweet *models.Tweet) (int, error) {
+	if l == nil {
+		return 0, nil
+	}
+	listIDs, err := l.listRepo.GetListsByMember(ctx, tweet.UserID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get member lists: %w", err)
+	}
+	if err := l.cache.AddToListTimelineBatch(ctx, listIDs, tweet); err != nil {
+		return 0, fmt.Errorf("failed to fan out to list timelines: %w", err)
+	}
+	return len(listIDs), nil
+}
+
+// unpublish removes a tweet from the timelines of every list containing
+// its author and returns how many there were
+func (l *Lists) unpublish(ctx context.Context, tweetID, authorID int64) (int, error) {
+	if l == nil {
+		return 0, nil
+	}
+	listIDs, err := l.listRepo.GetListsByMember(ctx, authorID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get member lists: %w", err)
+	}
+	if err := l.cache.RemoveFromListTimelineBatch(ctx, listIDs, tweetID); err != nil {
+		fmt.Printf("Warning: failed to remove from some list timelines: %v\n", err)
+	}
+	return len(listIDs), nil
+}
+
+// relayout pushes userID's recent tweets into, or evicts them from, the
+// timelines of every list containing them, for the reclassifier
+func (l *Lists) relayout(ctx context.Context, userID int64, tweets []*models.Tweet, push bool) error {
+	if l == nil {
+		return nil
+	}
+	listIDs, err := l.listRepo.GetListsByMember(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get member lists: %w", err)
+	}
+
+	tweetIDs := make([]int64, len(tweets))
+	for i, t := range tweets {
+		tweetIDs[i] = t.ID
+	}
+	for _, listID := range listIDs {
+		if push {
+			err = l.cache.AddTweetsToListTimeline(ctx, listID, tweets)
+		} else {
+			err = l.cache.RemoveTweetsFromListTimeline(ctx, listID, tweetIDs)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update timeline for list %d: %w", listID, err)
+		}
+	}
+	return nil
+}
+
+// addMember adds userID to a list for the strategy called name and, if
+// backfill is set, merges their recent tweets into the list's timeline
+func (l *Lists) addMember(ctx context.Context, name string, listID, userID int64, backfill bool) (*OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  name,
+		Operation: "add_list_member",
+		StartTime: time.Now(),
+	}
+	if l == nil {
+		metrics.Error = errNoLists
+		metrics.EndTime = time.Now()
+		return metrics, errNoLists
+	}
+
+	// 1. Update the membership
+	if err := l.listRepo.AddMember(ctx, listID, userID); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+
+	// 2. Merge the member's recent tweets into the list's timeline
+	if backfill {
+		tweets, err := l.tweetRepo.GetByUserID(ctx, userID, l.cache.MaxTimelineSize())
+		if err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return metrics, fmt.Errorf("failed to get member tweets: %w", err)
+		}
+
+		metrics.FanOutCount = len(tweets)
+
+		fanOutStart := time.Now()
+		if err := l.cache.AddTweetsToListTimeline(ctx, listID, tweets); err != nil {
+			fmt.Printf("Warning: failed to backfill list timeline: %v\n", err)
+		}
+		l.cache.CacheTweetsBatch(ctx, tweets)
+		metrics.FanOutDuration = time.Since(fanOutStart)
+	}
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return metrics, nil
+}
+
+// removeMember removes userID from a list for the strategy called name
+// and, if evict is set, evicts their tweets from the list's timeline
+func (l *Lists) removeMember(ctx context.Context, name string, listID, userID int64, evict bool) (*OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  name,
+		Operation: "remove_list_member",
+		StartTime: time.Now(),
+	}
+	if l == nil {
+		metrics.Error = errNoLists
+		metrics.EndTime = time.Now()
+		return metrics, errNoLists
+	}
+
+	// 1. Update the membership
+	if err := l.listRepo.RemoveMember(ctx, listID, userID); err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return metrics, err
+	}
+
+	// 2. Evict anything of the member's that could still be in the timeline
+	if evict {
+		tweets, err := l.tweetRepo.GetByUserID(ctx, userID, l.cache.MaxTimelineSize())
+		if err != nil {
+			metrics.Error = err
+			metrics.EndTime = time.Now()
+			return metrics, fmt.Errorf("failed to get member tweets: %w", err)
+		}
+
+		tweetIDs := make([]int64, len(tweets))
+		for i, t := range tweets {
+			tweetIDs[i] = t.ID
+		}
+
+		metrics.FanOutCount = len(tweetIDs)
+
+		fanOutStart := time.Now()
+		if err := l.cache.RemoveTweetsFromListTimeline(ctx, listID, tweetIDs); err != nil {
+			fmt.Printf("Warning: failed to evict from list timeline: %v\n", err)
+		}
+		metrics.FanOutDuration = time.Since(fanOutStart)
+	}
+
+	metrics.EndTime = time.Now()
+	metrics.Success = true
+
+	return metrics, nil
+}
+
+// timeline reads a page of a list's timeline for the strategy called name.
+// fetch returns raw entries for a page given the list's members; they're
+// filtered for the list's owner and collapsed like a home timeline.
+func (l *Lists) timeline(
+	ctx context.Context,
+	name string,
+	counters *Counters,
+	listID int64,
+	page models.Page,
+	fetch func(members []*models.User, page models.Page, metrics *OperationMetrics) ([]*models.Tweet, error),
+) ([]*models.Tweet, *OperationMetrics, error) {
+	metrics := &OperationMetrics{
+		Strategy:  name,
+		Operation: "get_list_timeline",
+		StartTime: time.Now(),
+	}
+	if l == nil {
+		metrics.Error = errNoLists
+		metrics.EndTime = time.Now()
+		return nil, metrics, errNoLists
+	}
+
+	list, err := l.listRepo.GetByID(ctx, listID)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return nil, metrics, err
+	}
+	members, err := l.listRepo.GetMembers(ctx, listID)
+	if err != nil {
+		metrics.Error = err
+		metrics.EndTime = time.Now()
+		return nil, metrics, err
+	}
+
+	// Replies only show when they answer another member
+	isMember := make(map[int64]bool, len(members))
+	for _, u := range members {
+		isMember[u.ID] = true
+	}
+	keep := func(t *models.Tweet) (bool, error) {
+		participant := replyParticipant(t)
+		return participant == 0 || isMember[participant], nil
+	}
+	if hidden := l.blocks.HiddenAuthors(list.OwnerID); hidden != nil {
+		keep = keepBoth(keep, func(t *models.Tweet) (bool, error) {
+			return hidden.Visible(ctx, t)
+		})
+	}
+	privacy := NewListPrivacy(l.userRepo, l.followRepo, list.OwnerID)
+	keep = keepBoth(keep, func(t *models.Tweet) (bool, error) {
+		return privacy.Visible(ctx, t)
+	})
+
+	tweets, err := collectPage(page, func(p models.Page) ([]*models.Tweet, error) {
+		return fetch(members, p, metrics)
+	}, keep)
+	if err == nil {
+		embedCounts(ctx, counters, tweets)
+	}
+	metrics.PrivacyCheck = privacy.Elapsed()
+	metrics.EndTime = time.Now()
+	if err != nil {
+		metrics.Error = err
+		return nil, metrics, err
+	}
+
+	metrics.Success = true
+
+	return tweets, metrics, nil
+}
+
+// pushedPage reads one page of a list's cached timeline, before retweets
+// are collapsed
+func (l *Lists) pushedPage(ctx context.Context, listID int64, page models.Page, metrics *OperationMetrics) ([]*models.Tweet, error) {
+	tweetIDs, err := l.cache.GetListTimelinePage(ctx, listID, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list timeline from cache: %w", err)
+	}
+	if len(tweetIDs) == 0 {
+		return []*models.Tweet{}, nil
+	}
+
+	metrics.CacheHit = true
+
+	tweets, missingIDs, err := l.cache.GetCachedTweets(ctx, tweetIDs)
+	if err != nil {
+		missingIDs = tweetIDs
+		tweets = []*models.Tweet{}
+	}
+	if len(missingIDs) > 0 {
+		dbTweets, err := l.tweetRepo.GetByIDs(ctx, missingIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tweets from DB: %w", err)
+		}
+		tweets = append(tweets, dbTweets...)
+		l.cache.CacheTweetsBatch(ctx, dbTweets)
+	}
+
+	sortTweetsByTime(tweets)
+
+	return tweets, nil
+}
+
+// pulledPage merges one page of the given members' tweets at read time,
+// before retweets are collapsed
+func (l *Lists) pulledPage(ctx context.Context, memberIDs []int64, page models.Page) ([]*models.Tweet, error) {
+	if len(memberIDs) == 0 {
+		return []*models.Tweet{}, nil
+	}
+
+	tweets, _, err := authorTweets(ctx, l.cache, l.tweetRepo, memberIDs, page)
+	return tweets, err
+}
+
+// memberIDs returns the IDs of users
+func memberIDs(users []*models.User) []int64 {
+	ids := make([]int64, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
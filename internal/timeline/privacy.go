@@ -0,0 +1,189 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// FollowRequests routes follows of protected users through requests that
+// the followee approves or denies. The follow is only created on approval,
+// so a protected user's followers are exactly the ones they approved, plus
+// those who followed before the account was protected, and fan-out needs
+// no check of its own.
+type FollowRequests struct {
+	userRepo    repository.UserStore
+	followRepo  repository.FollowStore
+	requestRepo repository.FollowRequestStore
+}
+
+// NewFollowRequests creates a FollowRequests
+func NewFollowRequests(userRepo repository.UserStore, followRepo repository.FollowStore, requestRepo repository.FollowRequestStore) *FollowRequests {
+	return &FollowRequests{userRepo: userRepo, followRepo: followRepo, requestRepo: requestRepo}
+}
+
+// Follow makes followerID follow followeeID through strategy or, if the
+// followee is protected and hasn't approved them, files a request instead.
+// It reports whether a request was filed.
+func (f *FollowRequests) Follow(ctx context.Context, strategy Strategy, followerID, followeeID int64) (*OperationMetrics, bool, error) {
+	followee, err := f.userRepo.GetByID(ctx, followeeID)
+	if err != nil {
+		return nil, false, err
+	}
+	if followee.Protected {
+		following, err := f.followRepo.IsFollowing(ctx, followerID, followeeID)
+		if err != nil {
+			return nil, false, err
+		}
+		if !following {
+			return nil, true, f.requestRepo.Create(ctx, followerID, followeeID)
+		}
+	}
+
+	metrics, err := strategy.Follow(ctx, followerID, followeeID)
+	return metrics, false, err
+}
+
+// Unfollow makes followerID unfollow followeeID through strategy and
+// cancels any pending request to follow them
+func (f *FollowRequests) Unfollow(ctx context.Context, strategy Strategy, followerID, followeeID int64) (*OperationMetrics, error) {
+	if err := f.requestRepo.Delete(ctx, followerID, followeeID); err != nil && !errors.Is(err, repository.ErrNoFollowRequest) {
+		return nil, err
+	}
+	return strategy.Unfollow(ctx, followerID, followeeID)
+}
+
+// Approve grants requesterID's pending request to follow targetID. The
+// follow goes through strategy, which backfills the requester's timeline.
+func (f *FollowRequests) Approve(ctx context.Context, strategy Strategy, targetID, requesterID int64) (*OperationMetrics, error) {
+	if err := f.requestRepo.Delete(ctx, requesterID, targetID); err != nil {
+		return nil, err
+	}
+	return strategy.Follow(ctx, requesterID, targetID)
+}
+
+// Deny drops requesterID's pending request to follow targetID
+func (f *FollowRequests) Deny(ctx context.Context, targetID, requesterID int64) error {
+	return f.requestRepo.Delete(ctx, requesterID, targetID)
+}
+
+// Pending returns up to limit requests to follow targetID, oldest first
+func (f *FollowRequests) Pending(ctx context.Context, targetID int64, limit int) ([]*models.FollowRequest, error) {
+	return f.requestRepo.GetPending(ctx, targetID, limit)
+}
+
+// SetProtected protects or unprotects userID. Existing followers stay
+// approved. Unprotecting approves every pending request through strategy;
+// it returns the number approved.
+func (f *FollowRequests) SetProtected(ctx context.Context, strategy Strategy, userID int64, protected bool) (int, error) {
+	if err := f.userRepo.SetProtected(ctx, userID, protected); err != nil {
+		return 0, err
+	}
+	if protected {
+		return 0, nil
+	}
+
+	requesters, err := f.requestRepo.DeleteAll(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for i, requesterID := range requesters {
+		if _, err := strategy.Follow(ctx, requesterID, userID); err != nil {
+			return i, fmt.Errorf("failed to approve follow request: %w", err)
+		}
+	}
+	return len(requesters), nil
+}
+
+// Privacy decides which retweets of protected users' tweets one viewer's
+// home timeline shows, or which protected members' tweets a list timeline
+// shows its owner. New retweets of a protected tweet are refused, but
+// one made before its author protected their account still reaches the
+// retweeter's followers - pushed, or pulled by fanout_read and hybrid's
+// celebrity path, which follow the retweeter, not the author. The viewer
+// only sees it if they follow the author. Authors are looked up on the
+// first retweet of theirs it checks, and the time spent looking them up is
+// reported as the read's PrivacyCheck.
+type Privacy struct {
+	userRepo   repository.UserStore
+	followRepo repository.FollowStore
+	viewerID   int64
+	hidden     map[int64]bool // Original author -> whether the viewer mustn't see their tweets
+	authors    bool           // Check tweets' own authors too, not just retweeted ones
+	elapsed    time.Duration
+}
+
+// NewPrivacy creates a Privacy for viewerID
+func NewPrivacy(userRepo repository.UserStore, followRepo repository.FollowStore, viewerID int64) *Privacy {
+	return &Privacy{userRepo: userRepo, followRepo: followRepo, viewerID: viewerID, hidden: make(map[int64]bool)}
+}
+
+// NewListPrivacy creates a Privacy for a list timeline owned by ownerID.
+// Listing someone doesn't require following them, so every author is
+// checked, not just the authors of retweeted tweets.
+func NewListPrivacy(userRepo repository.UserStore, followRepo repository.FollowStore, ownerID int64) *Privacy {
+	p := NewPrivacy(userRepo, followRepo, ownerID)
+	p.authors = true
+	return p
+}
+
+// NewThreadPrivacy creates a Privacy for viewerID reading a reply thread.
+// Anyone can reply, so as for lists every author is checked.
+func NewThreadPrivacy(userRepo repository.UserStore, followRepo repository.FollowStore, viewerID int64) *Privacy {
+	p := NewPrivacy(userRepo, followRepo, viewerID)
+	p.authors = true
+	return p
+}
+
+// Visible reports whether the viewer may see t. Only retweets of other
+// users' tweets are checked, unless this is a list's or thread's Privacy.
+func (p *Privacy) Visible(ctx context.Context, t *models.Tweet) (bool, error) {
+	if p.authors {
+		hidden, err := p.hiddenAuthor(ctx, t.UserID)
+		if err != nil || hidden {
+			return false, err
+		}
+	}
+	hidden, err := p.hiddenAuthor(ctx, t.OriginalUserID)
+	if err != nil {
+		return false, err
+	}
+	return !hidden, nil
+}
+
+// hiddenAuthor reports whether authorID is protected and the viewer isn't
+// one of their followers
+func (p *Privacy) hiddenAuthor(ctx context.Context, authorID int64) (bool, error) {
+	if authorID == 0 || authorID == p.viewerID {
+		return false, nil
+	}
+
+	hidden, checked := p.hidden[authorID]
+	if !checked {
+		start := time.Now()
+		defer func() { p.elapsed += time.Since(start) }()
+
+		author, err := p.userRepo.GetByID(ctx, authorID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get user: %w", err)
+		}
+		if author.Protected {
+			following, err := p.followRepo.IsFollowing(ctx, p.viewerID, authorID)
+			if err != nil {
+				return false, err
+			}
+			hidden = !following
+		}
+		p.hidden[authorID] = hidden
+	}
+	return hidden, nil
+}
+
+// Elapsed returns the time spent looking up authors so far
+func (p *Privacy) Elapsed() time.Duration {
+	return p.elapsed
+}
@@ -0,0 +1,507 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// ReclassifierConfig controls the celebrity reclassification job
+type ReclassifierConfig struct {
+	SweepInterval time.Duration // How often all users are checked against the threshold
+	ChunkSize     int           // Followers migrated between progress updates
+}
+
+// DefaultReclassifierConfig returns sensible defaults for the reclassifier
+func DefaultReclassifierConfig() ReclassifierConfig {
+	return ReclassifierConfig{
+		SweepInterval: time.Minute,
+		ChunkSize:     1000,
+	}
+}
+
+// Migration describes moving one user's tweets between the pushed timelines
+// and their author tweet cache, which their followers read them from while
+// they're a celebrity
+type Migration struct {
+	UserID        int64     `json:"user_id"`
+	ToCelebrity   bool      `json:"to_celebrity"` // false: back to a regular user
+	FollowerCount int       `json:"follower_count"`
+	Tweets        int       `json:"tweets"`
+	Followers     int       `json:"followers"`      // Timelines to update
+	FollowersDone int       `json:"followers_done"` // Timelines updated so far
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at,omitempty"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// ReclassifyStatus reports the progress of the reclassification job
+type ReclassifyStatus struct {
+	Threshold   int          `json:"threshold"`
+	Celebrities int          `json:"celebrities"` // Users whose tweets are laid out as celebrity tweets
+	Pending     int          `json:"pending"`
+	Migrated    int64        `json:"migrated"`
+	Failed      int64        `json:"failed"`
+	LastSweep   time.Time    `json:"last_sweep,omitempty"`
+	Current     *Migration   `json:"current,omitempty"`
+	Recent      []*Migration `json:"recent"`
+}
+
+const recentMigrations = 20
+
+// Reclassifier keeps the hybrid cache layout in line with who is a celebrity.
+// The hybrid strategy only looks at follower counts when a tweet is posted,
+// so when a user crosses the threshold - through follows, unfollows or a
+// threshold change - their existing tweets are in the wrong place:
+//   - New celebrities still have tweets pushed into every follower's timeline
+//   - Former celebrities have tweets that were never pushed at all
+//
+// The reclassifier remembers which layout each user's tweets are in and
+// migrates them in the background whenever that no longer matches. The
+// layout flips as a migration starts, so tweets posted while it runs are
+// already delivered the new way, and until it finishes readers merge the
+// user's author tweet cache as well, whichever way they're moving.
+type Reclassifier struct {
+	tweetRepo  repository.TweetStore
+	followRepo repository.FollowStore
+	userRepo   repository.UserStore
+	cache      cache.TimelineStore
+	cfg        ReclassifierConfig
+	blocks     *Blocks // Optional - keeps hidden authors out of pushed timelines when purging
+	lists      *Lists  // Optional - list timelines are migrated along with followers' timelines
+
+	mu          sync.Mutex
+	threshold   int
+	replyMode   string
+	celebrities map[int64]bool // Users whose tweets are read from their author tweet cache rather than pushed
+	moving      map[int64]bool // Users whose layout has flipped but whose existing tweets aren't all moved yet
+	queue       []int64
+	queued      map[int64]bool
+	sweepNeeded bool
+	lastSweep   time.Time
+	current     *Migration
+	recent      []*Migration
+	migrated    int64
+	failed      int64
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewReclassifier creates a new Reclassifier
+func NewReclassifier(
+	tweetRepo repository.TweetStore,
+	followRepo repository.FollowStore,
+	userRepo repository.UserStore,
+	cache cache.TimelineStore,
+	threshold int,
+	cfg ReclassifierConfig,
+) *Reclassifier {
+	defaults := DefaultReclassifierConfig()
+	if cfg.SweepInterval <= 0 {
+		cfg.SweepInterval = defaults.SweepInterval
+	}
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = defaults.ChunkSize
+	}
+
+	return &Reclassifier{
+		tweetRepo:   tweetRepo,
+		followRepo:  followRepo,
+		userRepo:    userRepo,
+		cache:       cache,
+		cfg:         cfg,
+		threshold:   threshold,
+		replyMode:   ReplyFilterRead,
+		celebrities: make(map[int64]bool),
+		moving:      make(map[int64]bool),
+		queued:      make(map[int64]bool),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Load records the current celebrities as already laid out as celebrities.
+// Call it once at startup, before any tweets have been posted against a
+// different threshold.
+func (r *Reclassifier) Load(ctx context.Context) error {
+	r.mu.Lock()
+	threshold := r.threshold
+	r.mu.Unlock()
+
+	celebrities, err := r.userRepo.GetCelebrities(ctx, threshold)
+	if err != nil {
+		return fmt.Errorf("failed to get celebrities: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range celebrities {
+		r.celebrities[u.ID] = true
+	}
+	r.lastSweep = time.Now()
+	return nil
+}
+
+// Start loads the current classification and launches the background job.
+// It runs until Stop is called or ctx is cancelled.
+func (r *Reclassifier) Start(ctx context.Context) error {
+	if err := r.Load(ctx); err != nil {
+		return err
+	}
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(ctx)
+	}()
+
+	return nil
+}
+
+// Stop signals the job to exit and waits for the current migration to finish
+func (r *Reclassifier) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
+
+// run is the main loop of the background job
+func (r *Reclassifier) run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			r.sweepNeeded = true
+			r.mu.Unlock()
+		case <-r.wake:
+		}
+
+		if err := r.RunPending(ctx); err != nil && ctx.Err() == nil {
+			fmt.Printf("Warning: celebrity reclassification failed: %v\n", err)
+		}
+	}
+}
+
+// notify wakes the background job without blocking
+func (r *Reclassifier) notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SetCelebrityThreshold changes the threshold and schedules a sweep to
+// migrate every user it reclassifies
+func (r *Reclassifier) SetCelebrityThreshold(threshold int) {
+	r.mu.Lock()
+	changed := threshold != r.threshold
+	r.threshold = threshold
+	if changed {
+		r.sweepNeeded = true
+	}
+	r.mu.Unlock()
+
+	if changed {
+		r.notify()
+	}
+}
+
+// SetReplyFilterMode sets whether replies are filtered per follower when a
+// former celebrity's tweets are pushed into their followers' timelines
+func (r *Reclassifier) SetReplyFilterMode(mode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replyMode = mode
+}
+
+// SetBlocks keeps blocked and muted authors' tweets out of the timelines a
+// former celebrity's tweets are pushed into when purging. Call it before
+// Start.
+func (r *Reclassifier) SetBlocks(b *Blocks) {
+	r.blocks = b
+}
+
+// SetLists migrates the timelines of the lists containing a reclassified
+// user along with their followers' timelines. Call it before Start.
+func (r *Reclassifier) SetLists(l *Lists) {
+	r.lists = l
+}
+
+// Observe schedules a migration if user's follower count has moved them
+// across the threshold. Call it after follows and unfollows.
+func (r *Reclassifier) Observe(user *models.User) {
+	r.mu.Lock()
+	crossed := user.IsCelebrity(r.threshold) != r.celebrities[user.ID]
+	if crossed {
+		r.enqueue(user.ID)
+	}
+	r.mu.Unlock()
+
+	if crossed {
+		r.notify()
+	}
+}
+
+// IsCelebrity reports whether userID's new tweets are laid out as celebrity
+// tweets. This lags the follower count until a migration starts.
+func (r *Reclassifier) IsCelebrity(userID int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.celebrities[userID]
+}
+
+// Moving reports whether userID's existing tweets are still being moved
+// into the layout IsCelebrity reports, so some of them are in either place
+func (r *Reclassifier) Moving(userID int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.moving[userID]
+}
+
+// enqueue adds a user to the migration queue. Callers must hold r.mu.
+func (r *Reclassifier) enqueue(userID int64) {
+	if r.queued[userID] {
+		return
+	}
+	r.queued[userID] = true
+	r.queue = append(r.queue, userID)
+}
+
+// Sweep compares every user's classification with the threshold and queues
+// the ones that crossed it
+func (r *Reclassifier) Sweep(ctx context.Context) error {
+	r.mu.Lock()
+	threshold := r.threshold
+	r.sweepNeeded = false
+	r.mu.Unlock()
+
+	celebrities, err := r.userRepo.GetCelebrities(ctx, threshold)
+	if err != nil {
+		r.mu.Lock()
+		r.sweepNeeded = true
+		r.mu.Unlock()
+		return fmt.Errorf("failed to get celebrities: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := make(map[int64]bool, len(celebrities))
+	for _, u := range celebrities {
+		current[u.ID] = true
+		if !r.celebrities[u.ID] {
+			r.enqueue(u.ID)
+		}
+	}
+	for userID := range r.celebrities {
+		if !current[userID] {
+			r.enqueue(userID)
+		}
+	}
+	// Failed migrations are finished whichever way they're going
+	for userID := range r.moving {
+		r.enqueue(userID)
+	}
+	r.lastSweep = time.Now()
+	return nil
+}
+
+// RunPending runs a pending sweep and migrates queued users until the queue is empty
+func (r *Reclassifier) RunPending(ctx context.Context) error {
+	r.mu.Lock()
+	sweep := r.sweepNeeded
+	r.mu.Unlock()
+
+	if sweep {
+		if err := r.Sweep(ctx); err != nil {
+			return err
+		}
+	}
+
+	for ctx.Err() == nil {
+		r.mu.Lock()
+		if len(r.queue) == 0 {
+			r.mu.Unlock()
+			return nil
+		}
+		userID := r.queue[0]
+		r.queue = r.queue[1:]
+		delete(r.queued, userID)
+		r.mu.Unlock()
+
+		if err := r.migrate(ctx, userID); err != nil {
+			fmt.Printf("Warning: failed to reclassify user %d: %v\n", userID, err)
+		}
+	}
+	return ctx.Err()
+}
+
+// migrate moves a user's recent tweets to match their current classification
+func (r *Reclassifier) migrate(ctx context.Context, userID int64) error {
+	// 1. Decide which way the user crossed, if they still have
+	user, err := r.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		// Deleted users take their tweets with them
+		r.mu.Lock()
+		delete(r.celebrities, userID)
+		delete(r.moving, userID)
+		r.mu.Unlock()
+		return nil
+	}
+
+	// 2. Flip the layout before moving anything, so tweets posted from now
+	// on are delivered the new way and move only has to handle those that
+	// already exist. Readers merge the author tweet cache until it's done.
+	r.mu.Lock()
+	toCelebrity := user.IsCelebrity(r.threshold)
+	if toCelebrity == r.celebrities[userID] && !r.moving[userID] {
+		r.mu.Unlock()
+		return nil
+	}
+	if toCelebrity {
+		r.celebrities[userID] = true
+	} else {
+		delete(r.celebrities, userID)
+	}
+	r.moving[userID] = true
+	m := &Migration{
+		UserID:        userID,
+		ToCelebrity:   toCelebrity,
+		FollowerCount: user.FollowerCount,
+		StartedAt:     time.Now(),
+	}
+	r.current = m
+	r.mu.Unlock()
+
+	err = r.move(ctx, m)
+
+	r.mu.Lock()
+	m.FinishedAt = time.Now()
+	if err != nil {
+		m.Error = err.Error()
+		r.failed++
+		// Still moving: try again on the next sweep
+		r.sweepNeeded = true
+	} else {
+		delete(r.moving, userID)
+		r.migrated++
+	}
+	r.current = nil
+	r.recent = append([]*Migration{m}, r.recent...)
+	if len(r.recent) > recentMigrations {
+		r.recent = r.recent[:recentMigrations]
+	}
+	r.mu.Unlock()
+
+	return err
+}
+
+// move rewrites the cache for one migration, updating its progress as it goes
+func (r *Reclassifier) move(ctx context.Context, m *Migration) error {
+	// 1. Get the tweets that can be in a timeline and the followers holding
+	// them. The layout has already flipped, so this includes every tweet
+	// delivered the old way; one still being pushed as it flipped is taken
+	// back out by the strategy that posted it.
+	tweets, err := r.tweetRepo.GetByUserID(ctx, m.UserID, r.cache.MaxTimelineSize())
+	if err != nil {
+		return fmt.Errorf("failed to get tweets: %w", err)
+	}
+	followers, err := r.followRepo.GetFollowers(ctx, m.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get followers: %w", err)
+	}
+
+	tweetIDs := make([]int64, len(tweets))
+	for i, t := range tweets {
+		tweetIDs[i] = t.ID
+	}
+
+	r.mu.Lock()
+	m.Tweets = len(tweets)
+	m.Followers = len(followers)
+	r.mu.Unlock()
+
+	// 2. New celebrities are read from their author tweet cache, so make
+	// sure it's warm first and only then evict their tweets from followers'
+	// timelines
+	if m.ToCelebrity {
+		complete := len(tweets) < r.cache.MaxTimelineSize()
+		if err := r.cache.AddAuthorTweets(ctx, m.UserID, tweets, complete); err != nil {
+			return fmt.Errorf("failed to add author tweets: %w", err)
+		}
+	} else {
+		r.cache.CacheTweetsBatch(ctx, tweets)
+	}
+
+	r.mu.Lock()
+	replyMode := r.replyMode
+	r.mu.Unlock()
+
+	// 3. Update followers' timelines in chunks
+	for start := 0; start < len(followers); start += r.cfg.ChunkSize {
+		end := min(start+r.cfg.ChunkSize, len(followers))
+		for _, followerID := range followers[start:end] {
+			if m.ToCelebrity {
+				err = r.cache.RemoveTweetsFromTimeline(ctx, followerID, tweetIDs)
+			} else {
+				var pushed []*models.Tweet
+				pushed, err = pushable(ctx, replyMode, r.followRepo, followerID, tweets)
+				if err == nil {
+					pushed, err = r.blocks.pushable(ctx, followerID, pushed)
+				}
+				if err == nil {
+					err = r.cache.AddTweetsToTimeline(ctx, followerID, pushed)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update timeline for user %d: %w", followerID, err)
+			}
+		}
+
+		r.mu.Lock()
+		m.FollowersDone = end
+		r.mu.Unlock()
+	}
+
+	// 4. The lists containing the user are laid out the same way. Former
+	// celebrities keep their author tweet cache - every author has one.
+	return r.lists.relayout(ctx, m.UserID, tweets, !m.ToCelebrity)
+}
+
+// Status returns a snapshot of the job's progress
+func (r *Reclassifier) Status() *ReclassifyStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := &ReclassifyStatus{
+		Threshold:   r.threshold,
+		Celebrities: len(r.celebrities),
+		Pending:     len(r.queue),
+		Migrated:    r.migrated,
+		Failed:      r.failed,
+		LastSweep:   r.lastSweep,
+		Recent:      make([]*Migration, len(r.recent)),
+	}
+	if r.current != nil {
+		current := *r.current
+		status.Current = &current
+	}
+	for i, m := range r.recent {
+		copied := *m
+		status.Recent[i] = &copied
+	}
+	return status
+}
@@ -0,0 +1,119 @@
+package timeline
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/config"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// Dependencies bundles everything a strategy constructor may need
+type Dependencies struct {
+	TweetRepo    repository.TweetStore
+	FollowRepo   repository.FollowStore
+	UserRepo     repository.UserStore
+	Cache        cache.TimelineStore
+	Config       *config.Config
+	FanOutQueue  *cache.FanOutQueue // Optional - enables async fan-out where supported
+	Reclassifier *Reclassifier      // Optional - enables celebrity reclassification for hybrid and for_you
+	Counters     *Counters          // Optional - embeds like and retweet counts in timelines
+	Blocks       *Blocks            // Optional - hides blocked and muted authors from timelines
+	Lists        *Lists             // Optional - enables list timelines
+}
+
+// Factory builds a strategy from its dependencies
+type Factory func(deps Dependencies) Strategy
+
+type registration struct {
+	strategyType StrategyType
+	order        int
+	factory      Factory
+}
+
+// registrations holds every known strategy sorted by display order
+var registrations []registration
+
+// Register makes a strategy available to the API, CLI and metrics.
+// Call it from an init function in the strategy's own file. Strategies are
+// listed by order, lowest first, whichever file's init runs first.
+func Register(strategyType StrategyType, order int, factory Factory) {
+	for _, r := range registrations {
+		if r.strategyType == strategyType {
+			panic(fmt.Sprintf("timeline: strategy %q registered twice", strategyType))
+		}
+	}
+	registrations = append(registrations, registration{strategyType: strategyType, order: order, factory: factory})
+	sort.SliceStable(registrations, func(i, j int) bool {
+		if registrations[i].order != registrations[j].order {
+			return registrations[i].order < registrations[j].order
+		}
+		return registrations[i].strategyType < registrations[j].strategyType
+	})
+}
+
+// withOptional hands s each optional dependency set in deps that it accepts,
+// so factories only construct the strategy
+func withOptional(s Strategy, deps Dependencies) Strategy {
+	if x, ok := s.(interface{ SetFanOutQueue(*cache.FanOutQueue) }); ok && deps.FanOutQueue != nil {
+		x.SetFanOutQueue(deps.FanOutQueue)
+	}
+	if x, ok := s.(interface{ SetReclassifier(*Reclassifier) }); ok && deps.Reclassifier != nil {
+		x.SetReclassifier(deps.Reclassifier)
+	}
+	if x, ok := s.(interface{ SetReplyFilterMode(string) }); ok && deps.Config != nil && deps.Config.ReplyFilter != "" {
+		x.SetReplyFilterMode(deps.Config.ReplyFilter)
+	}
+	if x, ok := s.(interface{ SetCounters(*Counters) }); ok && deps.Counters != nil {
+		x.SetCounters(deps.Counters)
+	}
+	if x, ok := s.(interface{ SetBlocks(*Blocks) }); ok && deps.Blocks != nil {
+		x.SetBlocks(deps.Blocks)
+	}
+	if x, ok := s.(interface{ SetLists(*Lists) }); ok && deps.Lists != nil {
+		x.SetLists(deps.Lists)
+	}
+	return s
+}
+
+// Registry holds one instance of every registered strategy
+type Registry struct {
+	order      []StrategyType
+	strategies map[StrategyType]Strategy
+}
+
+// NewRegistry instantiates all registered strategies
+func NewRegistry(deps Dependencies) *Registry {
+	r := &Registry{
+		order:      make([]StrategyType, 0, len(registrations)),
+		strategies: make(map[StrategyType]Strategy, len(registrations)),
+	}
+	for _, reg := range registrations {
+		r.order = append(r.order, reg.strategyType)
+		r.strategies[reg.strategyType] = reg.factory(deps)
+	}
+	return r
+}
+
+// Get returns the strategy registered under name
+func (r *Registry) Get(name string) (Strategy, bool) {
+	s, ok := r.strategies[StrategyType(name)]
+	return s, ok
+}
+
+// Names returns the registered strategy types in registration order
+func (r *Registry) Names() []StrategyType {
+	names := make([]StrategyType, len(r.order))
+	copy(names, r.order)
+	return names
+}
+
+// All returns the strategy instances in registration order
+func (r *Registry) All() []Strategy {
+	all := make([]Strategy, len(r.order))
+	for i, name := range r.order {
+		all[i] = r.strategies[name]
+	}
+	return all
+}
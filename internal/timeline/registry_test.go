@@ -0,0 +1,106 @@
+package timeline
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ritik/twitter-fan-out/internal/config"
+)
+
+func TestRegistryKeepsRegistrationOrder(t *testing.T) {
+	f := newFixture(t)
+	r := NewRegistry(Dependencies{
+		TweetRepo:  f.tweets,
+		FollowRepo: f.follows,
+		UserRepo:   f.users,
+		Cache:      f.cache,
+		Config:     config.Default(),
+	})
+
+	want := []StrategyType{StrategyFanOutWrite, StrategyFanOutRead, StrategyHybrid, StrategyForYou}
+	names := r.Names()
+	if len(names) != len(want) {
+		t.Fatalf("Names() = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("Names() = %v, want %v", names, want)
+		}
+	}
+
+	all := r.All()
+	for i, s := range all {
+		if s.Name() != string(want[i]) {
+			t.Errorf("All()[%d].Name() = %q, want %q", i, s.Name(), want[i])
+		}
+		got, ok := r.Get(string(want[i]))
+		if !ok || got != s {
+			t.Errorf("Get(%q) did not return the same instance as All()", want[i])
+		}
+	}
+	if _, ok := r.Get("nope"); ok {
+		t.Error("Get found an unregistered strategy")
+	}
+
+	// Callers can't reorder the registry through the returned slice
+	names[0] = "nope"
+	if r.Names()[0] != StrategyFanOutWrite {
+		t.Error("mutating Names() changed the registry")
+	}
+}
+
+func TestRegisterPanicsOnDuplicate(t *testing.T) {
+	saved := append([]registration(nil), registrations...)
+	t.Cleanup(func() { registrations = saved })
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering fanout_write twice did not panic")
+		}
+		if len(registrations) != len(saved) {
+			t.Errorf("the duplicate was added before panicking: %d registrations, want %d", len(registrations), len(saved))
+		}
+	}()
+	Register(StrategyFanOutWrite, 99, func(Dependencies) Strategy { return nil })
+}
+
+func TestRegisterSortsByOrder(t *testing.T) {
+	saved := append([]registration(nil), registrations...)
+	t.Cleanup(func() { registrations = saved })
+
+	// Registered last, listed between fanout_write and fanout_read
+	Register("between", 15, func(Dependencies) Strategy { return nil })
+	want := []StrategyType{StrategyFanOutWrite, "between", StrategyFanOutRead, StrategyHybrid, StrategyForYou}
+	if got := ValidStrategies(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidStrategies() = %v, want %v", got, want)
+	}
+}
+
+func TestRegistryWiresOptionalDependencies(t *testing.T) {
+	f := newFixture(t)
+	cfg := config.Default()
+	cfg.ReplyFilter = ReplyFilterFanOut
+	reclassifier := NewReclassifier(f.tweets, f.follows, f.users, f.cache, cfg.CelebrityThreshold, ReclassifierConfig{})
+	r := NewRegistry(Dependencies{
+		TweetRepo:    f.tweets,
+		FollowRepo:   f.follows,
+		UserRepo:     f.users,
+		Cache:        f.cache,
+		Config:       cfg,
+		Reclassifier: reclassifier,
+	})
+
+	for _, name := range []StrategyType{StrategyFanOutWrite, StrategyHybrid, StrategyForYou} {
+		s, _ := r.Get(string(name))
+		if mode := s.(interface{ ReplyFilterMode() string }).ReplyFilterMode(); mode != ReplyFilterFanOut {
+			t.Errorf("%s reply filter %q, want %q", name, mode, ReplyFilterFanOut)
+		}
+	}
+	// for_you gets hybrid's wiring through the embedded strategy
+	for _, name := range []StrategyType{StrategyHybrid, StrategyForYou} {
+		s, _ := r.Get(string(name))
+		if got := s.(interface{ Reclassifier() *Reclassifier }).Reclassifier(); got != reclassifier {
+			t.Errorf("%s has reclassifier %p, want %p", name, got, reclassifier)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// Reply filter modes. A reply shows in a home timeline only if the viewer
+// follows both its author and the user it answers; the modes decide when
+// that is checked.
+const (
+	// ReplyFilterRead delivers replies like any other tweet and drops the
+	// ones the viewer shouldn't see when the timeline is read
+	ReplyFilterRead = "read"
+	// ReplyFilterFanOut pushes replies only to the followers who should see
+	// them. Replies merged at read time are still filtered then.
+	ReplyFilterFanOut = "fanout"
+)
+
+// ReplyFilters returns the reply filter modes
+func ReplyFilters() []string {
+	return []string{ReplyFilterRead, ReplyFilterFanOut}
+}
+
+// IsValidReplyFilter checks if a reply filter mode is valid
+func IsValidReplyFilter(mode string) bool {
+	for _, valid := range ReplyFilters() {
+		if valid == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplyFiltering is implemented by strategies that push tweets, and so can
+// filter replies at fan-out time instead of at read time
+type ReplyFiltering interface {
+	SetReplyFilterMode(mode string)
+	ReplyFilterMode() string
+}
+
+// replyParticipant returns the user a follower of t's author must also
+// follow to see t, or 0 if every follower sees it
+func replyParticipant(t *models.Tweet) int64 {
+	if !t.IsReply() || t.InReplyToUserID == t.UserID {
+		return 0
+	}
+	return t.InReplyToUserID
+}
+
+// ReplyVisibility decides which replies one viewer's home timeline shows.
+// The viewer's follows are loaded on the first reply it has to check.
+type ReplyVisibility struct {
+	followRepo repository.FollowStore
+	viewerID   int64
+	following  map[int64]bool
+}
+
+// NewReplyVisibility creates a ReplyVisibility for viewerID
+func NewReplyVisibility(followRepo repository.FollowStore, viewerID int64) *ReplyVisibility {
+	return &ReplyVisibility{followRepo: followRepo, viewerID: viewerID}
+}
+
+// Visible reports whether the viewer should see t. Tweets that aren't
+// replies are always visible, as are the viewer's own replies, replies to
+// the viewer and replies to someone the viewer follows.
+func (v *ReplyVisibility) Visible(ctx context.Context, t *models.Tweet) (bool, error) {
+	participant := replyParticipant(t)
+	if participant == 0 || t.UserID == v.viewerID || participant == v.viewerID {
+		return true, nil
+	}
+
+	if v.following == nil {
+		following, err := v.followRepo.GetFollowing(ctx, v.viewerID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get following: %w", err)
+		}
+		v.following = make(map[int64]bool, len(following))
+		for _, id := range following {
+			v.following[id] = true
+		}
+	}
+	return v.following[participant], nil
+}
+
+// filterReplies drops the replies in tweets that the viewer shouldn't see
+func (v *ReplyVisibility) filterReplies(ctx context.Context, tweets []*models.Tweet) ([]*models.Tweet, error) {
+	result := make([]*models.Tweet, 0, len(tweets))
+	for _, t := range tweets {
+		visible, err := v.Visible(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// pushable returns the tweets that may be pushed into userID's timeline
+// outside of a normal fan-out, e.g. when backfilling a follow. In read mode
+// everything is pushed and filtered later.
+func pushable(ctx context.Context, mode string, followRepo repository.FollowStore, userID int64, tweets []*models.Tweet) ([]*models.Tweet, error) {
+	if mode != ReplyFilterFanOut {
+		return tweets, nil
+	}
+	return NewReplyVisibility(followRepo, userID).filterReplies(ctx, tweets)
+}
+
+// narrowAudience keeps the followers who should see a reply to participant:
+// those who follow participant too, and participant themselves
+func narrowAudience(ctx context.Context, followRepo repository.FollowStore, followers []int64, participant int64) ([]int64, error) {
+	participantFollowers, err := followRepo.GetFollowers(ctx, participant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get followers: %w", err)
+	}
+	following := make(map[int64]bool, len(participantFollowers))
+	for _, id := range participantFollowers {
+		following[id] = true
+	}
+
+	audience := make([]int64, 0, len(followers))
+	for _, id := range followers {
+		if id == participant || following[id] {
+			audience = append(audience, id)
+		}
+	}
+	return audience, nil
+}
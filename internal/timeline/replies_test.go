@@ -0,0 +1,63 @@
+package timeline
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// A reply only shows to followers of both its author and the user it
+// answers, whether it's filtered at read time or left out at fan-out
+func TestRepliesShowOnlyToMutualFollowers(t *testing.T) {
+	strategies := map[string]func(f *fixture) Strategy{
+		"fanout_write": func(f *fixture) Strategy {
+			return NewFanOutWriteStrategy(f.tweets, f.follows, f.users, f.cache)
+		},
+		"hybrid": func(f *fixture) Strategy {
+			return NewHybridStrategy(f.tweets, f.follows, f.users, f.cache, 100)
+		},
+	}
+
+	for name, newStrategy := range strategies {
+		for _, mode := range ReplyFilters() {
+			t.Run(name+"/"+mode, func(t *testing.T) {
+				f := newFixture(t)
+				s := newStrategy(f)
+				s.(ReplyFiltering).SetReplyFilterMode(mode)
+				u := f.newUsers(t, 4)
+				asker, replier, both, one := u[0], u[1], u[2], u[3]
+
+				f.follow(t, s, both, asker)
+				f.follow(t, s, both, replier)
+				f.follow(t, s, one, replier)
+				question := f.post(t, s, asker)
+				f.now = f.now.Add(time.Minute)
+				reply, _, err := s.PostReply(f.ctx, replier, question.ID, "reply")
+				if err != nil {
+					t.Fatalf("reply: %v", err)
+				}
+
+				timeline := func(userID int64) []int64 {
+					t.Helper()
+					tweets, _, err := s.GetTimeline(f.ctx, userID, pageOf(10))
+					if err != nil {
+						t.Fatalf("get timeline: %v", err)
+					}
+					return ids(tweets...)
+				}
+				if got, want := timeline(both), ids(reply, question); !reflect.DeepEqual(got, want) {
+					t.Errorf("mutual follower timeline = %v, want %v", got, want)
+				}
+				if got := timeline(one); len(got) != 0 {
+					t.Errorf("replier-only follower timeline = %v, want the reply hidden", got)
+				}
+
+				// Filtered at fan-out, it's never pushed to them at all
+				pushed := len(f.cached(t, one)) != 0
+				if want := mode == ReplyFilterRead; pushed != want {
+					t.Errorf("reply pushed to replier-only follower = %v, want %v", pushed, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// Scorers rank the candidates of a for_you timeline
+const (
+	// ScorerRecency decays each candidate's score with its age, so the
+	// timeline stays chronological but second-degree tweets compete on
+	// equal terms
+	ScorerRecency = "recency"
+	// ScorerEngagement favours likes and retweets, discounted by age
+	ScorerEngagement = "engagement"
+	// ScorerAffinity favours the authors closest to the viewer: mutual
+	// follows, then followed accounts, then second-degree authors by how
+	// many followees follow them
+	ScorerAffinity = "affinity"
+)
+
+// Scorers returns the scorer names
+func Scorers() []string {
+	return []string{ScorerRecency, ScorerEngagement, ScorerAffinity}
+}
+
+// IsValidScorer checks if a scorer name is valid
+func IsValidScorer(name string) bool {
+	for _, valid := range Scorers() {
+		if valid == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NewScorer creates the named scorer with its default settings, falling
+// back to recency for an unknown name
+func NewScorer(name string, followRepo repository.FollowStore) Scorer {
+	switch name {
+	case ScorerEngagement:
+		return NewEngagementScorer()
+	case ScorerAffinity:
+		return NewAffinityScorer(followRepo)
+	default:
+		return NewRecencyScorer(6 * time.Hour)
+	}
+}
+
+// Candidate is a tweet being considered for a ranked timeline
+type Candidate struct {
+	Tweet *models.Tweet
+	// InNetwork is set for tweets from the pushed timeline or a followed
+	// celebrity; the rest come from second-degree follows
+	InNetwork bool
+	// Via counts the viewer's followees who follow a second-degree
+	// candidate's author
+	Via int
+}
+
+// Scorer scores a ranked timeline's candidates for the viewer. It returns
+// one score per candidate; higher scores rank first and ties go to the
+// newer tweet.
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, viewerID int64, candidates []Candidate) ([]float64, error)
+}
+
+// RecencyScorer halves a candidate's score every HalfLife of age
+type RecencyScorer struct {
+	HalfLife time.Duration
+	now      func() time.Time
+}
+
+// NewRecencyScorer creates a RecencyScorer
+func NewRecencyScorer(halfLife time.Duration) *RecencyScorer {
+	return &RecencyScorer{HalfLife: halfLife, now: time.Now}
+}
+
+// Name returns the scorer name
+func (s *RecencyScorer) Name() string {
+	return ScorerRecency
+}
+
+// Score scores each candidate by its age
+func (s *RecencyScorer) Score(ctx context.Context, viewerID int64, candidates []Candidate) ([]float64, error) {
+	now := s.now()
+	scores := make([]float64, len(candidates))
+	for i, c := range candidates {
+		age := now.Sub(c.Tweet.CreatedAt)
+		scores[i] = math.Exp2(-age.Hours() / s.HalfLife.Hours())
+	}
+	return scores, nil
+}
+
+// EngagementScorer scores candidates by their likes and retweets over
+// their age raised to Gravity, the way link aggregators rank front pages
+type EngagementScorer struct {
+	RetweetWeight float64 // A retweet counts as this many likes
+	Gravity       float64 // How quickly engagement stops making up for age
+	now           func() time.Time
+}
+
+// NewEngagementScorer creates an EngagementScorer
+func NewEngagementScorer() *EngagementScorer {
+	return &EngagementScorer{RetweetWeight: 2, Gravity: 1.8, now: time.Now}
+}
+
+// Name returns the scorer name
+func (s *EngagementScorer) Name() string {
+	return ScorerEngagement
+}
+
+// Score scores each candidate by its like and retweet counts, which must
+// already be embedded
+func (s *EngagementScorer) Score(ctx context.Context, viewerID int64, candidates []Candidate) ([]float64, error) {
+	now := s.now()
+	scores := make([]float64, len(candidates))
+	for i, c := range candidates {
+		engagement := 1 + float64(c.Tweet.LikeCount) + s.RetweetWeight*float64(c.Tweet.RetweetCount)
+		hours := math.Max(now.Sub(c.Tweet.CreatedAt).Hours(), 0)
+		scores[i] = engagement / math.Pow(hours+2, s.Gravity)
+	}
+	return scores, nil
+}
+
+// AffinityScorer scores candidates by how close their author is to the
+// viewer. The viewer's own tweets and those of mutual follows score
+// highest, then other followed accounts, then second-degree authors, who
+// score higher the more of the viewer's followees follow them.
+type AffinityScorer struct {
+	followRepo repository.FollowStore
+}
+
+// NewAffinityScorer creates an AffinityScorer
+func NewAffinityScorer(followRepo repository.FollowStore) *AffinityScorer {
+	return &AffinityScorer{followRepo: followRepo}
+}
+
+// Name returns the scorer name
+func (s *AffinityScorer) Name() string {
+	return ScorerAffinity
+}
+
+// Score scores each candidate by its author's affinity with the viewer
+func (s *AffinityScorer) Score(ctx context.Context, viewerID int64, candidates []Candidate) ([]float64, error) {
+	followers, err := s.followRepo.GetFollowers(ctx, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get followers: %w", err)
+	}
+	followsViewer := make(map[int64]bool, len(followers))
+	for _, id := range followers {
+		followsViewer[id] = true
+	}
+
+	scores := make([]float64, len(candidates))
+	for i, c := range candidates {
+		switch {
+		case c.Tweet.UserID == viewerID || (c.InNetwork && followsViewer[c.Tweet.UserID]):
+			scores[i] = 1.5
+		case c.InNetwork:
+			scores[i] = 1
+		default:
+			scores[i] = 0.5 * float64(c.Via) / float64(c.Via+1)
+		}
+	}
+	return scores, nil
+}
@@ -0,0 +1,161 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ritik/twitter-fan-out/internal/cache"
+	"github.com/ritik/twitter-fan-out/internal/models"
+	"github.com/ritik/twitter-fan-out/internal/repository"
+)
+
+// TimelineDiff compares a user's cached timeline with the one the database implies
+type TimelineDiff struct {
+	UserID   int64
+	Strategy string
+	Expected int  // Tweets the timeline should hold
+	Cached   int  // Tweet IDs actually in the timeline set
+	Cold     bool // No timeline is cached at all although tweets are expected
+
+	Missing []int64 // Expected but not cached
+	Extra   []int64 // Cached, but the author is not a source for this user, or it's a reply or hidden author they shouldn't see
+	Stale   []int64 // Cached, but the tweet no longer exists
+}
+
+// Consistent reports whether the cached timeline matches the database
+func (d *TimelineDiff) Consistent() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Stale) == 0
+}
+
+// TimelineVerifier checks precomputed timelines against the database.
+// Failed fan-out writes are only logged, so this is how drift is found.
+type TimelineVerifier struct {
+	tweetRepo  repository.TweetStore
+	followRepo repository.FollowStore
+	cache      cache.TimelineStore
+	blocks     *Blocks // Optional - purged timelines shouldn't hold hidden authors
+}
+
+// NewTimelineVerifier creates a new TimelineVerifier
+func NewTimelineVerifier(tweetRepo repository.TweetStore, followRepo repository.FollowStore, cache cache.TimelineStore) *TimelineVerifier {
+	return &TimelineVerifier{tweetRepo: tweetRepo, followRepo: followRepo, cache: cache}
+}
+
+// SetBlocks makes the verifier expect blocked and muted authors to have been
+// purged from timelines when the blocks are filtered that way
+func (v *TimelineVerifier) SetBlocks(b *Blocks) {
+	v.blocks = b
+}
+
+// Verify diffs userID's cached timeline against the newest tweets from the
+// strategy's timeline sources
+func (v *TimelineVerifier) Verify(ctx context.Context, s Precomputed, userID int64) (*TimelineDiff, error) {
+	diff := &TimelineDiff{UserID: userID, Strategy: s.Name()}
+	limit := v.cache.MaxTimelineSize()
+
+	// 1. Compute the expected timeline from the database
+	sources, err := s.TimelineSources(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	isSource := make(map[int64]bool, len(sources))
+	for _, id := range sources {
+		isSource[id] = true
+	}
+
+	expected, err := v.tweetRepo.GetByUserIDs(ctx, sources, models.Page{Limit: limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expected tweets: %w", err)
+	}
+	// Replies filtered at fan-out were only pushed to some followers
+	replyMode := ReplyFilterRead
+	if rf, ok := s.(ReplyFiltering); ok {
+		replyMode = rf.ReplyFilterMode()
+	}
+	if expected, err = pushable(ctx, replyMode, v.followRepo, userID, expected); err != nil {
+		return nil, err
+	}
+	if expected, err = v.blocks.pushable(ctx, userID, expected); err != nil {
+		return nil, err
+	}
+	diff.Expected = len(expected)
+
+	// 2. Read the cached timeline
+	cachedIDs, err := v.cache.GetTimeline(ctx, userID, limit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached timeline: %w", err)
+	}
+	diff.Cached = len(cachedIDs)
+	if len(cachedIDs) == 0 {
+		exists, err := v.cache.TimelineExists(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check timeline: %w", err)
+		}
+		diff.Cold = !exists && len(expected) > 0
+		if diff.Cold {
+			return diff, nil
+		}
+	}
+
+	// 3. Classify cached entries that shouldn't be there
+	cachedTweets, err := v.tweetRepo.GetByIDs(ctx, cachedIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached tweets: %w", err)
+	}
+	live := make(map[int64]*models.Tweet, len(cachedTweets))
+	for _, t := range cachedTweets {
+		live[t.ID] = t
+	}
+
+	var visible map[int64]bool
+	if replyMode == ReplyFilterFanOut || v.blocks.purging() {
+		pushed, err := pushable(ctx, replyMode, v.followRepo, userID, cachedTweets)
+		if err == nil {
+			pushed, err = v.blocks.pushable(ctx, userID, pushed)
+		}
+		if err != nil {
+			return nil, err
+		}
+		visible = make(map[int64]bool, len(pushed))
+		for _, t := range pushed {
+			visible[t.ID] = true
+		}
+	}
+
+	var oldest *models.Tweet
+	for _, id := range cachedIDs {
+		t, ok := live[id]
+		switch {
+		case !ok:
+			diff.Stale = append(diff.Stale, id)
+		case !isSource[t.UserID], visible != nil && !visible[id]:
+			diff.Extra = append(diff.Extra, id)
+		case oldest == nil || models.CursorFor(oldest).Older(t):
+			oldest = t
+		}
+	}
+
+	// 4. Find expected tweets the cache lacks. A full timeline has been
+	// trimmed, so once deletes shift the expected window past its oldest
+	// entry the older tweets are legitimately absent.
+	full := len(cachedIDs) >= limit
+	for _, t := range expected {
+		if _, ok := live[t.ID]; ok {
+			continue
+		}
+		if full && oldest != nil && models.CursorFor(oldest).Older(t) {
+			continue
+		}
+		diff.Missing = append(diff.Missing, t.ID)
+	}
+
+	return diff, nil
+}
+
+// Repair rebuilds userID's timeline and verifies it again
+func (v *TimelineVerifier) Repair(ctx context.Context, s Precomputed, userID int64) (*TimelineDiff, error) {
+	if err := s.RebuildTimeline(ctx, userID, v.cache.MaxTimelineSize()); err != nil {
+		return nil, fmt.Errorf("failed to rebuild timeline: %w", err)
+	}
+	return v.Verify(ctx, s, userID)
+}
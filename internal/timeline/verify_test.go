@@ -0,0 +1,129 @@
+package timeline
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ritik/twitter-fan-out/internal/memory"
+)
+
+func TestVerifyReportsMissingExtraAndStale(t *testing.T) {
+	f := newFixture(t)
+	s := NewFanOutWriteStrategy(f.tweets, f.follows, f.users, f.cache)
+	v := NewTimelineVerifier(f.tweets, f.follows, f.cache)
+	u := f.newUsers(t, 3)
+	reader, followee, stranger := u[0], u[1], u[2]
+
+	f.follow(t, s, reader, followee)
+	gone := f.post(t, s, followee)
+	dropped := f.post(t, s, followee)
+	kept := f.post(t, s, followee)
+	foreign := f.post(t, s, stranger)
+
+	diff, err := v.Verify(f.ctx, s, reader)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !diff.Consistent() || diff.Cold || diff.Expected != 3 || diff.Cached != 3 {
+		t.Fatalf("fresh timeline = %+v, want 3 of 3 and consistent", diff)
+	}
+
+	// Drift the cache every way a failed fan-out or delete can
+	if err := f.cache.RemoveFromTimeline(f.ctx, reader, dropped.ID); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := f.cache.AddToTimeline(f.ctx, reader, foreign); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := f.tweets.Delete(f.ctx, gone.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	diff, err = v.Verify(f.ctx, s, reader)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if diff.Consistent() {
+		t.Fatal("drifted timeline reported consistent")
+	}
+	if want := []int64{dropped.ID}; !reflect.DeepEqual(diff.Missing, want) {
+		t.Errorf("missing = %v, want %v", diff.Missing, want)
+	}
+	if want := []int64{foreign.ID}; !reflect.DeepEqual(diff.Extra, want) {
+		t.Errorf("extra = %v, want %v", diff.Extra, want)
+	}
+	if want := []int64{gone.ID}; !reflect.DeepEqual(diff.Stale, want) {
+		t.Errorf("stale = %v, want %v", diff.Stale, want)
+	}
+	if diff.Expected != 2 || diff.Cached != 3 {
+		t.Errorf("expected/cached = %d/%d, want 2/3", diff.Expected, diff.Cached)
+	}
+
+	diff, err = v.Repair(f.ctx, s, reader)
+	if err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+	if !diff.Consistent() {
+		t.Fatalf("after repair = %+v, want consistent", diff)
+	}
+	if got, want := f.cached(t, reader), ids(kept, dropped); !reflect.DeepEqual(got, want) {
+		t.Errorf("rebuilt timeline = %v, want %v", got, want)
+	}
+}
+
+func TestVerifyReportsColdTimelines(t *testing.T) {
+	f := newFixture(t)
+	s := NewFanOutWriteStrategy(f.tweets, f.follows, f.users, f.cache)
+	v := NewTimelineVerifier(f.tweets, f.follows, f.cache)
+	u := f.newUsers(t, 3)
+
+	f.follow(t, s, u[0], u[1])
+	f.post(t, s, u[1])
+	if err := f.cache.ClearTimeline(f.ctx, u[0]); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+
+	diff, err := v.Verify(f.ctx, s, u[0])
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !diff.Cold {
+		t.Errorf("evicted timeline = %+v, want cold", diff)
+	}
+
+	// Nobody to hear from means nothing is expected, so no timeline isn't cold
+	diff, err = v.Verify(f.ctx, s, u[2])
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if diff.Cold || !diff.Consistent() {
+		t.Errorf("user with no sources = %+v, want consistent and not cold", diff)
+	}
+}
+
+// A full timeline has been trimmed, so tweets older than its oldest entry
+// aren't missing even once deletes pull them into the expected window
+func TestVerifyIgnoresTweetsTrimmedFromFullTimelines(t *testing.T) {
+	f := newFixture(t)
+	f.cache = memory.NewTimelineCache(3)
+	s := NewFanOutWriteStrategy(f.tweets, f.follows, f.users, f.cache)
+	v := NewTimelineVerifier(f.tweets, f.follows, f.cache)
+	u := f.newUsers(t, 2)
+
+	f.follow(t, s, u[0], u[1])
+	for i := 0; i < 4; i++ {
+		f.post(t, s, u[1])
+	}
+	newest := f.post(t, s, u[1])
+	if err := f.tweets.Delete(f.ctx, newest.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	diff, err := v.Verify(f.ctx, s, u[0])
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(diff.Missing) != 0 || !reflect.DeepEqual(diff.Stale, []int64{newest.ID}) {
+		t.Errorf("diff = %+v, want only the deleted tweet stale", diff)
+	}
+}
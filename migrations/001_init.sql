@@ -1,5 +1,7 @@
 -- Initial schema for Twitter Fan-Out prototype
 
+-- +migrate Up
+
 -- Users table
 CREATE TABLE IF NOT EXISTS users (
     id BIGSERIAL PRIMARY KEY,
@@ -33,26 +35,64 @@ CREATE INDEX IF NOT EXISTS idx_follows_follower ON follows(follower_id);
 CREATE INDEX IF NOT EXISTS idx_follows_followee ON follows(followee_id);
 CREATE INDEX IF NOT EXISTS idx_users_follower_count ON users(follower_count DESC);
 
--- Function to update follower/following counts
+-- Function to update follower/following counts. It runs once per statement
+-- over the rows that statement inserted or deleted, so a bulk load updates
+-- each affected user in a single pass instead of once per follow.
 CREATE OR REPLACE FUNCTION update_follow_counts()
 RETURNS TRIGGER AS $$
 BEGIN
     IF TG_OP = 'INSERT' THEN
-        UPDATE users SET follower_count = follower_count + 1 WHERE id = NEW.followee_id;
-        UPDATE users SET following_count = following_count + 1 WHERE id = NEW.follower_id;
-        RETURN NEW;
+        UPDATE users u
+        SET follower_count = u.follower_count + d.followers,
+            following_count = u.following_count + d.following
+        FROM (
+            SELECT id, SUM(followers) AS followers, SUM(following) AS following
+            FROM (
+                SELECT followee_id AS id, 1 AS followers, 0 AS following FROM new_follows
+                UNION ALL
+                SELECT follower_id, 0, 1 FROM new_follows
+            ) changes
+            GROUP BY id
+        ) d
+        WHERE u.id = d.id;
     ELSIF TG_OP = 'DELETE' THEN
-        UPDATE users SET follower_count = follower_count - 1 WHERE id = OLD.followee_id;
-        UPDATE users SET following_count = following_count - 1 WHERE id = OLD.follower_id;
-        RETURN OLD;
+        UPDATE users u
+        SET follower_count = u.follower_count - d.followers,
+            following_count = u.following_count - d.following
+        FROM (
+            SELECT id, SUM(followers) AS followers, SUM(following) AS following
+            FROM (
+                SELECT followee_id AS id, 1 AS followers, 0 AS following FROM old_follows
+                UNION ALL
+                SELECT follower_id, 0, 1 FROM old_follows
+            ) changes
+            GROUP BY id
+        ) d
+        WHERE u.id = d.id;
     END IF;
     RETURN NULL;
 END;
 $$ LANGUAGE plpgsql;
 
--- Trigger for follow counts
+-- Triggers for follow counts. Transition tables allow one event per trigger.
 DROP TRIGGER IF EXISTS trigger_update_follow_counts ON follows;
-CREATE TRIGGER trigger_update_follow_counts
-    AFTER INSERT OR DELETE ON follows
-    FOR EACH ROW
+DROP TRIGGER IF EXISTS trigger_follow_counts_insert ON follows;
+DROP TRIGGER IF EXISTS trigger_follow_counts_delete ON follows;
+CREATE TRIGGER trigger_follow_counts_insert
+    AFTER INSERT ON follows
+    REFERENCING NEW TABLE AS new_follows
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION update_follow_counts();
+CREATE TRIGGER trigger_follow_counts_delete
+    AFTER DELETE ON follows
+    REFERENCING OLD TABLE AS old_follows
+    FOR EACH STATEMENT
     EXECUTE FUNCTION update_follow_counts();
+
+-- +migrate Down
+DROP TRIGGER IF EXISTS trigger_follow_counts_delete ON follows;
+DROP TRIGGER IF EXISTS trigger_follow_counts_insert ON follows;
+DROP FUNCTION IF EXISTS update_follow_counts();
+DROP TABLE IF EXISTS follows;
+DROP TABLE IF EXISTS tweets;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,26 @@
+-- Retweets. A retweet is a row in tweets authored by the retweeter, with
+-- original_id pointing at the retweeted tweet and the original's content
+-- copied in, so it fans out, pages and backfills like any other tweet. The
+-- retweets table enforces one retweet per user per tweet.
+
+-- +migrate Up
+
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS original_id BIGINT REFERENCES tweets(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_tweets_original_id ON tweets(original_id) WHERE original_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS retweets (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tweet_id BIGINT NOT NULL REFERENCES tweets(id) ON DELETE CASCADE,
+    retweet_id BIGINT NOT NULL UNIQUE REFERENCES tweets(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, tweet_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_retweets_tweet_id ON retweets(tweet_id);
+
+-- +migrate Down
+
+DROP TABLE IF EXISTS retweets;
+DROP INDEX IF EXISTS idx_tweets_original_id;
+ALTER TABLE tweets DROP COLUMN IF EXISTS original_id;
@@ -0,0 +1,17 @@
+-- Replies. in_reply_to_id links a reply to the tweet it answers, forming
+-- conversation threads. in_reply_to_user_id copies that tweet's author, so
+-- home timelines can check whether the viewer follows both participants
+-- without a join, and it survives the parent being deleted.
+
+-- +migrate Up
+
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS in_reply_to_id BIGINT REFERENCES tweets(id) ON DELETE SET NULL;
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS in_reply_to_user_id BIGINT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tweets_in_reply_to_id ON tweets(in_reply_to_id) WHERE in_reply_to_id IS NOT NULL;
+
+-- +migrate Down
+
+DROP INDEX IF EXISTS idx_tweets_in_reply_to_id;
+ALTER TABLE tweets DROP COLUMN IF EXISTS in_reply_to_user_id;
+ALTER TABLE tweets DROP COLUMN IF EXISTS in_reply_to_id;
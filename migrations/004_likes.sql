@@ -0,0 +1,29 @@
+-- Likes. The likes table records who liked what, one like per user per
+-- tweet. like_count and retweet_count are denormalised onto tweets, but they
+-- are not updated by the statements that add likes and retweets: the
+-- counters live in Redis and are written back here in batches, so a hot
+-- tweet's row isn't locked once per like.
+
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS likes (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tweet_id BIGINT NOT NULL REFERENCES tweets(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, tweet_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_likes_tweet_id ON likes(tweet_id);
+
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS like_count BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE tweets ADD COLUMN IF NOT EXISTS retweet_count BIGINT NOT NULL DEFAULT 0;
+
+UPDATE tweets t SET retweet_count = r.count
+FROM (SELECT tweet_id, COUNT(*) AS count FROM retweets GROUP BY tweet_id) r
+WHERE t.id = r.tweet_id;
+
+-- +migrate Down
+
+ALTER TABLE tweets DROP COLUMN IF EXISTS retweet_count;
+ALTER TABLE tweets DROP COLUMN IF EXISTS like_count;
+DROP TABLE IF EXISTS likes;
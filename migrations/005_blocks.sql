@@ -0,0 +1,30 @@
+-- Blocks and mutes. Both hide an author's tweets from a viewer's home
+-- timeline. A block works both ways - neither user sees the other - while a
+-- mute only hides the muted user from the muter. Neither touches follows.
+
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS blocks (
+    blocker_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id != blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_blocks_blocked_id ON blocks(blocked_id);
+
+CREATE TABLE IF NOT EXISTS mutes (
+    muter_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (muter_id, muted_id),
+    CHECK (muter_id != muted_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mutes_muted_id ON mutes(muted_id);
+
+-- +migrate Down
+
+DROP TABLE IF EXISTS mutes;
+DROP TABLE IF EXISTS blocks;
@@ -0,0 +1,23 @@
+-- Protected accounts. A protected user's tweets are only seen by followers
+-- they approved: following them files a request, and the follow is only
+-- created when they approve it. Followers from before the account was
+-- protected stay approved.
+
+-- +migrate Up
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS protected BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS follow_requests (
+    requester_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (requester_id, target_id),
+    CHECK (requester_id != target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_follow_requests_target_id ON follow_requests(target_id, created_at);
+
+-- +migrate Down
+
+DROP TABLE IF EXISTS follow_requests;
+ALTER TABLE users DROP COLUMN IF EXISTS protected;
@@ -0,0 +1,30 @@
+-- Lists. A list is a user-curated set of accounts with a timeline of its
+-- own: the members' tweets, read with the same strategies as the home
+-- timeline. Posting fans out to every list containing the author as well
+-- as to the author's followers.
+
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS lists (
+    id BIGSERIAL PRIMARY KEY,
+    owner_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lists_owner_id ON lists(owner_id);
+
+CREATE TABLE IF NOT EXISTS list_members (
+    list_id BIGINT NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (list_id, user_id)
+);
+
+-- Fan-out looks up the lists containing a tweet's author
+CREATE INDEX IF NOT EXISTS idx_list_members_user_id ON list_members(user_id);
+
+-- +migrate Down
+
+DROP TABLE IF EXISTS list_members;
+DROP TABLE IF EXISTS lists;
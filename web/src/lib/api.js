@@ -13,9 +13,68 @@ export async function postTweet(userId, content, strategy) {
   return response.json();
 }
 
-export async function getTimeline(userId, strategy, limit = 50) {
+export async function retweet(tweetId, userId, strategy) {
+  const response = await fetch(`${API_BASE}/tweets/${tweetId}/retweet`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({
+      user_id: userId,
+      strategy
+    })
+  });
+  return response.json();
+}
+
+export async function likeTweet(tweetId, userId) {
+  const response = await fetch(`${API_BASE}/tweets/${tweetId}/like`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ user_id: userId })
+  });
+  return response.json();
+}
+
+export async function unlikeTweet(tweetId, userId) {
+  const response = await fetch(`${API_BASE}/tweets/${tweetId}/like?user_id=${userId}`, {
+    method: 'DELETE'
+  });
+  return response.json();
+}
+
+export async function postReply(tweetId, userId, content, strategy) {
+  const response = await fetch(`${API_BASE}/tweet`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({
+      user_id: userId,
+      content,
+      strategy,
+      in_reply_to_id: tweetId
+    })
+  });
+  return response.json();
+}
+
+export async function getThread(tweetId, viewerId, limit = 50, sinceId = '') {
+  const cursor = sinceId ? `&since_id=${encodeURIComponent(sinceId)}` : '';
+  const response = await fetch(
+    `${API_BASE}/tweets/${tweetId}/thread?viewer_id=${viewerId}&limit=${limit}${cursor}`
+  );
+  return response.json();
+}
+
+export async function deleteTweet(tweetId, userId, strategy) {
   const response = await fetch(
-    `${API_BASE}/timeline/${userId}?strategy=${strategy}&limit=${limit}`
+    `${API_BASE}/tweets/${tweetId}?user_id=${userId}&strategy=${strategy}`,
+    { method: 'DELETE' }
+  );
+  return response.json();
+}
+
+export async function getTimeline(userId, strategy, limit = 50, maxId = '') {
+  const cursor = maxId ? `&max_id=${encodeURIComponent(maxId)}` : '';
+  const response = await fetch(
+    `${API_BASE}/timeline/${userId}?strategy=${strategy}&limit=${limit}${cursor}`
   );
   return response.json();
 }
@@ -72,3 +131,141 @@ export async function getUserFollowing(userId) {
   const response = await fetch(`${API_BASE}/users/${userId}/following`);
   return response.json();
 }
+
+export async function getUserTweets(userId, viewerId, limit = 50, maxId = '') {
+  const cursor = maxId ? `&max_id=${encodeURIComponent(maxId)}` : '';
+  const response = await fetch(
+    `${API_BASE}/users/${userId}/tweets?viewer_id=${viewerId}&limit=${limit}${cursor}`
+  );
+  return response.json();
+}
+
+export async function followUser(userId, targetId, strategy) {
+  const response = await fetch(
+    `${API_BASE}/users/${userId}/follow/${targetId}?strategy=${strategy}`,
+    { method: 'POST' }
+  );
+  return response.json();
+}
+
+export async function unfollowUser(userId, targetId, strategy) {
+  const response = await fetch(
+    `${API_BASE}/users/${userId}/follow/${targetId}?strategy=${strategy}`,
+    { method: 'DELETE' }
+  );
+  return response.json();
+}
+
+export async function blockUser(userId, targetId) {
+  const response = await fetch(`${API_BASE}/users/${userId}/block/${targetId}`, {
+    method: 'POST'
+  });
+  return response.json();
+}
+
+export async function unblockUser(userId, targetId) {
+  const response = await fetch(`${API_BASE}/users/${userId}/block/${targetId}`, {
+    method: 'DELETE'
+  });
+  return response.json();
+}
+
+export async function muteUser(userId, targetId) {
+  const response = await fetch(`${API_BASE}/users/${userId}/mute/${targetId}`, {
+    method: 'POST'
+  });
+  return response.json();
+}
+
+export async function unmuteUser(userId, targetId) {
+  const response = await fetch(`${API_BASE}/users/${userId}/mute/${targetId}`, {
+    method: 'DELETE'
+  });
+  return response.json();
+}
+
+export async function setProtected(userId, isProtected, strategy) {
+  const response = await fetch(
+    `${API_BASE}/users/${userId}/protected?strategy=${strategy}`,
+    {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ protected: isProtected })
+    }
+  );
+  return response.json();
+}
+
+export async function getFollowRequests(userId, limit = 100) {
+  const response = await fetch(`${API_BASE}/users/${userId}/follow_requests?limit=${limit}`);
+  return response.json();
+}
+
+export async function approveFollowRequest(userId, requesterId, strategy) {
+  const response = await fetch(
+    `${API_BASE}/users/${userId}/follow_requests/${requesterId}/approve?strategy=${strategy}`,
+    { method: 'POST' }
+  );
+  return response.json();
+}
+
+export async function denyFollowRequest(userId, requesterId) {
+  const response = await fetch(`${API_BASE}/users/${userId}/follow_requests/${requesterId}/deny`, {
+    method: 'POST'
+  });
+  return response.json();
+}
+
+export async function createList(ownerId, name) {
+  const response = await fetch(`${API_BASE}/lists`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ owner_id: ownerId, name })
+  });
+  return response.json();
+}
+
+export async function getList(listId) {
+  const response = await fetch(`${API_BASE}/lists/${listId}`);
+  return response.json();
+}
+
+export async function deleteList(listId) {
+  const response = await fetch(`${API_BASE}/lists/${listId}`, { method: 'DELETE' });
+  return response.json();
+}
+
+export async function getUserLists(userId) {
+  const response = await fetch(`${API_BASE}/users/${userId}/lists`);
+  return response.json();
+}
+
+export async function addListMember(listId, userId, strategy) {
+  const response = await fetch(`${API_BASE}/lists/${listId}/members/${userId}?strategy=${strategy}`, {
+    method: 'POST'
+  });
+  return response.json();
+}
+
+export async function removeListMember(listId, userId, strategy) {
+  const response = await fetch(`${API_BASE}/lists/${listId}/members/${userId}?strategy=${strategy}`, {
+    method: 'DELETE'
+  });
+  return response.json();
+}
+
+export async function getListTimeline(listId, strategy, limit = 50, maxId = '') {
+  const cursor = maxId ? `&max_id=${encodeURIComponent(maxId)}` : '';
+  const response = await fetch(
+    `${API_BASE}/lists/${listId}/timeline?strategy=${strategy}&limit=${limit}${cursor}`
+  );
+  return response.json();
+}
+
+// Opens a Server-Sent Events stream of tweets reaching a user's timeline.
+// Call close() on the returned EventSource when done.
+export function streamTimeline(userId, strategy, onTweet) {
+  const source = new EventSource(`${API_BASE}/timeline/${userId}/stream?strategy=${strategy}`);
+  source.addEventListener('tweet', (e) => onTweet(JSON.parse(e.data)));
+  return source;
+}